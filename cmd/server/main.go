@@ -2,19 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Sofja96/GophKeeper.git/internal/server/app"
 	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver"
 )
 
 func main() {
+	dev := flag.Bool("dev", false, "run with in-memory storage (SQLite :memory: database and in-memory blob store) and a self-signed TLS certificate")
+	flag.Parse()
+
 	errorCh := make(chan error)
 	defer close(errorCh)
 
-	srv, err := app.Run()
+	srv, err := app.Run(*dev)
 	if err != nil {
 		log.Fatalf("cannot start application: %v", err)
 	}
@@ -23,6 +28,20 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer cancel()
 
+	// Файлы, загруженные до хранения по хешу содержимого, копируются до начала обработки запросов
+	migrated, err := srv.GetService().MigrateLegacyBlobs(ctx)
+	if err != nil {
+		log.Printf("cannot migrate legacy files: %v", err)
+	} else if migrated > 0 {
+		log.Printf("migrated %d legacy files", migrated)
+	}
+
+	settings := srv.GetSettings()
+	trashRetention := time.Duration(settings.TrashRetentionDays) * 24 * time.Hour
+	go srv.GetService().RunTrashPurge(ctx, trashRetention, settings.TrashPurgeInterval)
+	go srv.GetService().RunBlobOutbox(ctx, settings.BlobOutboxInterval)
+	go srv.GetService().RunBlobGC(ctx, settings.BlobGCMinAge, settings.BlobGCInterval)
+
 	go func() {
 		errorCh <- grpcserver.Run(ctx, srv)
 	}()
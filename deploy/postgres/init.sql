@@ -0,0 +1,5 @@
+-- Роль, под которой сервер подключается к базе данных (DB_DSN). Она не является суперпользователем
+-- и не обходит политики построчной защиты; права на таблицы ей выдают миграции, которые выполняет
+-- владелец базы данных (DB_MIGRATION_DSN).
+-- Скрипт выполняется только при первом запуске контейнера с пустым каталогом данных.
+create role keeper_app login password 'app_secret' nosuperuser nobypassrls;
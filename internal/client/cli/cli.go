@@ -6,12 +6,19 @@ import (
 	"os"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
 	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
 	"github.com/Sofja96/GophKeeper.git/pkg/buildinfo"
 )
 
+// syncHelp описывает синхронизацию в справке команд, которые синхронизируют данные с сервером.
+const syncHelp = `После выполнения команда синхронизирует данные с сервером.
+Записи без файлов отправляются пакетами, каждый из которых сервер применяет целиком или не применяет вовсе.
+Файлы в пакеты не входят: они загружаются по одному после пакетов, поэтому при обрыве связи часть файлов
+может остаться не отправленной; они будут отправлены при следующей синхронизации.`
+
 // StartCLI инициализирует CLI, принимая gRPC-клиент
 func StartCLI(client *grpcclient.Client) error {
 	rootCmd := &cobra.Command{
@@ -27,14 +34,17 @@ func StartCLI(client *grpcclient.Client) error {
 	}
 
 	rootCmd.AddCommand(LoginCmd(client), RegisterCmd(client),
-		VersionCmd(), CreateDataCmd(client), GetDataCmd(client), DeleteDataCmd(client), UpdateDataCmd(client))
+		VersionCmd(), CreateDataCmd(client), GetDataCmd(client), DeleteDataCmd(client), UpdateDataCmd(client),
+		ResolveConflictCmd(client), TrashCmd(client), HistoryCmd(client), GetFileCmd(client), LogoutCmd(client),
+		TOTPCmd(client))
 
 	return rootCmd.Execute()
 }
 
 // InteractiveMode запускает интерактивный режим для работы с клиентом.
 // В этом режиме пользователь может выбрать одну из команд для выполнения различных операций,
-// таких как логин, регистрация, создание, получение, удаление и обновление данных,
+// таких как логин, регистрация, создание, получение, удаление и обновление данных, разрешение конфликтов
+// работа с корзиной и историей изменений данных.
 func InteractiveMode(client *grpcclient.Client) error {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -48,6 +58,15 @@ func InteractiveMode(client *grpcclient.Client) error {
 		fmt.Println("6. Обновить данные")
 		fmt.Println("7. Получить информацию о версии и дате сборке клиента")
 		fmt.Println("8. Выйти")
+		fmt.Println("9. Разрешить конфликты версий данных")
+		fmt.Println("10. Просмотреть корзину")
+		fmt.Println("11. Восстановить данные из корзины")
+		fmt.Println("12. Очистить корзину")
+		fmt.Println("13. История изменений данных")
+		fmt.Println("14. Получить файл бинарных данных")
+		fmt.Println("15. Завершить сессию")
+		fmt.Println("16. Включить двухфакторную аутентификацию")
+		fmt.Println("17. Выключить двухфакторную аутентификацию")
 
 		fmt.Print("> ")
 		input, _ := reader.ReadString('\n')
@@ -82,6 +101,51 @@ func InteractiveMode(client *grpcclient.Client) error {
 		case "8":
 			fmt.Println("Выход из программы.")
 			return nil
+		case "9":
+			err := ResolveConflictCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при разрешении конфликта: %v\n", err)
+			}
+		case "10":
+			err := TrashListCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при получении корзины: %v\n", err)
+			}
+		case "11":
+			err := TrashRestoreCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при восстановлении данных: %v\n", err)
+			}
+		case "12":
+			err := TrashEmptyCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при очистке корзины: %v\n", err)
+			}
+		case "13":
+			err := HistoryCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при просмотре истории изменений: %v\n", err)
+			}
+		case "14":
+			err := GetFileCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при получении файла: %v\n", err)
+			}
+		case "15":
+			err := LogoutCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при завершении сессии: %v\n", err)
+			}
+		case "16":
+			err := TOTPEnableCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при включении двухфакторной аутентификации: %v\n", err)
+			}
+		case "17":
+			err := TOTPDisableCmd(client).RunE(dummyCmd, nil)
+			if err != nil {
+				fmt.Printf("Ошибка при выключении двухфакторной аутентификации: %v\n", err)
+			}
 		default:
 			fmt.Println("Неизвестная команда. Пожалуйста, выберите число от 1 до 4.")
 		}
@@ -98,3 +162,12 @@ func VersionCmd() *cobra.Command {
 		},
 	}
 }
+
+// parseDataID проверяет введенный пользователем ID записи и приводит его к каноническому виду UUID.
+func parseDataID(input string) (string, error) {
+	id, err := uuid.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
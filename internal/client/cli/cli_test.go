@@ -2,15 +2,19 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 
 	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
 	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
@@ -118,8 +122,8 @@ func TestLoginCmd_InvalidCredentials(t *testing.T) {
 		Error("Login failed: %v", gomock.Any()).
 		Times(1)
 
-	mockClient.EXPECT().GetAllData(gomock.Any(), gomock.Any()).
-		Return(nil, fmt.Errorf("ошибка получения данных с сервера: %v", gomock.Any())).
+	mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("ошибка получения изменений с сервера")).
 		Times(1)
 
 	var buf bytes.Buffer
@@ -136,6 +140,163 @@ func TestLoginCmd_InvalidCredentials(t *testing.T) {
 	cmd.Run(cmd, []string{})
 }
 
+// withStdin подменяет стандартный ввод на время теста строкой input.
+func withStdin(t *testing.T, input string) {
+	oldStdin := os.Stdin
+	t.Cleanup(func() { os.Stdin = oldStdin })
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	os.Stdin = r
+
+	_, err = w.WriteString(input)
+	assert.NoError(t, err)
+	w.Close()
+}
+
+func TestLoginCmd_TOTP(t *testing.T) {
+	useTempDir(t)
+
+	t.Cleanup(func() {
+		os.RemoveAll("user_data")
+	})
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().Login(gomock.Any(), &proto.LoginRequest{Username: "user", Password: "password"}).
+			Return(&proto.LoginResponse{TotpRequired: true}, nil),
+		mockClient.EXPECT().Login(gomock.Any(), &proto.LoginRequest{
+			Username: "user",
+			Password: "password",
+			TotpCode: "123456",
+		}).Return(&proto.LoginResponse{Token: "Bearer token", UserId: 1}, nil),
+	)
+	mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+		Return(nil, fmt.Errorf("ошибка получения изменений с сервера"))
+
+	client := &grpcclient.Client{Client: mockClient}
+	withStdin(t, "user\npassword\n123456\n")
+
+	var buf bytes.Buffer
+	cmd := LoginCmd(client)
+	cmd.SetOut(&buf)
+	cmd.Run(cmd, nil)
+
+	assert.Contains(t, buf.String(), "Введите код двухфакторной аутентификации")
+	assert.Equal(t, "Bearer token", client.GetToken())
+}
+
+func TestTOTPEnableCmd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	client := &grpcclient.Client{Client: mockClient, Token: "Bearer token"}
+
+	t.Run("success", func(t *testing.T) {
+		mockClient.EXPECT().EnableTOTP(gomock.Any(), gomock.Any()).Return(&proto.EnableTOTPResponse{
+			Secret:     "JBSWY3DPEHPK3PXP",
+			OtpauthUri: "otpauth://totp/GophKeeper:user?secret=JBSWY3DPEHPK3PXP&issuer=GophKeeper",
+		}, nil)
+		mockClient.EXPECT().ConfirmTOTP(gomock.Any(), &proto.ConfirmTOTPRequest{Code: "123456"}).
+			Return(&proto.ConfirmTOTPResponse{RecoveryCodes: []string{"aaaa-bbbb-cccc-dddd", "eeee-ffff-gggg-hhhh"}}, nil)
+		withStdin(t, "123456\n")
+
+		var buf bytes.Buffer
+		cmd := TOTPEnableCmd(client)
+		cmd.SetOut(&buf)
+
+		err := cmd.RunE(cmd, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "█")
+		assert.Contains(t, buf.String(), "JBSWY3DPEHPK3PXP")
+		assert.Contains(t, buf.String(), "Двухфакторная аутентификация включена.")
+		assert.Contains(t, buf.String(), "aaaa-bbbb-cccc-dddd")
+		assert.Contains(t, buf.String(), "eeee-ffff-gggg-hhhh")
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockClient.EXPECT().EnableTOTP(gomock.Any(), gomock.Any()).
+			Return(&proto.EnableTOTPResponse{Secret: "JBSWY3DPEHPK3PXP", OtpauthUri: "otpauth://totp/x"}, nil)
+		mockClient.EXPECT().ConfirmTOTP(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("invalid two-factor code"))
+		withStdin(t, "000000\n")
+
+		cmd := TOTPEnableCmd(client)
+		cmd.SetOut(io.Discard)
+
+		err := cmd.RunE(cmd, nil)
+		assert.ErrorContains(t, err, "invalid two-factor code")
+	})
+}
+
+func TestTOTPDisableCmd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	mockClient.EXPECT().DisableTOTP(gomock.Any(), &proto.DisableTOTPRequest{Code: "aaaa-bbbb-cccc-dddd"}).
+		Return(&proto.DisableTOTPResponse{}, nil)
+	withStdin(t, "aaaa-bbbb-cccc-dddd\n")
+
+	var buf bytes.Buffer
+	cmd := TOTPDisableCmd(&grpcclient.Client{Client: mockClient, Token: "Bearer token"})
+	cmd.SetOut(&buf)
+
+	err := cmd.RunE(cmd, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Двухфакторная аутентификация выключена.")
+}
+
+func TestPrintQRCode(t *testing.T) {
+	var buf bytes.Buffer
+	err := printQRCode(&buf, "otpauth://totp/GophKeeper:user?secret=JBSWY3DPEHPK3PXP")
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	width := utf8.RuneCountInString(lines[0])
+	// Две строки модулей на строку текста, с рамкой с каждой стороны
+	assert.Equal(t, (width+1)/2, len(lines))
+	for _, line := range lines {
+		assert.Equal(t, width, utf8.RuneCountInString(line))
+	}
+	// Рамка светлая
+	assert.Equal(t, strings.Repeat("█", width), lines[0])
+}
+
+func TestLogoutCmd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	t.Run("success", func(t *testing.T) {
+		mockClient.EXPECT().Logout(gomock.Any(), gomock.Any()).Return(&proto.LogoutResponse{}, nil)
+
+		client := &grpcclient.Client{Client: mockClient, Token: "Bearer token"}
+		var buf bytes.Buffer
+		cmd := LogoutCmd(client)
+		cmd.SetOut(&buf)
+
+		err := cmd.RunE(cmd, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "Сессия завершена.")
+		assert.Empty(t, client.GetToken())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mockClient.EXPECT().Logout(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("connection error"))
+
+		cmd := LogoutCmd(&grpcclient.Client{Client: mockClient, Token: "Bearer token"})
+		err := cmd.RunE(cmd, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "logout failed")
+	})
+}
+
 func TestRegisterCmd_RegistrationFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -167,6 +328,8 @@ func TestRegisterCmd_RegistrationFailed(t *testing.T) {
 }
 
 func TestCreateDataCmd(t *testing.T) {
+	useTempDir(t)
+
 	tmpFile, err := os.CreateTemp("", "testfile.txt")
 	if err != nil {
 		t.Fatalf("Не удалось создать временный файл: %v", err)
@@ -179,10 +342,6 @@ func TestCreateDataCmd(t *testing.T) {
 	}
 	tmpFile.Close()
 
-	userID := int64(2)
-	userDir := filepath.Join("user_data", fmt.Sprintf("%d", userID))
-	defer os.RemoveAll(userDir)
-
 	testCases := []struct {
 		name           string
 		input          string
@@ -271,21 +430,36 @@ func TestCreateDataCmd(t *testing.T) {
 			mockClient := mproto.NewMockGophKeeperClient(ctrl)
 			if !tc.expectedError {
 				mockClient.EXPECT().
-					CreateData(gomock.Any(), gomock.Any()).
-					Return(&proto.CreateDataResponse{DataId: 123}, nil).
+					BatchMutate(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, req *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+						resp := &proto.BatchMutateResponse{}
+						for i := range req.Mutations {
+							resp.Results = append(resp.Results, &proto.MutationResult{DataId: req.Mutations[i].GetCreate().GetDataId()})
+						}
+						return resp, nil
+					}).
+					AnyTimes()
+				mockClient.EXPECT().
+					UploadBlob(gomock.Any()).
+					Return(&fakeUploadStream{resp: &proto.UploadBlobResponse{DataId: "00000000-0000-0000-0000-00000000007c"}}, nil).
 					AnyTimes()
 			}
 
 			if tc.expectedError {
 				mockClient.EXPECT().
-					CreateData(gomock.Any(), gomock.Any()).
+					BatchMutate(gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf(tc.expectedOutput)).
+					AnyTimes()
+				// Файлы, сохраненные в предыдущих случаях, тоже отправляются при синхронизации
+				mockClient.EXPECT().
+					UploadBlob(gomock.Any()).
 					Return(nil, fmt.Errorf(tc.expectedOutput)).
 					AnyTimes()
 			}
 
 			mockClient.EXPECT().
-				GetAllData(gomock.Any(), gomock.Any()).
-				Return(&proto.GetAllDataResponse{}, nil).
+				GetChanges(gomock.Any(), gomock.Any()).
+				Return(&proto.GetChangesResponse{}, nil).
 				AnyTimes()
 
 			masterKey := make([]byte, 32)
@@ -324,6 +498,8 @@ func TestCreateDataCmd(t *testing.T) {
 }
 
 func TestUpdateDataCmd(t *testing.T) {
+	useTempDir(t)
+
 	tmpFile, err := os.CreateTemp("", "testfile.txt")
 	if err != nil {
 		t.Fatalf("Не удалось создать временный файл: %v", err)
@@ -348,7 +524,7 @@ func TestUpdateDataCmd(t *testing.T) {
 	}
 
 	testDatas := models.Data{
-		ID:          1,
+		ID:          "00000000-0000-0000-0000-000000000001",
 		DataType:    "LOGIN_PASSWORD",
 		DataContent: []byte(encryptedData),
 		Metadata:    nil,
@@ -368,13 +544,13 @@ func TestUpdateDataCmd(t *testing.T) {
 	}{
 		{
 			name:           "Успешное_обновлени_данных_логин_пароль",
-			input:          "1\nunutest\ntest\n\n", // Выбор типа данных, логин, пароль, пустая строка для метаданных
+			input:          "00000000-0000-0000-0000-000000000001\nunutest\ntest\n\n", // Выбор типа данных, логин, пароль, пустая строка для метаданных
 			expectedOutput: "Данные успешно обновлены!",
 			expectedError:  false,
 		},
 		{
 			name:           "Некорректный ID",
-			input:          "5\n",
+			input:          "00000000-0000-0000-0000-000000000005\n",
 			expectedOutput: "Ошибка: данные с таким ID не найдены",
 			expectedError:  true,
 		},
@@ -392,7 +568,7 @@ func TestUpdateDataCmd(t *testing.T) {
 		},
 		{
 			name:           "Ошибка ввода логин_пароль",
-			input:          "1\n\n\n\n",
+			input:          "00000000-0000-0000-0000-000000000001\n\n\n\n",
 			expectedOutput: "ошибка валидации данных: логин и пароль не могут быть пустыми",
 			expectedError:  true,
 		},
@@ -407,14 +583,20 @@ func TestUpdateDataCmd(t *testing.T) {
 
 			if !tc.expectedError {
 				mockClient.EXPECT().
-					CreateData(gomock.Any(), gomock.Any()).
-					Return(&proto.CreateDataResponse{DataId: 123}, nil).
+					BatchMutate(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, req *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+						resp := &proto.BatchMutateResponse{}
+						for i := range req.Mutations {
+							resp.Results = append(resp.Results, &proto.MutationResult{DataId: req.Mutations[i].GetCreate().GetDataId()})
+						}
+						return resp, nil
+					}).
 					AnyTimes()
 			}
 
 			if tc.expectedError {
 				mockClient.EXPECT().
-					CreateData(gomock.Any(), gomock.Any()).
+					BatchMutate(gomock.Any(), gomock.Any()).
 					Return(nil, fmt.Errorf(tc.expectedOutput)).
 					AnyTimes()
 			}
@@ -456,6 +638,22 @@ func TestUpdateDataCmd(t *testing.T) {
 }
 
 func TestDeleteDataCmd(t *testing.T) {
+	useTempDir(t)
+
+	masterKey := make([]byte, 32)
+	copy(masterKey, "16-byte-master-key")
+	content, err := encryption.EncryptData([]byte(`{"text":"test"}`), masterKey)
+	if err != nil {
+		t.Fatalf("Не удалось зашифровать тестовые данные: %v", err)
+	}
+	for _, id := range []string{"00000000-0000-0000-0000-00000000007b", "00000000-0000-0000-0000-000000000001"} {
+		err := localstorage.SaveData(0, models.Data{ID: id, DataType: models.TextData, DataContent: []byte(content),
+			Revision: 1, UpdatedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("Не удалось сохранить тестовые данные: %v", err)
+		}
+	}
+
 	testCases := []struct {
 		name           string
 		input          string
@@ -465,8 +663,8 @@ func TestDeleteDataCmd(t *testing.T) {
 	}{
 		{
 			name:           "Успешное удаление данных",
-			input:          "123\n",
-			expectedOutput: "Данные успешно удалены",
+			input:          "00000000-0000-0000-0000-00000000007b\n",
+			expectedOutput: "Данные перемещены в корзину",
 			expectedError:  false,
 			mockBehavior: func(mockClient *mproto.MockGophKeeperClient) {
 				mockClient.EXPECT().
@@ -477,7 +675,7 @@ func TestDeleteDataCmd(t *testing.T) {
 		},
 		{
 			name:           "Ошибка удаления данных",
-			input:          "1\n",
+			input:          "00000000-0000-0000-0000-000000000001\n",
 			expectedOutput: "Ошибка удаления данных:",
 			expectedError:  true,
 			mockBehavior: func(mockClient *mproto.MockGophKeeperClient) {
@@ -568,6 +766,8 @@ func TestDeleteDataCmd(t *testing.T) {
 }
 
 func TestDeleteDataCmd_ErrorGettingData(t *testing.T) {
+	useTempDir(t)
+
 	t.Cleanup(func() {
 		os.RemoveAll("user_data")
 	})
@@ -628,20 +828,12 @@ func TestDeleteDataCmd_ErrorGettingData(t *testing.T) {
 }
 
 func TestGetDataCmd_Integration(t *testing.T) {
+	useTempDir(t)
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockClient := mproto.NewMockGophKeeperClient(ctrl)
-	t.Cleanup(func() {
-		os.RemoveAll("user_data")
-	})
-
-	userID := int64(1)
-	userDir := filepath.Join("user_data", fmt.Sprintf("%d", userID))
-	err := os.MkdirAll(userDir, 0700)
-	if err != nil {
-		t.Fatalf("Не удалось создать директорию пользователя: %v", err)
-	}
 
 	masterKey := make([]byte, 32)
 	copy(masterKey, "16-byte-master-key")
@@ -651,18 +843,33 @@ func TestGetDataCmd_Integration(t *testing.T) {
 		t.Fatalf("Не удалось зашифровать тестовые данные: %v", err)
 	}
 
-	testData := models.Data{
-		ID:          1,
-		DataType:    "LOGIN_PASSWORD",
-		DataContent: []byte(encryptedData),
-		Metadata:    nil,
-		UpdatedAt:   time.Date(2025, 3, 2, 15, 22, 0, 0, time.FixedZone("MSK", 3*60*60)),
-	}
-
-	err = localstorage.SaveData(userID, testData)
-	if err != nil {
-		t.Fatalf("Не удалось сохранить тестовые данные: %v", err)
-	}
+	gomock.InOrder(
+		mockClient.EXPECT().
+			ListData(gomock.Any(), &proto.ListDataRequest{PageSize: 50}).
+			Return(&proto.ListDataResponse{
+				Data: []*proto.DataItem{
+					{
+						DataId:      "00000000-0000-0000-0000-000000000001",
+						DataType:    proto.DataType_LOGIN_PASSWORD,
+						DataContent: []byte(encryptedData),
+						UpdatedAt:   "2025-03-02T15:22:00+03:00",
+					},
+				},
+				NextPageToken: "next",
+			}, nil),
+		mockClient.EXPECT().
+			ListData(gomock.Any(), &proto.ListDataRequest{PageSize: 50, PageToken: "next"}).
+			Return(&proto.ListDataResponse{
+				Data: []*proto.DataItem{
+					{
+						DataId:    "00000000-0000-0000-0000-000000000002",
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: "2025-03-02T15:22:00+03:00",
+						Blob:      &proto.BlobManifest{BlobId: "users/1/abc", FileName: "report.pdf", Size: 1024},
+					},
+				},
+			}, nil),
+	)
 
 	client := &grpcclient.Client{
 		Client:        mockClient,
@@ -678,44 +885,90 @@ func TestGetDataCmd_Integration(t *testing.T) {
 	cmd.Run(cmd, []string{})
 
 	output := buf.String()
-	assert.Contains(t, output, "ID: 1")
+	assert.Contains(t, output, "ID: 00000000-0000-0000-0000-000000000001")
 	assert.Contains(t, output, "Тип данных: LOGIN_PASSWORD")
 	assert.Contains(t, output, `Содержимое: {"username":"unutest","password":"test"}`)
 	assert.Contains(t, output, "Метаданные: map[]")
-	assert.Regexp(t, `Обновлено: 2025-03-02 15:22:00 \+0300( MSK)?\n---\n`, output)
+	assert.Regexp(t, `Обновлено: 2025-03-02 15:22:00 \+0300( \S+)?\n---\n`, output)
+	assert.Contains(t, output, "ID: 00000000-0000-0000-0000-000000000002")
+	assert.Contains(t, output, "Файл: report.pdf (1024 байт, не загружен, используйте get-file)")
 }
 
-func TestGetDataCmd_Integration_Error(t *testing.T) {
+func TestGetDataCmd_Filters(t *testing.T) {
+	useTempDir(t)
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockClient := mproto.NewMockGophKeeperClient(ctrl)
-	t.Cleanup(func() {
-		os.RemoveAll("user_data")
+
+	mockClient.EXPECT().
+		ListData(gomock.Any(), &proto.ListDataRequest{
+			PageSize:     10,
+			DataType:     proto.DataType_TEXT_DATA,
+			UpdatedAfter: "2025-03-02T15:22:00Z",
+			Metadata:     map[string]string{"site": "example.com"},
+		}).
+		Return(&proto.ListDataResponse{}, nil)
+
+	client := &grpcclient.Client{Client: mockClient}
+
+	cmd := GetDataCmd(client)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--type", "text_data", "--updated-after", "2025-03-02T15:22:00Z",
+		"--meta", "site=example.com", "--page-size", "10"})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Ошибка")
+
+	t.Run("Неверный тип данных", func(t *testing.T) {
+		cmd := GetDataCmd(client)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"--type", "unknown"})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "Ошибка в параметрах фильтра: неизвестный тип данных")
 	})
 
-	userID := int64(1)
-	userDir := filepath.Join("user_data", fmt.Sprintf("%d", userID))
-	err := os.MkdirAll(userDir, 0700)
-	if err != nil {
-		t.Fatalf("Не удалось создать директорию пользователя: %v", err)
-	}
+	t.Run("Неверный формат времени", func(t *testing.T) {
+		cmd := GetDataCmd(client)
+		var buf bytes.Buffer
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"--updated-after", "yesterday"})
+
+		err := cmd.Execute()
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "Ошибка в параметрах фильтра: неверный формат времени")
+	})
+}
+
+func TestGetDataCmd_Integration_Error(t *testing.T) {
+	useTempDir(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
 
 	masterKey := make([]byte, 32)
 	copy(masterKey, "16-byte-master-key")
 
-	testData := models.Data{
-		ID:          1,
-		DataType:    "LOGIN_PASSWORD",
-		DataContent: []byte(`{"username":"unutest","password":"test"}`), // Не зашифрованные данные
-		Metadata:    nil,
-		UpdatedAt:   time.Date(2025, 3, 2, 15, 22, 0, 0, time.FixedZone("MSK", 3*60*60)),
-	}
-
-	err = localstorage.SaveData(userID, testData)
-	if err != nil {
-		t.Fatalf("Не удалось сохранить тестовые данные: %v", err)
-	}
+	mockClient.EXPECT().
+		ListData(gomock.Any(), gomock.Any()).
+		Return(&proto.ListDataResponse{
+			Data: []*proto.DataItem{
+				{
+					DataId:      "00000000-0000-0000-0000-000000000001",
+					DataType:    proto.DataType_LOGIN_PASSWORD,
+					DataContent: []byte(`{"username":"unutest","password":"test"}`), // Не зашифрованные данные
+					UpdatedAt:   "2025-03-02T15:22:00+03:00",
+				},
+			},
+		}, nil)
 
 	client := &grpcclient.Client{
 		Client:        mockClient,
@@ -733,3 +986,87 @@ func TestGetDataCmd_Integration_Error(t *testing.T) {
 	output := buf.String()
 	assert.Contains(t, output, "Ошибка получения данных:")
 }
+
+// fakeUploadStream - тестовая реализация клиентского потока UploadBlob.
+type fakeUploadStream struct {
+	grpc.ClientStream
+	resp *proto.UploadBlobResponse
+}
+
+func (f *fakeUploadStream) Send(*proto.UploadBlobRequest) error {
+	return nil
+}
+
+func (f *fakeUploadStream) CloseAndRecv() (*proto.UploadBlobResponse, error) {
+	return f.resp, nil
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		older []string
+		newer []string
+		want  []string
+	}{
+		{
+			name:  "без изменений",
+			older: []string{"a", "b"},
+			newer: []string{"a", "b"},
+			want:  []string{" a", " b"},
+		},
+		{
+			name:  "изменение строки",
+			older: []string{"a", "b", "c"},
+			newer: []string{"a", "x", "c"},
+			want:  []string{" a", "-b", "+x", " c"},
+		},
+		{
+			name:  "добавление и удаление строк",
+			older: []string{"a", "b"},
+			newer: []string{"b", "c"},
+			want:  []string{"-a", " b", "+c"},
+		},
+		{
+			name:  "пустое состояние",
+			older: nil,
+			newer: []string{"a"},
+			want:  []string{"+a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffLines(tt.older, tt.newer))
+		})
+	}
+}
+
+func TestDiffMetadata(t *testing.T) {
+	older := map[string]interface{}{"site": "old.com", "note": "x", "same": "y"}
+	newer := map[string]interface{}{"site": "new.com", "tag": "z", "same": "y"}
+
+	assert.Equal(t, []string{"-note: x", "-site: old.com", "+site: new.com", "+tag: z"}, diffMetadata(older, newer))
+}
+
+func TestContentLines(t *testing.T) {
+	assert.Equal(t, []string{"{", `  "login": "user"`, "}"}, contentLines([]byte(`{"login":"user"}`)))
+	assert.Equal(t, []string{"plain", "text"}, contentLines([]byte("plain\ntext")))
+	assert.Nil(t, contentLines(nil))
+}
+
+// useTempDir переводит тест во временный каталог, чтобы локальное хранилище клиента (каталог user_data)
+// создавалось в нем, а не в каталоге пакета. После теста рабочий каталог восстанавливается.
+func useTempDir(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Не удалось получить рабочий каталог: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Не удалось перейти во временный каталог: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
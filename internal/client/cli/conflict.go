@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+)
+
+// printConflict выводит сообщение о конфликте версий и подсказку, как его разрешить.
+// Возвращает false, если ошибка не является конфликтом версий.
+func printConflict(cmd *cobra.Command, err error) bool {
+	var conflict *grpcclient.ConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	cmd.Println("Конфликт версий:", conflict)
+	cmd.Println("Выберите версию данных командой resolve-conflict.")
+	return true
+}
@@ -20,6 +20,7 @@ func CreateDataCmd(client *grpcclient.Client) *cobra.Command {
 	return &cobra.Command{
 		Use:   "create-data",
 		Short: "Создать новые данные",
+		Long:  "Создать новые данные.\n\n" + syncHelp,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			reader := bufio.NewReader(os.Stdin)
 
@@ -84,8 +85,8 @@ func CreateDataCmd(client *grpcclient.Client) *cobra.Command {
 				return fmt.Errorf("ошибка: %v", err)
 			}
 
-			cmd.Printf("Данные успешно сохранены с ID: %d\n", dataID)
-			if err := client.SyncData(); err != nil {
+			cmd.Printf("Данные успешно сохранены с ID: %s\n", dataID)
+			if err := client.SyncData(); err != nil && !printConflict(cmd, err) {
 				cmd.Println("Ошибка синхронизации данных:", err)
 				return fmt.Errorf("ошибка синхронизации данных: %v", err)
 			}
@@ -153,19 +154,23 @@ func inputBinaryData(reader *bufio.Reader) *models.BinaryDataType {
 	filePath, _ := reader.ReadString('\n')
 	filePath = strings.TrimSpace(filePath)
 
-	binaryData, err := os.ReadFile(filePath)
+	info, err := os.Stat(filePath)
 	if err != nil {
 		fmt.Println("Ошибка чтения файла", err)
 		return nil
 	}
 
+	if info.IsDir() {
+		fmt.Println("Ошибка чтения файла: указан путь к директории")
+		return nil
+	}
+
 	fmt.Print("Введите название файла: ")
 	fileName, _ := reader.ReadString('\n')
 	fileName = strings.TrimSpace(fileName)
 
 	return &models.BinaryDataType{
 		FilePath: filePath,
-		Content:  binaryData,
 		Filename: fileName,
 	}
 }
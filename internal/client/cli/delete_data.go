@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -30,7 +29,7 @@ func DeleteDataCmd(client *grpcclient.Client) *cobra.Command {
 
 			cmd.Println("Ваши данные:")
 			for _, item := range data {
-				cmd.Printf("ID: %d, Тип данных: %s, Метаданные: %v\n", item.ID, item.DataType, item.Metadata)
+				cmd.Printf("ID: %s, Тип данных: %s, Метаданные: %v\n", item.ID, item.DataType, item.Metadata)
 			}
 
 			cmd.Print("Введите ID документа, который хотите удалить: ")
@@ -41,7 +40,7 @@ func DeleteDataCmd(client *grpcclient.Client) *cobra.Command {
 				return fmt.Errorf("ошибка ввода %w", err)
 			}
 
-			id, err := strconv.ParseInt(dataID, 10, 64)
+			id, err := parseDataID(dataID)
 			if err != nil {
 				cmd.Println("Ошибка: неверный формат ID")
 				return fmt.Errorf("ошибка: неверный формат ID %w", err)
@@ -53,7 +52,7 @@ func DeleteDataCmd(client *grpcclient.Client) *cobra.Command {
 				return fmt.Errorf("ошибка удаления данных: %w", err)
 			}
 
-			cmd.Println("Данные успешно удалены")
+			cmd.Println("Данные перемещены в корзину")
 			return nil
 		},
 	}
@@ -2,34 +2,119 @@ package cli
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+	"github.com/Sofja96/GophKeeper.git/internal/client/models"
+	mdata "github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
 )
 
-// GetDataCmd создает команду для получения всех данных пользователя.
+// GetDataCmd создает команду для получения данных пользователя.
+// Данные запрашиваются с сервера постранично с учетом фильтров из флагов команды.
 func GetDataCmd(client *grpcclient.Client) *cobra.Command {
-	return &cobra.Command{
+	var (
+		dataType     string
+		updatedAfter string
+		metadata     map[string]string
+		pageSize     int32
+	)
+
+	cmd := &cobra.Command{
 		Use:   "get-data",
 		Short: "Получить все данные пользователя",
 		Run: func(cmd *cobra.Command, _ []string) {
 			fmt.Println("\nРежим получения данных. Введите '8' или 'exit' для выхода.")
 
-			data, err := client.GetData()
+			filter, err := newListFilter(dataType, updatedAfter, metadata, pageSize)
 			if err != nil {
-				cmd.Println("Ошибка получения данных:", err)
+				cmd.Println("Ошибка в параметрах фильтра:", err)
 				return
 			}
 
-			for _, item := range data {
-				cmd.Println("ID:", item.ID)
-				cmd.Println("Тип данных:", item.DataType)
-				cmd.Println("Содержимое:", string(item.DataContent))
-				cmd.Println("Метаданные:", item.Metadata)
-				cmd.Println("Обновлено:", item.UpdatedAt)
-				cmd.Println("---")
+			pageToken := ""
+			for {
+				data, nextPageToken, err := client.ListData(filter, pageToken)
+				if err != nil {
+					cmd.Println("Ошибка получения данных:", err)
+					return
+				}
+
+				for _, item := range data {
+					cmd.Println("ID:", item.ID)
+					cmd.Println("Тип данных:", item.DataType)
+					if isFile(item) {
+						cmd.Println("Файл:", fileDescription(item))
+					} else {
+						cmd.Println("Содержимое:", string(item.DataContent))
+					}
+					cmd.Println("Метаданные:", item.Metadata)
+					cmd.Println("Обновлено:", item.UpdatedAt)
+					cmd.Println("---")
+				}
+
+				if nextPageToken == "" {
+					return
+				}
+				pageToken = nextPageToken
 			}
 		},
 	}
+
+	cmd.Flags().StringVar(&dataType, "type", "",
+		"фильтр по типу данных (LOGIN_PASSWORD, TEXT_DATA, BINARY_DATA, BANK_CARD)")
+	cmd.Flags().StringVar(&updatedAfter, "updated-after", "",
+		"показать данные, обновленные после указанного времени (RFC3339)")
+	cmd.Flags().StringToStringVar(&metadata, "meta", nil,
+		"фильтр по метаданным в формате ключ=значение")
+	cmd.Flags().Int32Var(&pageSize, "page-size", 50, "количество записей на странице")
+
+	return cmd
+}
+
+// isFile сообщает, хранится ли содержимое бинарных данных в отдельном файле, а не в самой записи.
+func isFile(item mdata.Data) bool {
+	return item.DataType == mdata.BinaryData && len(item.DataContent) == 0
+}
+
+// fileDescription описывает файл бинарных данных: имя и путь к локальному файлу, а для файла,
+// еще не загруженного с сервера, - имя и размер из описания файла.
+func fileDescription(item mdata.Data) string {
+	switch {
+	case item.FilePath != "":
+		return fmt.Sprintf("%s %s", item.FileName, item.FilePath)
+	case item.Blob != nil:
+		return fmt.Sprintf("%s (%d байт, не загружен, используйте get-file)", item.Blob.FileName, item.Blob.Size)
+	default:
+		return fmt.Sprintf("%s (не загружен, используйте get-file)", item.FileName)
+	}
+}
+
+// newListFilter формирует фильтр получения данных из значений флагов команды.
+func newListFilter(dataType, updatedAfter string, metadata map[string]string, pageSize int32) (models.ListFilter, error) {
+	filter := models.ListFilter{
+		Metadata: metadata,
+		PageSize: pageSize,
+	}
+
+	if dataType != "" {
+		value, ok := proto.DataType_value[strings.ToUpper(dataType)]
+		if !ok || value == int32(proto.DataType_UNKNOWN) {
+			return models.ListFilter{}, fmt.Errorf("неизвестный тип данных %q", dataType)
+		}
+		filter.DataType = proto.DataType(value)
+	}
+
+	if updatedAfter != "" {
+		t, err := time.Parse(time.RFC3339, updatedAfter)
+		if err != nil {
+			return models.ListFilter{}, fmt.Errorf("неверный формат времени, ожидается RFC3339: %w", err)
+		}
+		filter.UpdatedAfter = t
+	}
+
+	return filter, nil
 }
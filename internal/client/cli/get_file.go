@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+)
+
+// GetFileCmd создает команду для получения файла бинарных данных по ID записи.
+// Файл загружается с сервера только при первом обращении, дальше он берется из локального хранилища.
+func GetFileCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-file",
+		Short: "Получить файл бинарных данных по ID",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.Print("Введите ID данных: ")
+			var dataID string
+			_, err := fmt.Scanln(&dataID)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода %w", err)
+			}
+
+			id, err := parseDataID(dataID)
+			if err != nil {
+				cmd.Println("Ошибка: неверный формат ID")
+				return fmt.Errorf("ошибка: неверный формат ID %w", err)
+			}
+
+			path, err := client.FetchFile(id)
+			if err != nil {
+				cmd.Println("Ошибка получения файла:", err)
+				return fmt.Errorf("ошибка получения файла: %w", err)
+			}
+
+			cmd.Println("Файл сохранен:", path)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+	mdata "github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// HistoryCmd создает команду для просмотра истории изменений данных и отката к предыдущему состоянию.
+// Состояния расшифровываются и сравниваются на клиенте: для каждого состояния выводятся изменения,
+// сделанные в следующем за ним состоянии.
+func HistoryCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Показать историю изменений данных и откатить их к предыдущему состоянию",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.Print("Введите ID данных: ")
+			var dataID string
+			_, err := fmt.Scanln(&dataID)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода %w", err)
+			}
+
+			id, err := parseDataID(dataID)
+			if err != nil {
+				cmd.Println("Ошибка: неверный формат ID")
+				return fmt.Errorf("ошибка: неверный формат ID %w", err)
+			}
+
+			data, err := client.GetData()
+			if err != nil {
+				cmd.Println("Ошибка получения данных:", err)
+				return fmt.Errorf("ошибка получения данных: %w", err)
+			}
+
+			var current *mdata.Data
+			for i := range data {
+				if data[i].ID == id {
+					current = &data[i]
+					break
+				}
+			}
+			if current == nil {
+				cmd.Println("Данные с таким ID не найдены.")
+				return fmt.Errorf("данные с ID %s не найдены", id)
+			}
+
+			history, err := client.GetDataHistory(id)
+			if err != nil {
+				cmd.Println("Ошибка получения истории изменений:", err)
+				return fmt.Errorf("ошибка получения истории изменений: %w", err)
+			}
+
+			if len(history) == 0 {
+				cmd.Println("История изменений пуста.")
+				return nil
+			}
+
+			cmd.Printf("Текущее состояние, изменено %s\n", current.UpdatedAt.Local().Format(time.DateTime))
+			newer := *current
+			for _, revision := range history {
+				cmd.Printf("Ревизия %d (версия %d), изменено %s\n",
+					revision.Revision, revision.Version, revision.UpdatedAt.Local().Format(time.DateTime))
+				printDiff(cmd, revision, newer)
+				cmd.Println("---")
+				newer = revision
+			}
+
+			cmd.Print("Введите ревизию для отката или оставьте пустым для выхода: ")
+			var input string
+			_, err = fmt.Scanln(&input)
+			if err != nil || strings.TrimSpace(input) == "" {
+				return nil
+			}
+
+			revision, err := strconv.ParseInt(input, 10, 64)
+			if err != nil {
+				cmd.Println("Ошибка: неверный формат ревизии")
+				return fmt.Errorf("ошибка: неверный формат ревизии %w", err)
+			}
+
+			if err := client.RestoreRevision(id, revision); err != nil {
+				cmd.Println("Ошибка отката данных:", err)
+				return fmt.Errorf("ошибка отката данных: %w", err)
+			}
+
+			cmd.Println("Данные возвращены к выбранной ревизии")
+			return nil
+		},
+	}
+}
+
+// printDiff выводит изменения содержимого и метаданных между состоянием older и следующим за ним состоянием newer.
+func printDiff(cmd *cobra.Command, older, newer mdata.Data) {
+	changes := diffMetadata(older.Metadata, newer.Metadata)
+	if older.DataType != mdata.BinaryData {
+		for _, line := range diffLines(contentLines(older.DataContent), contentLines(newer.DataContent)) {
+			if !strings.HasPrefix(line, " ") {
+				changes = append(changes, line)
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		cmd.Println("  Изменений нет")
+		return
+	}
+	for _, line := range changes {
+		cmd.Println(" ", line)
+	}
+}
+
+// contentLines разбивает расшифрованное содержимое на строки для сравнения.
+// Содержимое в формате JSON предварительно форматируется, чтобы каждое поле оказалось на отдельной строке.
+func contentLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, content, "", "  "); err == nil {
+		content = formatted.Bytes()
+	}
+
+	return strings.Split(string(content), "\n")
+}
+
+// diffLines сравнивает строки по наибольшей общей подпоследовательности.
+// Возвращает строки результата с префиксом "-" для удаленных, "+" для добавленных и " " для неизмененных строк.
+func diffLines(older, newer []string) []string {
+	lcs := make([][]int, len(older)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newer)+1)
+	}
+	for i := len(older) - 1; i >= 0; i-- {
+		for j := len(newer) - 1; j >= 0; j-- {
+			if older[i] == newer[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]string, 0, len(older)+len(newer))
+	i, j := 0, 0
+	for i < len(older) && j < len(newer) {
+		switch {
+		case older[i] == newer[j]:
+			diff = append(diff, " "+older[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "-"+older[i])
+			i++
+		default:
+			diff = append(diff, "+"+newer[j])
+			j++
+		}
+	}
+	for ; i < len(older); i++ {
+		diff = append(diff, "-"+older[i])
+	}
+	for ; j < len(newer); j++ {
+		diff = append(diff, "+"+newer[j])
+	}
+
+	return diff
+}
+
+// diffMetadata сравнивает метаданные по ключам в алфавитном порядке.
+// Возвращает строки с префиксом "-" для удаленных и прежних значений и "+" для добавленных и новых значений.
+func diffMetadata(older, newer map[string]interface{}) []string {
+	keys := make([]string, 0, len(older)+len(newer))
+	for key := range older {
+		keys = append(keys, key)
+	}
+	for key := range newer {
+		if _, ok := older[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diff := make([]string, 0, len(keys))
+	for _, key := range keys {
+		oldValue, inOld := older[key]
+		newValue, inNew := newer[key]
+		if inOld && inNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if inOld {
+			diff = append(diff, fmt.Sprintf("-%s: %v", key, oldValue))
+		}
+		if inNew {
+			diff = append(diff, fmt.Sprintf("+%s: %v", key, newValue))
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+	mdata "github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// ResolveConflictCmd создает команду для выбора версии данных, измененных одновременно на нескольких устройствах.
+func ResolveConflictCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resolve-conflict",
+		Short: "Разрешить конфликт версий данных",
+		Long:  "Разрешить конфликт версий данных.\n\n" + syncHelp,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.Println("\nРежим разрешения конфликтов.")
+
+			conflicts, err := client.GetConflicts()
+			if err != nil {
+				cmd.Println("Ошибка получения конфликтов:", err)
+				return fmt.Errorf("ошибка получения конфликтов: %w", err)
+			}
+
+			if len(conflicts) == 0 {
+				cmd.Println("Конфликтов нет.")
+				return nil
+			}
+
+			for _, conflict := range conflicts {
+				cmd.Printf("Конфликтная копия ID: %s (данные с ID %s)\n", conflict.Copy.ID, conflict.Original.ID)
+				printConflictVersion(cmd, "Локальная версия", conflict.Copy)
+				if conflict.Original.DataType == "" {
+					cmd.Println("  Версия на сервере: данные удалены на другом устройстве")
+				} else {
+					printConflictVersion(cmd, "Версия на сервере", conflict.Original)
+				}
+				cmd.Println("---")
+			}
+
+			cmd.Print("Введите ID конфликтной копии: ")
+			var copyID string
+			_, err = fmt.Scanln(&copyID)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода: %w", err)
+			}
+
+			id, err := parseDataID(copyID)
+			if err != nil {
+				cmd.Println("Ошибка: неверный формат ID")
+				return fmt.Errorf("ошибка: неверный формат ID %w", err)
+			}
+
+			cmd.Println("Выберите версию:")
+			cmd.Println("1. Сохранить локальную версию")
+			cmd.Println("2. Оставить версию с сервера")
+			cmd.Println("3. Сохранить обе версии")
+			cmd.Print("> ")
+			var choice string
+			_, err = fmt.Scanln(&choice)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода: %w", err)
+			}
+
+			resolution, err := strconv.Atoi(choice)
+			if err != nil {
+				cmd.Println("Ошибка: неверный выбор версии")
+				return fmt.Errorf("ошибка: неверный выбор версии %w", err)
+			}
+
+			if err := client.ResolveConflict(id, grpcclient.ConflictResolution(resolution)); err != nil {
+				cmd.Println("Ошибка разрешения конфликта:", err)
+				return fmt.Errorf("ошибка разрешения конфликта: %w", err)
+			}
+
+			cmd.Println("Конфликт разрешен.")
+			if err := client.SyncData(); err != nil && !printConflict(cmd, err) {
+				cmd.Println("Ошибка синхронизации данных:", err)
+				return fmt.Errorf("ошибка синхронизации данных: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// printConflictVersion выводит одну из версий данных в конфликте.
+func printConflictVersion(cmd *cobra.Command, title string, item mdata.Data) {
+	cmd.Printf("  %s:\n", title)
+	if isFile(item) {
+		cmd.Println("    Файл:", fileDescription(item))
+	} else {
+		cmd.Println("    Содержимое:", string(item.DataContent))
+	}
+	cmd.Println("    Метаданные:", item.Metadata)
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+)
+
+// qrQuietZone - ширина светлой рамки вокруг QR-кода в модулях; без нее код плохо распознается.
+const qrQuietZone = 2
+
+// TOTPCmd создает команду для управления двухфакторной аутентификацией.
+func TOTPCmd(client *grpcclient.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "totp",
+		Short: "Управление двухфакторной аутентификацией",
+	}
+
+	cmd.AddCommand(TOTPEnableCmd(client), TOTPDisableCmd(client))
+
+	return cmd
+}
+
+// TOTPEnableCmd создает команду для включения двухфакторной аутентификации:
+// показывает QR-код для приложения-аутентификатора, запрашивает код из него и выводит коды восстановления.
+func TOTPEnableCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable",
+		Short: "Включить двухфакторную аутентификацию",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			secret, uri, err := client.EnableTOTP()
+			if err != nil {
+				cmd.Println("Ошибка настройки двухфакторной аутентификации:", err)
+				return err
+			}
+
+			cmd.Println("Отсканируйте QR-код в приложении-аутентификаторе:")
+			if err := printQRCode(cmd.OutOrStdout(), uri); err != nil {
+				cmd.Println("Не удалось показать QR-код:", err)
+			}
+			cmd.Println("Или введите секрет вручную:", secret)
+
+			reader := bufio.NewReader(os.Stdin)
+			cmd.Print("Введите код из приложения: ")
+			code, _ := reader.ReadString('\n')
+			code = strings.TrimSpace(code)
+
+			recoveryCodes, err := client.ConfirmTOTP(code)
+			if err != nil {
+				cmd.Println("Ошибка включения двухфакторной аутентификации:", err)
+				return err
+			}
+
+			cmd.Println("Двухфакторная аутентификация включена.")
+			cmd.Println("Сохраните коды восстановления в надежном месте. Каждый код действует один раз,")
+			cmd.Println("и больше они показаны не будут:")
+			for _, recoveryCode := range recoveryCodes {
+				cmd.Println("  " + recoveryCode)
+			}
+			return nil
+		},
+	}
+}
+
+// TOTPDisableCmd создает команду для выключения двухфакторной аутентификации.
+func TOTPDisableCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable",
+		Short: "Выключить двухфакторную аутентификацию",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reader := bufio.NewReader(os.Stdin)
+			cmd.Print("Введите код из приложения или код восстановления: ")
+			code, _ := reader.ReadString('\n')
+			code = strings.TrimSpace(code)
+
+			if err := client.DisableTOTP(code); err != nil {
+				cmd.Println("Ошибка выключения двухфакторной аутентификации:", err)
+				return err
+			}
+
+			cmd.Println("Двухфакторная аутентификация выключена.")
+			return nil
+		},
+	}
+}
+
+// printQRCode выводит QR-код с содержимым content символами полублоков: одна строка текста - две строки модулей.
+// Светлые модули выводятся закрашенными, поэтому код читается в терминале с темным фоном.
+func printQRCode(w io.Writer, content string) error {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return fmt.Errorf("ошибка построения QR-кода: %w", err)
+	}
+
+	size := code.Bounds().Dx()
+	light := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= size || y >= size {
+			return true
+		}
+		return isLight(code, x, y)
+	}
+
+	var sb strings.Builder
+	for y := -qrQuietZone; y < size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < size+qrQuietZone; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// isLight сообщает, что модуль QR-кода с координатами x, y светлый.
+func isLight(code barcode.Barcode, x, y int) bool {
+	return color.GrayModel.Convert(code.At(x, y)).(color.Gray).Y > 127
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+	mdata "github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// TrashCmd создает команду для работы с корзиной: просмотра, восстановления и окончательного удаления данных.
+func TrashCmd(client *grpcclient.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Работа с корзиной удаленных данных",
+	}
+
+	cmd.AddCommand(TrashListCmd(client), TrashRestoreCmd(client), TrashEmptyCmd(client))
+
+	return cmd
+}
+
+// TrashListCmd создает команду для просмотра данных в корзине.
+func TrashListCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Показать данные в корзине",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			data, err := client.ListTrash()
+			if err != nil {
+				cmd.Println("Ошибка получения корзины:", err)
+				return fmt.Errorf("ошибка получения корзины: %w", err)
+			}
+
+			if len(data) == 0 {
+				cmd.Println("Корзина пуста.")
+				return nil
+			}
+
+			printTrash(cmd, data)
+			return nil
+		},
+	}
+}
+
+// TrashRestoreCmd создает команду для восстановления данных из корзины.
+func TrashRestoreCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore",
+		Short: "Восстановить данные из корзины по ID",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			data, err := client.ListTrash()
+			if err != nil {
+				cmd.Println("Ошибка получения корзины:", err)
+				return fmt.Errorf("ошибка получения корзины: %w", err)
+			}
+
+			if len(data) == 0 {
+				cmd.Println("Корзина пуста.")
+				return fmt.Errorf("корзина пуста")
+			}
+
+			printTrash(cmd, data)
+
+			cmd.Print("Введите ID данных, которые хотите восстановить: ")
+			var dataID string
+			_, err = fmt.Scanln(&dataID)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода %w", err)
+			}
+
+			id, err := parseDataID(dataID)
+			if err != nil {
+				cmd.Println("Ошибка: неверный формат ID")
+				return fmt.Errorf("ошибка: неверный формат ID %w", err)
+			}
+
+			if err := client.RestoreData(id); err != nil {
+				cmd.Println("Ошибка восстановления данных:", err)
+				return fmt.Errorf("ошибка восстановления данных: %w", err)
+			}
+
+			cmd.Println("Данные успешно восстановлены")
+			return nil
+		},
+	}
+}
+
+// TrashEmptyCmd создает команду для окончательного удаления всех данных из корзины.
+func TrashEmptyCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "empty",
+		Short: "Очистить корзину",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.Print("Данные из корзины будут удалены без возможности восстановления. Продолжить? (y/n): ")
+			var answer string
+			_, err := fmt.Scanln(&answer)
+			if err != nil {
+				cmd.Println("Ошибка ввода:", err)
+				return fmt.Errorf("ошибка ввода %w", err)
+			}
+
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				cmd.Println("Очистка корзины отменена.")
+				return nil
+			}
+
+			purged, err := client.EmptyTrash()
+			if err != nil {
+				cmd.Println("Ошибка очистки корзины:", err)
+				return fmt.Errorf("ошибка очистки корзины: %w", err)
+			}
+
+			cmd.Printf("Корзина очищена, удалено записей: %d\n", purged)
+			return nil
+		},
+	}
+}
+
+// printTrash выводит данные из корзины.
+func printTrash(cmd *cobra.Command, data []mdata.Data) {
+	cmd.Println("Данные в корзине:")
+	for _, item := range data {
+		cmd.Printf("ID: %s, Тип данных: %s, Метаданные: %v, Удалено: %s\n",
+			item.ID, item.DataType, item.Metadata, item.DeletedAt.Local().Format(time.DateTime))
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -37,7 +36,7 @@ func UpdateDataCmd(client *grpcclient.Client) *cobra.Command {
 
 			cmd.Println("Ваши данные:")
 			for _, item := range data {
-				cmd.Printf("ID: %d, Тип данных: %s, Метаданные: %v\n", item.ID, item.DataType, item.Metadata)
+				cmd.Printf("ID: %s, Тип данных: %s, Метаданные: %v\n", item.ID, item.DataType, item.Metadata)
 			}
 
 			cmd.Print("Введите ID документа, который хотите обновить: ")
@@ -48,7 +47,7 @@ func UpdateDataCmd(client *grpcclient.Client) *cobra.Command {
 				return fmt.Errorf("ошибка ввода: %w", err)
 			}
 
-			id, err := strconv.ParseInt(dataID, 10, 64)
+			id, err := parseDataID(dataID)
 			if err != nil {
 				cmd.Println("Ошибка: неверный формат ID")
 				return fmt.Errorf("ошибка: неверный формат ID")
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"os"
 	"strings"
 
@@ -16,6 +17,7 @@ func LoginCmd(client *grpcclient.Client) *cobra.Command {
 	return &cobra.Command{
 		Use:   "login",
 		Short: "Authenticate and receive a token",
+		Long:  "Вход пользователя.\n\n" + syncHelp,
 		Run: func(cmd *cobra.Command, _ []string) {
 
 			reader := bufio.NewReader(os.Stdin)
@@ -29,6 +31,13 @@ func LoginCmd(client *grpcclient.Client) *cobra.Command {
 			password = strings.TrimSpace(password)
 
 			token, err := client.Login(username, password)
+			if errors.Is(err, grpcclient.ErrTOTPRequired) {
+				cmd.Print("Введите код двухфакторной аутентификации или код восстановления: ")
+				code, _ := reader.ReadString('\n')
+				code = strings.TrimSpace(code)
+
+				token, err = client.LoginWithTOTP(username, password, code)
+			}
 			if err != nil {
 				client.Logger.Error("Login failed: %v", err)
 			}
@@ -39,11 +48,13 @@ func LoginCmd(client *grpcclient.Client) *cobra.Command {
 			client.SetMasterKey(encryptionKey)
 			client.SetToken(token)
 
-			if err := client.SyncData(); err != nil {
+			if err := client.SyncData(); err != nil && !printConflict(cmd, err) {
 				cmd.Println("Ошибка синхронизации данных:", err)
 				return
 			}
 
+			// Изменения с других устройств применяются в фоне до выхода из клиента
+			client.StartWatch()
 		},
 	}
 }
@@ -74,3 +85,20 @@ func RegisterCmd(client *grpcclient.Client) *cobra.Command {
 		},
 	}
 }
+
+// LogoutCmd возвращает команду CLI для выхода: сессия завершается на сервере,
+// и ее токены перестают действовать
+func LogoutCmd(client *grpcclient.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "End the session and revoke its tokens",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := client.Logout(); err != nil {
+				return err
+			}
+
+			cmd.Println("Сессия завершена.")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,303 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Формат зашифрованного файла:
+//
+//	заголовок: streamMagic | ключ файла, зашифрованный мастер-ключом (nonce + AES-256-GCM) | префикс nonce частей
+//	части:     AES-256-GCM(часть файла размером StreamChunkSize) ...
+//
+// Каждый файл шифруется собственным случайным ключом, поэтому смена мастер-ключа требует
+// перешифрования только заголовков. Nonce части состоит из префикса, номера части и признака последней части,
+// а заголовок передается как дополнительные данные, поэтому перестановка, удаление или усечение частей
+// и подмена заголовка обнаруживаются при расшифровке.
+const (
+	// StreamChunkSize - размер части файла, шифруемой отдельно.
+	StreamChunkSize = 64 * 1024
+	// StreamMagicSize - число первых байт файла, достаточное для IsEncryptedStream.
+	StreamMagicSize = len(streamMagic)
+
+	// StreamMetadataKey - ключ метаданных записи, в котором клиент отмечает, что файл записи зашифрован.
+	// Значение ключа - StreamFormat.
+	StreamMetadataKey = "encryption"
+	// StreamFormat - обозначение формата зашифрованного файла.
+	StreamFormat = streamMagic
+
+	// streamMagic отличает зашифрованные файлы от файлов, сохраненных до появления шифрования бинарных данных.
+	streamMagic = "GKF1"
+
+	fileKeySize     = 32
+	noncePrefixSize = 7
+	gcmNonceSize    = 12
+	gcmTagSize      = 16
+	wrappedKeySize  = gcmNonceSize + fileKeySize + gcmTagSize
+	streamHeaderLen = len(streamMagic) + wrappedKeySize + noncePrefixSize
+)
+
+// ErrNotEncryptedStream возвращается при расшифровке файла без заголовка зашифрованного формата.
+var ErrNotEncryptedStream = errors.New("файл не зашифрован")
+
+// IsEncryptedStream сообщает, начинается ли prefix с заголовка зашифрованного файла.
+func IsEncryptedStream(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(streamMagic))
+}
+
+// EncryptedSize возвращает размер зашифрованного файла по размеру исходного.
+func EncryptedSize(plainSize int64) int64 {
+	chunks := (plainSize + StreamChunkSize - 1) / StreamChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return int64(streamHeaderLen) + plainSize + chunks*gcmTagSize
+}
+
+// NewEncryptReader возвращает io.Reader, который читает src и отдает его содержимое в зашифрованном виде.
+// Для файла создается случайный ключ, который сохраняется в заголовке зашифрованным мастер-ключом masterKey.
+func NewEncryptReader(src io.Reader, masterKey []byte) (io.Reader, error) {
+	fileKey := make([]byte, fileKeySize)
+	if _, err := io.ReadFull(rand.Reader, fileKey); err != nil {
+		return nil, fmt.Errorf("ошибка генерации ключа файла: %w", err)
+	}
+
+	wrapped, err := sealWithKey(masterKey, fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка шифрования ключа файла: %w", err)
+	}
+
+	header := make([]byte, 0, streamHeaderLen)
+	header = append(header, streamMagic...)
+	header = append(header, wrapped...)
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, fmt.Errorf("ошибка генерации nonce: %w", err)
+	}
+	header = append(header, prefix...)
+
+	aead, err := newGCM(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptReader{
+		src:    src,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		out:    header,
+		chunk:  make([]byte, StreamChunkSize+1),
+	}, nil
+}
+
+// NewDecryptReader возвращает io.Reader, который читает зашифрованный файл из src и отдает исходное содержимое.
+// Возвращает ErrNotEncryptedStream, если src не начинается с заголовка зашифрованного файла,
+// и ошибку, если ключ файла не удается расшифровать мастер-ключом masterKey.
+func NewDecryptReader(src io.Reader, masterKey []byte) (io.Reader, error) {
+	header := make([]byte, streamHeaderLen)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncryptedStream
+		}
+		return nil, fmt.Errorf("ошибка чтения заголовка файла: %w", err)
+	}
+	if !IsEncryptedStream(header) {
+		return nil, ErrNotEncryptedStream
+	}
+
+	wrapped := header[len(streamMagic) : len(streamMagic)+wrappedKeySize]
+	fileKey, err := openWithKey(masterKey, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки ключа файла: %w", err)
+	}
+
+	aead, err := newGCM(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		src:    src,
+		aead:   aead,
+		header: header,
+		prefix: header[streamHeaderLen-noncePrefixSize:],
+		chunk:  make([]byte, StreamChunkSize+gcmTagSize+1),
+	}, nil
+}
+
+// encryptReader шифрует поток по частям по мере чтения.
+type encryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	// chunk хранит очередную часть и один байт следующей, по которому определяется последняя часть
+	chunk    []byte
+	buffered int
+	sealed   []byte
+	out      []byte
+	done     bool
+}
+
+// Read отдает заголовок и зашифрованные части файла. По окончании потока возвращает io.EOF.
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// sealNext читает и шифрует очередную часть файла.
+func (r *encryptReader) sealNext() error {
+	n, err := io.ReadFull(r.src, r.chunk[r.buffered:])
+	n += r.buffered
+	last := false
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	size := min(n, StreamChunkSize)
+	nonce := chunkNonce(r.prefix, r.counter, last)
+	r.sealed = r.aead.Seal(r.sealed[:0], nonce, r.chunk[:size], r.header)
+	r.out = r.sealed
+
+	if last {
+		r.done = true
+		return nil
+	}
+
+	if r.counter == ^uint32(0) {
+		return errors.New("файл слишком большой для шифрования")
+	}
+	r.counter++
+	r.buffered = copy(r.chunk, r.chunk[size:n])
+	return nil
+}
+
+// decryptReader расшифровывает поток по частям по мере чтения.
+type decryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	// chunk хранит очередную зашифрованную часть и один байт следующей, по которому определяется последняя часть
+	chunk    []byte
+	buffered int
+	opened   []byte
+	out      []byte
+	done     bool
+}
+
+// Read отдает расшифрованное содержимое файла. По окончании потока возвращает io.EOF,
+// а если файл поврежден или усечен - ошибку.
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openNext(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// openNext читает и расшифровывает очередную часть файла.
+func (r *decryptReader) openNext() error {
+	n, err := io.ReadFull(r.src, r.chunk[r.buffered:])
+	n += r.buffered
+	last := false
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	size := min(n, StreamChunkSize+gcmTagSize)
+	nonce := chunkNonce(r.prefix, r.counter, last)
+	opened, err := r.aead.Open(r.opened[:0], nonce, r.chunk[:size], r.header)
+	if err != nil {
+		return fmt.Errorf("ошибка расшифровки файла: %w", err)
+	}
+	r.opened = opened
+	r.out = opened
+
+	if last {
+		r.done = true
+		return nil
+	}
+
+	r.counter++
+	r.buffered = copy(r.chunk, r.chunk[size:n])
+	return nil
+}
+
+// chunkNonce формирует nonce части: префикс файла, номер части и признак последней части.
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, gcmNonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[gcmNonceSize-1] = 1
+	}
+	return nonce
+}
+
+// newGCM создает AES-256-GCM для ключа key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealWithKey шифрует plain ключом key и возвращает nonce вместе с шифротекстом.
+func sealWithKey(key, plain []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// openWithKey расшифровывает результат sealWithKey ключом key.
+func openWithKey(key, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,77 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptAll(t *testing.T, content, key []byte) []byte {
+	reader, err := NewEncryptReader(bytes.NewReader(content), key)
+	require.NoError(t, err)
+	encrypted, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return encrypted
+}
+
+func decryptAll(key, encrypted []byte) ([]byte, error) {
+	reader, err := NewDecryptReader(bytes.NewReader(encrypted), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+func TestStreamEncryption(t *testing.T) {
+	key := make([]byte, 32)
+	copy(key, "16-byte-master-key")
+
+	for _, size := range []int{0, 1, StreamChunkSize - 1, StreamChunkSize, StreamChunkSize + 1, 3*StreamChunkSize + 5} {
+		content := make([]byte, size)
+		_, err := rand.Read(content)
+		require.NoError(t, err)
+
+		encrypted := encryptAll(t, content, key)
+		assert.True(t, IsEncryptedStream(encrypted))
+		assert.Equal(t, EncryptedSize(int64(size)), int64(len(encrypted)), "size %d", size)
+
+		decrypted, err := decryptAll(key, encrypted)
+		assert.NoError(t, err, "size %d", size)
+		assert.Equal(t, content, decrypted, "size %d", size)
+	}
+
+	content := bytes.Repeat([]byte("data"), StreamChunkSize/2)
+	encrypted := encryptAll(t, content, key)
+
+	t.Run("каждый файл шифруется своим ключом", func(t *testing.T) {
+		assert.NotEqual(t, encrypted, encryptAll(t, content, key))
+	})
+
+	t.Run("неверный мастер-ключ", func(t *testing.T) {
+		otherKey := make([]byte, 32)
+		_, err := decryptAll(otherKey, encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("измененная часть файла", func(t *testing.T) {
+		tampered := bytes.Clone(encrypted)
+		tampered[len(tampered)-1] ^= 1
+		_, err := decryptAll(key, tampered)
+		assert.Error(t, err)
+	})
+
+	t.Run("усеченный файл", func(t *testing.T) {
+		truncated := encrypted[:EncryptedSize(StreamChunkSize)]
+		_, err := decryptAll(key, truncated)
+		assert.Error(t, err)
+	})
+
+	t.Run("незашифрованный файл", func(t *testing.T) {
+		_, err := decryptAll(key, []byte("plain file"))
+		assert.ErrorIs(t, err, ErrNotEncryptedStream)
+	})
+}
@@ -2,28 +2,190 @@ package grpcclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/Sofja96/GophKeeper.git/proto"
 )
 
+// tokenRefreshMargin - запас времени до истечения токена доступа, при котором он обновляется заранее.
+const tokenRefreshMargin = 30 * time.Second
+
+// ErrTOTPRequired возвращается при входе, если пароль верный,
+// но у пользователя включена двухфакторная аутентификация и нужен код.
+var ErrTOTPRequired = errors.New("требуется код двухфакторной аутентификации")
+
 // Login выполняет аутентификацию пользователя с использованием логина и пароля.
 // При успешной аутентификации возвращает токен пользователя, который можно использовать для дальнейших запросов.
 // Если аутентификация не удалась, возвращает ошибку.
+// Если у пользователя включена двухфакторная аутентификация, возвращает ErrTOTPRequired;
+// тогда вход повторяется через LoginWithTOTP.
 func (c *Client) Login(username, password string) (string, error) {
+	return c.LoginWithTOTP(username, password, "")
+}
+
+// LoginWithTOTP выполняет аутентификацию пользователя по логину, паролю и коду двухфакторной аутентификации
+// из приложения или коду восстановления.
+func (c *Client) LoginWithTOTP(username, password, code string) (string, error) {
 	req := &proto.LoginRequest{
 		Username: username,
 		Password: password,
+		TotpCode: code,
 	}
 	resp, err := c.Client.Login(context.Background(), req)
 	if err != nil {
 		return "", fmt.Errorf("login failed: %w", err)
 	}
+	if resp.TotpRequired {
+		return "", ErrTOTPRequired
+	}
 
 	c.UserID = resp.UserId
+	c.setTokens(resp.Token, resp.RefreshToken, resp.ExpiresIn)
+	return resp.Token, nil
+}
+
+// Logout завершает сессию на сервере, после чего ее токены перестают действовать,
+// останавливает фоновое получение изменений и забывает токены и мастер-ключ.
+func (c *Client) Logout() error {
+	c.StopWatch()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+	_, err := c.Client.Logout(ctx, &proto.LogoutRequest{})
+
+	c.setTokens("", "", 0)
+	c.SetMasterKey(nil)
+
+	if err != nil {
+		return fmt.Errorf("logout failed: %w", err)
+	}
+	return nil
+}
+
+// RefreshAccessToken получает новый токен доступа по токену обновления.
+// Токен обновления при этом заменяется новым, выданным сервером.
+func (c *Client) RefreshAccessToken() error {
+	_, err := c.refreshAccessToken(c.GetToken())
+	return err
+}
+
+// refreshAccessToken обновляет токен доступа, если он все еще равен stale, и возвращает текущий токен.
+// Если токен уже обновлен другим запросом, пока этот ждал своей очереди, повторно он не обновляется:
+// сервер отзывает сессию при повторном предъявлении замененного токена обновления.
+func (c *Client) refreshAccessToken(stale string) (string, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.tokenMu.RLock()
+	token, refreshToken := c.Token, c.refreshToken
+	c.tokenMu.RUnlock()
+
+	if token != stale && token != "" {
+		return token, nil
+	}
+	if refreshToken == "" {
+		return "", errors.New("токен обновления отсутствует, выполните вход")
+	}
+
+	resp, err := c.Client.RefreshToken(context.Background(), &proto.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return "", fmt.Errorf("не удалось обновить токен доступа: %w", err)
+	}
+
+	c.setTokens(resp.Token, resp.RefreshToken, resp.ExpiresIn)
 	return resp.Token, nil
 }
 
+// setTokens сохраняет токен доступа, токен обновления и время жизни токена доступа в секундах.
+func (c *Client) setTokens(token, refreshToken string, expiresIn int64) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.Token = token
+	c.refreshToken = refreshToken
+	c.tokenExpiry = time.Time{}
+	if expiresIn > 0 {
+		c.tokenExpiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+}
+
+// tokenExpiresSoon сообщает, что токен доступа истекает в ближайшие tokenRefreshMargin.
+func (c *Client) tokenExpiresSoon() bool {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
+	return c.refreshToken != "" && !c.tokenExpiry.IsZero() && time.Until(c.tokenExpiry) < tokenRefreshMargin
+}
+
+// refreshUnaryInterceptor повторяет запрос с новым токеном доступа, если сервер отклонил токен запроса.
+func (c *Client) refreshUnaryInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if status.Code(err) != codes.Unauthenticated || !requiresToken(method) {
+		return err
+	}
+
+	token, refreshErr := c.refreshAccessToken(outgoingToken(ctx))
+	if refreshErr != nil {
+		if c.Logger != nil {
+			c.Logger.Error("Token refresh failed: %v", refreshErr)
+		}
+		return err
+	}
+
+	return invoker(withToken(ctx, token), method, req, reply, cc, opts...)
+}
+
+// refreshStreamInterceptor обновляет токен доступа перед открытием потока, если токен скоро истечет:
+// ошибку аутентификации потока клиент получает только при чтении, когда повторить запрос уже нельзя.
+func (c *Client) refreshStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if requiresToken(method) && c.tokenExpiresSoon() {
+		token, err := c.refreshAccessToken(outgoingToken(ctx))
+		if err == nil {
+			ctx = withToken(ctx, token)
+		} else if c.Logger != nil {
+			c.Logger.Error("Token refresh failed: %v", err)
+		}
+	}
+
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// requiresToken сообщает, что метод method вызывается с токеном доступа.
+func requiresToken(method string) bool {
+	for _, public := range []string{"/Login", "/Register", "/RefreshToken", "/GetJWKS"} {
+		if strings.HasSuffix(method, public) {
+			return false
+		}
+	}
+	return true
+}
+
+// outgoingToken возвращает токен доступа из исходящих метаданных запроса.
+func outgoingToken(ctx context.Context) string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		return values[len(values)-1]
+	}
+	return ""
+}
+
+// withToken заменяет токен доступа в исходящих метаданных запроса.
+func withToken(ctx context.Context, token string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set("authorization", token)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
 // Register регистрирует нового пользователя с заданным логином и паролем.
 // Если регистрация прошла успешно, функция возвращает nil. В случае ошибки возвращается ошибка с описанием причины.
 func (c *Client) Register(username, password string) error {
@@ -0,0 +1,294 @@
+package grpcclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
+	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// blobChunkSize - размер части файла, отправляемой на сервер в одном сообщении потока.
+const blobChunkSize = 64 * 1024
+
+// UploadBlob потоково отправляет содержимое бинарных данных на сервер частями.
+// Запись без серверной ревизии создается на сервере с ID data.ID, иначе обновляется существующая
+// при совпадении ее версии на сервере с data.Version.
+// Возвращает ID записи на сервере и присвоенные ей ревизию и версию.
+func (c *Client) UploadBlob(ctx context.Context, data models.Data, content io.Reader, size int64) (ServerState, error) {
+	structMetadata, err := models.ConvertJSONBToStruct(data.Metadata)
+	if err != nil {
+		return ServerState{}, fmt.Errorf("ошибка преобразования метаданных: %w", err)
+	}
+
+	fileName := data.FileName
+	if fileName == "" {
+		fileName = data.ID
+	}
+
+	stream, err := c.Client.UploadBlob(ctx)
+	if err != nil {
+		return ServerState{}, fmt.Errorf("ошибка открытия потока загрузки: %w", err)
+	}
+
+	err = stream.Send(&proto.UploadBlobRequest{
+		Payload: &proto.UploadBlobRequest_Info{
+			Info: &proto.UploadBlobInfo{
+				DataId:          data.ID,
+				Create:          data.Revision == 0,
+				FileName:        fileName,
+				Metadata:        structMetadata,
+				Size:            size,
+				ExpectedVersion: data.Version,
+				IdempotencyKey:  data.IdempotencyKey,
+			},
+		},
+	})
+	if err != nil {
+		return ServerState{}, fmt.Errorf("ошибка отправки описания файла: %w", err)
+	}
+
+	buf := make([]byte, blobChunkSize)
+	for {
+		n, readErr := content.Read(buf)
+		if n > 0 {
+			err = stream.Send(&proto.UploadBlobRequest{
+				Payload: &proto.UploadBlobRequest_Chunk{Chunk: buf[:n]},
+			})
+			// Сервер завершил поток досрочно, например вернул ранее созданную запись по ключу идемпотентности;
+			// результат или ошибка возвращаются из CloseAndRecv
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return ServerState{}, fmt.Errorf("ошибка отправки части файла: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return ServerState{}, fmt.Errorf("ошибка чтения файла: %w", readErr)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return ServerState{}, fmt.Errorf("ошибка загрузки файла на сервер: %w", err)
+	}
+
+	return ServerState{ID: resp.DataId, Revision: resp.Revision, Version: resp.Version}, nil
+}
+
+// DownloadBlob открывает поток получения бинарных данных с сервера.
+// Возвращаемый io.Reader читает содержимое файла по мере поступления частей.
+func (c *Client) DownloadBlob(ctx context.Context, dataID string) (io.Reader, error) {
+	stream, err := c.Client.DownloadBlob(ctx, &proto.DownloadBlobRequest{DataId: dataID})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия потока получения файла: %w", err)
+	}
+
+	return &blobStreamReader{stream: stream}, nil
+}
+
+// FetchBlob открывает поток получения файла бинарных данных по ID из описания файла, полученного с сервера.
+// Возвращаемый io.Reader читает содержимое файла по мере поступления частей.
+func (c *Client) FetchBlob(ctx context.Context, blobID string) (io.Reader, error) {
+	stream, err := c.Client.FetchBlob(ctx, &proto.FetchBlobRequest{BlobId: blobID})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия потока получения файла: %w", err)
+	}
+
+	return &blobStreamReader{stream: stream}, nil
+}
+
+// FetchFile возвращает путь к локальному файлу бинарных данных записи dataID.
+// При синхронизации с сервера получаются только описания файлов, поэтому файл, которого еще нет
+// в локальном хранилище, загружается с сервера при первом обращении и дальше берется из локального хранилища.
+func (c *Client) FetchFile(dataID string) (string, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	item, exists := localData[dataID]
+	if !exists {
+		return "", fmt.Errorf("данные с ID %s не найдены", dataID)
+	}
+	if item.DataType != models.BinaryData {
+		return "", fmt.Errorf("данные с ID %s не являются бинарными", dataID)
+	}
+
+	item.ID = dataID
+	if err := c.ensureLocalFile(ctx, item); err != nil {
+		return "", err
+	}
+
+	return localstorage.GetFilePath(c.UserID, dataID), nil
+}
+
+// ensureLocalFile загружает с сервера файл бинарных данных записи, если его нет в локальном хранилище.
+// Если файл хранится на сервере незашифрованным, запись помечается для повторной отправки на сервер.
+func (c *Client) ensureLocalFile(ctx context.Context, item models.Data) error {
+	if !isLocalFile(item) || localstorage.HasFile(c.UserID, item.ID) {
+		return nil
+	}
+
+	legacy, err := c.saveServerFile(ctx, item)
+	if err != nil {
+		return err
+	}
+
+	if legacy {
+		return localstorage.MarkDirty(c.UserID, item.ID)
+	}
+	return nil
+}
+
+// uploadLocalFile шифрует локальный файл бинарных данных мастер-ключом клиента и отправляет его на сервер.
+// Файл, еще не полученный с сервера, предварительно загружается, чтобы отправить изменения записи вместе с ним.
+// В метаданные записи на сервере добавляется отметка о шифровании файла, а локально запоминается,
+// что файл записи зашифрован.
+func (c *Client) uploadLocalFile(ctx context.Context, data models.Data) (ServerState, error) {
+	if err := c.ensureLocalFile(ctx, data); err != nil {
+		return ServerState{}, err
+	}
+
+	file, size, err := localstorage.OpenFile(c.UserID, data.ID)
+	if err != nil {
+		return ServerState{}, err
+	}
+	defer file.Close()
+
+	encrypted, err := encryption.NewEncryptReader(file, c.GetMasterKey())
+	if err != nil {
+		return ServerState{}, fmt.Errorf("ошибка шифрования файла: %w", err)
+	}
+
+	metadata := make(models.JSONB, len(data.Metadata)+1)
+	for key, value := range data.Metadata {
+		metadata[key] = value
+	}
+	metadata[encryption.StreamMetadataKey] = encryption.StreamFormat
+	data.Metadata = metadata
+
+	state, err := c.UploadBlob(ctx, data, encrypted, encryption.EncryptedSize(size))
+	if err != nil {
+		return ServerState{}, err
+	}
+
+	return state, localstorage.MarkEncrypted(c.UserID, state.ID)
+}
+
+// saveServerFile расшифровывает содержимое бинарных данных с сервера и сохраняет его в локальный файл.
+// Если сервер не передал содержимое в ответе, файл загружается потоком через FetchBlob по описанию файла,
+// а для записей без описания - через DownloadBlob.
+//
+// Зашифрованный файл определяется по заголовку формата. Файлы, отправленные на сервер до появления шифрования
+// бинарных данных, хранятся на сервере незашифрованными: они сохраняются как есть, а функция возвращает true,
+// чтобы запись была отправлена на сервер повторно уже в зашифрованном виде. Из Base64 декодируется только
+// содержимое, переданное в самой записи: так его передавали старые клиенты и сервер, а файл, полученный потоком,
+// может оказаться корректным Base64 случайно. Незашифрованный файл принимается, только если клиент не отправлял
+// и не получал файл записи зашифрованным и в метаданных записи нет отметки о шифровании. Иначе файл на сервере
+// считается подмененным и возвращается ошибка: метаданные на сервере могут быть изменены вместе с файлом.
+func (c *Client) saveServerFile(ctx context.Context, data models.Data) (bool, error) {
+	var content io.Reader
+	switch {
+	case len(data.DataContent) > 0:
+		content = bytes.NewReader(data.DataContent)
+	case data.Blob != nil:
+		reader, err := c.FetchBlob(ctx, data.Blob.ID)
+		if err != nil {
+			return false, err
+		}
+		content = reader
+	default:
+		reader, err := c.DownloadBlob(ctx, data.ID)
+		if err != nil {
+			return false, err
+		}
+		content = reader
+	}
+
+	buffered := bufio.NewReader(content)
+	magic, err := buffered.Peek(encryption.StreamMagicSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("ошибка получения файла: %w", err)
+	}
+
+	encrypted, err := localstorage.IsEncrypted(c.UserID, data.ID)
+	if err != nil {
+		return false, err
+	}
+
+	legacy := !encryption.IsEncryptedStream(magic)
+	if legacy && (encrypted || data.Metadata[encryption.StreamMetadataKey] != nil) {
+		return false, fmt.Errorf("ошибка получения файла записи %s: %w", data.ID, encryption.ErrNotEncryptedStream)
+	}
+
+	switch {
+	case !legacy:
+		content, err = encryption.NewDecryptReader(buffered, c.GetMasterKey())
+		if err != nil {
+			return false, err
+		}
+	case len(data.DataContent) > 0:
+		content = bytes.NewReader(legacyFileContent(data.DataContent))
+	default:
+		content = buffered
+	}
+
+	if _, err := localstorage.SaveFile(c.UserID, data.ID, content); err != nil {
+		return false, fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+
+	if !legacy {
+		if err := localstorage.MarkEncrypted(c.UserID, data.ID); err != nil {
+			return false, err
+		}
+	}
+
+	return legacy, nil
+}
+
+// legacyFileContent возвращает содержимое бинарных данных, переданное в самой записи старыми клиентами
+// или сервером: такое содержимое кодировалось в Base64, поэтому декодируется, а если оно не в Base64 -
+// возвращается как есть. Файлы, полученные потоком, через эту функцию не проходят.
+func legacyFileContent(content []byte) []byte {
+	decoded, err := encryption.DecodeData(string(content))
+	if err != nil {
+		return content
+	}
+	return decoded
+}
+
+// blobStreamReader представляет поток DownloadBlob в виде io.Reader.
+type blobStreamReader struct {
+	stream proto.GophKeeper_DownloadBlobClient
+	buf    []byte
+}
+
+// Read читает очередную часть файла из потока. По окончании потока возвращает io.EOF.
+func (r *blobStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		resp, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = resp.Chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -1,8 +1,11 @@
 package grpcclient
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -20,6 +23,14 @@ type Client struct {
 	EncryptionKey []byte
 	Token         string
 	UserID        int64
+
+	refreshToken string       // токен обновления, по которому клиент получает новый токен доступа
+	tokenExpiry  time.Time    // время истечения токена доступа
+	tokenMu      sync.RWMutex // защищает Token, refreshToken и tokenExpiry
+	refreshMu    sync.Mutex   // не дает нескольким запросам обновлять токен одновременно
+
+	syncMu      sync.Mutex         // не дает фоновой синхронизации выполняться одновременно с SyncData
+	watchCancel context.CancelFunc // останавливает фоновое получение изменений
 }
 
 // NewGRPCClient создает новый клиент для подключения к серверу GophKeeper.
@@ -38,22 +49,25 @@ func NewGRPCClient(settings *settings.Settings) (*Client, error) {
 		InsecureSkipVerify: true,
 	})
 
+	c := &Client{Logger: logger}
+
 	conn, err := grpc.NewClient(settings.Host+":"+settings.Port,
-		grpc.WithTransportCredentials(cred))
+		grpc.WithTransportCredentials(cred),
+		grpc.WithChainUnaryInterceptor(c.refreshUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(c.refreshStreamInterceptor))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to server: %w", err)
 	}
 
-	client := proto.NewGophKeeperClient(conn)
-	return &Client{
-		conn:   conn,
-		Client: client,
-		Logger: logger,
-	}, nil
+	c.conn = conn
+	c.Client = proto.NewGophKeeperClient(conn)
+	return c, nil
 }
 
-// Close закрывает соединение.
+// Close останавливает фоновое получение изменений и закрывает соединение.
 func (c *Client) Close() {
+	c.StopWatch()
+
 	err := c.conn.Close()
 	if err != nil {
 		c.Logger.Fatal("error closing connection: %v", err)
@@ -72,10 +86,16 @@ func (c *Client) GetMasterKey() []byte {
 
 // SetToken устанавливает токен для аунтентификации
 func (c *Client) SetToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
 	c.Token = token
 }
 
 // GetToken получает токен для аутентификации
 func (c *Client) GetToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+
 	return c.Token
 }
@@ -1,13 +1,20 @@
 package grpcclient
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	mlogging "github.com/Sofja96/GophKeeper.git/internal/server/logger/mocks"
 	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
 	"github.com/Sofja96/GophKeeper.git/pkg"
 	"github.com/Sofja96/GophKeeper.git/proto"
@@ -69,6 +76,7 @@ func TestClient_Login_Success(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, "mock-token", token)
+	assert.Equal(t, "mock-token", client.GetToken())
 }
 
 func TestClient_Login_Error(t *testing.T) {
@@ -93,6 +101,75 @@ func TestClient_Login_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "login failed")
 }
 
+func TestClient_LoginWithTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	client := &Client{Client: mockClient}
+
+	t.Run("code required", func(t *testing.T) {
+		mockClient.EXPECT().Login(gomock.Any(), &proto.LoginRequest{Username: "testuser", Password: "password123"}).
+			Return(&proto.LoginResponse{TotpRequired: true}, nil)
+
+		_, err := client.Login("testuser", "password123")
+		assert.ErrorIs(t, err, ErrTOTPRequired)
+		assert.Empty(t, client.GetToken())
+	})
+
+	t.Run("login with code", func(t *testing.T) {
+		mockClient.EXPECT().Login(gomock.Any(), &proto.LoginRequest{
+			Username: "testuser",
+			Password: "password123",
+			TotpCode: "123456",
+		}).Return(&proto.LoginResponse{Token: "mock-token", UserId: 1}, nil)
+
+		token, err := client.LoginWithTOTP("testuser", "password123", "123456")
+		require.NoError(t, err)
+		assert.Equal(t, "mock-token", token)
+		assert.Equal(t, int64(1), client.UserID)
+	})
+}
+
+func TestClient_TOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	client := &Client{Client: mockClient}
+	client.SetToken("Bearer access")
+
+	t.Run("enable", func(t *testing.T) {
+		mockClient.EXPECT().EnableTOTP(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ *proto.EnableTOTPRequest, _ ...grpc.CallOption) (*proto.EnableTOTPResponse, error) {
+				assert.Equal(t, "Bearer access", outgoingToken(ctx))
+				return &proto.EnableTOTPResponse{Secret: "SECRET", OtpauthUri: "otpauth://totp/x"}, nil
+			})
+
+		secret, uri, err := client.EnableTOTP()
+		require.NoError(t, err)
+		assert.Equal(t, "SECRET", secret)
+		assert.Equal(t, "otpauth://totp/x", uri)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		mockClient.EXPECT().ConfirmTOTP(gomock.Any(), &proto.ConfirmTOTPRequest{Code: "123456"}).
+			Return(&proto.ConfirmTOTPResponse{RecoveryCodes: []string{"aaaa-bbbb-cccc-dddd"}}, nil)
+
+		codes, err := client.ConfirmTOTP("123456")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aaaa-bbbb-cccc-dddd"}, codes)
+	})
+
+	t.Run("disable error", func(t *testing.T) {
+		mockClient.EXPECT().DisableTOTP(gomock.Any(), &proto.DisableTOTPRequest{Code: "000000"}).
+			Return(nil, status.Error(codes.InvalidArgument, "invalid two-factor code"))
+
+		err := client.DisableTOTP("000000")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
 func TestClient_Register_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -131,3 +208,164 @@ func TestClient_Register_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "registration failed")
 }
+
+func TestClient_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	client := &Client{Client: mockClient}
+	client.setTokens("Bearer access", "refresh", 900)
+	client.SetMasterKey([]byte("key"))
+
+	mockClient.EXPECT().Logout(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ *proto.LogoutRequest, _ ...grpc.CallOption) (*proto.LogoutResponse, error) {
+			assert.Equal(t, "Bearer access", outgoingToken(ctx))
+			return &proto.LogoutResponse{}, nil
+		})
+
+	err := client.Logout()
+	assert.NoError(t, err)
+	assert.Empty(t, client.GetToken())
+	assert.Nil(t, client.GetMasterKey())
+	assert.Error(t, client.RefreshAccessToken())
+}
+
+func TestClient_RefreshUnaryInterceptor(t *testing.T) {
+	unauthenticated := status.Error(codes.Unauthenticated, "token expired")
+
+	t.Run("retries request with refreshed token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mproto.NewMockGophKeeperClient(ctrl)
+		client := &Client{Client: mockClient}
+		client.setTokens("Bearer old", "refresh-1", 900)
+
+		mockClient.EXPECT().RefreshToken(gomock.Any(), &proto.RefreshTokenRequest{RefreshToken: "refresh-1"}).
+			Return(&proto.RefreshTokenResponse{Token: "Bearer new", RefreshToken: "refresh-2", ExpiresIn: 900}, nil)
+
+		var tokens []string
+		invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			tokens = append(tokens, outgoingToken(ctx))
+			if len(tokens) == 1 {
+				return unauthenticated
+			}
+			return nil
+		}
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", client.GetToken())
+		err := client.refreshUnaryInterceptor(ctx, "/keeper.GophKeeper/ListData", nil, nil, nil, invoker)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer old", "Bearer new"}, tokens)
+		assert.Equal(t, "Bearer new", client.GetToken())
+
+		// Токен обновления заменен новым
+		mockClient.EXPECT().RefreshToken(gomock.Any(), &proto.RefreshTokenRequest{RefreshToken: "refresh-2"}).
+			Return(&proto.RefreshTokenResponse{Token: "Bearer newer", RefreshToken: "refresh-3", ExpiresIn: 900}, nil)
+		require.NoError(t, client.RefreshAccessToken())
+		assert.Equal(t, "Bearer newer", client.GetToken())
+	})
+
+	t.Run("does not refresh token already refreshed by another request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := &Client{Client: mproto.NewMockGophKeeperClient(ctrl)}
+		client.setTokens("Bearer new", "refresh-2", 900)
+
+		var tokens []string
+		invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			tokens = append(tokens, outgoingToken(ctx))
+			if len(tokens) == 1 {
+				return unauthenticated
+			}
+			return nil
+		}
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer old")
+		err := client.refreshUnaryInterceptor(ctx, "/keeper.GophKeeper/ListData", nil, nil, nil, invoker)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bearer old", "Bearer new"}, tokens)
+	})
+
+	t.Run("returns original error if refresh fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mproto.NewMockGophKeeperClient(ctrl)
+		mockLogger := mlogging.NewMockILogger(ctrl)
+		client := &Client{Client: mockClient, Logger: mockLogger}
+		client.setTokens("Bearer old", "refresh-1", 900)
+
+		mockClient.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).
+			Return(nil, status.Error(codes.Unauthenticated, "refresh token is invalid or revoked"))
+		mockLogger.EXPECT().Error("Token refresh failed: %v", gomock.Any())
+
+		calls := 0
+		invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			calls++
+			return unauthenticated
+		}
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", client.GetToken())
+		err := client.refreshUnaryInterceptor(ctx, "/keeper.GophKeeper/ListData", nil, nil, nil, invoker)
+		assert.Equal(t, unauthenticated, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("does not refresh on public methods and other errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := &Client{Client: mproto.NewMockGophKeeperClient(ctrl)}
+		client.setTokens("Bearer old", "refresh-1", 900)
+
+		invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return unauthenticated
+		}
+		err := client.refreshUnaryInterceptor(context.Background(), "/keeper.GophKeeper/Login", nil, nil, nil, invoker)
+		assert.Equal(t, unauthenticated, err)
+
+		notFound := status.Error(codes.NotFound, "not found")
+		invoker = func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+			return notFound
+		}
+		err = client.refreshUnaryInterceptor(context.Background(), "/keeper.GophKeeper/ListData", nil, nil, nil, invoker)
+		assert.Equal(t, notFound, err)
+	})
+}
+
+func TestClient_RefreshStreamInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+	client := &Client{Client: mockClient}
+
+	var token string
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		token = outgoingToken(ctx)
+		return nil, nil
+	}
+
+	t.Run("keeps token that does not expire soon", func(t *testing.T) {
+		client.setTokens("Bearer old", "refresh-1", 900)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", client.GetToken())
+		_, err := client.refreshStreamInterceptor(ctx, nil, nil, "/keeper.GophKeeper/WatchChanges", streamer)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer old", token)
+	})
+
+	t.Run("refreshes token that expires soon", func(t *testing.T) {
+		client.setTokens("Bearer old", "refresh-1", 10)
+		mockClient.EXPECT().RefreshToken(gomock.Any(), gomock.Any()).
+			Return(&proto.RefreshTokenResponse{Token: "Bearer new", RefreshToken: "refresh-2", ExpiresIn: 900}, nil)
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", client.GetToken())
+		_, err := client.refreshStreamInterceptor(ctx, nil, nil, "/keeper.GophKeeper/WatchChanges", streamer)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer new", token)
+	})
+}
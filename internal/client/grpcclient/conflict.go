@@ -0,0 +1,71 @@
+package grpcclient
+
+import (
+	"fmt"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// ConflictError сообщает о данных, которые изменены локально и одновременно на другом устройстве
+// так, что изменения нельзя объединить. Локальные версии таких данных сохранены как конфликтные копии
+// и не отправляются на сервер, пока пользователь не выберет версию через ResolveConflict.
+type ConflictError struct {
+	IDs []string
+}
+
+// Error возвращает описание конфликта со списком ID данных.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("данные с ID %v изменены на другом устройстве, локальные изменения сохранены как конфликтные копии", e.IDs)
+}
+
+// conflictVersion извлекает текущую версию данных на сервере из ошибки конфликта версий.
+// Возвращает false, если ошибка не является конфликтом версий.
+func conflictVersion(err error) (int64, bool) {
+	info, ok := conflictInfo(err)
+	if !ok {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(info.Metadata[models.MetadataCurrentVersion], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// conflictOperation извлекает номер операции пакетного изменения из ошибки конфликта версий.
+// Возвращает false, если ошибка не является конфликтом версий в пакетном изменении.
+func conflictOperation(err error) (int, bool) {
+	info, ok := conflictInfo(err)
+	if !ok {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(info.Metadata[models.MetadataOperation])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// conflictInfo возвращает детали ErrorInfo ошибки конфликта версий.
+func conflictInfo(err error) (*errdetails.ErrorInfo, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Aborted {
+		return nil, false
+	}
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if ok && info.Reason == models.ReasonVersionConflict {
+			return info, true
+		}
+	}
+
+	return nil, false
+}
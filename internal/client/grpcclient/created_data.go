@@ -2,8 +2,11 @@ package grpcclient
 
 import (
 	"fmt"
+	"os"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
 	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
 	"github.com/Sofja96/GophKeeper.git/internal/client/models"
@@ -13,57 +16,78 @@ import (
 
 // CreateData создает новые данные и сохраняет их в локальное хранилище.
 // Функция выполняет валидацию входных данных, преобразует их в JSON, шифрует в зависимости от типа данных,
-// а затем сохраняет данные в локальное хранилище с уникальным идентификатором.
-func (c *Client) CreateData(reqData models.CreateData) (int64, error) {
+// а затем сохраняет данные в локальное хранилище с уникальным идентификатором, под которым запись
+// будет создана и на сервере, и помечает их для отправки на сервер при следующей синхронизации.
+// Записи присваивается ключ идемпотентности, чтобы сервер не создал ее повторно, если ответ на отправку был потерян.
+func (c *Client) CreateData(reqData models.CreateData) (string, error) {
 	var encryptedData string
 	var fileName string
 
 	if err := reqData.Data.Validate(); err != nil {
-		return 0, fmt.Errorf("ошибка валидации данных: %w", err)
+		return "", fmt.Errorf("ошибка валидации данных: %w", err)
 	}
 
 	rawData, err := reqData.Data.ToJSON()
 	if err != nil {
-		return 0, fmt.Errorf("ошибка преобразования в JSON: %w", err)
+		return "", fmt.Errorf("ошибка преобразования в JSON: %w", err)
 	}
 
+	dataID := mdata.NewDataID()
+
 	switch reqData.DataType {
 	case proto.DataType_BINARY_DATA:
 		if binaryData, ok := reqData.Data.(*models.BinaryDataType); ok {
-			encryptedData = encryption.EncodeData(binaryData.Content)
+			if err := saveLocalFile(c.UserID, dataID, binaryData.FilePath); err != nil {
+				return "", fmt.Errorf("ошибка сохранения файла в локальное хранилище: %w", err)
+			}
 			fileName = binaryData.Filename
 		} else {
-			return 0, fmt.Errorf("неверный тип данных для бинарного контента")
+			return "", fmt.Errorf("неверный тип данных для бинарного контента")
 		}
 	default:
 		var err error
 		if encryptedData, err = encryption.EncryptData(rawData, reqData.EncryptionKey); err != nil {
-			return 0, fmt.Errorf("ошибка шифрования: %w", err)
+			return "", fmt.Errorf("ошибка шифрования: %w", err)
 		}
 	}
 
-	tempID := time.Now().UnixNano()
-
 	dataType, err := mdata.GetModelType(reqData.DataType)
 	if err != nil {
-		return 0, fmt.Errorf("ошибка конвертации типа данных: %w", err)
+		return "", fmt.Errorf("ошибка конвертации типа данных: %w", err)
 	}
 
 	data := mdata.Data{
-		ID:          tempID,
-		UserID:      c.UserID,
-		DataType:    dataType,
-		DataContent: []byte(encryptedData),
-		Metadata:    reqData.Metadata.AsMap(),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		FileName:    fileName,
+		ID:             dataID,
+		UserID:         c.UserID,
+		DataType:       dataType,
+		DataContent:    []byte(encryptedData),
+		Metadata:       reqData.Metadata.AsMap(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		FileName:       fileName,
+		IdempotencyKey: uuid.NewString(),
 	}
 
 	if err := localstorage.SaveData(c.UserID, data); err != nil {
-		return 0, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+		return "", fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	if err := localstorage.MarkDirty(c.UserID, dataID); err != nil {
+		return "", fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
 	}
 
 	fmt.Println("Данные успешно сохранены в локальное хранилище.")
-	return tempID, nil
+	return dataID, nil
+}
+
+// saveLocalFile потоково копирует файл с диска пользователя в локальное хранилище.
+func saveLocalFile(userID int64, dataID string, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer file.Close()
+
+	_, err = localstorage.SaveFile(userID, dataID, file)
+	return err
 }
@@ -2,8 +2,11 @@
 package grpcclient
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -11,6 +14,11 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
 	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
@@ -54,7 +62,7 @@ func TestCreateData(t *testing.T) {
 		id, err := grpcClient.CreateData(reqData)
 
 		assert.NoError(t, err)
-		assert.True(t, id > 0)
+		assert.NotEmpty(t, id)
 
 		data, err := localstorage.GetAllData(grpcClient.UserID)
 		assert.NoError(t, err)
@@ -65,6 +73,7 @@ func TestCreateData(t *testing.T) {
 		assert.NotEmpty(t, storedData.DataContent)
 		assert.Equal(t, int64(12345), storedData.UserID)
 		assert.False(t, storedData.UpdatedAt.IsZero())
+		assert.NotEmpty(t, storedData.IdempotencyKey)
 	})
 	t.Run("Успешное создание бинарных данных", func(t *testing.T) {
 		tmpFile, err := os.CreateTemp("", "testfile.txt")
@@ -81,7 +90,6 @@ func TestCreateData(t *testing.T) {
 
 		binaryData := &models.BinaryDataType{
 			FilePath: tmpFile.Name(),
-			Content:  testData,
 			Filename: "testfile.txt",
 		}
 
@@ -95,7 +103,7 @@ func TestCreateData(t *testing.T) {
 		id, err := grpcClient.CreateData(reqData)
 
 		assert.NoError(t, err)
-		assert.True(t, id > 0)
+		assert.NotEmpty(t, id)
 
 		data, err := localstorage.GetAllData(grpcClient.UserID)
 		assert.NoError(t, err)
@@ -103,9 +111,32 @@ func TestCreateData(t *testing.T) {
 
 		storedData := data[id]
 		assert.Equal(t, "BINARY_DATA", storedData.DataType.String())
-		assert.NotEmpty(t, storedData.DataContent)
+		assert.Empty(t, storedData.DataContent)
+		assert.Equal(t, "testfile.txt", storedData.FileName)
 		assert.Equal(t, int64(12345), storedData.UserID)
 		assert.False(t, storedData.UpdatedAt.IsZero())
+
+		storedFile, err := os.ReadFile(localstorage.GetFilePath(grpcClient.UserID, id))
+		assert.NoError(t, err)
+		assert.Equal(t, testData, storedFile)
+	})
+	t.Run("Ошибка открытия бинарного файла", func(t *testing.T) {
+		binaryData := &models.BinaryDataType{
+			FilePath: "/nonexistent/file.txt",
+			Filename: "file.txt",
+		}
+
+		reqData := models.CreateData{
+			Data:          binaryData,
+			DataType:      proto.DataType_BINARY_DATA,
+			EncryptionKey: masterKey,
+			Metadata:      nil,
+		}
+
+		_, err := grpcClient.CreateData(reqData)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка сохранения файла в локальное хранилище")
 	})
 	t.Run("Ошибка валидации текстовых данных", func(t *testing.T) {
 		testData := &models.TextDataType{
@@ -126,7 +157,6 @@ func TestCreateData(t *testing.T) {
 	})
 	t.Run("Ошибка валидации бинарных данных (неверный путь к файлу)", func(t *testing.T) {
 		binaryData := &models.BinaryDataType{
-			Content:  []byte("test data"),
 			Filename: "",
 		}
 
@@ -270,7 +300,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -298,7 +328,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -315,7 +345,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -336,7 +366,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -357,7 +387,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -378,7 +408,6 @@ func TestUpdateData(t *testing.T) {
 		tmpFile.Close()
 
 		binaryData := &models.BinaryDataType{
-			Content:  testData,
 			Filename: "testfile.txt",
 			FilePath: tmpFile.Name(),
 		}
@@ -390,7 +419,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err = grpcClient.UpdateData(reqData, dataId)
 
@@ -402,9 +431,13 @@ func TestUpdateData(t *testing.T) {
 
 		storedData := data[dataId]
 		assert.Equal(t, "BINARY_DATA", storedData.DataType.String())
-		assert.NotEmpty(t, storedData.DataContent)
+		assert.Empty(t, storedData.DataContent)
 		assert.Equal(t, int64(12345), storedData.UserID)
 		assert.False(t, storedData.UpdatedAt.IsZero())
+
+		storedFile, err := os.ReadFile(localstorage.GetFilePath(grpcClient.UserID, dataId))
+		assert.NoError(t, err)
+		assert.Equal(t, testData, storedFile)
 	})
 	t.Run("Ошибка при неверном типе данных для бинарного контента", func(t *testing.T) {
 		invalidData := &models.TextDataType{
@@ -418,7 +451,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err := grpcClient.UpdateData(reqData, dataId)
 
@@ -443,7 +476,7 @@ func TestUpdateData(t *testing.T) {
 			Metadata:      nil,
 		}
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 
 		err = grpcClient.UpdateData(reqData, dataId)
 
@@ -471,7 +504,7 @@ func TestDeleteData(t *testing.T) {
 		EncryptionKey: masterKey,
 	}
 
-	createTestData := func(dataId int64) {
+	createTestData := func(dataId string) {
 		encryptedData, err := encryption.EncryptData([]byte(`{"username":"unutest","password":"test"}`), masterKey)
 		if err != nil {
 			t.Fatalf("Не удалось зашифровать тестовые данные: %v", err)
@@ -484,6 +517,7 @@ func TestDeleteData(t *testing.T) {
 			DataContent: []byte(encryptedData),
 			Metadata:    nil,
 			UpdatedAt:   time.Now(),
+			Revision:    1,
 		}
 
 		err = localstorage.SaveData(grpcClient.UserID, data)
@@ -493,11 +527,11 @@ func TestDeleteData(t *testing.T) {
 	}
 
 	t.Run("Успешное удаление данных", func(t *testing.T) {
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 		createTestData(dataId)
 
 		mockClient.EXPECT().DeleteData(gomock.Any(), &proto.DeleteDataRequest{DataId: dataId}).
-			Return(&proto.DeleteDataResponse{Message: "Данные с ID 1 успешно удалены"}, nil)
+			Return(&proto.DeleteDataResponse{Message: "Данные с ID 1 перемещены в корзину"}, nil)
 
 		err := grpcClient.DeleteData(dataId)
 		assert.NoError(t, err)
@@ -507,7 +541,7 @@ func TestDeleteData(t *testing.T) {
 		assert.NotContains(t, data, dataId)
 	})
 	t.Run("Ошибка удаления данных из локального хранилища", func(t *testing.T) {
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 		createTestData(dataId)
 
 		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
@@ -527,7 +561,7 @@ func TestDeleteData(t *testing.T) {
 		}
 	})
 	t.Run("Ошибка удаления данных через gRPC", func(t *testing.T) {
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 		createTestData(dataId)
 
 		mockClient.EXPECT().DeleteData(gomock.Any(), &proto.DeleteDataRequest{DataId: dataId}).
@@ -537,6 +571,64 @@ func TestDeleteData(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ошибка удаления данных")
 	})
+	t.Run("Конфликт версий при удалении", func(t *testing.T) {
+		dataId := "00000000-0000-0000-0000-000000000002"
+		createTestData(dataId)
+
+		mockClient.EXPECT().DeleteData(gomock.Any(), gomock.Any()).
+			Return(nil, versionConflictStatus(t, dataId, 3))
+
+		err := grpcClient.DeleteData(dataId)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "изменены на другом устройстве")
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Contains(t, data, dataId)
+	})
+	t.Run("Удаление данных, не отправленных на сервер", func(t *testing.T) {
+		data := mdata.Data{ID: "00000000-0000-0000-0000-000000000003", DataType: mdata.TextData, UpdatedAt: time.Now()}
+		assert.NoError(t, localstorage.SaveData(grpcClient.UserID, data))
+
+		err := grpcClient.DeleteData("00000000-0000-0000-0000-000000000003")
+		assert.NoError(t, err)
+	})
+}
+
+// versionConflictStatus возвращает ошибку конфликта версий в том виде, в котором ее отправляет сервер.
+func versionConflictStatus(t *testing.T, dataId string, currentVersion int64) error {
+	st, err := status.Newf(codes.Aborted, "данные с ID %s изменены на другом устройстве", dataId).
+		WithDetails(&errdetails.ErrorInfo{
+			Reason:   mdata.ReasonVersionConflict,
+			Domain:   mdata.ErrorDomain,
+			Metadata: map[string]string{mdata.MetadataCurrentVersion: fmt.Sprintf("%d", currentVersion)},
+		})
+	if err != nil {
+		t.Fatalf("Не удалось создать ошибку конфликта версий: %v", err)
+	}
+	return st.Err()
+}
+
+// operationConflictStatus возвращает ошибку конфликта версий для операции пакетного изменения с номером index.
+func operationConflictStatus(t *testing.T, index int, dataId string, currentVersion int64) error {
+	st, err := status.Newf(codes.Aborted, "операция %d: данные с ID %s изменены на другом устройстве", index, dataId).
+		WithDetails(&errdetails.ErrorInfo{
+			Reason: mdata.ReasonVersionConflict,
+			Domain: mdata.ErrorDomain,
+			Metadata: map[string]string{
+				mdata.MetadataCurrentVersion: fmt.Sprintf("%d", currentVersion),
+				mdata.MetadataOperation:      fmt.Sprintf("%d", index),
+			},
+		})
+	if err != nil {
+		t.Fatalf("Не удалось создать ошибку конфликта версий: %v", err)
+	}
+	return st.Err()
+}
+
+// batchResponse возвращает ответ BatchMutate с указанными результатами операций.
+func batchResponse(results ...*proto.MutationResult) *proto.BatchMutateResponse {
+	return &proto.BatchMutateResponse{Results: results}
 }
 
 func TestGetData(t *testing.T) {
@@ -552,7 +644,7 @@ func TestGetData(t *testing.T) {
 		EncryptionKey: masterKey,
 	}
 
-	createTestData := func(dataId int64) {
+	createTestData := func(dataId string) {
 		encryptedData, err := encryption.EncryptData([]byte(`{"username":"unutest","password":"test"}`), masterKey)
 		if err != nil {
 			t.Fatalf("Не удалось зашифровать тестовые данные: %v", err)
@@ -574,7 +666,7 @@ func TestGetData(t *testing.T) {
 	}
 
 	t.Run("Успешное получение данных", func(t *testing.T) {
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 		createTestData(dataId)
 
 		data, err := grpcClient.GetData()
@@ -589,7 +681,7 @@ func TestGetData(t *testing.T) {
 		assert.Equal(t, expectedData, data[0].DataContent)
 	})
 	t.Run("Успешное получение бинарных данных", func(t *testing.T) {
-		dataId := int64(2)
+		dataId := "00000000-0000-0000-0000-000000000002"
 		binaryContent := []byte("binary data")
 
 		encodedData := encryption.EncodeData(binaryContent)
@@ -625,7 +717,7 @@ func TestGetData(t *testing.T) {
 		assert.Equal(t, binaryContent, foundData.DataContent)
 	})
 	t.Run("Ошибка декодирования бинарных данных", func(t *testing.T) {
-		dataId := int64(3)
+		dataId := "00000000-0000-0000-0000-000000000003"
 		invalidBinaryContent := []byte("invalid binary data")
 
 		data := mdata.Data{
@@ -661,8 +753,44 @@ func TestGetData(t *testing.T) {
 
 		assert.Equal(t, invalidBinaryContent, foundData.DataContent)
 	})
+	t.Run("Получение бинарных данных из локального файла", func(t *testing.T) {
+		dataId := "00000000-0000-0000-0000-000000000005"
+
+		_, err := localstorage.SaveFile(grpcClient.UserID, dataId, bytes.NewReader([]byte("file data")))
+		if err != nil {
+			t.Fatalf("Не удалось создать тестовый файл: %v", err)
+		}
+
+		err = localstorage.SaveData(grpcClient.UserID, mdata.Data{
+			UserID:    grpcClient.UserID,
+			ID:        dataId,
+			DataType:  mdata.BinaryData,
+			FileName:  "testfile.txt",
+			UpdatedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Не удалось создать тестовые данные: %v", err)
+		}
+
+		retrievedData, err := grpcClient.GetData()
+		assert.NoError(t, err)
+
+		var foundData *mdata.Data
+		for _, item := range retrievedData {
+			if item.ID == dataId {
+				foundData = &item
+				break
+			}
+		}
+		assert.NotNil(t, foundData, "Данные с dataId должны быть найдены")
+		assert.Empty(t, foundData.DataContent)
+		assert.Equal(t, "testfile.txt", foundData.FileName)
+		assert.Equal(t, localstorage.GetFilePath(grpcClient.UserID, dataId), foundData.FilePath)
+
+		localstorage.DeleteData(grpcClient.UserID, dataId)
+	})
 	t.Run("Ошибка расшифровки данных", func(t *testing.T) {
-		dataId := int64(4)
+		dataId := "00000000-0000-0000-0000-000000000004"
 		invalidEncryptedData := []byte("invalid encrypted data")
 
 		data := mdata.Data{
@@ -705,6 +833,37 @@ func TestGetData(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ошибка получения данных из локального хранилища")
 	})
+	t.Run("Преобразование хранилища с числовыми ID", func(t *testing.T) {
+		err := os.RemoveAll("user_data")
+		assert.NoError(t, err)
+
+		filesDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID), "files")
+		err = os.MkdirAll(filesDir, 0700)
+		if err != nil {
+			t.Fatalf("Не удалось создать директорию пользователя: %v", err)
+		}
+		err = os.WriteFile(filepath.Join(filesDir, "5"), []byte("file content"), 0600)
+		assert.NoError(t, err)
+
+		legacy := `{"data":{"5":{"id":5,"data_type":"BINARY_DATA","revision":2}},"dirty":{"5":true},"cursor":7}`
+		err = os.WriteFile(filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID), "data.json"), []byte(legacy), 0600)
+		assert.NoError(t, err)
+
+		legacyID := mdata.LegacyDataID(5)
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000005", legacyID)
+		assert.Equal(t, legacyID, data[legacyID].ID)
+		assert.Equal(t, int64(2), data[legacyID].Revision)
+
+		content, err := os.ReadFile(localstorage.GetFilePath(grpcClient.UserID, legacyID))
+		assert.NoError(t, err)
+		assert.Equal(t, "file content", string(content))
+
+		dirty, err := localstorage.GetDirty(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]bool{legacyID: true}, dirty)
+	})
 }
 
 func TestSyncData_Success(t *testing.T) {
@@ -722,7 +881,7 @@ func TestSyncData_Success(t *testing.T) {
 		EncryptionKey: masterKey,
 	}
 
-	createTestData := func(dataId int64, dataType mdata.DataType, content []byte, updatedAt time.Time) {
+	createTestData := func(dataId string, dataType mdata.DataType, content []byte, updatedAt time.Time, revision int64) {
 		data := mdata.Data{
 			UserID:      grpcClient.UserID,
 			ID:          dataId,
@@ -730,6 +889,7 @@ func TestSyncData_Success(t *testing.T) {
 			DataContent: content,
 			Metadata:    nil,
 			UpdatedAt:   updatedAt,
+			Revision:    revision,
 		}
 
 		err := localstorage.SaveData(grpcClient.UserID, data)
@@ -738,282 +898,978 @@ func TestSyncData_Success(t *testing.T) {
 		}
 	}
 
-	t.Run("Успешная синхронизация данных", func(t *testing.T) {
+	t.Run("Применение изменений с сервера", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		dataId := int64(1)
+		dataId := "00000000-0000-0000-0000-000000000001"
 		updatedAt := time.Now()
-		createTestData(dataId, mdata.TextData, []byte("test data"), updatedAt)
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).Return(&proto.GetAllDataResponse{
-			Data: []*proto.DataItem{
-				{
-					DataId:      dataId,
-					DataType:    proto.DataType_TEXT_DATA,
-					DataContent: []byte("test data"),
-					Metadata:    nil,
-					UpdatedAt:   updatedAt.Format(time.RFC3339),
+		mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 0, Limit: syncPageSize}).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:      dataId,
+						DataType:    proto.DataType_TEXT_DATA,
+						DataContent: []byte("test data"),
+						UpdatedAt:   updatedAt.Format(time.RFC3339),
+						Revision:    5,
+					},
 				},
-			},
-		}, nil)
+				Cursor: 5,
+			}, nil)
 
-		mockClient.EXPECT().UpdateData(gomock.Any(), gomock.Any()).
-			Return(&proto.UpdateDataResponse{}, nil)
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("test data"), data[dataId].DataContent)
+		assert.Equal(t, int64(5), data[dataId].Revision)
+
+		cursor, err := localstorage.GetCursor(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), cursor)
+	})
+
+	t.Run("Постраничное получение изменений с сохраненного курсора", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 7))
+
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 7, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{
+					Changed: []*proto.DataItem{
+						{DataId: "00000000-0000-0000-0000-000000000001", DataType: proto.DataType_TEXT_DATA, UpdatedAt: time.Now().Format(time.RFC3339), Revision: 8},
+					},
+					Cursor:  8,
+					HasMore: true,
+				}, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 8, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{
+					Changed: []*proto.DataItem{
+						{DataId: "00000000-0000-0000-0000-000000000002", DataType: proto.DataType_TEXT_DATA, UpdatedAt: time.Now().Format(time.RFC3339), Revision: 9},
+					},
+					Cursor: 9,
+				}, nil),
+		)
 
 		err := grpcClient.SyncData()
 		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Len(t, data, 2)
+
+		cursor, err := localstorage.GetCursor(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), cursor)
 	})
 
-	t.Run("Обновление локальных данных, если серверные данные новее", func(t *testing.T) {
+	encrypt := func(text string) []byte {
+		encrypted, err := encryption.EncryptData([]byte(text), masterKey)
+		if err != nil {
+			t.Fatalf("Не удалось зашифровать тестовые данные: %v", err)
+		}
+		return []byte(encrypted)
+	}
+
+	// createSyncedData создает запись, синхронизированную с сервером в ревизии 3 и версии 1,
+	// и изменяет ее локально.
+	createSyncedData := func(dataId string, base, local mdata.Data) {
+		base.UserID, base.ID, base.DataType = grpcClient.UserID, dataId, mdata.TextData
+		assert.NoError(t, localstorage.SaveData(grpcClient.UserID, base))
+		assert.NoError(t, localstorage.MarkSynced(grpcClient.UserID, dataId, 3, 1))
+
+		local.UserID, local.ID, local.DataType = grpcClient.UserID, dataId, mdata.TextData
+		local.Revision, local.Version = 3, 1
+		assert.NoError(t, localstorage.SaveData(grpcClient.UserID, local))
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, dataId))
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
+	}
+
+	t.Run("Объединение непересекающихся изменений", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		dataId := int64(1)
-		localUpdatedAt := time.Now().Add(-1 * time.Hour) // Локальные данные старше
-		serverUpdatedAt := time.Now()
+		dataId := "00000000-0000-0000-0000-000000000001"
+		baseContent := encrypt("base")
+		serverContent := encrypt("server")
+		createSyncedData(dataId,
+			mdata.Data{DataContent: baseContent, Metadata: map[string]interface{}{"site": "example.com"}},
+			mdata.Data{DataContent: baseContent, Metadata: map[string]interface{}{"site": "example.com", "note": "local"}})
 
-		createTestData(dataId, mdata.TextData, []byte("old data"), localUpdatedAt)
+		serverMetadata, err := mdata.ConvertJSONBToStruct(map[string]interface{}{"site": "example.com"})
+		assert.NoError(t, err)
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
 					{
 						DataId:      dataId,
 						DataType:    proto.DataType_TEXT_DATA,
-						DataContent: []byte("new data"),
-						Metadata:    nil,
-						UpdatedAt:   serverUpdatedAt.Format(time.RFC3339),
+						DataContent: serverContent,
+						Metadata:    serverMetadata,
+						UpdatedAt:   time.Now().Format(time.RFC3339),
+						Revision:    4,
+						Version:     2,
 					},
 				},
+				Cursor: 4,
 			}, nil)
 
-		err := grpcClient.SyncData()
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, batch *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+				req := batch.Mutations[0].GetUpdate()
+				assert.Equal(t, int64(2), req.ExpectedVersion)
+				assert.Equal(t, serverContent, req.DataContent)
+				assert.Equal(t, map[string]interface{}{"site": "example.com", "note": "local"}, req.Metadata.AsMap())
+				return batchResponse(&proto.MutationResult{DataId: dataId, Revision: 5, Version: 3}), nil
+			})
+
+		err = grpcClient.SyncData()
 		assert.NoError(t, err)
 
 		data, err := localstorage.GetAllData(grpcClient.UserID)
 		assert.NoError(t, err)
-		assert.Equal(t, []byte("new data"), data[dataId].DataContent)
+		assert.Equal(t, serverContent, data[dataId].DataContent)
+		assert.Equal(t, int64(5), data[dataId].Revision)
+		assert.Equal(t, int64(3), data[dataId].Version)
 	})
 
-	t.Run("Синхронизация данных с сервера в локальное хранилище", func(t *testing.T) {
+	t.Run("Конфликтная копия при пересекающихся изменениях", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		dataId := int64(1)
-		updatedAt := time.Now()
+		dataId := "00000000-0000-0000-0000-000000000001"
+		localContent := encrypt("local")
+		serverContent := encrypt("server")
+		createSyncedData(dataId, mdata.Data{DataContent: encrypt("base")}, mdata.Data{DataContent: localContent})
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
 					{
 						DataId:      dataId,
 						DataType:    proto.DataType_TEXT_DATA,
-						DataContent: []byte("test data"),
-						Metadata:    nil,
-						UpdatedAt:   updatedAt.Format(time.RFC3339),
+						DataContent: serverContent,
+						UpdatedAt:   time.Now().Format(time.RFC3339),
+						Revision:    4,
+						Version:     2,
 					},
 				},
+				Cursor: 4,
 			}, nil)
 
 		err := grpcClient.SyncData()
-		assert.NoError(t, err)
+		var conflict *ConflictError
+		assert.ErrorAs(t, err, &conflict)
+		assert.Equal(t, []string{dataId}, conflict.IDs)
 
 		data, err := localstorage.GetAllData(grpcClient.UserID)
 		assert.NoError(t, err)
-		assert.Contains(t, data, dataId)
-		assert.Equal(t, []byte("test data"), data[dataId].DataContent)
+		assert.Equal(t, serverContent, data[dataId].DataContent)
+
+		conflicts, err := grpcClient.GetConflicts()
+		assert.NoError(t, err)
+		if assert.Len(t, conflicts, 1) {
+			assert.Equal(t, []byte("local"), conflicts[0].Copy.DataContent)
+			assert.Equal(t, []byte("server"), conflicts[0].Original.DataContent)
+		}
+
+		// Пользователь выбирает локальную версию — она заменяет серверную при следующей синхронизации
+		err = grpcClient.ResolveConflict(conflicts[0].Copy.ID, KeepLocal)
+		assert.NoError(t, err)
+
+		data, err = localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Len(t, data, 1)
+		assert.Equal(t, localContent, data[dataId].DataContent)
+		assert.Equal(t, int64(2), data[dataId].Version)
+
+		dirty, err := localstorage.GetDirty(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.True(t, dirty[dataId])
 	})
 
-	t.Run("Обновление локального ID после отправки данных на сервер", func(t *testing.T) {
+	t.Run("Отправка локальных изменений с ожидаемой версией", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		localID := int64(1)
-		newID := int64(2) // Новый ID, который вернет сервер
-		updatedAt := time.Now()
-
-		createTestData(localID, mdata.TextData, []byte("test data"), updatedAt)
+		dataId := "00000000-0000-0000-0000-000000000001"
+		createTestData(dataId, mdata.TextData, []byte("local data"), time.Now(), 3)
+		assert.NoError(t, localstorage.MarkSynced(grpcClient.UserID, dataId, 3, 2))
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, dataId))
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{}, // Пустой ответ от сервера
-			}, nil)
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{Cursor: 3}, nil)
 
-		mockClient.EXPECT().CreateData(gomock.Any(), gomock.Any()).
-			Return(&proto.CreateDataResponse{
-				DataId: newID,
-			}, nil)
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, batch *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+				assert.Equal(t, int64(2), batch.Mutations[0].GetUpdate().ExpectedVersion)
+				return batchResponse(&proto.MutationResult{DataId: dataId, Revision: 5, Version: 3}), nil
+			})
 
 		err := grpcClient.SyncData()
 		assert.NoError(t, err)
 
 		data, err := localstorage.GetAllData(grpcClient.UserID)
 		assert.NoError(t, err)
-		assert.NotContains(t, data, localID)
-		assert.Contains(t, data, newID)
-		assert.Equal(t, []byte("test data"), data[newID].DataContent)
+		assert.Equal(t, []byte("local data"), data[dataId].DataContent)
+		assert.Equal(t, int64(5), data[dataId].Revision)
+		assert.Equal(t, int64(3), data[dataId].Version)
 	})
-}
-
-func TestSyncData_Failure(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
 
-	mockClient := mproto.NewMockGophKeeperClient(ctrl)
-
-	masterKey := make([]byte, 32)
-	copy(masterKey, "16-byte-master-key")
+	t.Run("Повторная синхронизация при конфликте версий во время отправки", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
 
-	grpcClient := &Client{
-		Client:        mockClient,
-		UserID:        12345,
-		EncryptionKey: masterKey,
-	}
+		os.RemoveAll("user_data")
 
-	createTestData := func(dataId int64, dataType mdata.DataType, content []byte, updatedAt time.Time) {
-		data := mdata.Data{
-			UserID:      grpcClient.UserID,
-			ID:          dataId,
-			DataType:    dataType,
-			DataContent: content,
-			Metadata:    nil,
-			UpdatedAt:   updatedAt,
-		}
+		dataId := "00000000-0000-0000-0000-000000000001"
+		baseContent := encrypt("base")
+		localContent := encrypt("local")
+		createSyncedData(dataId, mdata.Data{DataContent: baseContent}, mdata.Data{DataContent: localContent})
 
-		err := localstorage.SaveData(grpcClient.UserID, data)
-		if err != nil {
-			t.Fatalf("Не удалось создать тестовые данные: %v", err)
-		}
-	}
+		serverMetadata, err := mdata.ConvertJSONBToStruct(map[string]interface{}{"site": "example.com"})
+		assert.NoError(t, err)
 
-	t.Run("Ошибка получения данных с сервера", func(t *testing.T) {
-		t.Cleanup(func() {
-			os.RemoveAll("user_data")
-		})
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+				Return(&proto.GetChangesResponse{Cursor: 3}, nil),
+			mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+				Return(nil, operationConflictStatus(t, 0, dataId, 2)),
+			mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+				Return(&proto.GetChangesResponse{
+					Changed: []*proto.DataItem{
+						{
+							DataId:      dataId,
+							DataType:    proto.DataType_TEXT_DATA,
+							DataContent: baseContent,
+							Metadata:    serverMetadata,
+							UpdatedAt:   time.Now().Format(time.RFC3339),
+							Revision:    4,
+							Version:     2,
+						},
+					},
+					Cursor: 4,
+				}, nil),
+			mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, batch *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+					req := batch.Mutations[0].GetUpdate()
+					assert.Equal(t, int64(2), req.ExpectedVersion)
+					assert.Equal(t, localContent, req.DataContent)
+					assert.Equal(t, map[string]interface{}{"site": "example.com"}, req.Metadata.AsMap())
+					return batchResponse(&proto.MutationResult{DataId: dataId, Revision: 5, Version: 3}), nil
+				}),
+		)
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(nil, fmt.Errorf("ошибка получения данных с сервера"))
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
 
-		err := grpcClient.SyncData()
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка получения данных с сервера")
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), data[dataId].Version)
 	})
-	t.Run("Ошибка получения данных из локального хранилища", func(t *testing.T) {
+
+	t.Run("Конфликтная копия для данных, удаленных на сервере", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
-		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
-		err := os.MkdirAll(userDir, 0700)
-		if err != nil {
-			t.Fatalf("Не удалось создать директорию пользователя: %v", err)
-		}
+		os.RemoveAll("user_data")
 
-		dataFilePath := filepath.Join(userDir, "data.json")
-		err = os.WriteFile(dataFilePath, []byte(""), 0644)
+		dataId := "00000000-0000-0000-0000-000000000001"
+		localContent := encrypt("local")
+		createSyncedData(dataId, mdata.Data{DataContent: encrypt("base")}, mdata.Data{DataContent: localContent})
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{},
-			}, nil)
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{DeletedIds: []string{dataId}, Cursor: 4}, nil)
 
-		err = grpcClient.SyncData()
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка получения данных из локального хранилища")
+		err := grpcClient.SyncData()
+		var conflict *ConflictError
+		assert.ErrorAs(t, err, &conflict)
 
-		err = os.Chmod(dataFilePath, 0644)
-		if err != nil {
-			t.Fatalf("Не удалось восстановить права на файл: %v", err)
+		conflicts, err := grpcClient.GetConflicts()
+		assert.NoError(t, err)
+		if assert.Len(t, conflicts, 1) {
+			assert.Equal(t, dataId, conflicts[0].Original.ID)
+			assert.Empty(t, conflicts[0].Original.DataType)
+
+			// Пользователь оставляет серверную версию — конфликтная копия удаляется
+			assert.NoError(t, grpcClient.ResolveConflict(conflicts[0].Copy.ID, KeepServer))
 		}
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
 	})
-	t.Run("Ошибка отправки данных на сервер", func(t *testing.T) {
+
+	t.Run("Удаление данных, удаленных на сервере", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
-		dataId := int64(1)
-		updatedAt := time.Now()
+		os.RemoveAll("user_data")
 
-		createTestData(dataId, mdata.TextData, []byte("test data"), updatedAt)
+		dataId := "00000000-0000-0000-0000-000000000001"
+		createTestData(dataId, mdata.TextData, []byte("test data"), time.Now(), 3)
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{},
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				DeletedIds: []string{dataId, "00000000-0000-0000-0000-00000000002a"},
+				Cursor:     5,
 			}, nil)
 
-		mockClient.EXPECT().CreateData(gomock.Any(), gomock.Any()).
-			Return(nil, fmt.Errorf("ошибка отправки данных на сервер"))
-
 		err := grpcClient.SyncData()
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка отправки данных на сервер")
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.NotContains(t, data, dataId)
 	})
-	t.Run("Ошибка обновления данных на сервере", func(t *testing.T) {
+
+	t.Run("Полная синхронизация при устаревшем курсоре", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		dataId := int64(1)
-		updatedAt := time.Now()
-
-		createTestData(dataId, mdata.TextData, []byte("test data"), updatedAt)
-
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{
-					{
-						DataId:      dataId,
-						DataType:    proto.DataType_TEXT_DATA,
-						DataContent: []byte("test data"),
-						Metadata:    nil,
-						UpdatedAt:   updatedAt.Format(time.RFC3339),
+		// Данные 2 удалены на другом устройстве, а метка удаления уже удалена на сервере
+		createTestData("00000000-0000-0000-0000-000000000001", mdata.TextData, []byte("test data"), time.Now(), 3)
+		createTestData("00000000-0000-0000-0000-000000000002", mdata.TextData, []byte("deleted data"), time.Now(), 2)
+		createTestData("00000000-0000-0000-0000-000000000063", mdata.TextData, []byte("new data"), time.Now(), 0)
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, "00000000-0000-0000-0000-000000000063"))
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
+
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 3, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{ResetRequired: true}, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 0, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{
+					Changed: []*proto.DataItem{
+						{
+							DataId:      "00000000-0000-0000-0000-000000000001",
+							DataType:    proto.DataType_TEXT_DATA,
+							DataContent: []byte("test data"),
+							UpdatedAt:   time.Now().Format(time.RFC3339),
+							Revision:    3,
+						},
 					},
-				},
-			}, nil)
+					DeletedIds: []string{"00000000-0000-0000-0000-000000000005"},
+					Cursor:     30,
+				}, nil),
+		)
 
-		mockClient.EXPECT().UpdateData(gomock.Any(), gomock.Any()).
-			Return(nil, fmt.Errorf("ошибка обновления данных на сервере"))
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			Return(batchResponse(&proto.MutationResult{DataId: "00000000-0000-0000-0000-000000000063", Revision: 31, Version: 1}), nil)
 
 		err := grpcClient.SyncData()
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка обновления данных на сервере")
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Contains(t, data, "00000000-0000-0000-0000-000000000001")
+		assert.NotContains(t, data, "00000000-0000-0000-0000-000000000002")
+		assert.Equal(t, int64(31), data["00000000-0000-0000-0000-000000000063"].Revision)
+		assert.Len(t, data, 2)
 	})
-	t.Run("Ошибка сохранения данных в локальное хранилище", func(t *testing.T) {
+
+	t.Run("Прерванная полная синхронизация начинается заново", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
 		os.RemoveAll("user_data")
 
-		dataId := int64(1)
-		updatedAt := time.Now()
+		// Данные 2 удалены на другом устройстве, а метка удаления уже удалена на сервере
+		createTestData("00000000-0000-0000-0000-000000000001", mdata.TextData, []byte("test data"), time.Now(), 3)
+		createTestData("00000000-0000-0000-0000-000000000002", mdata.TextData, []byte("deleted data"), time.Now(), 2)
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{
+		firstPage := &proto.GetChangesResponse{
+			Changed: []*proto.DataItem{
+				{
+					DataId:      "00000000-0000-0000-0000-000000000001",
+					DataType:    proto.DataType_TEXT_DATA,
+					DataContent: []byte("test data"),
+					UpdatedAt:   time.Now().Format(time.RFC3339),
+					Revision:    3,
+				},
+			},
+			Cursor:  10,
+			HasMore: true,
+		}
+
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 3, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{ResetRequired: true}, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 0, Limit: syncPageSize}).
+				Return(firstPage, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 10, Limit: syncPageSize}).
+				Return(nil, errors.New("connection lost")),
+		)
+
+		err := grpcClient.SyncData()
+		assert.Error(t, err)
+
+		// Курсор полной синхронизации не сохранен, поэтому следующая синхронизация снова будет полной
+		cursor, err := localstorage.GetCursor(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), cursor)
+
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 3, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{ResetRequired: true}, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 0, Limit: syncPageSize}).
+				Return(firstPage, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 10, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{Cursor: 20}, nil),
+		)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Contains(t, data, "00000000-0000-0000-0000-000000000001")
+		assert.NotContains(t, data, "00000000-0000-0000-0000-000000000002")
+
+		cursor, err = localstorage.GetCursor(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20), cursor)
+	})
+
+	t.Run("Отправка локального файла на сервер потоком", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		localID := "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"
+
+		_, err := localstorage.SaveFile(grpcClient.UserID, localID, bytes.NewReader([]byte("file data")))
+		assert.NoError(t, err)
+
+		err = localstorage.SaveData(grpcClient.UserID, mdata.Data{
+			UserID:    grpcClient.UserID,
+			ID:        localID,
+			DataType:  mdata.BinaryData,
+			FileName:  "testfile.txt",
+			UpdatedAt: time.Now(),
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, localID))
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{}, nil)
+
+		stream := &fakeUploadClientStream{resp: &proto.UploadBlobResponse{DataId: localID, Revision: 6}}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		assert.Equal(t, "testfile.txt", stream.requests[0].GetInfo().FileName)
+		assert.Equal(t, localID, stream.requests[0].GetInfo().DataId)
+		assert.True(t, stream.requests[0].GetInfo().Create)
+		assert.Equal(t, encryption.EncryptedSize(9), stream.requests[0].GetInfo().Size)
+		assert.Equal(t, encryption.StreamFormat, stream.requests[0].GetInfo().Metadata.AsMap()[encryption.StreamMetadataKey])
+
+		// На сервер отправляется только зашифрованное содержимое файла
+		uploaded := stream.content()
+		assert.NotContains(t, string(uploaded), "file data")
+		assert.Len(t, uploaded, int(encryption.EncryptedSize(9)))
+		assert.Equal(t, []byte("file data"), decryptFile(t, uploaded, masterKey))
+
+		storedFile, err := os.ReadFile(localstorage.GetFilePath(grpcClient.UserID, localID))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("file data"), storedFile)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), data[localID].Revision)
+	})
+
+	t.Run("Файл бинарных данных загружается с сервера по требованию", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+		manifest := &proto.BlobManifest{BlobId: "users/1/abc", FileName: "report.pdf", Size: 42, ContentHash: "abc"}
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  2,
+						Blob:      manifest,
+					},
+				},
+				Cursor: 2,
+			}, nil)
+
+		// При синхронизации файл не загружается, сохраняется только его описание
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Empty(t, data[dataId].DataContent)
+		assert.Equal(t, "report.pdf", data[dataId].FileName)
+		assert.Equal(t, &mdata.BlobManifest{ID: "users/1/abc", FileName: "report.pdf", Size: 42, ContentHash: "abc"},
+			data[dataId].Blob)
+		assert.False(t, localstorage.HasFile(grpcClient.UserID, dataId))
+
+		encrypted := encryptFile(t, []byte("file data"), masterKey)
+		mockClient.EXPECT().FetchBlob(gomock.Any(), &proto.FetchBlobRequest{BlobId: "users/1/abc"}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{encrypted[:20], encrypted[20:]}}, nil)
+
+		path, err := grpcClient.FetchFile(dataId)
+		assert.NoError(t, err)
+		storedFile, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("file data"), storedFile)
+
+		// Повторное обращение берет файл из локального хранилища
+		_, err = grpcClient.FetchFile(dataId)
+		assert.NoError(t, err)
+
+		// Изменение метаданных не меняет описание файла, и загруженный файл сохраняется
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						Metadata:  &structpb.Struct{Fields: map[string]*structpb.Value{"tag": structpb.NewStringValue("work")}},
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  3,
+						Blob:      manifest,
+					},
+				},
+				Cursor: 3,
+			}, nil)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+		assert.True(t, localstorage.HasFile(grpcClient.UserID, dataId))
+
+		// Новый файл на сервере заменяет загруженный при следующем обращении
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  4,
+						Blob:      &proto.BlobManifest{BlobId: "users/1/def", FileName: "report.pdf", Size: 43, ContentHash: "def"},
+					},
+				},
+				Cursor: 4,
+			}, nil)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+		assert.False(t, localstorage.HasFile(grpcClient.UserID, dataId))
+	})
+
+	t.Run("Незашифрованный файл на сервере заменяется зашифрованным", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  2,
+						Version:   1,
+					},
+				},
+				Cursor: 2,
+			}, nil)
+
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		// Клиенты до появления шифрования отправляли файлы потоком как есть: файл, похожий на Base64,
+		// не декодируется. Запись без описания файла получает файл по ID записи
+		mockClient.EXPECT().DownloadBlob(gomock.Any(), &proto.DownloadBlobRequest{DataId: dataId}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{[]byte("ZmlsZQ==")}}, nil)
+
+		path, err := grpcClient.FetchFile(dataId)
+		assert.NoError(t, err)
+
+		storedFile, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("ZmlsZQ=="), storedFile)
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{Cursor: 2}, nil)
+		stream := &fakeUploadClientStream{resp: &proto.UploadBlobResponse{DataId: dataId, Revision: 3, Version: 2}}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		assert.False(t, stream.requests[0].GetInfo().Create)
+		assert.Equal(t, int64(1), stream.requests[0].GetInfo().ExpectedVersion)
+		assert.Equal(t, encryption.StreamFormat, stream.requests[0].GetInfo().Metadata.AsMap()[encryption.StreamMetadataKey])
+		assert.Equal(t, []byte("ZmlsZQ=="), decryptFile(t, stream.content(), masterKey))
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), data[dataId].Revision)
+
+		// После отправки зашифрованного файла незашифрованный файл этой записи больше не принимается
+		encrypted, err := localstorage.IsEncrypted(grpcClient.UserID, dataId)
+		assert.NoError(t, err)
+		assert.True(t, encrypted)
+	})
+
+	t.Run("Незашифрованный файл не принимается после получения зашифрованного", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  2,
+						Version:   1,
+						Blob:      &proto.BlobManifest{BlobId: "users/1/abc", FileName: "report.pdf", Size: 9, ContentHash: "abc"},
+					},
+				},
+				Cursor: 2,
+			}, nil)
+
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		encrypted := encryptFile(t, []byte("file data"), masterKey)
+		mockClient.EXPECT().FetchBlob(gomock.Any(), &proto.FetchBlobRequest{BlobId: "users/1/abc"}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{encrypted}}, nil)
+
+		_, err = grpcClient.FetchFile(dataId)
+		assert.NoError(t, err)
+
+		// Сервер заменил файл незашифрованным и убрал отметку о шифровании из метаданных
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:    dataId,
+						DataType:  proto.DataType_BINARY_DATA,
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  3,
+						Version:   2,
+						Blob:      &proto.BlobManifest{BlobId: "users/1/def", FileName: "report.pdf", Size: 9, ContentHash: "def"},
+					},
+				},
+				Cursor: 3,
+			}, nil)
+
+		err = grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		mockClient.EXPECT().FetchBlob(gomock.Any(), &proto.FetchBlobRequest{BlobId: "users/1/def"}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{[]byte("file data")}}, nil)
+
+		_, err = grpcClient.FetchFile(dataId)
+		assert.ErrorIs(t, err, encryption.ErrNotEncryptedStream)
+		assert.False(t, localstorage.HasFile(grpcClient.UserID, dataId))
+	})
+
+	t.Run("Незашифрованный файл записи с отметкой о шифровании не принимается", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{
+						DataId:   dataId,
+						DataType: proto.DataType_BINARY_DATA,
+						Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+							encryption.StreamMetadataKey: structpb.NewStringValue(encryption.StreamFormat),
+						}},
+						UpdatedAt: time.Now().Format(time.RFC3339),
+						Revision:  2,
+						Version:   1,
+						Blob:      &proto.BlobManifest{BlobId: "users/1/abc", FileName: "report.pdf", Size: 9, ContentHash: "abc"},
+					},
+				},
+				Cursor: 2,
+			}, nil)
+
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		mockClient.EXPECT().FetchBlob(gomock.Any(), &proto.FetchBlobRequest{BlobId: "users/1/abc"}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{[]byte("file data")}}, nil)
+
+		_, err = grpcClient.FetchFile(dataId)
+		assert.ErrorIs(t, err, encryption.ErrNotEncryptedStream)
+		assert.False(t, localstorage.HasFile(grpcClient.UserID, dataId))
+
+		dirty, err := localstorage.GetDirty(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Empty(t, dirty)
+	})
+
+	t.Run("Файл, сохраненный в записи, отправляется зашифрованным", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		localID := "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"
+		createTestData(localID, mdata.BinaryData, []byte(encryption.EncodeData([]byte("file data"))), time.Now(), 0)
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, localID))
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{}, nil)
+
+		stream := &fakeUploadClientStream{resp: &proto.UploadBlobResponse{DataId: localID, Revision: 4, Version: 1}}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		assert.Equal(t, []byte("file data"), decryptFile(t, stream.content(), masterKey))
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Empty(t, data[localID].DataContent)
+		assert.Equal(t, int64(4), data[localID].Revision)
+	})
+
+	t.Run("Обновление локального ID, если сервер вернул запись с другим ID", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		localID := "00000000-0000-0000-0000-000000000001"
+		newID := "00000000-0000-0000-0000-000000000002" // ID записи, ранее созданной на сервере по ключу идемпотентности
+		updatedAt := time.Now()
+
+		// Данные без ревизии, не помеченные как измененные, отправляются при первой синхронизации
+		createTestData(localID, mdata.TextData, []byte("test data"), updatedAt, 0)
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{}, nil)
+
+		var idempotencyKey string
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, batch *proto.BatchMutateRequest, _ ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+				// Данным без ключа идемпотентности ключ присваивается перед первой отправкой
+				idempotencyKey = batch.Mutations[0].GetCreate().IdempotencyKey
+				assert.NotEmpty(t, idempotencyKey)
+				assert.Equal(t, localID, batch.Mutations[0].GetCreate().DataId)
+				return batchResponse(&proto.MutationResult{DataId: newID, Revision: 3}), nil
+			})
+
+		err := grpcClient.SyncData()
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.NotContains(t, data, localID)
+		assert.Contains(t, data, newID)
+		assert.Equal(t, []byte("test data"), data[newID].DataContent)
+		assert.Equal(t, int64(3), data[newID].Revision)
+		assert.Equal(t, idempotencyKey, data[newID].IdempotencyKey)
+
+		dirty, err := localstorage.GetDirty(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Empty(t, dirty)
+	})
+}
+
+func TestSyncData_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	masterKey := make([]byte, 32)
+	copy(masterKey, "16-byte-master-key")
+
+	grpcClient := &Client{
+		Client:        mockClient,
+		UserID:        12345,
+		EncryptionKey: masterKey,
+	}
+
+	createTestData := func(dataId string, dataType mdata.DataType, content []byte, updatedAt time.Time, revision int64) {
+		data := mdata.Data{
+			UserID:      grpcClient.UserID,
+			ID:          dataId,
+			DataType:    dataType,
+			DataContent: content,
+			Metadata:    nil,
+			UpdatedAt:   updatedAt,
+			Revision:    revision,
+		}
+
+		err := localstorage.SaveData(grpcClient.UserID, data)
+		if err != nil {
+			t.Fatalf("Не удалось создать тестовые данные: %v", err)
+		}
+	}
+
+	t.Run("Ошибка получения изменений с сервера", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("connection error"))
+
+		err := grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка получения изменений с сервера")
+	})
+	t.Run("Ошибка получения курсора синхронизации", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
+		err := os.MkdirAll(userDir, 0700)
+		if err != nil {
+			t.Fatalf("Не удалось создать директорию пользователя: %v", err)
+		}
+
+		dataFilePath := filepath.Join(userDir, "data.json")
+		err = os.WriteFile(dataFilePath, []byte(""), 0644)
+		if err != nil {
+			t.Fatalf("Не удалось создать файл данных: %v", err)
+		}
+
+		err = grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка получения курсора синхронизации")
+	})
+	t.Run("Ошибка отправки данных на сервер", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+		updatedAt := time.Now()
+
+		createTestData(dataId, mdata.TextData, []byte("test data"), updatedAt, 0)
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{}, nil)
+
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("connection error"))
+
+		err := grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка отправки данных на сервер")
+	})
+	t.Run("Ошибка обновления данных на сервере", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+		updatedAt := time.Now()
+
+		createTestData(dataId, mdata.TextData, []byte("test data"), updatedAt, 3)
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, dataId))
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 3))
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{Cursor: 3}, nil)
+
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			Return(nil, status.Error(codes.NotFound, "операция 0: данные с ID 1 не найдены"))
+
+		err := grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка отправки данных на сервер")
+	})
+	t.Run("Ошибка сохранения данных в локальное хранилище", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		dataId := "00000000-0000-0000-0000-000000000001"
+		updatedAt := time.Now()
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
 					{
 						DataId:      dataId,
 						DataType:    proto.DataType_TEXT_DATA,
 						DataContent: []byte("test data"),
-						Metadata:    nil,
 						UpdatedAt:   updatedAt.Format(time.RFC3339),
+						Revision:    1,
 					},
 				},
+				Cursor: 1,
 			}, nil)
 
 		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
@@ -1022,94 +1878,639 @@ func TestSyncData_Failure(t *testing.T) {
 			t.Fatalf("Не удалось создать директорию пользователя: %v", err)
 		}
 
-		dataFilePath := filepath.Join(userDir, "data.json")
-		err = os.WriteFile(dataFilePath, []byte("{}"), 0444)
-		if err != nil {
-			t.Fatalf("Не удалось установить права на файл: %v", err)
-		}
+		dataFilePath := filepath.Join(userDir, "data.json")
+		err = os.WriteFile(dataFilePath, []byte("{}"), 0444)
+		if err != nil {
+			t.Fatalf("Не удалось установить права на файл: %v", err)
+		}
+
+		err = grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка сохранения данных в локальное хранилище")
+	})
+	t.Run("Ошибка обновления локального ID", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+
+		localID := "00000000-0000-0000-0000-000000000001"
+		newID := "00000000-0000-0000-0000-000000000002" // Новый ID, который вернет сервер
+		updatedAt := time.Now()
+
+		createTestData(localID, mdata.TextData, []byte("test data"), updatedAt, 0)
+		assert.NoError(t, localstorage.MarkDirty(grpcClient.UserID, localID))
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 1))
+
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{Cursor: 1}, nil)
+
+		mockClient.EXPECT().BatchMutate(gomock.Any(), gomock.Any()).
+			Return(batchResponse(&proto.MutationResult{DataId: newID}), nil)
+
+		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
+		dataFilePath := filepath.Join(userDir, "data.json")
+		err := os.Chmod(dataFilePath, 0444)
+		if err != nil {
+			t.Fatalf("Не удалось восстановить права на файл: %v", err)
+		}
+
+		err = grpcClient.SyncData()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка обновления локального ID")
+	})
+}
+
+func TestWatchChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	grpcClient := &Client{
+		Client: mockClient,
+		UserID: 12345,
+	}
+
+	t.Run("Применение изменений по событиям сервера", func(t *testing.T) {
+		t.Cleanup(func() {
+			os.RemoveAll("user_data")
+		})
+
+		os.RemoveAll("user_data")
+		assert.NoError(t, localstorage.SetCursor(grpcClient.UserID, 5))
+
+		stream := &fakeWatchClientStream{events: []*proto.ChangeEvent{
+			{DataId: "00000000-0000-0000-0000-000000000001", Revision: 4}, // уже применено, изменения не запрашиваются
+			{DataId: "00000000-0000-0000-0000-000000000002", Revision: 6},
+		}}
+		mockClient.EXPECT().WatchChanges(gomock.Any(), &proto.WatchChangesRequest{}).Return(stream, nil)
+
+		gomock.InOrder(
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 5, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{Cursor: 5}, nil),
+			mockClient.EXPECT().GetChanges(gomock.Any(), &proto.GetChangesRequest{SinceCursor: 5, Limit: syncPageSize}).
+				Return(&proto.GetChangesResponse{
+					Changed: []*proto.DataItem{
+						{DataId: "00000000-0000-0000-0000-000000000002", DataType: proto.DataType_TEXT_DATA, UpdatedAt: time.Now().Format(time.RFC3339), Revision: 6},
+					},
+					Cursor: 6,
+				}, nil),
+		)
+
+		err := grpcClient.WatchChanges(context.Background())
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Contains(t, data, "00000000-0000-0000-0000-000000000002")
+
+		cursor, err := localstorage.GetCursor(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), cursor)
+	})
 
-		err = grpcClient.SyncData()
+	t.Run("Ошибка подписки на изменения", func(t *testing.T) {
+		mockClient.EXPECT().WatchChanges(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("connection error"))
+
+		err := grpcClient.WatchChanges(context.Background())
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка сохранения данных в локальное хранилище")
+		assert.Contains(t, err.Error(), "ошибка подписки на изменения")
 	})
-	t.Run("Ошибка обновления локальных данных", func(t *testing.T) {
+
+	t.Run("Ошибка получения события об изменении", func(t *testing.T) {
 		t.Cleanup(func() {
 			os.RemoveAll("user_data")
 		})
 
-		os.RemoveAll("user_data")
+		mockClient.EXPECT().WatchChanges(gomock.Any(), gomock.Any()).
+			Return(&fakeWatchClientStream{err: errors.New("stream error")}, nil)
+		mockClient.EXPECT().GetChanges(gomock.Any(), gomock.Any()).
+			Return(&proto.GetChangesResponse{}, nil)
+
+		err := grpcClient.WatchChanges(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка получения события об изменении")
+	})
+}
+
+// InvalidDataType Вспомогательная структура для тестирования ошибки преобразования в JSON
+type InvalidDataType struct{}
+
+func (i *InvalidDataType) Validate() error {
+	return nil
+}
+
+func (i *InvalidDataType) ToJSON() ([]byte, error) {
+	return nil, errors.New("ошибка преобразования в JSON")
+}
+
+func TestListData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	masterKey := make([]byte, 32)
+	copy(masterKey, "16-byte-master-key")
+
+	grpcClient := &Client{
+		Client:        mockClient,
+		UserID:        12345,
+		EncryptionKey: masterKey,
+	}
 
-		dataId := int64(1)
-		localUpdatedAt := time.Now().Add(-1 * time.Hour) // Локальные данные старше
-		serverUpdatedAt := time.Now()
+	encryptedData, err := encryption.EncryptData([]byte("secret"), masterKey)
+	assert.NoError(t, err)
 
-		createTestData(dataId, mdata.TextData, []byte("old data"), localUpdatedAt)
+	t.Run("Успешное получение страницы данных", func(t *testing.T) {
+		updatedAfter := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).Return(&proto.GetAllDataResponse{
+		mockClient.EXPECT().ListData(gomock.Any(), &proto.ListDataRequest{
+			PageSize:     20,
+			PageToken:    "token",
+			DataType:     proto.DataType_TEXT_DATA,
+			UpdatedAfter: "2025-03-02T00:00:00Z",
+			Metadata:     map[string]string{"key": "value"},
+		}).Return(&proto.ListDataResponse{
 			Data: []*proto.DataItem{
 				{
-					DataId:      dataId,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataType:    proto.DataType_TEXT_DATA,
-					DataContent: []byte("new data"),
-					Metadata:    nil,
-					UpdatedAt:   serverUpdatedAt.Format(time.RFC3339),
+					DataContent: []byte(encryptedData),
+					UpdatedAt:   "2025-03-02T15:22:00Z",
 				},
 			},
+			NextPageToken: "next",
 		}, nil)
 
-		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
-		dataFilePath := filepath.Join(userDir, "data.json")
-		err := os.Chmod(dataFilePath, 0444)
-		if err != nil {
-			t.Fatalf("Не удалось восстановить права на файл: %v", err)
-		}
-		err = grpcClient.SyncData()
+		data, next, err := grpcClient.ListData(models.ListFilter{
+			DataType:     proto.DataType_TEXT_DATA,
+			UpdatedAfter: updatedAfter,
+			Metadata:     map[string]string{"key": "value"},
+			PageSize:     20,
+		}, "token")
+		assert.NoError(t, err)
+		assert.Equal(t, "next", next)
+		assert.Len(t, data, 1)
+		assert.Equal(t, []byte("secret"), data[0].DataContent)
+	})
+
+	t.Run("Ошибка получения данных с сервера", func(t *testing.T) {
+		mockClient.EXPECT().ListData(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("server error"))
+
+		_, _, err := grpcClient.ListData(models.ListFilter{}, "")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка обновления локальных данных")
+		assert.Contains(t, err.Error(), "ошибка получения данных с сервера")
 	})
-	t.Run("Ошибка обновления локального ID", func(t *testing.T) {
-		t.Cleanup(func() {
-			os.RemoveAll("user_data")
-		})
 
+	t.Run("Ошибка расшифровки данных", func(t *testing.T) {
+		mockClient.EXPECT().ListData(gomock.Any(), gomock.Any()).
+			Return(&proto.ListDataResponse{
+				Data: []*proto.DataItem{
+					{
+						DataId:      "00000000-0000-0000-0000-000000000001",
+						DataType:    proto.DataType_TEXT_DATA,
+						DataContent: []byte("not encrypted"),
+						UpdatedAt:   "2025-03-02T15:22:00Z",
+					},
+				},
+			}, nil)
+
+		_, _, err := grpcClient.ListData(models.ListFilter{}, "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка расшифровки данных")
+	})
+}
+
+func TestTrash(t *testing.T) {
+	t.Cleanup(func() {
 		os.RemoveAll("user_data")
+	})
 
-		localID := int64(1)
-		newID := int64(2) // Новый ID, который вернет сервер
-		updatedAt := time.Now()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	masterKey := make([]byte, 32)
+	copy(masterKey, "16-byte-master-key")
+
+	grpcClient := &Client{
+		Client:        mockClient,
+		UserID:        12345,
+		EncryptionKey: masterKey,
+	}
 
-		createTestData(localID, mdata.TextData, []byte("test data"), updatedAt)
+	encryptedData, err := encryption.EncryptData([]byte("secret"), masterKey)
+	assert.NoError(t, err)
 
-		mockClient.EXPECT().GetAllData(gomock.Any(), &proto.GetAllDataRequest{}).
-			Return(&proto.GetAllDataResponse{
-				Data: []*proto.DataItem{},
+	t.Run("Получение данных из корзины", func(t *testing.T) {
+		mockClient.EXPECT().ListTrash(gomock.Any(), &proto.ListTrashRequest{}).
+			Return(&proto.ListTrashResponse{
+				Data: []*proto.DataItem{
+					{
+						DataId:      "00000000-0000-0000-0000-000000000001",
+						DataType:    proto.DataType_TEXT_DATA,
+						DataContent: []byte(encryptedData),
+						UpdatedAt:   "2025-03-02T15:22:00Z",
+						DeletedAt:   "2025-03-02T15:22:00Z",
+					},
+				},
 			}, nil)
 
-		mockClient.EXPECT().CreateData(gomock.Any(), gomock.Any()).
-			Return(&proto.CreateDataResponse{
-				DataId: newID,
+		data, err := grpcClient.ListTrash()
+		assert.NoError(t, err)
+		if assert.Len(t, data, 1) {
+			assert.Equal(t, []byte("secret"), data[0].DataContent)
+			assert.Equal(t, time.Date(2025, 3, 2, 15, 22, 0, 0, time.UTC), data[0].DeletedAt.UTC())
+		}
+	})
+
+	t.Run("Восстановление данных из корзины", func(t *testing.T) {
+		os.RemoveAll("user_data")
+
+		mockClient.EXPECT().RestoreData(gomock.Any(), &proto.RestoreDataRequest{DataId: "00000000-0000-0000-0000-000000000001"}).
+			Return(&proto.RestoreDataResponse{
+				Data: &proto.DataItem{
+					DataId:      "00000000-0000-0000-0000-000000000001",
+					DataType:    proto.DataType_TEXT_DATA,
+					DataContent: []byte(encryptedData),
+					UpdatedAt:   "2025-03-02T15:22:00Z",
+					Revision:    15,
+					Version:     3,
+				},
 			}, nil)
 
-		userDir := filepath.Join("user_data", fmt.Sprintf("%d", grpcClient.UserID))
-		dataFilePath := filepath.Join(userDir, "data.json")
-		err := os.Chmod(dataFilePath, 0444)
-		if err != nil {
-			t.Fatalf("Не удалось восстановить права на файл: %v", err)
+		err := grpcClient.RestoreData("00000000-0000-0000-0000-000000000001")
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(encryptedData), data["00000000-0000-0000-0000-000000000001"].DataContent)
+		assert.Equal(t, int64(15), data["00000000-0000-0000-0000-000000000001"].Revision)
+		assert.Equal(t, int64(3), data["00000000-0000-0000-0000-000000000001"].Version)
+	})
+
+	t.Run("Ошибка восстановления данных", func(t *testing.T) {
+		mockClient.EXPECT().RestoreData(gomock.Any(), gomock.Any()).
+			Return(nil, status.Error(codes.NotFound, "данные с ID 2 не найдены в корзине"))
+
+		err := grpcClient.RestoreData("00000000-0000-0000-0000-000000000002")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка восстановления данных")
+	})
+
+	t.Run("Очистка корзины", func(t *testing.T) {
+		mockClient.EXPECT().PurgeData(gomock.Any(), &proto.PurgeDataRequest{}).
+			Return(&proto.PurgeDataResponse{Purged: 2}, nil)
+
+		purged, err := grpcClient.EmptyTrash()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+	})
+}
+
+func TestMergeMetadata(t *testing.T) {
+	base := map[string]interface{}{"site": "example.com", "login": "user"}
+
+	tests := []struct {
+		name     string
+		local    map[string]interface{}
+		server   map[string]interface{}
+		expected map[string]interface{}
+		ok       bool
+	}{
+		{
+			name:     "Разные ключи изменены на разных устройствах",
+			local:    map[string]interface{}{"site": "example.org", "login": "user"},
+			server:   map[string]interface{}{"site": "example.com", "login": "admin"},
+			expected: map[string]interface{}{"site": "example.org", "login": "admin"},
+			ok:       true,
+		},
+		{
+			name:     "Ключ удален на сервере и добавлен локально",
+			local:    map[string]interface{}{"site": "example.com", "login": "user", "note": "new"},
+			server:   map[string]interface{}{"site": "example.com"},
+			expected: map[string]interface{}{"site": "example.com", "note": "new"},
+			ok:       true,
+		},
+		{
+			name:     "Одинаковое изменение на обоих устройствах",
+			local:    map[string]interface{}{"site": "example.org", "login": "user"},
+			server:   map[string]interface{}{"site": "example.org", "login": "user"},
+			expected: map[string]interface{}{"site": "example.org", "login": "user"},
+			ok:       true,
+		},
+		{
+			name:   "Один ключ изменен по-разному",
+			local:  map[string]interface{}{"site": "example.org", "login": "user"},
+			server: map[string]interface{}{"site": "example.net", "login": "user"},
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, ok := mergeMetadata(base, tt.local, tt.server)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, merged)
+			}
+		})
+	}
+}
+
+func TestUploadBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	grpcClient := &Client{
+		Client: mockClient,
+		UserID: 12345,
+	}
+
+	data := mdata.Data{ID: "00000000-0000-0000-0000-000000000001", DataType: mdata.BinaryData, FileName: "big.bin"}
+
+	t.Run("Успешная загрузка файла частями", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), blobChunkSize*2+10)
+
+		stream := &fakeUploadClientStream{resp: &proto.UploadBlobResponse{DataId: data.ID}}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		state, err := grpcClient.UploadBlob(context.Background(), data, bytes.NewReader(content), int64(len(content)))
+		assert.NoError(t, err)
+		assert.Equal(t, data.ID, state.ID)
+
+		assert.Len(t, stream.requests, 4)
+		assert.Equal(t, data.ID, stream.requests[0].GetInfo().DataId)
+		assert.True(t, stream.requests[0].GetInfo().Create)
+		assert.Equal(t, "big.bin", stream.requests[0].GetInfo().FileName)
+		assert.Equal(t, int64(len(content)), stream.requests[0].GetInfo().Size)
+
+		var received []byte
+		for _, req := range stream.requests[1:] {
+			assert.LessOrEqual(t, len(req.GetChunk()), blobChunkSize)
+			received = append(received, req.GetChunk()...)
 		}
+		assert.Equal(t, content, received)
+	})
 
-		err = grpcClient.SyncData()
+	t.Run("Ошибка открытия потока", func(t *testing.T) {
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(nil, errors.New("connection error"))
+
+		_, err := grpcClient.UploadBlob(context.Background(), data, bytes.NewReader(nil), 0)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "ошибка обновления локального ID")
+		assert.Contains(t, err.Error(), "ошибка открытия потока загрузки")
+	})
+
+	t.Run("Ошибка отправки части файла", func(t *testing.T) {
+		stream := &fakeUploadClientStream{sendErr: errors.New("send error"), failAfter: 1}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		_, err := grpcClient.UploadBlob(context.Background(), data, bytes.NewReader([]byte("data")), 4)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка отправки части файла")
+	})
+
+	t.Run("Ошибка ответа сервера", func(t *testing.T) {
+		stream := &fakeUploadClientStream{closeErr: errors.New("server error")}
+		mockClient.EXPECT().UploadBlob(gomock.Any()).Return(stream, nil)
+
+		_, err := grpcClient.UploadBlob(context.Background(), data, bytes.NewReader([]byte("data")), 4)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка загрузки файла на сервер")
 	})
 }
 
-// InvalidDataType Вспомогательная структура для тестирования ошибки преобразования в JSON
-type InvalidDataType struct{}
+func TestDownloadBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-func (i *InvalidDataType) Validate() error {
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	grpcClient := &Client{
+		Client: mockClient,
+		UserID: 12345,
+	}
+
+	t.Run("Успешное получение файла частями", func(t *testing.T) {
+		mockClient.EXPECT().DownloadBlob(gomock.Any(), &proto.DownloadBlobRequest{DataId: "00000000-0000-0000-0000-000000000001"}).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{[]byte("part1"), []byte("part2")}}, nil)
+
+		reader, err := grpcClient.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001")
+		assert.NoError(t, err)
+
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("part1part2"), content)
+	})
+
+	t.Run("Ошибка открытия потока", func(t *testing.T) {
+		mockClient.EXPECT().DownloadBlob(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("connection error"))
+
+		_, err := grpcClient.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ошибка открытия потока получения файла")
+	})
+
+	t.Run("Ошибка во время получения файла", func(t *testing.T) {
+		mockClient.EXPECT().DownloadBlob(gomock.Any(), gomock.Any()).
+			Return(&fakeDownloadClientStream{chunks: [][]byte{[]byte("part1")}, err: errors.New("stream error")}, nil)
+
+		reader, err := grpcClient.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001")
+		assert.NoError(t, err)
+
+		_, err = io.ReadAll(reader)
+		assert.EqualError(t, err, "stream error")
+	})
+}
+
+// fakeUploadClientStream - тестовая реализация клиентского потока UploadBlob.
+type fakeUploadClientStream struct {
+	grpc.ClientStream
+	requests  []*proto.UploadBlobRequest
+	resp      *proto.UploadBlobResponse
+	sendErr   error
+	failAfter int
+	closeErr  error
+}
+
+func (f *fakeUploadClientStream) Send(req *proto.UploadBlobRequest) error {
+	if f.sendErr != nil && len(f.requests) >= f.failAfter {
+		return f.sendErr
+	}
+	// Клиент переиспользует буфер части файла, поэтому часть копируется, как при отправке по сети
+	if chunk := req.GetChunk(); chunk != nil {
+		req = &proto.UploadBlobRequest{Payload: &proto.UploadBlobRequest_Chunk{Chunk: bytes.Clone(chunk)}}
+	}
+	f.requests = append(f.requests, req)
 	return nil
 }
 
-func (i *InvalidDataType) ToJSON() ([]byte, error) {
-	return nil, errors.New("ошибка преобразования в JSON")
+func (f *fakeUploadClientStream) CloseAndRecv() (*proto.UploadBlobResponse, error) {
+	if f.closeErr != nil {
+		return nil, f.closeErr
+	}
+	return f.resp, nil
+}
+
+// content возвращает содержимое файла, отправленное в поток.
+func (f *fakeUploadClientStream) content() []byte {
+	var content []byte
+	for _, req := range f.requests {
+		content = append(content, req.GetChunk()...)
+	}
+	return content
+}
+
+// encryptFile шифрует содержимое файла так же, как клиент перед отправкой на сервер.
+func encryptFile(t *testing.T, content, masterKey []byte) []byte {
+	reader, err := encryption.NewEncryptReader(bytes.NewReader(content), masterKey)
+	assert.NoError(t, err)
+	encrypted, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return encrypted
+}
+
+// decryptFile расшифровывает содержимое файла, отправленное клиентом на сервер.
+func decryptFile(t *testing.T, encrypted, masterKey []byte) []byte {
+	reader, err := encryption.NewDecryptReader(bytes.NewReader(encrypted), masterKey)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return content
+}
+
+// fakeDownloadClientStream - тестовая реализация клиентского потока DownloadBlob.
+type fakeDownloadClientStream struct {
+	grpc.ClientStream
+	chunks [][]byte
+	err    error
+}
+
+func (f *fakeDownloadClientStream) Recv() (*proto.DownloadBlobResponse, error) {
+	if len(f.chunks) == 0 {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, io.EOF
+	}
+	chunk := f.chunks[0]
+	f.chunks = f.chunks[1:]
+	return &proto.DownloadBlobResponse{Chunk: chunk}, nil
+}
+
+// fakeWatchClientStream - тестовая реализация клиентского потока WatchChanges.
+type fakeWatchClientStream struct {
+	grpc.ClientStream
+	events []*proto.ChangeEvent
+	err    error
+}
+
+func (f *fakeWatchClientStream) Recv() (*proto.ChangeEvent, error) {
+	if len(f.events) == 0 {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, io.EOF
+	}
+	event := f.events[0]
+	f.events = f.events[1:]
+	return event, nil
+}
+
+func TestHistory(t *testing.T) {
+	t.Cleanup(func() {
+		os.RemoveAll("user_data")
+	})
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mproto.NewMockGophKeeperClient(ctrl)
+
+	masterKey := make([]byte, 32)
+	copy(masterKey, "16-byte-master-key")
+
+	grpcClient := &Client{
+		Client:        mockClient,
+		UserID:        12345,
+		EncryptionKey: masterKey,
+	}
+
+	oldData, err := encryption.EncryptData([]byte("old secret"), masterKey)
+	assert.NoError(t, err)
+	currentData, err := encryption.EncryptData([]byte("secret"), masterKey)
+	assert.NoError(t, err)
+
+	t.Run("Получение истории изменений", func(t *testing.T) {
+		mockClient.EXPECT().GetDataHistory(gomock.Any(), &proto.GetDataHistoryRequest{DataId: "00000000-0000-0000-0000-000000000001"}).
+			Return(&proto.GetDataHistoryResponse{
+				Revisions: []*proto.DataItem{
+					{
+						DataId:      "00000000-0000-0000-0000-000000000001",
+						DataType:    proto.DataType_TEXT_DATA,
+						DataContent: []byte(oldData),
+						UpdatedAt:   "2025-03-02T15:22:00Z",
+						Revision:    10,
+						Version:     1,
+					},
+				},
+			}, nil)
+
+		history, err := grpcClient.GetDataHistory("00000000-0000-0000-0000-000000000001")
+		assert.NoError(t, err)
+		if assert.Len(t, history, 1) {
+			assert.Equal(t, []byte("old secret"), history[0].DataContent)
+			assert.Equal(t, int64(10), history[0].Revision)
+			assert.Equal(t, int64(1), history[0].Version)
+		}
+	})
+
+	t.Run("Откат данных к предыдущей ревизии", func(t *testing.T) {
+		os.RemoveAll("user_data")
+
+		err := localstorage.SaveData(grpcClient.UserID, mdata.Data{
+			ID: "00000000-0000-0000-0000-000000000001", DataType: mdata.TextData, DataContent: []byte(currentData), Revision: 12, Version: 2,
+		})
+		assert.NoError(t, err)
+
+		mockClient.EXPECT().RestoreRevision(gomock.Any(),
+			&proto.RestoreRevisionRequest{DataId: "00000000-0000-0000-0000-000000000001", Revision: 10, ExpectedVersion: 2}).
+			Return(&proto.RestoreRevisionResponse{
+				Data: &proto.DataItem{
+					DataId:      "00000000-0000-0000-0000-000000000001",
+					DataType:    proto.DataType_TEXT_DATA,
+					DataContent: []byte(oldData),
+					UpdatedAt:   "2025-03-02T15:22:00Z",
+					Revision:    15,
+					Version:     3,
+				},
+			}, nil)
+
+		err = grpcClient.RestoreRevision("00000000-0000-0000-0000-000000000001", 10)
+		assert.NoError(t, err)
+
+		data, err := localstorage.GetAllData(grpcClient.UserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(oldData), data["00000000-0000-0000-0000-000000000001"].DataContent)
+		assert.Equal(t, int64(15), data["00000000-0000-0000-0000-000000000001"].Revision)
+		assert.Equal(t, int64(3), data["00000000-0000-0000-0000-000000000001"].Version)
+	})
+
+	t.Run("Откат данных с неотправленными изменениями", func(t *testing.T) {
+		err := localstorage.MarkDirty(grpcClient.UserID, "00000000-0000-0000-0000-000000000001")
+		assert.NoError(t, err)
+
+		err = grpcClient.RestoreRevision("00000000-0000-0000-0000-000000000001", 10)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "неотправленные изменения")
+	})
 }
@@ -10,22 +10,40 @@ import (
 	"github.com/Sofja96/GophKeeper.git/proto"
 )
 
-// DeleteData удаляет данные с указанным ID из локального хранилища и сервера.
+// DeleteData перемещает данные с указанным ID в корзину на сервере и удаляет их из локального хранилища.
 // Использует токен для аутентификации при взаимодействии с сервером.
+// Данные удаляются на сервере только при совпадении их версии с локальной; если данные изменены
+// на другом устройстве, локальная копия сохраняется, чтобы пользователь мог синхронизировать данные
+// и проверить изменения перед повторным удалением.
+// Данные, которые еще не были отправлены на сервер, удаляются только локально.
 // Возвращает ошибку в случае неудачи.
-func (c *Client) DeleteData(dataId int64) error {
+func (c *Client) DeleteData(dataId string) error {
 	ctx := metadata.AppendToOutgoingContext(context.Background(),
 		"authorization", c.GetToken())
 
-	if err := localstorage.DeleteData(c.UserID, dataId); err != nil {
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
 		return fmt.Errorf("ошибка удаления данных из локального хранилища: %w", err)
 	}
 
-	req := &proto.DeleteDataRequest{DataId: dataId}
+	localItem, exists := localData[dataId]
+	if !exists || localItem.Revision != 0 {
+		req := &proto.DeleteDataRequest{DataId: dataId, ExpectedVersion: localItem.Version}
+
+		_, err := c.Client.DeleteData(ctx, req)
+		if _, conflicted := conflictVersion(err); conflicted {
+			return fmt.Errorf("ошибка удаления данных: данные с ID %s изменены на другом устройстве, "+
+				"синхронизируйте данные и повторите удаление", dataId)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка удаления данных: %w", err)
+		}
+	}
 
-	_, err := c.Client.DeleteData(ctx, req)
-	if err != nil {
-		return fmt.Errorf("ошибка удаления данных: %w", err)
+	if exists {
+		if err := localstorage.DeleteData(c.UserID, dataId); err != nil {
+			return fmt.Errorf("ошибка удаления данных из локального хранилища: %w", err)
+		}
 	}
 
 	fmt.Println("Данные успешно удалены из локального хранилища.")
@@ -11,6 +11,7 @@ import (
 
 // GetData получает все данные пользователя из локального хранилища,
 // расшифровывая их при необходимости, и возвращает их в виде среза данных.
+// Для бинарных данных содержимое не читается в память, вместо него возвращается путь к локальному файлу.
 // В случае ошибки при получении или расшифровке данных возвращается ошибка.
 func (c *Client) GetData() ([]models.Data, error) {
 	dataMap, err := localstorage.GetAllData(c.UserID)
@@ -18,32 +19,15 @@ func (c *Client) GetData() ([]models.Data, error) {
 		return nil, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
 	}
 
-	key := c.GetMasterKey()
 	data := make([]models.Data, 0, len(dataMap))
 
 	for _, item := range dataMap {
-		var decryptedData []byte
-
-		if item.DataType == models.BinaryData {
-			decryptedData, err = encryption.DecodeData(string(item.DataContent))
-			if err != nil {
-				decryptedData = item.DataContent
-			}
-		} else {
-			decryptedData, err = encryption.DecryptData(string(item.DataContent), key)
-			if err != nil {
-				return nil, fmt.Errorf("ошибка расшифровки данных: %w", err)
-			}
-
+		decrypted, err := c.decryptItem(item)
+		if err != nil {
+			return nil, err
 		}
 
-		data = append(data, models.Data{
-			ID:          item.ID,
-			DataType:    item.DataType,
-			DataContent: decryptedData,
-			Metadata:    item.Metadata,
-			UpdatedAt:   item.UpdatedAt,
-		})
+		data = append(data, decrypted)
 	}
 
 	sort.Slice(data, func(i, j int) bool {
@@ -52,3 +36,38 @@ func (c *Client) GetData() ([]models.Data, error) {
 
 	return data, nil
 }
+
+// decryptItem расшифровывает содержимое записи мастер-ключом клиента.
+// Для бинарных данных без содержимого возвращается описание файла и путь к локальному файлу,
+// если файл уже есть в локальном хранилище.
+func (c *Client) decryptItem(item models.Data) (models.Data, error) {
+	var decryptedData []byte
+	var filePath string
+	var err error
+
+	if item.DataType == models.BinaryData {
+		if len(item.DataContent) == 0 {
+			if localstorage.HasFile(c.UserID, item.ID) {
+				filePath = localstorage.GetFilePath(c.UserID, item.ID)
+			}
+		} else {
+			decryptedData = legacyFileContent(item.DataContent)
+		}
+	} else {
+		decryptedData, err = encryption.DecryptData(string(item.DataContent), c.GetMasterKey())
+		if err != nil {
+			return models.Data{}, fmt.Errorf("ошибка расшифровки данных: %w", err)
+		}
+	}
+
+	return models.Data{
+		ID:          item.ID,
+		DataType:    item.DataType,
+		DataContent: decryptedData,
+		FileName:    item.FileName,
+		FilePath:    filePath,
+		Blob:        item.Blob,
+		Metadata:    item.Metadata,
+		UpdatedAt:   item.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// GetDataHistory получает с сервера предыдущие состояния данных с указанным ID, начиная с последнего,
+// и расшифровывает их. Содержимое бинарных данных не загружается.
+func (c *Client) GetDataHistory(dataId string) ([]models.Data, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.GetDataHistory(ctx, &proto.GetDataHistoryRequest{DataId: dataId})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории изменений с сервера: %w", err)
+	}
+
+	history := make([]models.Data, 0, len(resp.Revisions))
+	for _, item := range resp.Revisions {
+		serverItem, err := dataFromProto(item)
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted := serverItem
+		if serverItem.DataType != models.BinaryData {
+			decrypted, err = c.decryptItem(serverItem)
+			if err != nil {
+				return nil, err
+			}
+			decrypted.Revision = serverItem.Revision
+			decrypted.Version = serverItem.Version
+		}
+
+		history = append(history, decrypted)
+	}
+
+	return history, nil
+}
+
+// RestoreRevision возвращает данные с указанным ID к состоянию с ревизией revision и сохраняет результат
+// в локальное хранилище. Данные с неотправленными локальными изменениями не откатываются,
+// чтобы не потерять эти изменения. Содержимое бинарных данных загружается с сервера.
+func (c *Client) RestoreRevision(dataId string, revision int64) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	dirty, err := localstorage.GetDirty(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+	if dirty[dataId] {
+		return fmt.Errorf("данные с ID %s имеют неотправленные изменения, выполните синхронизацию", dataId)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.RestoreRevision(ctx, &proto.RestoreRevisionRequest{
+		DataId:          dataId,
+		Revision:        revision,
+		ExpectedVersion: localData[dataId].Version,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка отката данных: %w", err)
+	}
+
+	item, err := dataFromProto(resp.Data)
+	if err != nil {
+		return err
+	}
+
+	legacy, err := c.saveServerItem(ctx, &item)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	if err := markSyncedItem(c.UserID, item, legacy); err != nil {
+		return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/models"
+	mdata "github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// ListData получает с сервера страницу данных пользователя, удовлетворяющих фильтру,
+// и расшифровывает их. Пустой pageToken запрашивает первую страницу.
+// Возвращает данные страницы и токен следующей страницы, пустой для последней страницы.
+func (c *Client) ListData(filter models.ListFilter, pageToken string) ([]mdata.Data, string, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	req := &proto.ListDataRequest{
+		PageSize:  filter.PageSize,
+		PageToken: pageToken,
+		DataType:  filter.DataType,
+		Metadata:  filter.Metadata,
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		req.UpdatedAfter = filter.UpdatedAfter.Format(time.RFC3339)
+	}
+
+	resp, err := c.Client.ListData(ctx, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка получения данных с сервера: %w", err)
+	}
+
+	data := make([]mdata.Data, 0, len(resp.Data))
+	for _, item := range resp.Data {
+		serverItem, err := dataFromProto(item)
+		if err != nil {
+			return nil, "", err
+		}
+
+		decrypted, err := c.decryptItem(serverItem)
+		if err != nil {
+			return nil, "", err
+		}
+
+		data = append(data, decrypted)
+	}
+
+	return data, resp.NextPageToken, nil
+}
+
+// dataFromProto преобразует элемент ответа сервера в модель данных.
+func dataFromProto(item *proto.DataItem) (mdata.Data, error) {
+	dataType, err := mdata.GetModelType(item.DataType)
+	if err != nil {
+		return mdata.Data{}, fmt.Errorf("ошибка конвертации типа данных: %w", err)
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339, item.UpdatedAt)
+	if err != nil {
+		return mdata.Data{}, fmt.Errorf("ошибка преобразования времени UpdatedAt: %w", err)
+	}
+
+	var deletedAt time.Time
+	if item.DeletedAt != "" {
+		deletedAt, err = time.Parse(time.RFC3339, item.DeletedAt)
+		if err != nil {
+			return mdata.Data{}, fmt.Errorf("ошибка преобразования времени DeletedAt: %w", err)
+		}
+	}
+
+	data := mdata.Data{
+		ID:          item.DataId,
+		DataType:    dataType,
+		DataContent: item.DataContent,
+		Metadata:    item.Metadata.AsMap(),
+		UpdatedAt:   updatedAt,
+		Revision:    item.Revision,
+		Version:     item.Version,
+		DeletedAt:   deletedAt,
+	}
+
+	if item.Blob != nil {
+		data.FileName = item.Blob.FileName
+		data.Blob = &mdata.BlobManifest{
+			ID:          item.Blob.BlobId,
+			FileName:    item.Blob.FileName,
+			Size:        item.Blob.Size,
+			ContentHash: item.Blob.ContentHash,
+		}
+	}
+
+	return data, nil
+}
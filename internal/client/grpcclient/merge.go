@@ -0,0 +1,108 @@
+package grpcclient
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// mergeItem объединяет локальные и серверные изменения записи относительно базовой версии,
+// сохраненной при последней синхронизации.
+//
+// Содержимое сравнивается в расшифрованном виде: оно берется с той стороны, где изменилось,
+// и считается конфликтом, только если изменилось по-разному с обеих сторон. Метаданные объединяются по ключам.
+// Бинарные данные не объединяются, так как содержимое файла на сервере нельзя сравнить без загрузки.
+// Возвращает false, если изменения пересекаются и объединить их нельзя.
+func (c *Client) mergeItem(base, local, server models.Data) (models.Data, bool, error) {
+	if local.DataType == models.BinaryData || server.DataType == models.BinaryData {
+		return models.Data{}, false, nil
+	}
+
+	baseContent, err := c.plainContent(base)
+	if err != nil {
+		return models.Data{}, false, err
+	}
+	localContent, err := c.plainContent(local)
+	if err != nil {
+		return models.Data{}, false, err
+	}
+	serverContent, err := c.plainContent(server)
+	if err != nil {
+		return models.Data{}, false, err
+	}
+
+	merged := server
+	merged.FileName = local.FileName
+
+	localChanged := !bytes.Equal(localContent, baseContent)
+	serverChanged := !bytes.Equal(serverContent, baseContent)
+	if localChanged {
+		if serverChanged && !bytes.Equal(localContent, serverContent) {
+			return models.Data{}, false, nil
+		}
+		merged.DataContent = local.DataContent
+	}
+
+	metadata, ok := mergeMetadata(base.Metadata, local.Metadata, server.Metadata)
+	if !ok {
+		return models.Data{}, false, nil
+	}
+	merged.Metadata = metadata
+
+	return merged, true, nil
+}
+
+// plainContent возвращает расшифрованное содержимое записи. Для пустого содержимого возвращает nil.
+func (c *Client) plainContent(item models.Data) ([]byte, error) {
+	if len(item.DataContent) == 0 {
+		return nil, nil
+	}
+
+	content, err := encryption.DecryptData(string(item.DataContent), c.GetMasterKey())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки данных: %w", err)
+	}
+	return content, nil
+}
+
+// mergeMetadata выполняет трехстороннее объединение метаданных по ключам.
+// Ключ берется с той стороны, где он изменился относительно base; удаление ключа считается изменением.
+// Возвращает false, если один и тот же ключ изменен по-разному локально и на сервере.
+func mergeMetadata(base, local, server map[string]interface{}) (map[string]interface{}, bool) {
+	keys := make(map[string]struct{}, len(local)+len(server))
+	for key := range local {
+		keys[key] = struct{}{}
+	}
+	for key := range server {
+		keys[key] = struct{}{}
+	}
+
+	merged := make(map[string]interface{}, len(keys))
+	for key := range keys {
+		baseValue, inBase := base[key]
+		localValue, inLocal := local[key]
+		serverValue, inServer := server[key]
+
+		var value interface{}
+		var present bool
+		switch {
+		case inLocal == inServer && reflect.DeepEqual(localValue, serverValue):
+			value, present = localValue, inLocal
+		case inLocal == inBase && reflect.DeepEqual(localValue, baseValue):
+			value, present = serverValue, inServer
+		case inServer == inBase && reflect.DeepEqual(serverValue, baseValue):
+			value, present = localValue, inLocal
+		default:
+			return nil, false
+		}
+
+		if present {
+			merged[key] = value
+		}
+	}
+
+	return merged, true
+}
@@ -0,0 +1,107 @@
+package grpcclient
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// ConflictResolution - способ разрешения конфликта между локальной и серверной версиями данных.
+type ConflictResolution int
+
+const (
+	KeepLocal  ConflictResolution = iota + 1 // сохранить локальную версию вместо серверной
+	KeepServer                               // оставить серверную версию и удалить конфликтную копию
+	KeepBoth                                 // сохранить конфликтную копию как отдельную запись
+)
+
+// Conflict описывает конфликтную копию и запись, из которой она создана.
+// Если запись удалена на другом устройстве, Original содержит только ID.
+type Conflict struct {
+	Copy     models.Data
+	Original models.Data
+}
+
+// GetConflicts возвращает расшифрованные конфликтные копии вместе с серверными версиями записей.
+func (c *Client) GetConflicts() ([]Conflict, error) {
+	copies, err := localstorage.GetConflictCopies(c.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	conflicts := make([]Conflict, 0, len(copies))
+	for copyID, originalID := range copies {
+		conflict := Conflict{Original: models.Data{ID: originalID}}
+
+		conflict.Copy, err = c.decryptItem(localData[copyID])
+		if err != nil {
+			return nil, err
+		}
+
+		if original, exists := localData[originalID]; exists {
+			conflict.Original, err = c.decryptItem(original)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Copy.ID < conflicts[j].Copy.ID
+	})
+
+	return conflicts, nil
+}
+
+// ResolveConflict разрешает конфликт для конфликтной копии copyID выбранным способом.
+// Изменения отправляются на сервер при следующей синхронизации.
+// Если запись удалена на другом устройстве, сохранение локальной версии создает ее заново.
+func (c *Client) ResolveConflict(copyID string, resolution ConflictResolution) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	copies, err := localstorage.GetConflictCopies(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	originalID, ok := copies[copyID]
+	if !ok {
+		return fmt.Errorf("конфликтная копия с ID %s не найдена", copyID)
+	}
+
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+	_, originalExists := localData[originalID]
+
+	switch resolution {
+	case KeepLocal:
+		if originalExists {
+			err = localstorage.ApplyConflictCopy(c.UserID, copyID, originalID)
+		} else {
+			err = localstorage.KeepConflictCopy(c.UserID, copyID)
+		}
+	case KeepServer:
+		err = localstorage.DeleteData(c.UserID, copyID)
+	case KeepBoth:
+		err = localstorage.KeepConflictCopy(c.UserID, copyID)
+	default:
+		return fmt.Errorf("неизвестный способ разрешения конфликта: %d", resolution)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	return nil
+}
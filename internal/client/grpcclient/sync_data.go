@@ -1,163 +1,571 @@
 package grpcclient
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"time"
+	"reflect"
+	"sort"
 
-	"google.golang.org/grpc/codes"
+	"github.com/google/uuid"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 
 	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 	"github.com/Sofja96/GophKeeper.git/proto"
 )
 
-// SyncData синхронизирует данные между сервером и клиентом
+const (
+	syncPageSize  = 200 // количество изменений, запрашиваемых с сервера за один вызов GetChanges
+	syncAttempts  = 2   // количество попыток отправить изменения, если данные успели измениться на сервере
+	syncBatchSize = 100 // количество изменений, отправляемых на сервер в одном вызове BatchMutate
+)
+
+// ServerState описывает запись на сервере после ее создания или обновления.
+type ServerState struct {
+	ID       string // ID записи на сервере
+	Revision int64  // ревизия последнего изменения записи
+	Version  int64  // версия записи для проверки одновременных изменений
+}
+
+// SyncData синхронизирует данные между сервером и клиентом.
+// С сервера запрашиваются только изменения после сохраненного локально курсора,
+// на сервер отправляются только записи, измененные локально после последней синхронизации.
+// Записи, измененные и локально, и на другом устройстве, объединяются; если изменения пересекаются,
+// локальная версия сохраняется как конфликтная копия, а функция возвращает *ConflictError.
 func (c *Client) SyncData() error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
 	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
 
-	// 1. Получаем данные с сервера
-	serverData, err := c.GetAllDataFromServer(ctx)
+	var rejected []string
+	for attempt := 0; attempt < syncAttempts; attempt++ {
+		cursor, err := localstorage.GetCursor(c.UserID)
+		if err != nil {
+			return fmt.Errorf("ошибка получения курсора синхронизации: %w", err)
+		}
+
+		// 1. Получаем изменения с сервера и объединяем их с локальными
+		full, err := c.pullChanges(ctx, cursor)
+		if err != nil {
+			return err
+		}
+
+		// 2. При полной синхронизации отправляем на сервер все локальные данные, которых там еще нет
+		if full {
+			if err := c.markUnsyncedDirty(); err != nil {
+				return err
+			}
+		}
+
+		// 3. Отправляем локальные изменения на сервер. Если данные успели измениться на сервере,
+		// повторяем синхронизацию, чтобы объединить их с новыми изменениями
+		rejected, err = c.pushChanges(ctx)
+		if err != nil {
+			return err
+		}
+		if len(rejected) == 0 {
+			break
+		}
+	}
+
+	if len(rejected) > 0 {
+		return fmt.Errorf("данные с ID %v снова изменены на другом устройстве, повторите синхронизацию", rejected)
+	}
+
+	// 4. Сообщаем о конфликтных копиях, для которых пользователь должен выбрать версию
+	copies, err := localstorage.GetConflictCopies(c.UserID)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			fmt.Println("Нет данных на сервере, продолжаем синхронизацию...")
-			serverData = make(map[int64]models.Data)
-		} else {
-			return fmt.Errorf("ошибка получения данных с сервера: %w", err)
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+	if len(copies) > 0 {
+		ids := make([]string, 0, len(copies))
+		for _, originalID := range copies {
+			ids = append(ids, originalID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return &ConflictError{IDs: ids}
+	}
+
+	fmt.Println("Данные успешно синхронизированы.")
+	return nil
+}
+
+// pullChanges постранично получает изменения с сервера после ревизии cursor и применяет их к локальному хранилищу.
+// При синхронизации изменений курсор сохраняется после каждой страницы, поэтому прерванная синхронизация
+// продолжается с места остановки.
+// Серверные изменения записей, измененных и локально, объединяются с локальными через mergeRemoteChange.
+//
+// Если сервер уже удалил метки удаления после cursor, изменения запрашиваются заново с нулевого курсора.
+// При синхронизации с нулевого курсора сервер передает все данные пользователя, поэтому локальные данные
+// с серверной ревизией, которых нет в ответе, были удалены на другом устройстве и удаляются через dropMissing.
+// Курсор полной синхронизации сохраняется только после dropMissing: прерванная полная синхронизация
+// начинается заново, а не продолжается как синхронизация изменений, при которой dropMissing не выполняется.
+// Возвращает true, если была выполнена полная синхронизация.
+func (c *Client) pullChanges(ctx context.Context, cursor int64) (bool, error) {
+	full := cursor == 0
+	seen := make(map[string]struct{})
+
+	for {
+		resp, err := c.Client.GetChanges(ctx, &proto.GetChangesRequest{
+			SinceCursor: cursor,
+			Limit:       syncPageSize,
+		})
+		if err != nil {
+			return false, fmt.Errorf("ошибка получения изменений с сервера: %w", err)
+		}
+
+		if resp.ResetRequired {
+			cursor, full = 0, true
+			seen = make(map[string]struct{})
+			continue
+		}
+
+		localData, err := localstorage.GetAllData(c.UserID)
+		if err != nil {
+			return false, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
 		}
+
+		dirty, err := localstorage.GetDirty(c.UserID)
+		if err != nil {
+			return false, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+		}
+
+		for _, item := range resp.Changed {
+			serverItem, err := dataFromProto(item)
+			if err != nil {
+				return false, err
+			}
+			seen[serverItem.ID] = struct{}{}
+
+			localItem, exists := localData[serverItem.ID]
+			if exists {
+				// Изменение уже применено локально, например, отправлено с этого клиента
+				if localItem.Revision == serverItem.Revision {
+					continue
+				}
+				if dirty[serverItem.ID] {
+					merged, err := c.mergeRemoteChange(localItem, serverItem)
+					if err != nil {
+						return false, err
+					}
+					if merged {
+						continue
+					}
+				}
+				if serverItem.FileName == "" {
+					serverItem.FileName = localItem.FileName
+				}
+			}
+
+			legacy, err := c.saveServerItem(ctx, &serverItem)
+			if err != nil {
+				return false, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+			}
+
+			if err := markSyncedItem(c.UserID, serverItem, legacy); err != nil {
+				return false, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+			}
+		}
+
+		for _, id := range resp.DeletedIds {
+			seen[id] = struct{}{}
+			localItem, exists := localData[id]
+			if !exists {
+				continue
+			}
+			// Локальные изменения удаленной на сервере записи сохраняются как конфликтная копия
+			if dirty[id] {
+				if err := c.saveConflictCopy(id, localItem); err != nil {
+					return false, err
+				}
+			}
+			if err := localstorage.DeleteData(c.UserID, id); err != nil {
+				return false, fmt.Errorf("ошибка удаления данных из локального хранилища: %w", err)
+			}
+		}
+
+		cursor = resp.Cursor
+		if !full {
+			if err := localstorage.SetCursor(c.UserID, cursor); err != nil {
+				return false, fmt.Errorf("ошибка сохранения курсора синхронизации: %w", err)
+			}
+		}
+
+		if !resp.HasMore {
+			break
+		}
+	}
+
+	if full {
+		if err := c.dropMissing(seen); err != nil {
+			return false, err
+		}
+		if err := localstorage.SetCursor(c.UserID, cursor); err != nil {
+			return false, fmt.Errorf("ошибка сохранения курсора синхронизации: %w", err)
+		}
+	}
+
+	return full, nil
+}
+
+// dropMissing удаляет локальные данные с серверной ревизией, которых нет среди seen.
+// Такие данные удалены на другом устройстве, а метки удаления уже удалены на сервере.
+// Локальные изменения таких данных сохраняются как конфликтные копии.
+func (c *Client) dropMissing(seen map[string]struct{}) error {
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	dirty, err := localstorage.GetDirty(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	for id, item := range localData {
+		if _, ok := seen[id]; ok || item.Revision == 0 {
+			continue
+		}
+		if dirty[id] {
+			if err := c.saveConflictCopy(id, item); err != nil {
+				return err
+			}
+		}
+		if err := localstorage.DeleteData(c.UserID, id); err != nil {
+			return fmt.Errorf("ошибка удаления данных из локального хранилища: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// mergeRemoteChange объединяет серверное изменение записи с ее локальными изменениями
+// относительно версии, сохраненной при последней синхронизации.
+// Возвращает true, если результат объединения сохранен локально и будет отправлен на сервер.
+// Если изменения пересекаются, локальная версия сохраняется как конфликтная копия и возвращается false:
+// запись принимает серверную версию.
+func (c *Client) mergeRemoteChange(localItem, serverItem models.Data) (bool, error) {
+	base, _, err := localstorage.GetBase(c.UserID, serverItem.ID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
 	}
 
-	// 2. Получаем данные из локального хранилища
+	merged, ok, err := c.mergeItem(base, localItem, serverItem)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		return false, c.saveConflictCopy(serverItem.ID, localItem)
+	}
+
+	// Локальные изменения уже содержатся в серверной версии
+	if bytes.Equal(merged.DataContent, serverItem.DataContent) && reflect.DeepEqual(merged.Metadata, serverItem.Metadata) {
+		return false, nil
+	}
+
+	if err := localstorage.SaveMerged(c.UserID, merged, serverItem); err != nil {
+		return false, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	return true, nil
+}
+
+// saveConflictCopy сохраняет локальную версию записи originalID как конфликтную копию с новым локальным ID.
+func (c *Client) saveConflictCopy(originalID string, localItem models.Data) error {
+	copyItem := localItem
+	copyItem.ID = models.NewDataID()
+	copyItem.Revision = 0
+	copyItem.Version = 0
+	copyItem.IdempotencyKey = uuid.NewString()
+
+	if err := localstorage.SaveConflictCopy(c.UserID, originalID, copyItem); err != nil {
+		return fmt.Errorf("ошибка сохранения конфликтной копии: %w", err)
+	}
+
+	return nil
+}
+
+// markUnsyncedDirty помечает для отправки на сервер локальные данные без серверной ревизии.
+// Такие данные могли остаться от версии клиента, которая не отслеживала локальные изменения.
+// Конфликтные копии не отправляются, пока пользователь не выберет версию.
+func (c *Client) markUnsyncedDirty() error {
 	localData, err := localstorage.GetAllData(c.UserID)
 	if err != nil {
 		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
 	}
 
-	// 3. Синхронизация данных с сервера в локальное хранилище
-	for serverID, serverItem := range serverData {
-		localItem, exists := localData[serverID]
+	copies, err := localstorage.GetConflictCopies(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
 
-		if !exists {
-			fmt.Println("Синхронизация данных с сервера в локальное хранилище")
-			// Данных нет локально — сохраняем их в локальное хранилище
-			if err := localstorage.SaveData(c.UserID, serverItem); err != nil {
-				return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	for id, item := range localData {
+		if item.Revision != 0 {
+			continue
+		}
+		if _, isCopy := copies[id]; isCopy {
+			continue
+		}
+		if err := localstorage.MarkDirty(c.UserID, id); err != nil {
+			return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// pushChanges отправляет на сервер локально измененные данные.
+// Данные без серверной ревизии создаются на сервере, остальные обновляются с проверкой версии.
+// Изменения отправляются пакетами через BatchMutate: сервер применяет каждый пакет атомарно,
+// а локальные ID и ревизии обновляются только после успешного выполнения пакета.
+// Бинарные данные из локальных файлов не входят в пакеты: каждый файл загружается потоком отдельным вызовом
+// после пакетов, поэтому изменения с файлами не атомарны ни с пакетом, ни друг с другом. Если отправка прервется,
+// часть файлов останется только локально и будет отправлена при следующей синхронизации.
+// Возвращает ID записей, которые сервер отклонил из-за изменения на другом устройстве;
+// они остаются помеченными для отправки и объединяются при следующем получении изменений.
+func (c *Client) pushChanges(ctx context.Context) ([]string, error) {
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	dirty, err := localstorage.GetDirty(c.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
+	ids := make([]string, 0, len(dirty))
+	for localID := range dirty {
+		if _, exists := localData[localID]; exists {
+			ids = append(ids, localID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var batch, files []models.Data
+	for _, localID := range ids {
+		localItem := localData[localID]
+		localItem.ID = localID
+
+		// Записи, созданные до появления ключей идемпотентности, получают ключ перед первой отправкой
+		if localItem.Revision == 0 && localItem.IdempotencyKey == "" {
+			localItem.IdempotencyKey = uuid.NewString()
+			if err := localstorage.SaveData(c.UserID, localItem); err != nil {
+				return nil, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
 			}
-		} else if serverItem.UpdatedAt.After(localItem.UpdatedAt) {
-			// Серверные данные новее — обновляем локальные
-			if err := localstorage.SaveData(c.UserID, serverItem); err != nil {
-				return fmt.Errorf("ошибка обновления локальных данных: %w", err)
+		}
+
+		// Файлы, сохраненные в записи старыми клиентами, переносятся в локальный файл и шифруются при отправке
+		if localItem.DataType == models.BinaryData && len(localItem.DataContent) > 0 {
+			if err := c.moveInlineFile(&localItem); err != nil {
+				return nil, fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
 			}
 		}
+
+		if isLocalFile(localItem) {
+			files = append(files, localItem)
+		} else {
+			batch = append(batch, localItem)
+		}
 	}
 
-	// 4. Синхронизация данных из локального хранилища на сервер
-	for localID, localItem := range localData {
+	var rejected []string
+	for start := 0; start < len(batch); start += syncBatchSize {
+		end := min(start+syncBatchSize, len(batch))
 
-		serverItem, exists := serverData[localID]
+		batchRejected, err := c.pushBatch(ctx, batch[start:end])
+		if err != nil {
+			return nil, err
+		}
+		rejected = append(rejected, batchRejected...)
+	}
+
+	for _, localItem := range files {
+		localID := localItem.ID
 
-		if !exists {
+		var state ServerState
+		if localItem.Revision == 0 {
 			// Данных нет на сервере — отправляем их на сервер
-			newID, err := c.SendDataToServer(ctx, localItem)
+			state, err = c.uploadLocalFile(ctx, localItem)
 			if err != nil {
-				return fmt.Errorf("ошибка отправки данных на сервер: %w", err)
+				return nil, fmt.Errorf("ошибка отправки данных на сервер: %w", err)
 			}
 
-			// Обновляем локальный ID на новый, который вернул сервер
-			if err := localstorage.UpdateID(c.UserID, localID, newID); err != nil {
-				return fmt.Errorf("ошибка обновления локального ID: %w", err)
+			// Сервер вернул запись с другим ID — она уже была создана под ним по ключу идемпотентности
+			if state.ID != localID {
+				if err := localstorage.UpdateID(c.UserID, localID, state.ID); err != nil {
+					return nil, fmt.Errorf("ошибка обновления локального ID: %w", err)
+				}
+				localID = state.ID
 			}
-		} else if localItem.UpdatedAt.After(serverItem.UpdatedAt) {
-			// Локальные данные новее — обновляем их на сервере
-			if err := c.UpdateDataOnServer(ctx, localItem, localID); err != nil {
-				return fmt.Errorf("ошибка обновления данных на сервере: %w", err)
+		} else {
+			// Данные изменены локально — обновляем их на сервере
+			state, err = c.uploadLocalFile(ctx, localItem)
+			if _, conflicted := conflictVersion(err); conflicted {
+				rejected = append(rejected, localID)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("ошибка обновления данных на сервере: %w", err)
 			}
 		}
+
+		if err := localstorage.MarkSynced(c.UserID, localID, state.Revision, state.Version); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения ревизии данных: %w", err)
+		}
 	}
 
-	fmt.Println("Данные успешно синхронизированы.")
-	return nil
+	return rejected, nil
 }
 
-// GetAllDataFromServer получает данные с сервера
-func (c *Client) GetAllDataFromServer(ctx context.Context) (map[int64]models.Data, error) {
-	resp, err := c.Client.GetAllData(ctx, &proto.GetAllDataRequest{})
-	if err != nil {
-		return nil, fmt.Errorf("ошибка получения данных с сервера: %w", err)
-	}
-
-	serverData := make(map[int64]models.Data)
-	for _, item := range resp.Data {
-		dataType, err := models.GetModelType(item.DataType)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка конвертации типа данных: %w", err)
+// pushBatch атомарно отправляет на сервер пакет локальных изменений через BatchMutate.
+// Если сервер отклонил операцию из-за изменения данных на другом устройстве, пакет отправляется повторно
+// без этой записи, а ее ID возвращается среди отклоненных.
+func (c *Client) pushBatch(ctx context.Context, items []models.Data) ([]string, error) {
+	var rejected []string
+	for len(items) > 0 {
+		mutations := make([]*proto.Mutation, 0, len(items))
+		for _, item := range items {
+			mutation, err := newMutation(item)
+			if err != nil {
+				return nil, err
+			}
+			mutations = append(mutations, mutation)
 		}
 
-		location, err := time.LoadLocation("Europe/Moscow")
+		resp, err := c.Client.BatchMutate(ctx, &proto.BatchMutateRequest{Mutations: mutations})
+		if index, conflicted := conflictOperation(err); conflicted && index < len(items) {
+			rejected = append(rejected, items[index].ID)
+			items = append(items[:index:index], items[index+1:]...)
+			continue
+		}
 		if err != nil {
-			return nil, fmt.Errorf("ошибка загрузки часового пояса: %w", err)
+			return nil, fmt.Errorf("ошибка отправки данных на сервер: %w", err)
+		}
+		if len(resp.Results) != len(items) {
+			return nil, fmt.Errorf("ошибка отправки данных на сервер: получено %d результатов для %d операций",
+				len(resp.Results), len(items))
 		}
 
-		updatedAt, err := time.ParseInLocation(time.RFC3339, item.UpdatedAt, location)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка преобразования времени UpdatedAt: %w", err)
+		for i, item := range items {
+			result := resp.Results[i]
+			localID := item.ID
+
+			if item.Revision == 0 && result.DataId != localID {
+				// Сервер вернул запись с другим ID — она уже была создана под ним по ключу идемпотентности
+				if err := localstorage.UpdateID(c.UserID, localID, result.DataId); err != nil {
+					return nil, fmt.Errorf("ошибка обновления локального ID: %w", err)
+				}
+				localID = result.DataId
+			}
+
+			if err := localstorage.MarkSynced(c.UserID, localID, result.Revision, result.Version); err != nil {
+				return nil, fmt.Errorf("ошибка сохранения ревизии данных: %w", err)
+			}
 		}
 
-		serverData[item.DataId] = models.Data{
-			ID:          item.DataId,
-			DataType:    dataType,
-			DataContent: item.DataContent,
-			Metadata:    item.Metadata.AsMap(),
-			UpdatedAt:   updatedAt,
+		return rejected, nil
+	}
+
+	return rejected, nil
+}
+
+// saveServerItem сохраняет данные с сервера в локальное хранилище.
+// Файл бинарных данных не загружается: сохраняется его описание, а сам файл загружается через FetchFile
+// при первом обращении. Ранее загруженный файл остается в локальном хранилище, только если описание файла
+// не изменилось. Содержимое, переданное сервером в ответе, расшифровывается и сразу записывается в локальный файл.
+// Возвращает true, если файл хранится на сервере незашифрованным и запись нужно отправить на сервер повторно.
+func (c *Client) saveServerItem(ctx context.Context, item *models.Data) (bool, error) {
+	var legacy bool
+	if item.DataType == models.BinaryData {
+		var err error
+		if len(item.DataContent) > 0 {
+			if legacy, err = c.saveServerFile(ctx, *item); err != nil {
+				return false, err
+			}
+			item.DataContent = nil
+		} else if err = c.dropStaleFile(*item); err != nil {
+			return false, err
 		}
 	}
 
-	return serverData, nil
+	return legacy, localstorage.SaveData(c.UserID, *item)
 }
 
-// SendDataToServer отправляет данные на сервер
-func (c *Client) SendDataToServer(ctx context.Context, data models.Data) (int64, error) {
-	structMetadata, err := models.ConvertJSONBToStruct(data.Metadata)
+// dropStaleFile удаляет локальный файл бинарных данных, если описание файла записи на сервере
+// отличается от сохраненного локально: файл будет загружен заново при следующем обращении.
+func (c *Client) dropStaleFile(item models.Data) error {
+	localData, err := localstorage.GetAllData(c.UserID)
 	if err != nil {
-		return 0, fmt.Errorf("ошибка преобразования метаданных: %w", err)
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
 	}
 
-	req := &proto.CreateDataRequest{
-		DataType:    proto.DataType(proto.DataType_value[data.DataType.String()]),
-		DataContent: data.DataContent,
-		Metadata:    structMetadata,
-		FileName:    data.FileName,
+	localItem, exists := localData[item.ID]
+	if exists && item.Blob != nil && localItem.Blob != nil && *item.Blob == *localItem.Blob {
+		return nil
 	}
 
-	resp, err := c.Client.CreateData(ctx, req)
-	if err != nil {
-		return 0, fmt.Errorf("ошибка отправки данных на сервер: %w", err)
+	return localstorage.DeleteFile(c.UserID, item.ID)
+}
+
+// markSyncedItem сохраняет ревизию и версию записи, полученной с сервера.
+// Если файл записи хранится на сервере незашифрованным, запись помечается для повторной отправки,
+// чтобы при синхронизации файл был заменен на сервере зашифрованным.
+func markSyncedItem(userID int64, item models.Data, legacy bool) error {
+	if err := localstorage.MarkSynced(userID, item.ID, item.Revision, item.Version); err != nil {
+		return err
+	}
+	if legacy {
+		return localstorage.MarkDirty(userID, item.ID)
+	}
+	return nil
+}
+
+// moveInlineFile переносит содержимое бинарных данных, сохраненное в записи до появления потоковой загрузки,
+// в отдельный локальный файл, чтобы отправить его на сервер в зашифрованном виде.
+func (c *Client) moveInlineFile(item *models.Data) error {
+	content := legacyFileContent(item.DataContent)
+	if _, err := localstorage.SaveFile(c.UserID, item.ID, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("ошибка сохранения файла: %w", err)
 	}
 
-	return resp.DataId, nil
+	item.DataContent = nil
+	return localstorage.SaveData(c.UserID, *item)
 }
 
-// UpdateDataOnServer обновляет данные на сервере
-func (c *Client) UpdateDataOnServer(ctx context.Context, data models.Data, dataId int64) error {
+// isLocalFile сообщает, хранится ли содержимое бинарных данных в отдельном локальном файле.
+// Бинарные данные, сохраненные до появления потоковой загрузки, содержат файл в DataContent.
+func isLocalFile(item models.Data) bool {
+	return item.DataType == models.BinaryData && len(item.DataContent) == 0
+}
 
+// newMutation формирует операцию пакетного изменения для локальной записи.
+// Записи без серверной ревизии создаются на сервере, остальные обновляются при совпадении версии с data.Version.
+func newMutation(data models.Data) (*proto.Mutation, error) {
 	structMetadata, err := models.ConvertJSONBToStruct(data.Metadata)
 	if err != nil {
-		return fmt.Errorf("ошибка преобразования метаданных: %w", err)
+		return nil, fmt.Errorf("ошибка преобразования метаданных: %w", err)
 	}
 
-	req := &proto.UpdateDataRequest{
-		DataId:      dataId,
-		DataContent: data.DataContent,
-		Metadata:    structMetadata,
-		FileName:    data.FileName,
+	if data.Revision == 0 {
+		return &proto.Mutation{Operation: &proto.Mutation_Create{Create: &proto.CreateDataRequest{
+			DataId:         data.ID,
+			DataType:       proto.DataType(proto.DataType_value[data.DataType.String()]),
+			DataContent:    data.DataContent,
+			Metadata:       structMetadata,
+			FileName:       data.FileName,
+			IdempotencyKey: data.IdempotencyKey,
+		}}}, nil
 	}
 
-	_, err = c.Client.UpdateData(ctx, req)
-	if err != nil {
-		return fmt.Errorf("ошибка обновления данных на сервере: %w", err)
-	}
-
-	return nil
+	return &proto.Mutation{Operation: &proto.Mutation_Update{Update: &proto.UpdateDataRequest{
+		DataId:          data.ID,
+		DataContent:     data.DataContent,
+		Metadata:        structMetadata,
+		FileName:        data.FileName,
+		ExpectedVersion: data.Version,
+	}}}, nil
 }
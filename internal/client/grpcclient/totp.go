@@ -0,0 +1,49 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// EnableTOTP начинает настройку двухфакторной аутентификации.
+// Возвращает секрет и ссылку otpauth:// для приложения-аутентификатора.
+// Двухфакторная аутентификация включается только после подтверждения кодом в ConfirmTOTP.
+func (c *Client) EnableTOTP() (secret, uri string, err error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.EnableTOTP(ctx, &proto.EnableTOTPRequest{})
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка настройки двухфакторной аутентификации: %w", err)
+	}
+
+	return resp.Secret, resp.OtpauthUri, nil
+}
+
+// ConfirmTOTP включает двухфакторную аутентификацию по коду из приложения
+// и возвращает одноразовые коды восстановления.
+func (c *Client) ConfirmTOTP(code string) ([]string, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{Code: code})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка включения двухфакторной аутентификации: %w", err)
+	}
+
+	return resp.RecoveryCodes, nil
+}
+
+// DisableTOTP выключает двухфакторную аутентификацию по коду из приложения или коду восстановления.
+func (c *Client) DisableTOTP(code string) error {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	_, err := c.Client.DisableTOTP(ctx, &proto.DisableTOTPRequest{Code: code})
+	if err != nil {
+		return fmt.Errorf("ошибка выключения двухфакторной аутентификации: %w", err)
+	}
+
+	return nil
+}
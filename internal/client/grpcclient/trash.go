@@ -0,0 +1,86 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// ListTrash получает с сервера данные пользователя, находящиеся в корзине, и расшифровывает их.
+// Содержимое бинарных данных не загружается.
+func (c *Client) ListTrash() ([]models.Data, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.ListTrash(ctx, &proto.ListTrashRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения корзины с сервера: %w", err)
+	}
+
+	data := make([]models.Data, 0, len(resp.Data))
+	for _, item := range resp.Data {
+		serverItem, err := dataFromProto(item)
+		if err != nil {
+			return nil, err
+		}
+
+		decrypted := serverItem
+		if serverItem.DataType != models.BinaryData {
+			decrypted, err = c.decryptItem(serverItem)
+			if err != nil {
+				return nil, err
+			}
+			decrypted.DeletedAt = serverItem.DeletedAt
+		}
+
+		data = append(data, decrypted)
+	}
+
+	return data, nil
+}
+
+// RestoreData восстанавливает данные с указанным ID из корзины и сохраняет их в локальное хранилище.
+// Содержимое бинарных данных загружается с сервера.
+func (c *Client) RestoreData(dataId string) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.RestoreData(ctx, &proto.RestoreDataRequest{DataId: dataId})
+	if err != nil {
+		return fmt.Errorf("ошибка восстановления данных: %w", err)
+	}
+
+	item, err := dataFromProto(resp.Data)
+	if err != nil {
+		return err
+	}
+
+	legacy, err := c.saveServerItem(ctx, &item)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	if err := markSyncedItem(c.UserID, item, legacy); err != nil {
+		return fmt.Errorf("ошибка сохранения данных в локальное хранилище: %w", err)
+	}
+
+	return nil
+}
+
+// EmptyTrash окончательно удаляет все данные из корзины пользователя.
+// Возвращает количество удаленных записей.
+func (c *Client) EmptyTrash() (int64, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", c.GetToken())
+
+	resp, err := c.Client.PurgeData(ctx, &proto.PurgeDataRequest{})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки корзины: %w", err)
+	}
+
+	return resp.Purged, nil
+}
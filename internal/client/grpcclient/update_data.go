@@ -13,8 +13,9 @@ import (
 
 // UpdateData обновляет данные в локальном хранилище на основе переданных данных.
 // Функция выполняет валидацию входных данных, преобразует их в JSON, шифрует в зависимости от типа данных,
-// и затем сохраняет обновленные данные в локальное хранилище.
-func (c *Client) UpdateData(reqData models.CreateData, dataId int64) error {
+// и затем сохраняет обновленные данные в локальное хранилище, помечая их для отправки на сервер.
+// Ревизия записи сохраняется, чтобы при синхронизации данные обновились на сервере, а не создались заново.
+func (c *Client) UpdateData(reqData models.CreateData, dataId string) error {
 	var encryptedData string
 	var fileName string
 
@@ -30,7 +31,9 @@ func (c *Client) UpdateData(reqData models.CreateData, dataId int64) error {
 	switch reqData.DataType {
 	case proto.DataType_BINARY_DATA:
 		if binaryData, ok := reqData.Data.(*models.BinaryDataType); ok {
-			encryptedData = encryption.EncodeData(binaryData.Content)
+			if err := saveLocalFile(c.UserID, dataId, binaryData.FilePath); err != nil {
+				return fmt.Errorf("ошибка сохранения файла в локальное хранилище: %w", err)
+			}
 			fileName = binaryData.Filename
 		} else {
 			return fmt.Errorf("неверный тип данных для бинарного контента")
@@ -47,9 +50,16 @@ func (c *Client) UpdateData(reqData models.CreateData, dataId int64) error {
 		return fmt.Errorf("ошибка конвертации типа данных: %w", err)
 	}
 
+	localData, err := localstorage.GetAllData(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных из локального хранилища: %w", err)
+	}
+
 	data := mdata.Data{
 		UserID:      c.UserID,
 		ID:          dataId,
+		Revision:    localData[dataId].Revision,
+		Version:     localData[dataId].Version,
 		DataType:    dataType,
 		DataContent: []byte(encryptedData),
 		Metadata:    reqData.Metadata.AsMap(),
@@ -61,6 +71,10 @@ func (c *Client) UpdateData(reqData models.CreateData, dataId int64) error {
 		return fmt.Errorf("ошибка обновления данных в локальном хранилище: %w", err)
 	}
 
+	if err := localstorage.MarkDirty(c.UserID, dataId); err != nil {
+		return fmt.Errorf("ошибка обновления данных в локальном хранилище: %w", err)
+	}
+
 	fmt.Println("Данные успешно обновлены в локальном хранилище.")
 	return nil
 }
@@ -0,0 +1,98 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/localstorage"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// watchRetryInterval - пауза перед повторной подпиской на изменения после разрыва потока.
+const watchRetryInterval = 5 * time.Second
+
+// StartWatch запускает в фоне получение изменений с сервера через WatchChanges.
+// После разрыва потока подписка восстанавливается. Ранее запущенное получение изменений останавливается.
+func (c *Client) StartWatch() {
+	c.StopWatch()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watchCancel = cancel
+
+	go func() {
+		for {
+			if err := c.WatchChanges(ctx); err != nil && c.Logger != nil {
+				c.Logger.Error("Watch changes failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryInterval):
+			}
+		}
+	}()
+}
+
+// StopWatch останавливает фоновое получение изменений с сервера.
+func (c *Client) StopWatch() {
+	if c.watchCancel != nil {
+		c.watchCancel()
+		c.watchCancel = nil
+	}
+}
+
+// WatchChanges подписывается на события об изменении данных пользователя на сервере
+// и применяет изменения к локальному хранилищу по мере поступления событий.
+// После подписки изменения запрашиваются сразу, чтобы учесть пропущенные, пока поток был разорван.
+// Функция блокируется до отмены ctx или разрыва потока.
+func (c *Client) WatchChanges(ctx context.Context) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", c.GetToken())
+
+	stream, err := c.Client.WatchChanges(ctx, &proto.WatchChangesRequest{})
+	if err != nil {
+		return fmt.Errorf("ошибка подписки на изменения: %w", err)
+	}
+
+	if err := c.applyChangeEvent(ctx, &proto.ChangeEvent{}); err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("ошибка получения события об изменении: %w", err)
+		}
+
+		if err := c.applyChangeEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+}
+
+// applyChangeEvent получает с сервера изменения после локального курсора, если событие еще не применено.
+// Событие без ревизии всегда приводит к запросу изменений.
+func (c *Client) applyChangeEvent(ctx context.Context, event *proto.ChangeEvent) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	cursor, err := localstorage.GetCursor(c.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения курсора синхронизации: %w", err)
+	}
+
+	if event.Revision != 0 && event.Revision <= cursor {
+		return nil
+	}
+
+	_, err = c.pullChanges(ctx, cursor)
+	return err
+}
@@ -2,18 +2,40 @@ package localstorage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 )
 
 // Storage представляет собой структуру для хранения данных пользователя.
+// Cursor - ревизия последнего полученного с сервера изменения,
+// Dirty - ID записей, измененных локально и еще не отправленных на сервер,
+// Bases - копии записей на момент последней синхронизации, относительно которых объединяются изменения,
+// Copies - конфликтные копии локальных изменений и ID записей, из которых они созданы,
+// Encrypted - ID записей, файлы которых клиент уже отправлял на сервер или получал с сервера зашифрованными.
 type Storage struct {
-	Data map[int64]models.Data `json:"data"`
+	Format    int                    `json:"format"`
+	Data      map[string]models.Data `json:"data"`
+	Cursor    int64                  `json:"cursor,omitempty"`
+	Dirty     map[string]bool        `json:"dirty,omitempty"`
+	Bases     map[string]models.Data `json:"bases,omitempty"`
+	Copies    map[string]string      `json:"copies,omitempty"`
+	Encrypted map[string]bool        `json:"encrypted,omitempty"`
 }
 
+// storageFormat - версия формата файла данных пользователя.
+// Файлы без версии хранят записи под числовыми ID и преобразуются при чтении.
+const storageFormat = 1
+
+// storageMu защищает файл данных пользователя от одновременного изменения,
+// например, фоновой синхронизацией и командами пользователя.
+var storageMu sync.Mutex
+
 // getUserDir возвращает путь к папке пользователя.
 func getUserDir(userID int64) string {
 	return filepath.Join("user_data", fmt.Sprintf("%d", userID))
@@ -24,8 +46,83 @@ func getUserDataPath(userID int64) string {
 	return filepath.Join(getUserDir(userID), "data.json")
 }
 
+// getUserFilesDir возвращает путь к папке с файлами бинарных данных пользователя.
+func getUserFilesDir(userID int64) string {
+	return filepath.Join(getUserDir(userID), "files")
+}
+
+// GetFilePath возвращает путь к локальному файлу бинарных данных.
+func GetFilePath(userID int64, dataID string) string {
+	return filepath.Join(getUserFilesDir(userID), dataID)
+}
+
+// SaveFile потоково сохраняет содержимое бинарных данных в локальное хранилище.
+// Данные сначала пишутся во временный файл, который затем атомарно переименовывается,
+// поэтому при ошибке чтения ранее сохраненный файл остается нетронутым.
+func SaveFile(userID int64, dataID string, content io.Reader) (int64, error) {
+	filesDir := getUserFilesDir(userID)
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return 0, fmt.Errorf("ошибка создания папки файлов: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filesDir, "upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	size, err := io.Copy(tmpFile, content)
+	if err != nil {
+		tmpFile.Close()
+		return 0, fmt.Errorf("ошибка записи файла: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return 0, fmt.Errorf("ошибка записи файла: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), GetFilePath(userID, dataID)); err != nil {
+		return 0, fmt.Errorf("ошибка сохранения файла: %w", err)
+	}
+
+	return size, nil
+}
+
+// HasFile сообщает, есть ли в локальном хранилище файл бинарных данных записи dataID.
+func HasFile(userID int64, dataID string) bool {
+	_, err := os.Stat(GetFilePath(userID, dataID))
+	return err == nil
+}
+
+// DeleteFile удаляет локальный файл бинарных данных записи dataID. Отсутствие файла не считается ошибкой.
+func DeleteFile(userID int64, dataID string) error {
+	if err := os.Remove(GetFilePath(userID, dataID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ошибка удаления файла: %w", err)
+	}
+	return nil
+}
+
+// OpenFile открывает локальный файл бинарных данных для чтения и возвращает его размер.
+func OpenFile(userID int64, dataID string) (*os.File, int64, error) {
+	file, err := os.Open(GetFilePath(userID, dataID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("ошибка получения размера файла: %w", err)
+	}
+
+	return file, info.Size(), nil
+}
+
 // SaveData сохраняет данные в локальное хранилище.
 func SaveData(userID int64, data models.Data) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
 	userDir := getUserDir(userID)
 	if err := os.MkdirAll(userDir, 0700); err != nil {
 		return fmt.Errorf("ошибка создания папки пользователя: %w", err)
@@ -42,7 +139,10 @@ func SaveData(userID int64, data models.Data) error {
 }
 
 // GetAllData возвращает все данные из локального хранилища для указанного пользователя.
-func GetAllData(userID int64) (map[int64]models.Data, error) {
+func GetAllData(userID int64) (map[string]models.Data, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
 	storage, err := readUserData(userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
@@ -51,22 +151,39 @@ func GetAllData(userID int64) (map[int64]models.Data, error) {
 }
 
 // DeleteData удаляет данные из локального хранилища по userID и dataID.
-func DeleteData(userID, dataID int64) error {
+func DeleteData(userID int64, dataID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
 	storage, err := readUserData(userID)
 	if err != nil {
 		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
 	}
 
 	if _, exists := storage.Data[dataID]; !exists {
-		return fmt.Errorf("данные с ID %d не найдены", dataID)
+		return fmt.Errorf("данные с ID %s не найдены", dataID)
 	}
 
 	delete(storage.Data, dataID)
+	delete(storage.Dirty, dataID)
+	delete(storage.Bases, dataID)
+	delete(storage.Copies, dataID)
+	delete(storage.Encrypted, dataID)
+
+	if err := DeleteFile(userID, dataID); err != nil {
+		return err
+	}
+
 	return writeUserData(userID, storage)
 }
 
 // UpdateID обновляет ID записи в локальном хранилище.
-func UpdateID(userID, oldID, newID int64) error {
+// Используется, если сервер вернул для созданной записи другой ID, например, при повторной отправке
+// записи, созданной до перехода на UUID и уже сохраненной на сервере по ключу идемпотентности.
+func UpdateID(userID int64, oldID, newID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
 	storage, err := readUserData(userID)
 	if err != nil {
 		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
@@ -74,20 +191,289 @@ func UpdateID(userID, oldID, newID int64) error {
 
 	data, exists := storage.Data[oldID]
 	if !exists {
-		return fmt.Errorf("данные с ID %d не найдены", oldID)
+		return fmt.Errorf("данные с ID %s не найдены", oldID)
+	}
+
+	err = os.Rename(GetFilePath(userID, oldID), GetFilePath(userID, newID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ошибка переименования файла: %w", err)
 	}
 
 	delete(storage.Data, oldID)
 	data.ID = newID
 	storage.Data[newID] = data
 
+	if storage.Dirty[oldID] {
+		delete(storage.Dirty, oldID)
+		storage.Dirty[newID] = true
+	}
+	if storage.Encrypted[oldID] {
+		delete(storage.Encrypted, oldID)
+		storage.Encrypted[newID] = true
+	}
+
+	return writeUserData(userID, storage)
+}
+
+// GetCursor возвращает ревизию последнего изменения, полученного с сервера.
+// Для хранилища, которое еще не синхронизировалось, возвращает 0.
+func GetCursor(userID int64) (int64, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+	return storage.Cursor, nil
+}
+
+// SetCursor сохраняет ревизию последнего изменения, полученного с сервера.
+func SetCursor(userID, cursor int64) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	if err := os.MkdirAll(getUserDir(userID), 0700); err != nil {
+		return fmt.Errorf("ошибка создания папки пользователя: %w", err)
+	}
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	storage.Cursor = cursor
+
+	return writeUserData(userID, storage)
+}
+
+// MarkDirty помечает запись как измененную локально, чтобы отправить ее на сервер при синхронизации.
+func MarkDirty(userID int64, dataID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	if _, exists := storage.Data[dataID]; !exists {
+		return fmt.Errorf("данные с ID %s не найдены", dataID)
+	}
+
+	storage.Dirty[dataID] = true
+
+	return writeUserData(userID, storage)
+}
+
+// MarkEncrypted запоминает, что файл записи хранится на сервере зашифрованным. Незашифрованный файл,
+// полученный потом с сервера для этой записи, считается подмененным независимо от метаданных записи на сервере.
+func MarkEncrypted(userID int64, dataID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	if err := os.MkdirAll(getUserDir(userID), 0700); err != nil {
+		return fmt.Errorf("ошибка создания папки пользователя: %w", err)
+	}
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	storage.Encrypted[dataID] = true
+
+	return writeUserData(userID, storage)
+}
+
+// IsEncrypted сообщает, отмечен ли файл записи как хранящийся на сервере зашифрованным.
+func IsEncrypted(userID int64, dataID string) (bool, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+	return storage.Encrypted[dataID], nil
+}
+
+// GetDirty возвращает ID записей, измененных локально и еще не отправленных на сервер.
+func GetDirty(userID int64) (map[string]bool, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+	return storage.Dirty, nil
+}
+
+// MarkSynced сохраняет ревизию и версию, присвоенные записи сервером, снимает с записи отметку об изменении
+// и запоминает запись как базовую для объединения следующих изменений.
+func MarkSynced(userID int64, dataID string, revision, version int64) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	data, exists := storage.Data[dataID]
+	if !exists {
+		return fmt.Errorf("данные с ID %s не найдены", dataID)
+	}
+
+	data.Revision = revision
+	data.Version = version
+	storage.Data[dataID] = data
+	storage.Bases[dataID] = data
+	delete(storage.Dirty, dataID)
+
+	return writeUserData(userID, storage)
+}
+
+// GetBase возвращает запись в том виде, в котором она была при последней синхронизации.
+// Возвращает false, если запись еще не синхронизировалась.
+func GetBase(userID int64, dataID string) (models.Data, bool, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return models.Data{}, false, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	base, exists := storage.Bases[dataID]
+	return base, exists, nil
+}
+
+// SaveMerged сохраняет результат объединения локальных и серверных изменений.
+// Серверная запись base становится базовой, а объединенная запись помечается для отправки на сервер.
+func SaveMerged(userID int64, merged, base models.Data) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	storage.Data[merged.ID] = merged
+	storage.Bases[merged.ID] = base
+	storage.Dirty[merged.ID] = true
+
+	return writeUserData(userID, storage)
+}
+
+// SaveConflictCopy сохраняет локальные изменения записи originalID как конфликтную копию copyData.
+// Файл бинарных данных переносится к копии, чтобы серверная версия записи не перезаписала его.
+// Конфликтная копия не отправляется на сервер, пока пользователь не выберет версию.
+func SaveConflictCopy(userID int64, originalID string, copyData models.Data) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	err = os.Rename(GetFilePath(userID, originalID), GetFilePath(userID, copyData.ID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ошибка переименования файла: %w", err)
+	}
+
+	storage.Data[copyData.ID] = copyData
+	storage.Copies[copyData.ID] = originalID
+	delete(storage.Dirty, originalID)
+
+	return writeUserData(userID, storage)
+}
+
+// GetConflictCopies возвращает конфликтные копии и ID записей, из которых они созданы.
+func GetConflictCopies(userID int64) (map[string]string, error) {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+	return storage.Copies, nil
+}
+
+// ApplyConflictCopy заменяет содержимое записи originalID содержимым конфликтной копии copyID
+// и удаляет копию. Запись сохраняет серверные ревизию и версию и помечается для отправки на сервер.
+func ApplyConflictCopy(userID int64, copyID, originalID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	copyData, exists := storage.Data[copyID]
+	if !exists {
+		return fmt.Errorf("данные с ID %s не найдены", copyID)
+	}
+
+	original, exists := storage.Data[originalID]
+	if !exists {
+		return fmt.Errorf("данные с ID %s не найдены", originalID)
+	}
+
+	err = os.Rename(GetFilePath(userID, copyID), GetFilePath(userID, originalID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("ошибка переименования файла: %w", err)
+	}
+
+	copyData.ID = originalID
+	copyData.Revision = original.Revision
+	copyData.Version = original.Version
+	storage.Data[originalID] = copyData
+	storage.Dirty[originalID] = true
+
+	delete(storage.Data, copyID)
+	delete(storage.Copies, copyID)
+
+	return writeUserData(userID, storage)
+}
+
+// KeepConflictCopy превращает конфликтную копию в самостоятельную запись,
+// которая будет создана на сервере при следующей синхронизации.
+func KeepConflictCopy(userID int64, copyID string) error {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+
+	storage, err := readUserData(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных пользователя: %w", err)
+	}
+
+	if _, exists := storage.Data[copyID]; !exists {
+		return fmt.Errorf("данные с ID %s не найдены", copyID)
+	}
+
+	delete(storage.Copies, copyID)
+	storage.Dirty[copyID] = true
+
 	return writeUserData(userID, storage)
 }
 
 // readUserData читает данные пользователя из файла.
 func readUserData(userID int64) (*Storage, error) {
 	filePath := getUserDataPath(userID)
-	storage := &Storage{Data: make(map[int64]models.Data)}
+	storage := &Storage{
+		Format:    storageFormat,
+		Data:      make(map[string]models.Data),
+		Dirty:     make(map[string]bool),
+		Bases:     make(map[string]models.Data),
+		Copies:    make(map[string]string),
+		Encrypted: make(map[string]bool),
+	}
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return storage, nil
@@ -98,10 +484,33 @@ func readUserData(userID int64) (*Storage, error) {
 		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
 	}
 
+	var header struct {
+		Format int `json:"format"`
+	}
+	if err := json.Unmarshal(file, &header); err != nil {
+		return nil, fmt.Errorf("ошибка десериализации данных: %w", err)
+	}
+	if header.Format < storageFormat {
+		return migrateLegacyData(userID, file)
+	}
+
 	if err := json.Unmarshal(file, storage); err != nil {
 		return nil, fmt.Errorf("ошибка десериализации данных: %w", err)
 	}
 
+	if storage.Dirty == nil {
+		storage.Dirty = make(map[string]bool)
+	}
+	if storage.Bases == nil {
+		storage.Bases = make(map[string]models.Data)
+	}
+	if storage.Copies == nil {
+		storage.Copies = make(map[string]string)
+	}
+	if storage.Encrypted == nil {
+		storage.Encrypted = make(map[string]bool)
+	}
+
 	return storage, nil
 }
 
@@ -119,3 +528,93 @@ func writeUserData(userID int64, storage *Storage) error {
 
 	return nil
 }
+
+// legacyStorage - формат файла данных пользователя, в котором записи хранились под числовыми ID.
+type legacyStorage struct {
+	Data   map[int64]json.RawMessage `json:"data"`
+	Cursor int64                     `json:"cursor,omitempty"`
+	Dirty  map[int64]bool            `json:"dirty,omitempty"`
+	Bases  map[int64]json.RawMessage `json:"bases,omitempty"`
+	Copies map[int64]int64           `json:"copies,omitempty"`
+}
+
+// migrateLegacyData преобразует файл данных с числовыми ID записей в текущий формат.
+// ID заменяются на UUID так же, как при миграции базы данных сервера, файлы бинарных данных
+// переименовываются, а результат сразу записывается, чтобы преобразование выполнялось один раз.
+func migrateLegacyData(userID int64, file []byte) (*Storage, error) {
+	var legacy legacyStorage
+	if err := json.Unmarshal(file, &legacy); err != nil {
+		return nil, fmt.Errorf("ошибка десериализации данных: %w", err)
+	}
+
+	storage := &Storage{
+		Format:    storageFormat,
+		Data:      make(map[string]models.Data, len(legacy.Data)),
+		Cursor:    legacy.Cursor,
+		Dirty:     make(map[string]bool, len(legacy.Dirty)),
+		Bases:     make(map[string]models.Data, len(legacy.Bases)),
+		Copies:    make(map[string]string, len(legacy.Copies)),
+		Encrypted: make(map[string]bool),
+	}
+
+	for id, raw := range legacy.Data {
+		data, err := legacyItem(raw)
+		if err != nil {
+			return nil, err
+		}
+		storage.Data[data.ID] = data
+
+		err = os.Rename(filepath.Join(getUserFilesDir(userID), fmt.Sprintf("%d", id)), GetFilePath(userID, data.ID))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("ошибка переименования файла: %w", err)
+		}
+	}
+	for id, raw := range legacy.Bases {
+		base, err := legacyItem(raw)
+		if err != nil {
+			return nil, err
+		}
+		storage.Bases[models.LegacyDataID(id)] = base
+	}
+	for id, dirty := range legacy.Dirty {
+		storage.Dirty[models.LegacyDataID(id)] = dirty
+	}
+	for copyID, originalID := range legacy.Copies {
+		storage.Copies[models.LegacyDataID(copyID)] = models.LegacyDataID(originalID)
+	}
+
+	if err := writeUserData(userID, storage); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// legacyItem декодирует запись с числовым ID, заменяя ID на соответствующий UUID.
+func legacyItem(raw json.RawMessage) (models.Data, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return models.Data{}, fmt.Errorf("ошибка десериализации данных: %w", err)
+	}
+
+	var id int64
+	if len(fields["id"]) > 0 {
+		if err := json.Unmarshal(fields["id"], &id); err != nil {
+			return models.Data{}, fmt.Errorf("ошибка десериализации ID записи: %w", err)
+		}
+	}
+	delete(fields, "id")
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return models.Data{}, fmt.Errorf("ошибка сериализации данных: %w", err)
+	}
+
+	var data models.Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return models.Data{}, fmt.Errorf("ошибка десериализации данных: %w", err)
+	}
+	data.ID = models.LegacyDataID(id)
+
+	return data, nil
+}
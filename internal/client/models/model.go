@@ -5,10 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
 
-	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
 	"github.com/Sofja96/GophKeeper.git/pkg"
 	"github.com/Sofja96/GophKeeper.git/proto"
 )
@@ -40,7 +40,6 @@ func (t *TextDataType) ToJSON() ([]byte, error) {
 // BinaryDataType - структура бинарных данных
 type BinaryDataType struct {
 	FilePath string `json:"file_path"`
-	Content  []byte `json:"content"`
 	Filename string `json:"filename"`
 }
 
@@ -57,13 +56,10 @@ func (b *BinaryDataType) Validate() error {
 	return nil
 }
 
-// ToJSON кодирует бинарные данные в base64 и возвращает их в формате JSON.
+// ToJSON сериализует описание файла в JSON.
+// Содержимое файла не загружается в память и передается потоком при сохранении.
 func (b *BinaryDataType) ToJSON() ([]byte, error) {
-	encodedData := encryption.EncodeData(b.Content)
-	if encodedData == "" {
-		return nil, errors.New("ошибка кодирования данных: пустой результат")
-	}
-	return []byte(encodedData), nil
+	return json.Marshal(b)
 }
 
 // BankCardType - структура банковской карты
@@ -126,3 +122,12 @@ type CreateData struct {
 	Metadata      *structpb.Struct
 	Filename      string
 }
+
+// ListFilter - параметры постраничного получения данных с сервера.
+// Пустые значения фильтров не применяются.
+type ListFilter struct {
+	DataType     proto.DataType
+	UpdatedAfter time.Time
+	Metadata     map[string]string
+	PageSize     int32
+}
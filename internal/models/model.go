@@ -1,12 +1,14 @@
 package models
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/Sofja96/GophKeeper.git/proto"
@@ -15,7 +17,31 @@ import (
 // ContextKey - тип для ключа контекста
 type ContextKey string
 
-const ContextKeyUser ContextKey = "username"
+// ContextKeyPrincipal - ключ контекста, под которым AuthInterceptor сохраняет Principal.
+const ContextKeyPrincipal ContextKey = "principal"
+
+// Principal - аутентифицированный пользователь запроса, данные которого взяты из токена доступа.
+// Пользователь определяется по неизменяемому ID, а не по имени, поэтому токен
+// нельзя использовать от имени другого пользователя, даже если тот займет прежнее имя.
+type Principal struct {
+	UserID    int64
+	Username  string
+	SessionID string // ID сессии, которой выдан токен доступа
+}
+
+// PrincipalFromContext возвращает пользователя, аутентифицированного для запроса с контекстом ctx.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(ContextKeyPrincipal).(Principal)
+	return principal, ok
+}
+
+// Детали ошибки ABORTED, которую сервер возвращает при несовпадении версии данных.
+const (
+	ErrorDomain            = "gophkeeper"       // домен ErrorInfo ошибок сервера
+	ReasonVersionConflict  = "VERSION_CONFLICT" // причина ErrorInfo при конфликте версий
+	MetadataCurrentVersion = "current_version"  // ключ ErrorInfo с текущей версией данных
+	MetadataOperation      = "operation_index"  // ключ ErrorInfo с номером операции пакетного изменения
+)
 
 // User - структура для хранения данных пользователя
 // Содержит логин и пароль.
@@ -24,6 +50,37 @@ type User struct {
 	Password string `db:"password"`
 }
 
+// Session - сессия пользователя, открытая при входе.
+// Сервер хранит только хеш текущего токена обновления сессии.
+type Session struct {
+	ID        string    `db:"id"`
+	UserID    int64     `db:"user_id"`
+	Username  string    `db:"username"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// TOTP - настройка двухфакторной аутентификации пользователя по одноразовым кодам из приложения.
+type TOTP struct {
+	Secret      string `db:"secret"`       // секрет в base32
+	Enabled     bool   `db:"enabled"`      // настройка подтверждена кодом из приложения
+	LastCounter int64  `db:"last_counter"` // последний принятый интервал; коды из него и более ранних не принимаются
+}
+
+// TOTPEnrollment - данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPEnrollment struct {
+	Secret string // секрет в base32 для ручного ввода
+	URI    string // ссылка otpauth://, которую приложение считывает из QR-кода
+}
+
+// TokenPair - токены, которые выдаются при входе и при обновлении токена доступа.
+type TokenPair struct {
+	UserID       int64         // ID пользователя, которому выданы токены
+	AccessToken  string        // токен доступа со схемой Bearer
+	RefreshToken string        // токен обновления, который заменяется новым при каждом обновлении
+	ExpiresIn    time.Duration // время жизни токена доступа
+}
+
 // DataType - тип для представления разных типов данных
 type DataType string
 
@@ -41,14 +98,117 @@ const (
 
 // Data Общая структура данных
 type Data struct {
-	ID          int64     `json:"id,omitempty" db:"id"`
-	UserID      int64     `json:"user_id,omitempty" db:"user_id"`
-	DataType    DataType  `json:"data_type" db:"data_type"`
-	FileName    string    `json:"file_name,omitempty"`
-	DataContent []byte    `json:"data_content" db:"data_content"`
-	Metadata    JSONB     `json:"metadata,omitempty" db:"metadata"`
-	CreatedAt   time.Time `json:"-" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID             string        `json:"id,omitempty" db:"id"`
+	UserID         int64         `json:"user_id,omitempty" db:"user_id"`
+	DataType       DataType      `json:"data_type" db:"data_type"`
+	FileName       string        `json:"file_name,omitempty" db:"file_name"`
+	DataContent    []byte        `json:"data_content" db:"data_content"`
+	Metadata       JSONB         `json:"metadata,omitempty" db:"metadata"`
+	FilePath       string        `json:"-" db:"-"`
+	CreatedAt      time.Time     `json:"-" db:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
+	Revision       int64         `json:"revision,omitempty" db:"revision"`
+	Version        int64         `json:"version,omitempty" db:"version"`
+	DeletedAt      time.Time     `json:"-" db:"deleted_at"`
+	IdempotencyKey string        `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	BlobKey        string        `json:"-" db:"blob_key"` // ключ файла бинарных данных в MinIO
+	BlobSize       int64         `json:"-" db:"blob_size"`
+	Blob           *BlobManifest `json:"blob,omitempty" db:"-"` // описание файла бинарных данных без содержимого
+}
+
+// BlobManifest - описание файла бинарных данных, которое передается вместо его содержимого.
+// Содержимое файла клиент получает отдельно по ID и хранит локально, пока описание не изменится.
+type BlobManifest struct {
+	ID          string `json:"id"`
+	FileName    string `json:"file_name,omitempty"`
+	Size        int64  `json:"size"`
+	ContentHash string `json:"content_hash,omitempty"` // SHA-256 содержимого файла в шестнадцатеричном виде
+}
+
+// NewDataID генерирует ID записи данных - UUID версии 7.
+// Такие ID упорядочены по времени создания, поэтому клиент может создавать записи без обращения к серверу.
+func NewDataID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// LegacyDataID возвращает UUID, в который преобразован числовой ID записи, использовавшийся до перехода на UUID.
+// Преобразование совпадает с миграцией базы данных, поэтому клиент сопоставляет локальные записи
+// с записями на сервере без обращения к серверу.
+func LegacyDataID(id int64) string {
+	return fmt.Sprintf("00000000-0000-0000-%04x-%012x", uint64(id)>>48, uint64(id)&0xffffffffffff)
+}
+
+// DataChange - изменение данных пользователя для инкрементальной синхронизации.
+// Для удаленных данных заполнены только ID, UpdatedAt (время удаления) и Revision.
+type DataChange struct {
+	Data
+	Deleted bool `db:"deleted"`
+}
+
+// ChangeEvent - событие об изменении данных пользователя, рассылаемое подписанным клиентам.
+// Событие с нулевой ревизией означает, что часть событий могла быть потеряна.
+type ChangeEvent struct {
+	UserID   int64  `json:"user_id"`
+	DataID   string `json:"data_id"`
+	Revision int64  `json:"revision"`
+	Deleted  bool   `json:"deleted"`
+}
+
+// MutationType - вид операции пакетного изменения данных.
+type MutationType int
+
+const (
+	MutationCreate MutationType = iota + 1 // создание данных
+	MutationUpdate                         // обновление данных
+	MutationDelete                         // перемещение данных в корзину
+)
+
+// Mutation - операция пакетного изменения данных пользователя.
+// Для обновления и удаления в Data заполняется ID, а Data.Version содержит ожидаемую версию записи;
+// нулевая версия означает изменение без проверки версии.
+type Mutation struct {
+	Type MutationType
+	Data Data
+}
+
+// MutationResult - состояние записи после выполнения операции пакетного изменения.
+type MutationResult struct {
+	ID       string
+	Revision int64
+	Version  int64
+}
+
+// PurgeResult - результат окончательного удаления данных из корзины.
+type PurgeResult struct {
+	Count          int64           // количество удаленных записей
+	BlobOperations []BlobOperation // удаление файлов, на которые больше не ссылаются ни записи, ни их история
+}
+
+// BlobOperation - отложенная операция удаления файла из хранилища файлов.
+// Файл удаляется, только если к моменту выполнения операции на него не ссылается ни одна запись.
+type BlobOperation struct {
+	ID        int64  `db:"id"`
+	UserID    int64  `db:"user_id"`
+	BlobKey   string `db:"object_key"`
+	Attempts  int    `db:"attempts"`   // количество начатых попыток выполнения
+	LastError string `db:"last_error"` // ошибка последней неудачной попытки
+}
+
+// LegacyBlob - файл пользователя, загруженный до хранения по хешу содержимого и еще не скопированный под такой ключ.
+type LegacyBlob struct {
+	BlobKey   string `db:"object_key"` // ключ, на который ссылаются записи пользователя
+	SourceKey string `db:"source_key"` // общий для всех пользователей ключ, под которым лежит содержимое
+	UserID    int64  `db:"user_id"`
+}
+
+// DataFilter - параметры постраничной выборки данных пользователя.
+// Пустые значения фильтров не применяются.
+type DataFilter struct {
+	DataType     DataType
+	UpdatedAfter time.Time
+	Metadata     map[string]string
+	AfterID      string // ID последней записи предыдущей страницы
+	Limit        int
 }
 
 // SetMetadata обновляет метаданные для модели.
@@ -1,31 +1,46 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	logging "github.com/Sofja96/GophKeeper.git/internal/server/logger"
 	"github.com/Sofja96/GophKeeper.git/internal/server/service"
 	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
 	"github.com/Sofja96/GophKeeper.git/internal/server/storage/db"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/filesystem"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/memblob"
 	"github.com/Sofja96/GophKeeper.git/internal/server/storage/minio"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/pgblob"
+	"github.com/Sofja96/GophKeeper.git/pkg"
 )
 
+// devDbDsn - строка подключения к базе данных SQLite в памяти, которая используется в режиме разработки.
+// Отдельного адаптера базы данных в памяти нет: его роль выполняет адаптер SQLite.
+const devDbDsn = "sqlite://:memory:"
+
 // Server - интерфейс, предоставляющий доступ к различным компонентам сервера,
 type Server interface {
 	GetSettings() settings.Settings
 	GetDbAdapter() db.Adapter
 	GetService() service.Service
 	GetLogger() logging.ILogger
-	GetMinioClient() minio.Client
+	GetBlobStore() blobstore.Store
+	GetKeySet() *interceptors.KeySet
 }
 
 // server - структура, которая реализует интерфейс Server.
 type server struct {
-	settings    settings.Settings
-	dbAdapter   db.Adapter
-	service     service.Service
-	logger      logging.ILogger
-	minioClient minio.Client
+	settings  settings.Settings
+	dbAdapter db.Adapter
+	service   service.Service
+	logger    logging.ILogger
+	blobStore blobstore.Store
+	keySet    *interceptors.KeySet
 }
 
 // GetSettings возвращает настройки сервера.
@@ -48,14 +63,23 @@ func (s *server) GetLogger() logging.ILogger {
 	return s.logger
 }
 
-// GetMinioClient возвращает клиент для работы с MinIO.
-func (s *server) GetMinioClient() minio.Client {
-	return s.minioClient
+// GetBlobStore возвращает хранилище файлов бинарных данных.
+func (s *server) GetBlobStore() blobstore.Store {
+	return s.blobStore
+}
+
+// GetKeySet возвращает набор ключей подписи и проверки токенов.
+func (s *server) GetKeySet() *interceptors.KeySet {
+	return s.keySet
 }
 
 // Run инициализирует все компоненты сервера, включая конфигурацию, базу данных,
-// логгер, клиент MinIO и сам сервис. Возвращает экземпляр сервера.
-func Run() (Server, error) {
+// логгер, хранилище файлов и сам сервис. Возвращает экземпляр сервера.
+//
+// В режиме разработки dev база данных и файлы хранятся в памяти процесса,
+// а при отсутствии TLS-сертификата генерируется самоподписанный,
+// поэтому сервер запускается без PostgreSQL, MinIO и заранее выпущенных сертификатов.
+func Run(dev bool) (Server, error) {
 	conf, err := settings.GetSettings()
 	if err != nil {
 		return nil, fmt.Errorf("error load configuration: %w", err)
@@ -63,21 +87,119 @@ func Run() (Server, error) {
 
 	logger := logging.New(conf)
 
+	if dev {
+		if err := applyDevMode(conf); err != nil {
+			return nil, fmt.Errorf("failed to prepare dev mode: %w", err)
+		}
+		logger.Info("Dev mode: data is stored in memory, TLS certificate %s, key %s", conf.PathCert, conf.PathKey)
+	}
+
 	dbAdapter, err := db.NewAdapter(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	minioClient, err := minio.NewMinioClient(conf)
+	blobStore, err := newBlobStore(conf)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+		return nil, fmt.Errorf("failed to initialize blob storage: %w", err)
+	}
+
+	keySet, err := newKeySet(conf, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token signing keys: %w", err)
 	}
 
 	return &server{
-		settings:    *conf,
-		dbAdapter:   dbAdapter,
-		logger:      logger,
-		minioClient: minioClient,
-		service:     service.New(dbAdapter, minioClient, logger),
+		settings:  *conf,
+		dbAdapter: dbAdapter,
+		logger:    logger,
+		blobStore: blobStore,
+		keySet:    keySet,
+		service:   service.New(dbAdapter, blobStore, logger, keySet),
 	}, nil
 }
+
+// newBlobStore создает хранилище файлов бинарных данных, выбранное настройкой BLOB_BACKEND.
+func newBlobStore(conf *settings.Settings) (blobstore.Store, error) {
+	switch conf.BlobBackend {
+	case blobstore.BackendMinio:
+		return minio.NewMinioClient(conf)
+	case blobstore.BackendFilesystem:
+		return filesystem.New(conf.BlobFSPath)
+	case blobstore.BackendPostgres:
+		return pgblob.New(conf)
+	case blobstore.BackendMemory:
+		return memblob.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown blob storage backend %q", conf.BlobBackend)
+	}
+}
+
+// newKeySet загружает ключи токенов из файла JWT_KEYS_FILE или секрета JWT_SECRET.
+// Если ни то ни другое не задано, создается случайный ключ, и токены перестают действовать после перезапуска.
+func newKeySet(conf *settings.Settings, logger logging.ILogger) (*interceptors.KeySet, error) {
+	switch {
+	case conf.JwtKeysFile != "":
+		return interceptors.LoadKeySetFile(conf.JwtKeysFile)
+	case conf.JwtSecret != "":
+		return interceptors.NewKeySet(conf.JwtKeyID, interceptors.NewHMACKey(conf.JwtKeyID, []byte(conf.JwtSecret)))
+	default:
+		logger.Info("JWT_KEYS_FILE and JWT_SECRET are not set: tokens are signed with a random key and expire on restart")
+		return interceptors.GenerateKeySet()
+	}
+}
+
+// applyDevMode переключает настройки на хранение базы данных и файлов в памяти процесса.
+//
+// Если пути к сертификату и ключу не заданы, самоподписанный сертификат создается во временном каталоге.
+// Если пути заданы, но файлов еще нет, сертификат создается по этим путям, чтобы клиент мог их использовать.
+// Если существует только один из файлов, возвращается ошибка: пара сертификата и ключа не перезаписывается.
+func applyDevMode(conf *settings.Settings) error {
+	conf.DbDsn = devDbDsn
+	conf.DbAutoMigration = true
+	conf.BlobBackend = blobstore.BackendMemory
+
+	if conf.PathCert == "" || conf.PathKey == "" {
+		dir, err := os.MkdirTemp("", "gophkeeper-dev-")
+		if err != nil {
+			return fmt.Errorf("failed to create certificate directory: %w", err)
+		}
+		conf.PathCert = filepath.Join(dir, "cert.pem")
+		conf.PathKey = filepath.Join(dir, "key.pem")
+	}
+
+	certExists, err := fileExists(conf.PathCert)
+	if err != nil {
+		return err
+	}
+	keyExists, err := fileExists(conf.PathKey)
+	if err != nil {
+		return err
+	}
+	if certExists != keyExists {
+		return fmt.Errorf("only one of certificate %s and key %s exists: remove it or provide both files",
+			conf.PathCert, conf.PathKey)
+	}
+	if certExists {
+		return nil
+	}
+
+	if err := pkg.GenerateCertificate(conf.PathCert, conf.PathKey); err != nil {
+		return fmt.Errorf("failed to generate certificate: %w", err)
+	}
+
+	return nil
+}
+
+// fileExists сообщает, существует ли файл path.
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check file %s: %w", path, err)
+	}
+
+	return true, nil
+}
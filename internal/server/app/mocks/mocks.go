@@ -9,11 +9,12 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 
+	interceptors "github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	logging "github.com/Sofja96/GophKeeper.git/internal/server/logger"
 	service "github.com/Sofja96/GophKeeper.git/internal/server/service"
 	settings "github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	blobstore "github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
 	db "github.com/Sofja96/GophKeeper.git/internal/server/storage/db"
-	minio "github.com/Sofja96/GophKeeper.git/internal/server/storage/minio"
 )
 
 // MockServer is a mock of Server interface.
@@ -39,6 +40,20 @@ func (m *MockServer) EXPECT() *MockServerMockRecorder {
 	return m.recorder
 }
 
+// GetBlobStore mocks base method.
+func (m *MockServer) GetBlobStore() blobstore.Store {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlobStore")
+	ret0, _ := ret[0].(blobstore.Store)
+	return ret0
+}
+
+// GetBlobStore indicates an expected call of GetBlobStore.
+func (mr *MockServerMockRecorder) GetBlobStore() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlobStore", reflect.TypeOf((*MockServer)(nil).GetBlobStore))
+}
+
 // GetDbAdapter mocks base method.
 func (m *MockServer) GetDbAdapter() db.Adapter {
 	m.ctrl.T.Helper()
@@ -53,32 +68,32 @@ func (mr *MockServerMockRecorder) GetDbAdapter() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDbAdapter", reflect.TypeOf((*MockServer)(nil).GetDbAdapter))
 }
 
-// GetLogger mocks base method.
-func (m *MockServer) GetLogger() logging.ILogger {
+// GetKeySet mocks base method.
+func (m *MockServer) GetKeySet() *interceptors.KeySet {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLogger")
-	ret0, _ := ret[0].(logging.ILogger)
+	ret := m.ctrl.Call(m, "GetKeySet")
+	ret0, _ := ret[0].(*interceptors.KeySet)
 	return ret0
 }
 
-// GetLogger indicates an expected call of GetLogger.
-func (mr *MockServerMockRecorder) GetLogger() *gomock.Call {
+// GetKeySet indicates an expected call of GetKeySet.
+func (mr *MockServerMockRecorder) GetKeySet() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogger", reflect.TypeOf((*MockServer)(nil).GetLogger))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeySet", reflect.TypeOf((*MockServer)(nil).GetKeySet))
 }
 
-// GetMinioClient mocks base method.
-func (m *MockServer) GetMinioClient() minio.Client {
+// GetLogger mocks base method.
+func (m *MockServer) GetLogger() logging.ILogger {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMinioClient")
-	ret0, _ := ret[0].(minio.Client)
+	ret := m.ctrl.Call(m, "GetLogger")
+	ret0, _ := ret[0].(logging.ILogger)
 	return ret0
 }
 
-// GetMinioClient indicates an expected call of GetMinioClient.
-func (mr *MockServerMockRecorder) GetMinioClient() *gomock.Call {
+// GetLogger indicates an expected call of GetLogger.
+func (mr *MockServerMockRecorder) GetLogger() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMinioClient", reflect.TypeOf((*MockServer)(nil).GetMinioClient))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLogger", reflect.TypeOf((*MockServer)(nil).GetLogger))
 }
 
 // GetService mocks base method.
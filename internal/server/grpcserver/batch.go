@@ -0,0 +1,123 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// maxBatchMutations - максимальное количество операций в одном запросе BatchMutate.
+const maxBatchMutations = 500
+
+// BatchMutate атомарно выполняет операции создания, обновления и удаления данных текущего пользователя.
+// Если любая операция не выполнена, не выполняется ни одна; для конфликта версий номер операции
+// передается в деталях ошибки ABORTED. Возвращает результаты операций в порядке запроса.
+func (s *gophKeeperServer) BatchMutate(ctx context.Context, req *proto.BatchMutateRequest) (*proto.BatchMutateResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if len(req.Mutations) > maxBatchMutations {
+		return nil, status.Errorf(codes.InvalidArgument, "too many mutations: %d, maximum %d",
+			len(req.Mutations), maxBatchMutations)
+	}
+
+	mutations := make([]models.Mutation, 0, len(req.Mutations))
+	for i, m := range req.Mutations {
+		mutation, err := mutationFromProto(m)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid mutation %d: %v", i, err)
+		}
+		mutations = append(mutations, mutation)
+	}
+
+	results, err := s.server.GetService().BatchMutate(ctx, principal.UserID, mutations)
+	if err != nil {
+		var opErr *utils.OperationError
+		var conflict *utils.VersionConflictError
+		switch {
+		case errors.As(err, &opErr) && errors.As(err, &conflict):
+			return nil, operationConflictStatus(opErr.Index, mutations[opErr.Index].Data.ID, conflict)
+		case errors.As(err, &opErr) && errors.Is(err, utils.ErrUserDataNotFound):
+			return nil, status.Errorf(codes.NotFound, "операция %d: данные с ID %s не найдены",
+				opErr.Index, mutations[opErr.Index].Data.ID)
+		case errors.As(err, &opErr) && errors.Is(err, utils.ErrDataExists):
+			return nil, status.Errorf(codes.AlreadyExists, "операция %d: данные с ID %s уже существуют",
+				opErr.Index, mutations[opErr.Index].Data.ID)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to apply mutations: %v", err)
+		}
+	}
+
+	resp := &proto.BatchMutateResponse{Results: make([]*proto.MutationResult, 0, len(results))}
+	for _, result := range results {
+		resp.Results = append(resp.Results, &proto.MutationResult{
+			DataId:   result.ID,
+			Revision: result.Revision,
+			Version:  result.Version,
+		})
+	}
+
+	return resp, nil
+}
+
+// mutationFromProto преобразует операцию из запроса gRPC в операцию пакетного изменения данных.
+func mutationFromProto(m *proto.Mutation) (models.Mutation, error) {
+	switch op := m.GetOperation().(type) {
+	case *proto.Mutation_Create:
+		dataType, err := models.GetModelType(op.Create.DataType)
+		if err != nil {
+			return models.Mutation{}, err
+		}
+		if op.Create.DataId != "" {
+			if err := validateDataID(op.Create.DataId); err != nil {
+				return models.Mutation{}, err
+			}
+		}
+		if err := validateIdempotencyKey(op.Create.IdempotencyKey); err != nil {
+			return models.Mutation{}, err
+		}
+		return models.Mutation{
+			Type: models.MutationCreate,
+			Data: models.Data{
+				ID:             op.Create.DataId,
+				DataType:       dataType,
+				DataContent:    op.Create.DataContent,
+				Metadata:       op.Create.Metadata.AsMap(),
+				FileName:       op.Create.FileName,
+				IdempotencyKey: op.Create.IdempotencyKey,
+			},
+		}, nil
+	case *proto.Mutation_Update:
+		if err := validateDataID(op.Update.DataId); err != nil {
+			return models.Mutation{}, err
+		}
+		return models.Mutation{
+			Type: models.MutationUpdate,
+			Data: models.Data{
+				ID:          op.Update.DataId,
+				DataContent: op.Update.DataContent,
+				Metadata:    op.Update.Metadata.AsMap(),
+				FileName:    op.Update.FileName,
+				Version:     op.Update.ExpectedVersion,
+			},
+		}, nil
+	case *proto.Mutation_Delete:
+		if err := validateDataID(op.Delete.DataId); err != nil {
+			return models.Mutation{}, err
+		}
+		return models.Mutation{
+			Type: models.MutationDelete,
+			Data: models.Data{ID: op.Delete.DataId, Version: op.Delete.ExpectedVersion},
+		}, nil
+	default:
+		return models.Mutation{}, errors.New("operation is not set")
+	}
+}
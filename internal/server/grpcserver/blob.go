@@ -0,0 +1,187 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// blobChunkSize - максимальный размер части файла в одном сообщении потока.
+const blobChunkSize = 64 * 1024
+
+// UploadBlob принимает бинарные данные потоком частей.
+// Первое сообщение потока должно содержать описание файла, остальные - его содержимое.
+func (s *gophKeeperServer) UploadBlob(stream proto.GophKeeper_UploadBlobServer) error {
+	ctx := stream.Context()
+
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to receive blob info: %v", err)
+	}
+
+	info := req.GetInfo()
+	if info == nil {
+		return status.Errorf(codes.InvalidArgument, "first message must contain blob info")
+	}
+
+	if info.FileName == "" {
+		return status.Errorf(codes.InvalidArgument, "file name is required")
+	}
+
+	create := info.Create || info.DataId == ""
+	if info.DataId != "" {
+		if err := validateDataID(info.DataId); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
+
+	if err := validateIdempotencyKey(info.IdempotencyKey); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	data := &models.Data{
+		ID:             info.DataId,
+		UserID:         principal.UserID,
+		DataType:       models.BinaryData,
+		Metadata:       info.Metadata.AsMap(),
+		FileName:       info.FileName,
+		Version:        info.ExpectedVersion,
+		IdempotencyKey: info.IdempotencyKey,
+	}
+
+	reader := &blobReader{stream: stream}
+
+	dataId := info.DataId
+	if create {
+		dataId, err = s.server.GetService().CreateBlob(ctx, data, reader, info.Size)
+	} else {
+		err = s.server.GetService().UpdateBlob(ctx, data, reader, info.Size)
+	}
+
+	if err != nil {
+		var conflict *utils.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			return conflictStatus(info.DataId, conflict)
+		case errors.Is(err, utils.ErrUserDataNotFound):
+			return status.Errorf(codes.NotFound, "данные с ID %s не найдены", info.DataId)
+		case errors.Is(err, utils.ErrDataExists):
+			return status.Errorf(codes.AlreadyExists, "данные с ID %s уже существуют", info.DataId)
+		case errors.Is(err, utils.ErrNotBinaryData):
+			return status.Errorf(codes.FailedPrecondition, "данные с ID %s не являются бинарными", info.DataId)
+		default:
+			return status.Errorf(codes.Internal, "failed to upload blob: %v", err)
+		}
+	}
+
+	return stream.SendAndClose(&proto.UploadBlobResponse{
+		Message:  "Blob successfully uploaded",
+		DataId:   dataId,
+		Revision: data.Revision,
+		Version:  data.Version,
+	})
+}
+
+// DownloadBlob отправляет содержимое бинарных данных потоком частей.
+func (s *gophKeeperServer) DownloadBlob(req *proto.DownloadBlobRequest, stream proto.GophKeeper_DownloadBlobServer) error {
+	ctx := stream.Context()
+
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if err := validateDataID(req.DataId); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	err := s.server.GetService().DownloadBlob(ctx, req.DataId, principal.UserID, &blobWriter{stream: stream})
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUserDataNotFound):
+			return status.Errorf(codes.NotFound, "данные с ID %s не найдены", req.DataId)
+		case errors.Is(err, utils.ErrNotBinaryData):
+			return status.Errorf(codes.FailedPrecondition, "данные с ID %s не являются бинарными", req.DataId)
+		default:
+			return status.Errorf(codes.Internal, "failed to download blob: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// FetchBlob обрабатывает gRPC-запрос на потоковое получение файла бинарных данных по ID из описания файла.
+// Файл передается клиенту частями; файлы других пользователей не передаются.
+func (s *gophKeeperServer) FetchBlob(req *proto.FetchBlobRequest, stream proto.GophKeeper_FetchBlobServer) error {
+	ctx := stream.Context()
+
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if req.BlobId == "" {
+		return status.Errorf(codes.InvalidArgument, "blob ID is required")
+	}
+
+	err := s.server.GetService().FetchBlob(ctx, principal.UserID, req.BlobId, &blobWriter{stream: stream})
+	if err != nil {
+		if errors.Is(err, utils.ErrBlobNotFound) {
+			return status.Errorf(codes.NotFound, "файл %s не найден", req.BlobId)
+		}
+		return status.Errorf(codes.Internal, "failed to fetch blob: %v", err)
+	}
+
+	return nil
+}
+
+// blobReader представляет поток частей UploadBlob в виде io.Reader.
+type blobReader struct {
+	stream proto.GophKeeper_UploadBlobServer
+	buf    []byte
+}
+
+// Read читает очередную часть файла из потока. По окончании потока возвращает io.EOF.
+func (r *blobReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = req.GetChunk()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// blobWriter представляет поток DownloadBlob в виде io.Writer,
+// разбивая записываемые данные на части не больше blobChunkSize.
+type blobWriter struct {
+	stream proto.GophKeeper_DownloadBlobServer
+}
+
+// Write отправляет данные клиенту частями.
+func (w *blobWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), blobChunkSize)
+		if err := w.stream.Send(&proto.DownloadBlobResponse{Chunk: p[:n]}); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
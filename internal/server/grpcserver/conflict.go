@@ -0,0 +1,49 @@
+package grpcserver
+
+import (
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// conflictStatus формирует ошибку ABORTED для конфликта версий.
+// Текущая версия данных передается клиенту в деталях ошибки ErrorInfo.
+func conflictStatus(dataID string, conflict *utils.VersionConflictError) error {
+	st := status.Newf(codes.Aborted, "данные с ID %s изменены на другом устройстве, текущая версия %d",
+		dataID, conflict.CurrentVersion)
+
+	return withConflictInfo(st, map[string]string{
+		models.MetadataCurrentVersion: strconv.FormatInt(conflict.CurrentVersion, 10),
+	})
+}
+
+// operationConflictStatus формирует ошибку ABORTED для конфликта версий в операции пакетного изменения.
+// Кроме текущей версии данных, в деталях ошибки ErrorInfo передается номер операции.
+func operationConflictStatus(index int, dataID string, conflict *utils.VersionConflictError) error {
+	st := status.Newf(codes.Aborted, "операция %d: данные с ID %s изменены на другом устройстве, текущая версия %d",
+		index, dataID, conflict.CurrentVersion)
+
+	return withConflictInfo(st, map[string]string{
+		models.MetadataCurrentVersion: strconv.FormatInt(conflict.CurrentVersion, 10),
+		models.MetadataOperation:      strconv.Itoa(index),
+	})
+}
+
+// withConflictInfo добавляет к ошибке детали ErrorInfo конфликта версий с указанными метаданными.
+func withConflictInfo(st *status.Status, metadata map[string]string) error {
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   models.ReasonVersionConflict,
+		Domain:   models.ErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -17,14 +18,15 @@ import (
 // CreateData создает новые данные для пользователя.
 // Принимает запрос на создание данных и возвращает ответ с ID созданных данных.
 func (s *gophKeeperServer) CreateData(ctx context.Context, req *proto.CreateDataRequest) (*proto.CreateDataResponse, error) {
-	userName, ok := ctx.Value(models.ContextKeyUser).(string)
+	principal, ok := models.PrincipalFromContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
 	}
 
-	userID, err := s.server.GetService().GetUserIDByUsername(ctx, userName)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user ID: %v", err)
+	if req.DataId != "" {
+		if err := validateDataID(req.DataId); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
 	}
 
 	dataType, err := models.GetModelType(req.DataType)
@@ -32,99 +34,184 @@ func (s *gophKeeperServer) CreateData(ctx context.Context, req *proto.CreateData
 		return nil, status.Errorf(codes.InvalidArgument, "invalid data type :%v", err)
 	}
 
+	if err := validateIdempotencyKey(req.IdempotencyKey); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
 	data := &models.Data{
-		UserID:      userID,
-		DataType:    dataType,
-		DataContent: req.DataContent,
-		Metadata:    req.Metadata.AsMap(),
-		FileName:    req.FileName,
+		ID:             req.DataId,
+		UserID:         principal.UserID,
+		DataType:       dataType,
+		DataContent:    req.DataContent,
+		Metadata:       req.Metadata.AsMap(),
+		FileName:       req.FileName,
+		IdempotencyKey: req.IdempotencyKey,
 	}
 
 	dataId, err := s.server.GetService().CreateData(ctx, data)
+	if errors.Is(err, utils.ErrDataExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "данные с ID %s уже существуют", req.DataId)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create data: %v", err)
 	}
 
 	return &proto.CreateDataResponse{
-		Message: "Data successfully created",
-		DataId:  dataId,
+		Message:  "Data successfully created",
+		DataId:   dataId,
+		Revision: data.Revision,
+		Version:  data.Version,
 	}, nil
 
 }
 
+// validateDataID проверяет, что ID записи является UUID в каноническом виде.
+func validateDataID(id string) error {
+	parsed, err := uuid.Parse(id)
+	if err != nil || parsed.String() != id {
+		return fmt.Errorf("invalid data ID %q", id)
+	}
+	return nil
+}
+
+// validateIdempotencyKey проверяет, что непустой ключ идемпотентности является UUID.
+func validateIdempotencyKey(key string) error {
+	if key == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(key); err != nil {
+		return fmt.Errorf("invalid idempotency key: %w", err)
+	}
+	return nil
+}
+
 // GetAllData получает все данные для текущего пользователя.
 // Возвращает список всех данных пользователя.
 func (s *gophKeeperServer) GetAllData(ctx context.Context, _ *proto.GetAllDataRequest) (*proto.GetAllDataResponse, error) {
-	userName, ok := ctx.Value(models.ContextKeyUser).(string)
+	principal, ok := models.PrincipalFromContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
 	}
 
-	userID, err := s.server.GetService().GetUserIDByUsername(ctx, userName)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user ID: %v", err)
-	}
-
-	data, err := s.server.GetService().GetData(ctx, userID)
+	data, err := s.server.GetService().GetData(ctx, principal.UserID)
 	if err != nil {
 		if errors.Is(err, utils.ErrUserDataNotFound) {
-			return nil, status.Errorf(codes.NotFound, "not found data for user %s", userName)
+			return nil, status.Errorf(codes.NotFound, "not found data for user %s", principal.Username)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to get data: %v", err)
 	}
 
-	responseData := make([]*proto.DataItem, 0, len(data))
-	for i := range data {
-		item := &data[i]
+	responseData, err := toProtoDataItems(data)
+	if err != nil {
+		return nil, err
+	}
 
-		protoDataType, err := models.ConvertModelDataTypeToProto(item.DataType)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to convert data type: %v", err)
-		}
+	return &proto.GetAllDataResponse{
+		Data: responseData,
+	}, nil
 
-		protoMetadata, err := models.ConvertJSONBToStruct(item.Metadata)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to convert metadata: %v", err)
-		}
+}
 
-		responseData = append(responseData, &proto.DataItem{
-			DataId:      item.ID,
-			DataType:    protoDataType,
-			DataContent: item.DataContent,
-			Metadata:    protoMetadata,
-			UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
-		})
+// ListData возвращает страницу данных текущего пользователя с учетом фильтров.
+// Токен следующей страницы пуст, если страниц больше нет.
+func (s *gophKeeperServer) ListData(ctx context.Context, req *proto.ListDataRequest) (*proto.ListDataResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
 	}
 
-	return &proto.GetAllDataResponse{
-		Data: responseData,
+	filter, err := toDataFilter(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	data, lastID, err := s.server.GetService().ListData(ctx, principal.UserID, filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list data: %v", err)
+	}
+
+	responseData, err := toProtoDataItems(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListDataResponse{
+		Data:          responseData,
+		NextPageToken: encodePageToken(lastID),
 	}, nil
+}
+
+// GetChanges возвращает изменения данных текущего пользователя после ревизии since_cursor.
+// Курсор в ответе указывает ревизию последнего переданного изменения, с него клиент продолжает синхронизацию.
+// Если метки удаления после since_cursor уже удалены, в ответе выставляется reset_required.
+func (s *gophKeeperServer) GetChanges(ctx context.Context, req *proto.GetChangesRequest) (*proto.GetChangesResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if req.SinceCursor < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid since_cursor")
+	}
 
+	changes, hasMore, err := s.server.GetService().GetChanges(ctx, principal.UserID, req.SinceCursor, pageLimit(req.Limit))
+	if errors.Is(err, utils.ErrCursorExpired) {
+		return &proto.GetChangesResponse{ResetRequired: true}, nil
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get changes: %v", err)
+	}
+
+	resp := &proto.GetChangesResponse{
+		Cursor:  req.SinceCursor,
+		HasMore: hasMore,
+	}
+
+	changed := make([]models.Data, 0, len(changes))
+	for _, change := range changes {
+		if change.Deleted {
+			resp.DeletedIds = append(resp.DeletedIds, change.ID)
+		} else {
+			changed = append(changed, change.Data)
+		}
+		resp.Cursor = change.Revision
+	}
+
+	resp.Changed, err = toProtoDataItems(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
 
-// DeleteData удаляет данные с указанным ID для текущего пользователя.
+// DeleteData перемещает данные с указанным ID для текущего пользователя в корзину.
 // Принимает ID данных для удаления и возвращает сообщение о результате.
 func (s *gophKeeperServer) DeleteData(ctx context.Context, req *proto.DeleteDataRequest) (*proto.DeleteDataResponse, error) {
-	userName, ok := ctx.Value(models.ContextKeyUser).(string)
+	principal, ok := models.PrincipalFromContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
 	}
 
-	userID, err := s.server.GetService().GetUserIDByUsername(ctx, userName)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user ID: %v", err)
+	if err := validateDataID(req.DataId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
-	_, err = s.server.GetService().DeleteData(ctx, req.DataId, userID)
+	_, err := s.server.GetService().DeleteData(ctx, req.DataId, principal.UserID, req.ExpectedVersion)
 	if err != nil {
-		if errors.Is(err, utils.ErrUserDataNotFound) {
-			return nil, status.Errorf(codes.NotFound, "данные с ID %d не найдены", req.DataId)
+		var conflict *utils.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			return nil, conflictStatus(req.DataId, conflict)
+		case errors.Is(err, utils.ErrUserDataNotFound):
+			return nil, status.Errorf(codes.NotFound, "данные с ID %s не найдены", req.DataId)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed delete data with ID %s", req.DataId)
 		}
-		return nil, status.Errorf(codes.Internal, "failed delete data with ID %d", req.DataId)
 	}
 
 	return &proto.DeleteDataResponse{
-		Message: fmt.Sprintf("Данные с ID %d успешно удалены", req.DataId),
+		Message: fmt.Sprintf("Данные с ID %s перемещены в корзину", req.DataId),
 	}, nil
 
 }
@@ -132,31 +219,89 @@ func (s *gophKeeperServer) DeleteData(ctx context.Context, req *proto.DeleteData
 // UpdateData обновляет данные с указанным ID для текущего пользователя.
 // Принимает запрос на обновление данных и возвращает сообщение о результате.
 func (s *gophKeeperServer) UpdateData(ctx context.Context, req *proto.UpdateDataRequest) (*proto.UpdateDataResponse, error) {
-	userName, ok := ctx.Value(models.ContextKeyUser).(string)
+	principal, ok := models.PrincipalFromContext(ctx)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
 	}
 
-	userID, err := s.server.GetService().GetUserIDByUsername(ctx, userName)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user ID: %v", err)
+	if err := validateDataID(req.DataId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
 
 	data := &models.Data{
-		UserID:      userID,
+		UserID:      principal.UserID,
 		DataContent: req.DataContent,
 		Metadata:    req.Metadata.AsMap(),
 		FileName:    req.FileName,
 		ID:          req.DataId,
+		Version:     req.ExpectedVersion,
 	}
 
-	err = s.server.GetService().UpdateData(ctx, data)
+	err := s.server.GetService().UpdateData(ctx, data)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update data: %v", err)
+		var conflict *utils.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			return nil, conflictStatus(req.DataId, conflict)
+		case errors.Is(err, utils.ErrUserDataNotFound):
+			return nil, status.Errorf(codes.NotFound, "данные с ID %s не найдены", req.DataId)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to update data: %v", err)
+		}
 	}
 
 	return &proto.UpdateDataResponse{
-		Message: "Data successfully updated",
+		Message:  "Data successfully updated",
+		Revision: data.Revision,
+		Version:  data.Version,
 	}, nil
 
 }
+
+// toProtoDataItems преобразует данные пользователя в элементы ответа gRPC.
+func toProtoDataItems(data []models.Data) ([]*proto.DataItem, error) {
+	responseData := make([]*proto.DataItem, 0, len(data))
+	for i := range data {
+		item := &data[i]
+
+		protoDataType, err := models.ConvertModelDataTypeToProto(item.DataType)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to convert data type: %v", err)
+		}
+
+		protoMetadata, err := models.ConvertJSONBToStruct(item.Metadata)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to convert metadata: %v", err)
+		}
+
+		responseData = append(responseData, &proto.DataItem{
+			DataId:      item.ID,
+			DataType:    protoDataType,
+			DataContent: item.DataContent,
+			Metadata:    protoMetadata,
+			UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+			Revision:    item.Revision,
+			Version:     item.Version,
+			Blob:        toProtoBlobManifest(item.Blob),
+		})
+		if !item.DeletedAt.IsZero() {
+			responseData[len(responseData)-1].DeletedAt = item.DeletedAt.Format(time.RFC3339)
+		}
+	}
+
+	return responseData, nil
+}
+
+// toProtoBlobManifest преобразует описание файла бинарных данных в сообщение gRPC.
+func toProtoBlobManifest(blob *models.BlobManifest) *proto.BlobManifest {
+	if blob == nil {
+		return nil
+	}
+
+	return &proto.BlobManifest{
+		BlobId:      blob.ID,
+		FileName:    blob.FileName,
+		Size:        blob.Size,
+		ContentHash: blob.ContentHash,
+	}
+}
@@ -2,13 +2,17 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"io"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -34,7 +38,7 @@ func TestCreateData(t *testing.T) {
 		mockBehavior    mockBehavior
 		expectedError   error
 		expectedMessage string
-		expectedDataID  int64
+		expectedDataID  string
 	}{
 		{
 			name: "TestCreateDataSuccess",
@@ -47,13 +51,12 @@ func TestCreateData(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
-				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("00000000-0000-0000-0000-000000000001", nil)
 			},
 			expectedError:   nil,
 			expectedMessage: "Data successfully created",
-			expectedDataID:  1,
+			expectedDataID:  "00000000-0000-0000-0000-000000000001",
 		},
 		{
 			name: "TestCreateDataServiceError",
@@ -66,14 +69,13 @@ func TestCreateData(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 
-				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("service error"))
+				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("", errors.New("service error"))
 			},
 			expectedError:   status.Errorf(codes.Internal, "failed to create data: service error"),
 			expectedMessage: "",
-			expectedDataID:  0,
+			expectedDataID:  "",
 		},
 		{
 			name: "TestCreateDataUnauthenticated",
@@ -89,43 +91,116 @@ func TestCreateData(t *testing.T) {
 			},
 			expectedError:   status.Errorf(codes.Unauthenticated, "invalid user authentication"),
 			expectedMessage: "",
-			expectedDataID:  0,
+			expectedDataID:  "",
 		},
 		{
-			name: "TestCreateDataGetUserIDError",
+			name: "TestCreateDataInvalidDataType",
 			args: args{
 				req: &proto.CreateDataRequest{
 					DataContent: []byte("test content"),
-					DataType:    proto.DataType_BINARY_DATA,
+					DataType:    proto.DataType_UNKNOWN,
 					FileName:    "testfile",
 					Metadata:    &structpb.Struct{},
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(0), errors.New("failed to get user ID"))
 			},
-			expectedError:   status.Errorf(codes.Internal, "failed to get user ID: failed to get user ID"),
+			expectedError:   status.Errorf(codes.InvalidArgument, "invalid data type :unknown proto.DataType"),
 			expectedMessage: "",
-			expectedDataID:  0,
+			expectedDataID:  "",
 		},
 		{
-			name: "TestCreateDataInvalidDataType",
+			name: "TestCreateDataClientID",
 			args: args{
 				req: &proto.CreateDataRequest{
+					DataId:      "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191",
 					DataContent: []byte("test content"),
-					DataType:    proto.DataType_UNKNOWN,
-					FileName:    "testfile",
+					DataType:    proto.DataType_TEXT_DATA,
 					Metadata:    &structpb.Struct{},
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
 				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, data *models.Data) (string, error) {
+						assert.Equal(t, args.req.DataId, data.ID)
+						return data.ID, nil
+					})
 			},
-			expectedError:   status.Errorf(codes.InvalidArgument, "invalid data type :unknown proto.DataType"),
+			expectedError:   nil,
+			expectedMessage: "Data successfully created",
+			expectedDataID:  "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191",
+		},
+		{
+			name: "TestCreateDataInvalidID",
+			args: args{
+				req: &proto.CreateDataRequest{
+					DataId:      "42",
+					DataContent: []byte("test content"),
+					DataType:    proto.DataType_TEXT_DATA,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+			},
+			expectedError:   status.Errorf(codes.InvalidArgument, `invalid data ID "42"`),
+			expectedMessage: "",
+			expectedDataID:  "",
+		},
+		{
+			name: "TestCreateDataIDExists",
+			args: args{
+				req: &proto.CreateDataRequest{
+					DataId:      "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191",
+					DataContent: []byte("test content"),
+					DataType:    proto.DataType_TEXT_DATA,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("", utils.ErrDataExists)
+			},
+			expectedError: status.Errorf(codes.AlreadyExists,
+				"данные с ID 0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191 уже существуют"),
+			expectedMessage: "",
+			expectedDataID:  "",
+		},
+		{
+			name: "TestCreateDataIdempotencyKey",
+			args: args{
+				req: &proto.CreateDataRequest{
+					DataContent:    []byte("test content"),
+					DataType:       proto.DataType_TEXT_DATA,
+					Metadata:       &structpb.Struct{},
+					IdempotencyKey: "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192",
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().CreateData(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, data *models.Data) (string, error) {
+						assert.Equal(t, args.req.IdempotencyKey, data.IdempotencyKey)
+						return "00000000-0000-0000-0000-000000000007", nil
+					})
+			},
+			expectedError:   nil,
+			expectedMessage: "Data successfully created",
+			expectedDataID:  "00000000-0000-0000-0000-000000000007",
+		},
+		{
+			name: "TestCreateDataInvalidIdempotencyKey",
+			args: args{
+				req: &proto.CreateDataRequest{
+					DataContent:    []byte("test content"),
+					DataType:       proto.DataType_TEXT_DATA,
+					Metadata:       &structpb.Struct{},
+					IdempotencyKey: "retry-1",
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+			},
+			expectedError:   status.Errorf(codes.InvalidArgument, "invalid idempotency key: invalid UUID length: 7"),
 			expectedMessage: "",
-			expectedDataID:  0,
+			expectedDataID:  "",
 		},
 	}
 
@@ -147,7 +222,7 @@ func TestCreateData(t *testing.T) {
 
 			var ctx context.Context
 			if tt.name != "TestCreateDataUnauthenticated" {
-				ctx = context.WithValue(context.Background(), models.ContextKeyUser, "testuser")
+				ctx = withPrincipal(context.Background(), 1)
 			} else {
 				ctx = context.Background()
 			}
@@ -187,11 +262,10 @@ func TestGetAllData(t *testing.T) {
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return([]models.Data{
 					{
-						ID:          1,
+						ID:          "00000000-0000-0000-0000-000000000001",
 						DataType:    models.TextData,
 						DataContent: []byte("test content"),
 						Metadata:    map[string]interface{}{"key": "value"},
@@ -203,7 +277,7 @@ func TestGetAllData(t *testing.T) {
 			expectedMessage: "",
 			expectedData: []*proto.DataItem{
 				{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataType:    proto.DataType_TEXT_DATA,
 					DataContent: []byte("test content"),
 					Metadata:    &structpb.Struct{Fields: map[string]*structpb.Value{"key": {Kind: &structpb.Value_StringValue{StringValue: "value"}}}},
@@ -212,26 +286,50 @@ func TestGetAllData(t *testing.T) {
 			},
 		},
 		{
-			name: "TestGetAllDataUnauthenticated",
+			name: "TestGetAllDataBinaryManifest",
 			args: args{
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return([]models.Data{
+					{
+						ID:        "00000000-0000-0000-0000-000000000002",
+						DataType:  models.BinaryData,
+						Metadata:  map[string]interface{}{},
+						UpdatedAt: time.Now(),
+						Blob: &models.BlobManifest{
+							ID:          "users/1/abc",
+							FileName:    "report.pdf",
+							Size:        1024,
+							ContentHash: "abc",
+						},
+					},
+				}, nil)
+			},
+			expectedData: []*proto.DataItem{
+				{
+					DataId:    "00000000-0000-0000-0000-000000000002",
+					DataType:  proto.DataType_BINARY_DATA,
+					Metadata:  &structpb.Struct{Fields: map[string]*structpb.Value{}},
+					UpdatedAt: time.Now().Format(time.RFC3339),
+					Blob: &proto.BlobManifest{
+						BlobId:      "users/1/abc",
+						FileName:    "report.pdf",
+						Size:        1024,
+						ContentHash: "abc",
+					},
+				},
 			},
-			expectedError:   status.Errorf(codes.Unauthenticated, "invalid user authentication"),
-			expectedMessage: "",
-			expectedData:    nil,
 		},
 		{
-			name: "TestGetAllDataGetUserIDError",
+			name: "TestGetAllDataUnauthenticated",
 			args: args{
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(0), errors.New("failed to get user ID"))
 			},
-			expectedError:   status.Errorf(codes.Internal, "failed to get user ID: failed to get user ID"),
+			expectedError:   status.Errorf(codes.Unauthenticated, "invalid user authentication"),
 			expectedMessage: "",
 			expectedData:    nil,
 		},
@@ -241,8 +339,7 @@ func TestGetAllData(t *testing.T) {
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return(nil, utils.ErrUserDataNotFound)
 			},
 			expectedError:   status.Errorf(codes.NotFound, "not found data for user testuser"),
@@ -255,8 +352,7 @@ func TestGetAllData(t *testing.T) {
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return(nil, errors.New("error service"))
 			},
 			expectedError:   status.Errorf(codes.Internal, "failed to get data: error service"),
@@ -269,11 +365,10 @@ func TestGetAllData(t *testing.T) {
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return([]models.Data{
 					{
-						ID:          1,
+						ID:          "00000000-0000-0000-0000-000000000001",
 						DataType:    models.DataType(rune(999)),
 						DataContent: []byte("test content"),
 						Metadata:    map[string]interface{}{"key": "value"},
@@ -291,11 +386,10 @@ func TestGetAllData(t *testing.T) {
 				req: &proto.GetAllDataRequest{},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().GetData(gomock.Any(), int64(1)).Return([]models.Data{
 					{
-						ID:          1,
+						ID:          "00000000-0000-0000-0000-000000000001",
 						DataType:    models.TextData,
 						DataContent: []byte("test content"),
 						Metadata:    map[string]interface{}{"key": func() {}},
@@ -328,7 +422,7 @@ func TestGetAllData(t *testing.T) {
 
 			var ctx context.Context
 			if tt.name != "TestGetAllDataUnauthenticated" {
-				ctx = context.WithValue(context.Background(), models.ContextKeyUser, "testuser")
+				ctx = withPrincipal(context.Background(), 1)
 			} else {
 				ctx = context.Background()
 			}
@@ -346,6 +440,274 @@ func TestGetAllData(t *testing.T) {
 	}
 }
 
+func TestListData(t *testing.T) {
+	updatedAt := time.Date(2025, 3, 2, 15, 22, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		req           *proto.ListDataRequest
+		authenticated bool
+		mockBehavior  func(m *mocks)
+		expectedError error
+		expectedResp  *proto.ListDataResponse
+	}{
+		{
+			name:          "TestListDataSuccess",
+			req:           &proto.ListDataRequest{},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().ListData(gomock.Any(), int64(1), models.DataFilter{Limit: defaultPageSize}).
+					Return([]models.Data{{ID: "00000000-0000-0000-0000-000000000007", DataType: models.TextData, UpdatedAt: updatedAt}}, "00000000-0000-0000-0000-000000000007", nil)
+			},
+			expectedResp: &proto.ListDataResponse{
+				Data: []*proto.DataItem{
+					{DataId: "00000000-0000-0000-0000-000000000007", DataType: proto.DataType_TEXT_DATA, UpdatedAt: "2025-03-02T15:22:00Z"},
+				},
+				NextPageToken: encodePageToken("00000000-0000-0000-0000-000000000007"),
+			},
+		},
+		{
+			name: "TestListDataWithFilters",
+			req: &proto.ListDataRequest{
+				PageSize:     1000,
+				PageToken:    encodePageToken("00000000-0000-0000-0000-000000000007"),
+				DataType:     proto.DataType_BANK_CARD,
+				UpdatedAfter: "2025-03-02T15:22:00Z",
+				Metadata:     map[string]string{"bank": "test"},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().ListData(gomock.Any(), int64(1), models.DataFilter{
+					DataType:     models.BankCard,
+					UpdatedAfter: updatedAt,
+					Metadata:     map[string]string{"bank": "test"},
+					AfterID:      "00000000-0000-0000-0000-000000000007",
+					Limit:        maxPageSize,
+				}).Return(nil, "", nil)
+			},
+			expectedResp: &proto.ListDataResponse{Data: []*proto.DataItem{}},
+		},
+		{
+			name:          "TestListDataUnauthenticated",
+			req:           &proto.ListDataRequest{},
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name:          "TestListDataInvalidPageToken",
+			req:           &proto.ListDataRequest{PageToken: "!!!"},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+			},
+			expectedError: status.Errorf(codes.InvalidArgument, "invalid page token"),
+		},
+		{
+			name:          "TestListDataInvalidUpdatedAfter",
+			req:           &proto.ListDataRequest{UpdatedAfter: "yesterday"},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+			},
+			expectedError: status.Errorf(codes.InvalidArgument,
+				`invalid updated_after: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`),
+		},
+		{
+			name:          "TestListDataServiceError",
+			req:           &proto.ListDataRequest{},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().ListData(gomock.Any(), int64(1), gomock.Any()).
+					Return(nil, "", errors.New("db error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to list data: db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			resp, err := server.ListData(ctx, tt.req)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp.NextPageToken, resp.NextPageToken)
+				assert.Equal(t, len(tt.expectedResp.Data), len(resp.Data))
+				for i := range tt.expectedResp.Data {
+					assert.Equal(t, tt.expectedResp.Data[i].DataId, resp.Data[i].DataId)
+					assert.Equal(t, tt.expectedResp.Data[i].DataType, resp.Data[i].DataType)
+					assert.Equal(t, tt.expectedResp.Data[i].UpdatedAt, resp.Data[i].UpdatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	id, err := decodePageToken(encodePageToken("0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191", id)
+
+	assert.Equal(t, "", encodePageToken(""))
+
+	id, err = decodePageToken("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	_, err = decodePageToken(base64.RawURLEncoding.EncodeToString([]byte("abc")))
+	assert.Error(t, err)
+}
+
+func TestValidateDataID(t *testing.T) {
+	assert.NoError(t, validateDataID("0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"))
+	assert.Error(t, validateDataID("42"))
+	assert.Error(t, validateDataID("0195F3A4-7C1E-7D2A-9B3C-4D5E6F708191"))
+	assert.Error(t, validateDataID("{0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191}"))
+}
+
+func TestGetChanges(t *testing.T) {
+	updatedAt := time.Date(2025, 3, 2, 15, 22, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		req           *proto.GetChangesRequest
+		authenticated bool
+		mockBehavior  func(m *mocks)
+		expectedError error
+		expectedResp  *proto.GetChangesResponse
+	}{
+		{
+			name:          "TestGetChangesSuccess",
+			req:           &proto.GetChangesRequest{SinceCursor: 10},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().GetChanges(gomock.Any(), int64(1), int64(10), defaultPageSize).
+					Return([]models.DataChange{
+						{Data: models.Data{ID: "00000000-0000-0000-0000-000000000007", DataType: models.TextData, UpdatedAt: updatedAt, Revision: 11}},
+						{Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", Revision: 12}, Deleted: true},
+					}, true, nil)
+			},
+			expectedResp: &proto.GetChangesResponse{
+				Changed: []*proto.DataItem{
+					{DataId: "00000000-0000-0000-0000-000000000007", DataType: proto.DataType_TEXT_DATA, UpdatedAt: "2025-03-02T15:22:00Z", Revision: 11},
+				},
+				DeletedIds: []string{"00000000-0000-0000-0000-000000000003"},
+				Cursor:     12,
+				HasMore:    true,
+			},
+		},
+		{
+			name:          "TestGetChangesNoChanges",
+			req:           &proto.GetChangesRequest{SinceCursor: 12, Limit: 1000},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().GetChanges(gomock.Any(), int64(1), int64(12), maxPageSize).
+					Return(nil, false, nil)
+			},
+			expectedResp: &proto.GetChangesResponse{Changed: []*proto.DataItem{}, Cursor: 12},
+		},
+		{
+			name:          "TestGetChangesResetRequired",
+			req:           &proto.GetChangesRequest{SinceCursor: 5},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().GetChanges(gomock.Any(), int64(1), int64(5), defaultPageSize).
+					Return(nil, false, utils.ErrCursorExpired)
+			},
+			expectedResp: &proto.GetChangesResponse{ResetRequired: true},
+		},
+		{
+			name:          "TestGetChangesUnauthenticated",
+			req:           &proto.GetChangesRequest{},
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name:          "TestGetChangesInvalidCursor",
+			req:           &proto.GetChangesRequest{SinceCursor: -1},
+			authenticated: true,
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.InvalidArgument, "invalid since_cursor"),
+		},
+		{
+			name:          "TestGetChangesServiceError",
+			req:           &proto.GetChangesRequest{},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().GetChanges(gomock.Any(), int64(1), int64(0), defaultPageSize).
+					Return(nil, false, errors.New("db error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to get changes: db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			resp, err := server.GetChanges(ctx, tt.req)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResp.Cursor, resp.Cursor)
+				assert.Equal(t, tt.expectedResp.HasMore, resp.HasMore)
+				assert.Equal(t, tt.expectedResp.ResetRequired, resp.ResetRequired)
+				assert.Equal(t, tt.expectedResp.DeletedIds, resp.DeletedIds)
+				assert.Equal(t, len(tt.expectedResp.Changed), len(resp.Changed))
+				for i := range tt.expectedResp.Changed {
+					assert.Equal(t, tt.expectedResp.Changed[i].DataId, resp.Changed[i].DataId)
+					assert.Equal(t, tt.expectedResp.Changed[i].UpdatedAt, resp.Changed[i].UpdatedAt)
+					assert.Equal(t, tt.expectedResp.Changed[i].Revision, resp.Changed[i].Revision)
+				}
+			}
+		})
+	}
+}
+
 func TestDeleteData(t *testing.T) {
 	type (
 		args struct {
@@ -366,58 +728,73 @@ func TestDeleteData(t *testing.T) {
 			name: "TestDeleteDataSuccess",
 			args: args{
 				req: &proto.DeleteDataRequest{
-					DataId: 1,
+					DataId: "00000000-0000-0000-0000-000000000001",
 				},
 				userId: int64(3),
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(3), nil)
-				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId).
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId, int64(0)).
 					Return(true, nil)
 			},
 			expectedError:   nil,
-			expectedMessage: "Данные с ID 1 успешно удалены",
+			expectedMessage: "Данные с ID 00000000-0000-0000-0000-000000000001 перемещены в корзину",
 		},
 		{
 			name: "TestDeleteDataNotFound",
 			args: args{
 				req: &proto.DeleteDataRequest{
-					DataId: 1,
+					DataId: "00000000-0000-0000-0000-000000000001",
 				},
 				userId: int64(2),
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(2), nil)
-				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId).
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId, int64(0)).
 					Return(false, utils.ErrUserDataNotFound)
 			},
-			expectedError:   status.Errorf(codes.NotFound, "данные с ID 1 не найдены"),
+			expectedError:   status.Errorf(codes.NotFound, "данные с ID 00000000-0000-0000-0000-000000000001 не найдены"),
 			expectedMessage: "",
 		},
 		{
 			name: "TestDeleteDataInternalError",
 			args: args{
 				req: &proto.DeleteDataRequest{
-					DataId: 1,
+					DataId: "00000000-0000-0000-0000-000000000001",
 				},
 				userId: int64(1),
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
-				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId).
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId, int64(0)).
 					Return(false, errors.New("internal error"))
 			},
-			expectedError:   status.Errorf(codes.Internal, "failed delete data with ID 1"),
+			expectedError:   status.Errorf(codes.Internal, "failed delete data with ID 00000000-0000-0000-0000-000000000001"),
+			expectedMessage: "",
+		},
+		{
+			name: "TestDeleteDataVersionConflict",
+			args: args{
+				req: &proto.DeleteDataRequest{
+					DataId:          "00000000-0000-0000-0000-000000000001",
+					ExpectedVersion: 2,
+				},
+				userId: int64(1),
+			},
+			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DeleteData(gomock.Any(), args.req.DataId, args.userId, int64(2)).
+					Return(false, &utils.VersionConflictError{CurrentVersion: 3})
+			},
+			expectedError: status.Errorf(codes.Aborted,
+				"данные с ID 00000000-0000-0000-0000-000000000001 изменены на другом устройстве, текущая версия 3"),
 			expectedMessage: "",
 		},
 		{
 			name: "TestDeleteDataUnauthenticated",
 			args: args{
 				req: &proto.DeleteDataRequest{
-					DataId: 1,
+					DataId: "00000000-0000-0000-0000-000000000001",
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
@@ -445,7 +822,7 @@ func TestDeleteData(t *testing.T) {
 
 			var ctx context.Context
 			if tt.name != "TestDeleteDataUnauthenticated" {
-				ctx = context.WithValue(context.Background(), models.ContextKeyUser, "testuser")
+				ctx = withPrincipal(context.Background(), tt.args.userId)
 			} else {
 				ctx = context.Background()
 			}
@@ -481,15 +858,14 @@ func TestUpdateData(t *testing.T) {
 			name: "TestUpdateDataSuccess",
 			args: args{
 				req: &proto.UpdateDataRequest{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataContent: []byte("updated content"),
 					FileName:    "updatedfile",
 					Metadata:    &structpb.Struct{},
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
+				m.app.EXPECT().GetService().Return(m.service)
 				m.service.EXPECT().UpdateData(gomock.Any(), gomock.Any()).Return(nil)
 			},
 			expectedError:   nil,
@@ -499,7 +875,7 @@ func TestUpdateData(t *testing.T) {
 			name: "TestUpdateDataUnauthenticated",
 			args: args{
 				req: &proto.UpdateDataRequest{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataContent: []byte("updated content"),
 					FileName:    "updatedfile",
 					Metadata:    &structpb.Struct{},
@@ -511,10 +887,10 @@ func TestUpdateData(t *testing.T) {
 			expectedMessage: "",
 		},
 		{
-			name: "TestUpdateDataGetUserIDError",
+			name: "TestUpdateDataServiceError",
 			args: args{
 				req: &proto.UpdateDataRequest{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataContent: []byte("updated content"),
 					FileName:    "updatedfile",
 					Metadata:    &structpb.Struct{},
@@ -522,27 +898,47 @@ func TestUpdateData(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(0), errors.New("failed to get user ID"))
+				m.service.EXPECT().UpdateData(gomock.Any(), gomock.Any()).Return(errors.New("service error"))
 			},
-			expectedError:   status.Errorf(codes.Internal, "failed to get user ID: failed to get user ID"),
+			expectedError:   status.Errorf(codes.Internal, "failed to update data: service error"),
 			expectedMessage: "",
 		},
 		{
-			name: "TestUpdateDataServiceError",
+			name: "TestUpdateDataVersionConflict",
+			args: args{
+				req: &proto.UpdateDataRequest{
+					DataId:          "00000000-0000-0000-0000-000000000001",
+					DataContent:     []byte("updated content"),
+					Metadata:        &structpb.Struct{},
+					ExpectedVersion: 4,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().UpdateData(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, data *models.Data) error {
+						assert.Equal(t, int64(4), data.Version)
+						return &utils.VersionConflictError{CurrentVersion: 5}
+					})
+			},
+			expectedError: status.Errorf(codes.Aborted,
+				"данные с ID 00000000-0000-0000-0000-000000000001 изменены на другом устройстве, текущая версия 5"),
+			expectedMessage: "",
+		},
+		{
+			name: "TestUpdateDataNotFound",
 			args: args{
 				req: &proto.UpdateDataRequest{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataContent: []byte("updated content"),
-					FileName:    "updatedfile",
 					Metadata:    &structpb.Struct{},
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").Return(int64(1), nil)
-				m.service.EXPECT().UpdateData(gomock.Any(), gomock.Any()).Return(errors.New("service error"))
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().UpdateData(gomock.Any(), gomock.Any()).Return(utils.ErrUserDataNotFound)
 			},
-			expectedError:   status.Errorf(codes.Internal, "failed to update data: service error"),
+			expectedError:   status.Errorf(codes.NotFound, "данные с ID 00000000-0000-0000-0000-000000000001 не найдены"),
 			expectedMessage: "",
 		},
 	}
@@ -565,7 +961,7 @@ func TestUpdateData(t *testing.T) {
 
 			var ctx context.Context
 			if tt.name != "TestUpdateDataUnauthenticated" {
-				ctx = context.WithValue(context.Background(), models.ContextKeyUser, "testuser")
+				ctx = withPrincipal(context.Background(), 1)
 			} else {
 				ctx = context.Background()
 			}
@@ -581,3 +977,753 @@ func TestUpdateData(t *testing.T) {
 		})
 	}
 }
+
+func TestConflictStatus(t *testing.T) {
+	err := conflictStatus("00000000-0000-0000-0000-000000000007", &utils.VersionConflictError{CurrentVersion: 3})
+
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Aborted, st.Code())
+	assert.Equal(t, "данные с ID 00000000-0000-0000-0000-000000000007 изменены на другом устройстве, текущая версия 3", st.Message())
+
+	if assert.Len(t, st.Details(), 1) {
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		assert.True(t, ok)
+		assert.Equal(t, models.ReasonVersionConflict, info.Reason)
+		assert.Equal(t, models.ErrorDomain, info.Domain)
+		assert.Equal(t, "3", info.Metadata[models.MetadataCurrentVersion])
+	}
+}
+
+func TestUploadBlob(t *testing.T) {
+	tests := []struct {
+		name           string
+		requests       []*proto.UploadBlobRequest
+		authenticated  bool
+		mockBehavior   func(m *mocks)
+		expectedError  error
+		expectedDataID string
+	}{
+		{
+			name: "TestUploadBlobCreateSuccess",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Info{Info: &proto.UploadBlobInfo{FileName: "file", Size: 10}}},
+				{Payload: &proto.UploadBlobRequest_Chunk{Chunk: []byte("hello")}},
+				{Payload: &proto.UploadBlobRequest_Chunk{Chunk: []byte("world")}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().CreateBlob(gomock.Any(), gomock.Any(), gomock.Any(), int64(10)).
+					DoAndReturn(func(_ context.Context, data *models.Data, content io.Reader, _ int64) (string, error) {
+						body, err := io.ReadAll(content)
+						assert.NoError(t, err)
+						assert.Equal(t, "helloworld", string(body))
+						assert.Equal(t, "file", data.FileName)
+						assert.Equal(t, int64(1), data.UserID)
+						return "00000000-0000-0000-0000-000000000005", nil
+					})
+			},
+			expectedDataID: "00000000-0000-0000-0000-000000000005",
+		},
+		{
+			name: "TestUploadBlobUpdateSuccess",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Info{Info: &proto.UploadBlobInfo{DataId: "00000000-0000-0000-0000-000000000003", FileName: "file"}}},
+				{Payload: &proto.UploadBlobRequest_Chunk{Chunk: []byte("hello")}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().UpdateBlob(gomock.Any(), gomock.Any(), gomock.Any(), int64(0)).Return(nil)
+			},
+			expectedDataID: "00000000-0000-0000-0000-000000000003",
+		},
+		{
+			name:          "TestUploadBlobUnauthenticated",
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name: "TestUploadBlobMissingInfo",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Chunk{Chunk: []byte("hello")}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+			},
+			expectedError: status.Errorf(codes.InvalidArgument, "first message must contain blob info"),
+		},
+		{
+			name: "TestUploadBlobMissingFileName",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Info{Info: &proto.UploadBlobInfo{}}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+			},
+			expectedError: status.Errorf(codes.InvalidArgument, "file name is required"),
+		},
+		{
+			name: "TestUploadBlobNotFound",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Info{Info: &proto.UploadBlobInfo{DataId: "00000000-0000-0000-0000-000000000003", FileName: "file"}}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().UpdateBlob(gomock.Any(), gomock.Any(), gomock.Any(), int64(0)).
+					Return(utils.ErrUserDataNotFound)
+			},
+			expectedError: status.Errorf(codes.NotFound, "данные с ID 00000000-0000-0000-0000-000000000003 не найдены"),
+		},
+		{
+			name: "TestUploadBlobServiceError",
+			requests: []*proto.UploadBlobRequest{
+				{Payload: &proto.UploadBlobRequest_Info{Info: &proto.UploadBlobInfo{FileName: "file"}}},
+			},
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().CreateBlob(gomock.Any(), gomock.Any(), gomock.Any(), int64(0)).
+					Return("", errors.New("minio error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to upload blob: minio error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			stream := &fakeUploadServerStream{ctx: ctx, requests: tt.requests}
+			err := server.UploadBlob(stream)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDataID, stream.resp.DataId)
+			}
+		})
+	}
+}
+
+func TestDownloadBlob(t *testing.T) {
+	tests := []struct {
+		name           string
+		authenticated  bool
+		mockBehavior   func(m *mocks)
+		expectedError  error
+		expectedChunks int
+	}{
+		{
+			name:          "TestDownloadBlobSuccess",
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DownloadBlob(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, _ int64, w io.Writer) error {
+						_, err := w.Write(make([]byte, blobChunkSize+1))
+						return err
+					})
+			},
+			expectedChunks: 2,
+		},
+		{
+			name:          "TestDownloadBlobUnauthenticated",
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name:          "TestDownloadBlobNotBinary",
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DownloadBlob(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1), gomock.Any()).
+					Return(utils.ErrNotBinaryData)
+			},
+			expectedError: status.Errorf(codes.FailedPrecondition, "данные с ID 00000000-0000-0000-0000-000000000001 не являются бинарными"),
+		},
+		{
+			name:          "TestDownloadBlobServiceError",
+			authenticated: true,
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().DownloadBlob(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1), gomock.Any()).
+					Return(errors.New("minio error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to download blob: minio error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			stream := &fakeDownloadServerStream{ctx: ctx}
+			err := server.DownloadBlob(&proto.DownloadBlobRequest{DataId: "00000000-0000-0000-0000-000000000001"}, stream)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, stream.responses, tt.expectedChunks)
+			}
+		})
+	}
+}
+
+func TestFetchBlob(t *testing.T) {
+	tests := []struct {
+		name           string
+		authenticated  bool
+		blobID         string
+		mockBehavior   func(m *mocks)
+		expectedError  error
+		expectedChunks int
+	}{
+		{
+			name:          "TestFetchBlobSuccess",
+			authenticated: true,
+			blobID:        "users/1/abc",
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().FetchBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int64, _ string, w io.Writer) error {
+						_, err := w.Write(make([]byte, blobChunkSize+1))
+						return err
+					})
+			},
+			expectedChunks: 2,
+		},
+		{
+			name:          "TestFetchBlobUnauthenticated",
+			blobID:        "users/1/abc",
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name:          "TestFetchBlobEmptyID",
+			authenticated: true,
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.InvalidArgument, "blob ID is required"),
+		},
+		{
+			name:          "TestFetchBlobNotFound",
+			authenticated: true,
+			blobID:        "users/2/abc",
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().FetchBlob(gomock.Any(), int64(1), "users/2/abc", gomock.Any()).
+					Return(utils.ErrBlobNotFound)
+			},
+			expectedError: status.Errorf(codes.NotFound, "файл users/2/abc не найден"),
+		},
+		{
+			name:          "TestFetchBlobServiceError",
+			authenticated: true,
+			blobID:        "users/1/abc",
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().FetchBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).
+					Return(errors.New("minio error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to fetch blob: minio error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			stream := &fakeDownloadServerStream{ctx: ctx}
+			err := server.FetchBlob(&proto.FetchBlobRequest{BlobId: tt.blobID}, stream)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, stream.responses, tt.expectedChunks)
+			}
+		})
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		authenticated  bool
+		events         []models.ChangeEvent
+		mockBehavior   func(m *mocks, events chan models.ChangeEvent)
+		expectedError  error
+		expectedEvents []*proto.ChangeEvent
+	}{
+		{
+			name:          "TestWatchChangesSendsEvents",
+			authenticated: true,
+			events: []models.ChangeEvent{
+				{UserID: 1, DataID: "00000000-0000-0000-0000-000000000005", Revision: 10},
+				{UserID: 1, DataID: "00000000-0000-0000-0000-000000000006", Revision: 11, Deleted: true},
+			},
+			mockBehavior: func(m *mocks, events chan models.ChangeEvent) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().WatchChanges(gomock.Any(), int64(1)).Return(events, nil)
+			},
+			expectedError: status.Errorf(codes.Unavailable, "change notifications stopped"),
+			expectedEvents: []*proto.ChangeEvent{
+				{DataId: "00000000-0000-0000-0000-000000000005", Revision: 10},
+				{DataId: "00000000-0000-0000-0000-000000000006", Revision: 11, Deleted: true},
+			},
+		},
+		{
+			name:          "TestWatchChangesUnauthenticated",
+			mockBehavior:  func(m *mocks, _ chan models.ChangeEvent) {},
+			expectedError: status.Errorf(codes.Unauthenticated, "invalid user authentication"),
+		},
+		{
+			name:          "TestWatchChangesSubscribeError",
+			authenticated: true,
+			mockBehavior: func(m *mocks, _ chan models.ChangeEvent) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().WatchChanges(gomock.Any(), int64(1)).
+					Return(nil, errors.New("change notifications are not available"))
+			},
+			expectedError: status.Errorf(codes.Unavailable,
+				"failed to watch changes: change notifications are not available"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+
+			events := make(chan models.ChangeEvent, len(tt.events))
+			for _, event := range tt.events {
+				events <- event
+			}
+			close(events)
+
+			tt.mockBehavior(m, events)
+
+			server := &gophKeeperServer{
+				UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+				server:                        m.app,
+			}
+
+			ctx := context.Background()
+			if tt.authenticated {
+				ctx = withPrincipal(ctx, 1)
+			}
+
+			stream := &fakeWatchServerStream{ctx: ctx}
+			err := server.WatchChanges(&proto.WatchChangesRequest{}, stream)
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError.Error(), err.Error())
+			assert.Len(t, stream.events, len(tt.expectedEvents))
+			for i := range tt.expectedEvents {
+				assert.Equal(t, tt.expectedEvents[i].DataId, stream.events[i].DataId)
+				assert.Equal(t, tt.expectedEvents[i].Revision, stream.events[i].Revision)
+				assert.Equal(t, tt.expectedEvents[i].Deleted, stream.events[i].Deleted)
+			}
+		})
+	}
+
+	t.Run("TestWatchChangesClientDisconnect", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		m := &mocks{
+			app:     amock.NewMockServer(ctrl),
+			service: smock.NewMockService(ctrl),
+		}
+
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().WatchChanges(gomock.Any(), int64(1)).Return(make(chan models.ChangeEvent), nil)
+
+		server := &gophKeeperServer{
+			UnimplementedGophKeeperServer: proto.UnimplementedGophKeeperServer{},
+			server:                        m.app,
+		}
+
+		ctx, cancel := context.WithCancel(withPrincipal(context.Background(), 1))
+		cancel()
+
+		err := server.WatchChanges(&proto.WatchChangesRequest{}, &fakeWatchServerStream{ctx: ctx})
+		assert.NoError(t, err)
+	})
+}
+
+// fakeUploadServerStream - тестовая реализация серверного потока UploadBlob.
+type fakeUploadServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*proto.UploadBlobRequest
+	resp     *proto.UploadBlobResponse
+}
+
+func (f *fakeUploadServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeUploadServerStream) Recv() (*proto.UploadBlobRequest, error) {
+	if len(f.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := f.requests[0]
+	f.requests = f.requests[1:]
+	return req, nil
+}
+
+func (f *fakeUploadServerStream) SendAndClose(resp *proto.UploadBlobResponse) error {
+	f.resp = resp
+	return nil
+}
+
+// fakeDownloadServerStream - тестовая реализация серверного потока DownloadBlob.
+type fakeDownloadServerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*proto.DownloadBlobResponse
+}
+
+func (f *fakeDownloadServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeDownloadServerStream) Send(resp *proto.DownloadBlobResponse) error {
+	f.responses = append(f.responses, resp)
+	return nil
+}
+
+// fakeWatchServerStream - тестовая реализация серверного потока WatchChanges.
+type fakeWatchServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events []*proto.ChangeEvent
+}
+
+func (f *fakeWatchServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchServerStream) Send(event *proto.ChangeEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestTrash(t *testing.T) {
+	deletedAt := time.Date(2025, 3, 2, 15, 22, 0, 0, time.UTC)
+
+	newServer := func(t *testing.T) (*gophKeeperServer, *mocks) {
+		ctrl := gomock.NewController(t)
+		m := &mocks{
+			app:     amock.NewMockServer(ctrl),
+			service: smock.NewMockService(ctrl),
+		}
+		return &gophKeeperServer{server: m.app}, m
+	}
+	ctx := withPrincipal(context.Background(), 1)
+
+	t.Run("TestListTrashSuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().ListTrash(gomock.Any(), int64(1)).
+			Return([]models.Data{{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, UpdatedAt: deletedAt, DeletedAt: deletedAt}}, nil)
+
+		resp, err := server.ListTrash(ctx, &proto.ListTrashRequest{})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Data, 1) {
+			assert.Equal(t, "00000000-0000-0000-0000-000000000003", resp.Data[0].DataId)
+			assert.Equal(t, "2025-03-02T15:22:00Z", resp.Data[0].DeletedAt)
+		}
+	})
+
+	t.Run("TestListTrashServiceError", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().ListTrash(gomock.Any(), int64(1)).Return(nil, errors.New("db error"))
+
+		_, err := server.ListTrash(ctx, &proto.ListTrashRequest{})
+		assert.Equal(t, status.Errorf(codes.Internal, "failed to list trash: db error").Error(), err.Error())
+	})
+
+	t.Run("TestRestoreDataSuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().RestoreData(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, UpdatedAt: deletedAt, Revision: 15, Version: 3}, nil)
+
+		resp, err := server.RestoreData(ctx, &proto.RestoreDataRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15), resp.Data.Revision)
+		assert.Equal(t, int64(3), resp.Data.Version)
+		assert.Empty(t, resp.Data.DeletedAt)
+	})
+
+	t.Run("TestRestoreDataNotFound", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().RestoreData(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).Return(nil, utils.ErrUserDataNotFound)
+
+		_, err := server.RestoreData(ctx, &proto.RestoreDataRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("TestPurgeDataSuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().PurgeData(gomock.Any(), int64(1), "").Return(int64(2), nil)
+
+		resp, err := server.PurgeData(ctx, &proto.PurgeDataRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), resp.Purged)
+	})
+
+	t.Run("TestPurgeDataNotFound", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().PurgeData(gomock.Any(), int64(1), "00000000-0000-0000-0000-000000000003").Return(int64(0), nil)
+
+		_, err := server.PurgeData(ctx, &proto.PurgeDataRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("TestTrashUnauthenticated", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		_, err := server.ListTrash(context.Background(), &proto.ListTrashRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		_, err = server.RestoreData(context.Background(), &proto.RestoreDataRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		_, err = server.PurgeData(context.Background(), &proto.PurgeDataRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestHistory(t *testing.T) {
+	updatedAt := time.Date(2025, 3, 2, 15, 22, 0, 0, time.UTC)
+
+	newServer := func(t *testing.T) (*gophKeeperServer, *mocks) {
+		ctrl := gomock.NewController(t)
+		m := &mocks{
+			app:     amock.NewMockServer(ctrl),
+			service: smock.NewMockService(ctrl),
+		}
+		return &gophKeeperServer{server: m.app}, m
+	}
+	ctx := withPrincipal(context.Background(), 1)
+
+	t.Run("TestGetDataHistorySuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().GetDataHistory(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return([]models.Data{
+				{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, UpdatedAt: updatedAt, Revision: 12, Version: 2},
+				{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, UpdatedAt: updatedAt, Revision: 10, Version: 1},
+			}, nil)
+
+		resp, err := server.GetDataHistory(ctx, &proto.GetDataHistoryRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Revisions, 2) {
+			assert.Equal(t, int64(12), resp.Revisions[0].Revision)
+			assert.Equal(t, int64(10), resp.Revisions[1].Revision)
+		}
+	})
+
+	t.Run("TestGetDataHistoryNotFound", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().GetDataHistory(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).Return(nil, utils.ErrUserDataNotFound)
+
+		_, err := server.GetDataHistory(ctx, &proto.GetDataHistoryRequest{DataId: "00000000-0000-0000-0000-000000000003"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("TestRestoreRevisionSuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().RestoreRevision(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(2)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, UpdatedAt: updatedAt, Revision: 15, Version: 3}, nil)
+
+		resp, err := server.RestoreRevision(ctx, &proto.RestoreRevisionRequest{DataId: "00000000-0000-0000-0000-000000000003", Revision: 10, ExpectedVersion: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15), resp.Data.Revision)
+		assert.Equal(t, int64(3), resp.Data.Version)
+	})
+
+	t.Run("TestRestoreRevisionConflict", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().RestoreRevision(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(1)).
+			Return(nil, &utils.VersionConflictError{CurrentVersion: 2})
+
+		_, err := server.RestoreRevision(ctx, &proto.RestoreRevisionRequest{DataId: "00000000-0000-0000-0000-000000000003", Revision: 10, ExpectedVersion: 1})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("TestRestoreRevisionNotFound", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().RestoreRevision(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(0)).
+			Return(nil, utils.ErrUserDataNotFound)
+
+		_, err := server.RestoreRevision(ctx, &proto.RestoreRevisionRequest{DataId: "00000000-0000-0000-0000-000000000003", Revision: 10})
+		assert.Equal(t, status.Errorf(codes.NotFound, "ревизия 10 данных с ID 00000000-0000-0000-0000-000000000003 не найдена").Error(), err.Error())
+	})
+}
+
+func TestBatchMutate(t *testing.T) {
+	newServer := func(t *testing.T) (*gophKeeperServer, *mocks) {
+		ctrl := gomock.NewController(t)
+		m := &mocks{
+			app:     amock.NewMockServer(ctrl),
+			service: smock.NewMockService(ctrl),
+		}
+		return &gophKeeperServer{server: m.app}, m
+	}
+	ctx := withPrincipal(context.Background(), 1)
+
+	req := &proto.BatchMutateRequest{Mutations: []*proto.Mutation{
+		{Operation: &proto.Mutation_Create{Create: &proto.CreateDataRequest{
+			DataType: proto.DataType_TEXT_DATA, DataContent: []byte("new"),
+		}}},
+		{Operation: &proto.Mutation_Update{Update: &proto.UpdateDataRequest{
+			DataId: "00000000-0000-0000-0000-000000000003", DataContent: []byte("changed"), ExpectedVersion: 2,
+		}}},
+		{Operation: &proto.Mutation_Delete{Delete: &proto.DeleteDataRequest{DataId: "00000000-0000-0000-0000-000000000004"}}},
+	}}
+
+	t.Run("TestBatchMutateSuccess", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().BatchMutate(gomock.Any(), int64(1), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+				assert.Equal(t, []models.MutationType{models.MutationCreate, models.MutationUpdate, models.MutationDelete},
+					[]models.MutationType{mutations[0].Type, mutations[1].Type, mutations[2].Type})
+				assert.Equal(t, models.TextData, mutations[0].Data.DataType)
+				assert.Equal(t, int64(2), mutations[1].Data.Version)
+				assert.Equal(t, "00000000-0000-0000-0000-000000000004", mutations[2].Data.ID)
+				return []models.MutationResult{
+					{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1},
+					{ID: "00000000-0000-0000-0000-000000000003", Revision: 21, Version: 3},
+					{ID: "00000000-0000-0000-0000-000000000004", Revision: 22},
+				}, nil
+			})
+
+		resp, err := server.BatchMutate(ctx, req)
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Results, 3) {
+			assert.Equal(t, "00000000-0000-0000-0000-00000000000a", resp.Results[0].DataId)
+			assert.Equal(t, int64(3), resp.Results[1].Version)
+			assert.Equal(t, int64(22), resp.Results[2].Revision)
+		}
+	})
+
+	t.Run("TestBatchMutateConflict", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().BatchMutate(gomock.Any(), int64(1), gomock.Any()).
+			Return(nil, &utils.OperationError{Index: 1, Err: &utils.VersionConflictError{CurrentVersion: 5}})
+
+		_, err := server.BatchMutate(ctx, req)
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.Aborted, st.Code())
+		assert.Equal(t, "операция 1: данные с ID 00000000-0000-0000-0000-000000000003 изменены на другом устройстве, текущая версия 5", st.Message())
+		if assert.Len(t, st.Details(), 1) {
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			assert.True(t, ok)
+			assert.Equal(t, "5", info.Metadata[models.MetadataCurrentVersion])
+			assert.Equal(t, "1", info.Metadata[models.MetadataOperation])
+		}
+	})
+
+	t.Run("TestBatchMutateNotFound", func(t *testing.T) {
+		server, m := newServer(t)
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().BatchMutate(gomock.Any(), int64(1), gomock.Any()).
+			Return(nil, &utils.OperationError{Index: 2, Err: utils.ErrUserDataNotFound})
+
+		_, err := server.BatchMutate(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("TestBatchMutateInvalidMutation", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		_, err := server.BatchMutate(ctx, &proto.BatchMutateRequest{Mutations: []*proto.Mutation{{}}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("TestBatchMutateTooManyMutations", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		mutations := make([]*proto.Mutation, maxBatchMutations+1)
+		_, err := server.BatchMutate(ctx, &proto.BatchMutateRequest{Mutations: mutations})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("TestBatchMutateUnauthenticated", func(t *testing.T) {
+		server, _ := newServer(t)
+
+		_, err := server.BatchMutate(context.Background(), req)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
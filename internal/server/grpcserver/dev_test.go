@@ -0,0 +1,117 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/client/encryption"
+	"github.com/Sofja96/GophKeeper.git/internal/client/grpcclient"
+	"github.com/Sofja96/GophKeeper.git/internal/client/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/app"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// TestRun_DevMode запускает сервер в режиме разработки и проходит сценарий клиента целиком:
+// регистрация, вход, создание записи и получение ее с сервера.
+func TestRun_DevMode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SERVER_HOST", "127.0.0.1")
+	t.Setenv("SERVER_PORT", freePort(t))
+	t.Setenv("CERT_PATH", filepath.Join(dir, "cert.pem"))
+	t.Setenv("KEY_PATH", filepath.Join(dir, "key.pem"))
+
+	srv, err := app.Run(true)
+	require.NoError(t, err)
+	defer srv.GetDbAdapter().Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, srv) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conf := srv.GetSettings()
+	assert.FileExists(t, conf.PathCert)
+	assert.FileExists(t, conf.PathKey)
+
+	client, err := grpcclient.NewGRPCClient(&conf)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.Eventually(t, func() bool {
+		return client.Register("alice", "secret") == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	token, err := client.Login("alice", "secret")
+	require.NoError(t, err)
+	client.SetToken(token)
+	client.SetMasterKey(encryption.GenerateEncryptionKey("secret", []byte("alice")))
+
+	content, err := encryption.EncryptData([]byte(`{"text":"hello"}`), client.GetMasterKey())
+	require.NoError(t, err)
+
+	authCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+	_, err = client.Client.CreateData(authCtx, &proto.CreateDataRequest{
+		DataType:    proto.DataType_TEXT_DATA,
+		DataContent: []byte(content),
+	})
+	require.NoError(t, err)
+
+	data, _, err := client.ListData(models.ListFilter{}, "")
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	assert.JSONEq(t, `{"text":"hello"}`, string(data[0].DataContent))
+
+	// Токен доступа обновляется по токену обновления
+	require.NoError(t, client.RefreshAccessToken())
+	refreshed := client.GetToken()
+	refreshedCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", refreshed)
+	_, err = client.Client.ListData(refreshedCtx, &proto.ListDataRequest{})
+	require.NoError(t, err)
+
+	// После выхода токены сессии отклоняются
+	require.NoError(t, client.Logout())
+	_, err = client.Client.ListData(refreshedCtx, &proto.ListDataRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// TestRun_DevModeIncompleteCertificate проверяет, что режим разработки не запускается,
+// если из пары сертификата и ключа существует только один файл.
+func TestRun_DevModeIncompleteCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(certPath, []byte("certificate"), 0600))
+
+	t.Setenv("SERVER_HOST", "127.0.0.1")
+	t.Setenv("SERVER_PORT", freePort(t))
+	t.Setenv("CERT_PATH", certPath)
+	t.Setenv("KEY_PATH", filepath.Join(dir, "key.pem"))
+
+	_, err := app.Run(true)
+	assert.ErrorContains(t, err, "only one of certificate")
+	assert.NoFileExists(t, filepath.Join(dir, "key.pem"))
+}
+
+// freePort возвращает свободный TCP-порт на локальном интерфейсе.
+func freePort(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	_, port, err := net.SplitHostPort(lis.Addr().String())
+	require.NoError(t, err)
+
+	return port
+}
@@ -35,11 +35,27 @@ func NewGRPCServer(srv app.Server) (*GRPCServer, error) {
 		return nil, fmt.Errorf("failed to create credentials: %w", err)
 	}
 
+	conf := srv.GetSettings()
+	limiter := interceptors.NewRateLimiter(interceptors.RateLimitConfig{
+		IPRate:       conf.RateLimitIPRate,
+		IPBurst:      conf.RateLimitIPBurst,
+		UserRate:     conf.RateLimitUserRate,
+		UserBurst:    conf.RateLimitUserBurst,
+		MaxFailures:  conf.LoginMaxFailures,
+		FailureDelay: conf.LoginFailureDelay,
+		Lockout:      conf.LoginLockout,
+	})
+
 	grpcServer := grpc.NewServer(
 		grpc.Creds(cred),
 		grpc.ChainUnaryInterceptor(
 			interceptors.LoggingInterceptor(srv.GetLogger()),
-			interceptors.AuthInterceptor(),
+			interceptors.RateLimitInterceptor(limiter),
+			interceptors.AuthInterceptor(srv.GetKeySet(), srv.GetService()),
+		),
+		grpc.ChainStreamInterceptor(
+			interceptors.LoggingStreamInterceptor(srv.GetLogger()),
+			interceptors.AuthStreamInterceptor(srv.GetKeySet(), srv.GetService()),
 		),
 	)
 
@@ -115,7 +115,7 @@ func TestGophKeeperServer_CreateData(t *testing.T) {
 		CreateData(gomock.Any(), gomock.Any()).
 		Return(&proto.CreateDataResponse{
 			Message: "Data successfully created",
-			DataId:  int64(2)}, nil).
+			DataId:  "00000000-0000-0000-0000-000000000002"}, nil).
 		Times(1)
 
 	resp, err := mockServer.CreateData(context.Background(), &proto.CreateDataRequest{
@@ -126,7 +126,7 @@ func TestGophKeeperServer_CreateData(t *testing.T) {
 	})
 
 	assert.NoError(t, err)
-	assert.Equal(t, int64(2), resp.DataId)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000002", resp.DataId)
 	assert.Equal(t, "Data successfully created", resp.Message)
 }
 
@@ -165,7 +165,7 @@ func TestGophKeeperServer_GetAllData(t *testing.T) {
 		Return(&proto.GetAllDataResponse{
 			Data: []*proto.DataItem{
 				{
-					DataId:      1,
+					DataId:      "00000000-0000-0000-0000-000000000001",
 					DataType:    proto.DataType_TEXT_DATA,
 					DataContent: []byte("test content"),
 					Metadata:    &structpb.Struct{},
@@ -180,7 +180,7 @@ func TestGophKeeperServer_GetAllData(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, 1, len(resp.Data))
-	assert.Equal(t, int64(1), resp.Data[0].DataId)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", resp.Data[0].DataId)
 	assert.Equal(t, proto.DataType_TEXT_DATA, resp.Data[0].DataType)
 	assert.Equal(t, []byte("test content"), resp.Data[0].DataContent)
 }
@@ -213,17 +213,17 @@ func TestGophKeeperServer_DeleteData(t *testing.T) {
 	mockServer.EXPECT().
 		DeleteData(gomock.Any(), gomock.Any()).
 		Return(&proto.DeleteDataResponse{
-			Message: "Данные с ID 1 успешно удалены",
+			Message: "Данные с ID 00000000-0000-0000-0000-000000000001 перемещены в корзину",
 		}, nil).
 		Times(1)
 
 	resp, err := mockServer.DeleteData(context.Background(), &proto.DeleteDataRequest{
-		DataId: 1,
+		DataId: "00000000-0000-0000-0000-000000000001",
 	})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
-	assert.Equal(t, "Данные с ID 1 успешно удалены", resp.Message)
+	assert.Equal(t, "Данные с ID 00000000-0000-0000-0000-000000000001 перемещены в корзину", resp.Message)
 }
 
 func TestGophKeeperServer_DeleteData_Error(t *testing.T) {
@@ -234,17 +234,17 @@ func TestGophKeeperServer_DeleteData_Error(t *testing.T) {
 
 	mockServer.EXPECT().
 		DeleteData(gomock.Any(), gomock.Any()).
-		Return(nil, status.Errorf(codes.Internal, "failed delete data with ID 1")).
+		Return(nil, status.Errorf(codes.Internal, "failed delete data with ID 00000000-0000-0000-0000-000000000001")).
 		Times(1)
 
 	resp, err := mockServer.DeleteData(context.Background(), &proto.DeleteDataRequest{
-		DataId: 1,
+		DataId: "00000000-0000-0000-0000-000000000001",
 	})
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
 	assert.Equal(t, codes.Internal, status.Code(err))
-	assert.Equal(t, "rpc error: code = Internal desc = failed delete data with ID 1", err.Error())
+	assert.Equal(t, "rpc error: code = Internal desc = failed delete data with ID 00000000-0000-0000-0000-000000000001", err.Error())
 }
 
 func TestGophKeeperServer_UpdateData(t *testing.T) {
@@ -261,7 +261,7 @@ func TestGophKeeperServer_UpdateData(t *testing.T) {
 		Times(1)
 
 	resp, err := mockServer.UpdateData(context.Background(), &proto.UpdateDataRequest{
-		DataId:      1,
+		DataId:      "00000000-0000-0000-0000-000000000001",
 		DataContent: []byte("updated content"),
 		FileName:    "updatedfile",
 		Metadata:    &structpb.Struct{},
@@ -284,7 +284,7 @@ func TestGophKeeperServer_UpdateData_Error(t *testing.T) {
 		Times(1)
 
 	resp, err := mockServer.UpdateData(context.Background(), &proto.UpdateDataRequest{
-		DataId:      1,
+		DataId:      "00000000-0000-0000-0000-000000000001",
 		DataContent: []byte("updated content"),
 		FileName:    "updatedfile",
 		Metadata:    &structpb.Struct{},
@@ -318,9 +318,11 @@ func TestNewGRPCServer_Success(t *testing.T) {
 		Port:     "50052",
 		PathCert: certPath,
 		PathKey:  keyPath,
-	}).Times(4)
+	}).Times(5)
 
-	m.app.EXPECT().GetLogger().Times(2)
+	m.app.EXPECT().GetLogger().Times(3)
+	m.app.EXPECT().GetKeySet().Times(2)
+	m.app.EXPECT().GetService().Times(2)
 
 	server, err := NewGRPCServer(m.app)
 	assert.NoError(t, err)
@@ -363,9 +365,11 @@ func TestRun_Success(t *testing.T) {
 		Port:     "50053",
 		PathCert: certPath,
 		PathKey:  keyPath,
-	}).Times(4)
+	}).Times(5)
 
-	mockApp.EXPECT().GetLogger().Return(mockLogger).Times(3)
+	mockApp.EXPECT().GetLogger().Return(mockLogger).Times(4)
+	mockApp.EXPECT().GetKeySet().Times(2)
+	mockApp.EXPECT().GetService().Times(2)
 	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).Times(1)
 
 	go func() {
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// GetDataHistory возвращает предыдущие состояния данных с указанным ID текущего пользователя, начиная с последнего.
+func (s *gophKeeperServer) GetDataHistory(ctx context.Context, req *proto.GetDataHistoryRequest) (*proto.GetDataHistoryResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if err := validateDataID(req.DataId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	history, err := s.server.GetService().GetDataHistory(ctx, req.DataId, principal.UserID)
+	if errors.Is(err, utils.ErrUserDataNotFound) {
+		return nil, status.Errorf(codes.NotFound, "данные с ID %s не найдены", req.DataId)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get data history: %v", err)
+	}
+
+	items, err := toProtoDataItems(history)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.GetDataHistoryResponse{Revisions: items}, nil
+}
+
+// RestoreRevision возвращает данные с указанным ID текущего пользователя к состоянию с указанной ревизией.
+// Возвращает данные после отката с новыми ревизией и версией.
+func (s *gophKeeperServer) RestoreRevision(ctx context.Context, req *proto.RestoreRevisionRequest) (*proto.RestoreRevisionResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if err := validateDataID(req.DataId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	data, err := s.server.GetService().RestoreRevision(ctx, req.DataId, principal.UserID, req.Revision, req.ExpectedVersion)
+	if err != nil {
+		var conflict *utils.VersionConflictError
+		switch {
+		case errors.As(err, &conflict):
+			return nil, conflictStatus(req.DataId, conflict)
+		case errors.Is(err, utils.ErrUserDataNotFound):
+			return nil, status.Errorf(codes.NotFound, "ревизия %d данных с ID %s не найдена", req.Revision, req.DataId)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to restore revision: %v", err)
+		}
+	}
+
+	items, err := toProtoDataItems([]models.Data{*data})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.RestoreRevisionResponse{Data: items[0]}, nil
+}
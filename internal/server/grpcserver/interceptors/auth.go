@@ -18,95 +18,166 @@ import (
 // Claims представляет собой структуру для хранения информации о пользователе в JWT.
 type Claims struct {
 	jwt.RegisteredClaims
-	User string
+	UserID    int64 `json:"uid,omitempty"` // неизменяемый ID пользователя
+	User      string
+	SessionID string `json:"sid,omitempty"` // ID сессии, которой выдан токен
 }
 
 const (
-	// JwtSecret используется для подписи токенов JWT.
-	JwtSecret = "JWT_SECRET"
-
-	// TokenExp указывает время истечения токена (24 часа).
-	TokenExp = time.Hour * 24
+	// AccessTokenExp указывает время истечения токена доступа (15 минут).
+	// После этого клиент получает новый токен доступа по токену обновления.
+	AccessTokenExp = time.Minute * 15
 
 	// BearerSchema - схема авторизации, ожидаемая в заголовке Authorization.
 	BearerSchema = "Bearer "
 )
 
-// CreateToken создает новый JWT токен для пользователя с указанным именем.
-func CreateToken(user string) (string, error) {
-	claims := Claims{
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExp)),
-		},
-		user,
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString([]byte(JwtSecret))
-	if err != nil {
-		return "", err
-	}
-	return tokenString, nil
-}
-
-// VerifyToken проверяет и расшифровывает JWT токен.
-func VerifyToken(tokenString string) (string, error) {
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
-			return []byte(JwtSecret), nil
-		})
-	if err != nil {
-		return "", fmt.Errorf("error on parsing token: %w", err)
-	}
-
-	if !token.Valid {
-		return "", fmt.Errorf("token is not valid")
-	}
+// sessionCheckInterval - период, с которым у открытого потока повторно проверяется, что его сессия не отозвана.
+var sessionCheckInterval = time.Minute
 
-	fmt.Println("Token is valid")
-	return claims.User, nil
+// SessionChecker проверяет, что сессия, которой выдан токен, не отозвана.
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
 }
 
-// AuthInterceptor перехватывает gRPC-запросы и проверяет токен
-func AuthInterceptor() grpc.UnaryServerInterceptor {
+// AuthInterceptor перехватывает gRPC-запросы, проверяет токен ключами из набора keys
+// и то, что сессия токена не отозвана.
+func AuthInterceptor(keys *KeySet, sessions SessionChecker) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
+		ctx, _, err := authenticate(ctx, info.FullMethod, keys, sessions)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
 
-		if strings.HasSuffix(info.FullMethod, "/Login") || strings.HasSuffix(info.FullMethod, "/Register") {
-			return handler(ctx, req)
+// AuthStreamInterceptor перехватывает потоковые gRPC-запросы, проверяет токен ключами из набора keys
+// и то, что сессия токена не отозвана.
+// Поток закрывается с ошибкой UNAUTHENTICATED, когда истекает его токен или отзывается сессия,
+// поэтому открытый поток не переживает выход пользователя.
+func AuthStreamInterceptor(keys *KeySet, sessions SessionChecker) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, claims, err := authenticate(ss.Context(), info.FullMethod, keys, sessions)
+		if err != nil {
+			return err
+		}
+		if claims == nil {
+			return handler(srv, ss)
 		}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, fmt.Errorf("missing metadata")
+		ctx, stop := watchSession(ctx, claims, sessions)
+		defer stop()
+
+		err = handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+		if cause := context.Cause(ctx); status.Code(cause) == codes.Unauthenticated {
+			return cause
 		}
+		return err
+	}
+}
 
-		authHeaders := md.Get("authorization")
-		if len(authHeaders) == 0 {
-			return nil, fmt.Errorf("authorization token is required")
+// watchSession возвращает контекст потока, который отменяется, когда истекает токен с утверждениями claims
+// или сессия токена оказывается отозванной при периодической проверке.
+// Причина отмены - ошибка UNAUTHENTICATED. Ошибка проверки сессии не закрывает поток.
+func watchSession(ctx context.Context, claims *Claims, sessions SessionChecker) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ticker := time.NewTicker(sessionCheckInterval)
+
+	go func() {
+		defer ticker.Stop()
+
+		var expired <-chan time.Time
+		if claims.ExpiresAt != nil {
+			timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+			defer timer.Stop()
+			expired = timer.C
 		}
 
-		authHeader := authHeaders[0]
-		if !strings.HasPrefix(authHeader, BearerSchema) {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid authorization format")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-expired:
+				cancel(status.Errorf(codes.Unauthenticated, "access token expired"))
+				return
+			case <-ticker.C:
+				active, err := sessions.IsSessionActive(ctx, claims.SessionID)
+				if err == nil && !active {
+					cancel(status.Errorf(codes.Unauthenticated, "session has been revoked, please log in again"))
+					return
+				}
+			}
 		}
+	}()
 
-		tokenString := strings.TrimPrefix(authHeader, BearerSchema)
+	return ctx, func() { cancel(nil) }
+}
 
-		user, err := VerifyToken(tokenString)
-		if err != nil {
-			return nil, status.Errorf(codes.Unauthenticated, "You must be logged in to access this resource")
-		}
+// wrappedStream подменяет контекст потока на контекст с данными аутентифицированного пользователя.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-		ctx = context.WithValue(ctx, models.ContextKeyUser, user)
-		return handler(ctx, req)
+// Context возвращает контекст потока.
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+// authenticate проверяет токен из метаданных запроса и возвращает контекст с models.Principal пользователя
+// и утверждения токена.
+// Методы Login, Register, RefreshToken и GetJWKS не требуют аутентификации: для них утверждения равны nil.
+func authenticate(ctx context.Context, fullMethod string, keys *KeySet, sessions SessionChecker) (context.Context, *Claims, error) {
+	if strings.HasSuffix(fullMethod, "/Login") || strings.HasSuffix(fullMethod, "/Register") ||
+		strings.HasSuffix(fullMethod, "/RefreshToken") || strings.HasSuffix(fullMethod, "/GetJWKS") {
+		return ctx, nil, nil
 	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("missing metadata")
+	}
+
+	authHeaders := md.Get("authorization")
+	if len(authHeaders) == 0 {
+		return nil, nil, fmt.Errorf("authorization token is required")
+	}
+
+	authHeader := authHeaders[0]
+	if !strings.HasPrefix(authHeader, BearerSchema) {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid authorization format")
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, BearerSchema)
+
+	claims, err := keys.VerifyToken(tokenString)
+	if err != nil || claims.UserID == 0 || claims.SessionID == "" {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "You must be logged in to access this resource")
+	}
+
+	active, err := sessions.IsSessionActive(ctx, claims.SessionID)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to check session: %v", err)
+	}
+	if !active {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "session has been revoked, please log in again")
+	}
+
+	return context.WithValue(ctx, models.ContextKeyPrincipal, models.Principal{
+		UserID:    claims.UserID,
+		Username:  claims.User,
+		SessionID: claims.SessionID,
+	}), claims, nil
 }
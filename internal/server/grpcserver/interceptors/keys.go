@@ -0,0 +1,321 @@
+package interceptors
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	// AlgHS256 - симметричная подпись токенов HMAC-SHA256 общим секретом.
+	AlgHS256 = "HS256"
+
+	// AlgEdDSA - асимметричная подпись токенов ключом Ed25519.
+	AlgEdDSA = "EdDSA"
+
+	// ephemeralKeyID - идентификатор случайного ключа, который создается, если ключи не настроены.
+	ephemeralKeyID = "ephemeral"
+)
+
+// SigningKey описывает один ключ из набора: ключ подписи или ключ, которым только проверяются токены.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+
+	secret  []byte
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// NewHMACKey создает ключ HS256 с общим секретом secret.
+func NewHMACKey(id string, secret []byte) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgHS256, secret: secret}
+}
+
+// NewEd25519Key создает ключ EdDSA, которым можно подписывать и проверять токены.
+func NewEd25519Key(id string, private ed25519.PrivateKey) SigningKey {
+	return SigningKey{
+		ID:        id,
+		Algorithm: AlgEdDSA,
+		private:   private,
+		public:    private.Public().(ed25519.PublicKey),
+	}
+}
+
+// NewEd25519PublicKey создает ключ EdDSA, которым только проверяются токены,
+// например выпущенные ключом, выведенным из ротации.
+func NewEd25519PublicKey(id string, public ed25519.PublicKey) SigningKey {
+	return SigningKey{ID: id, Algorithm: AlgEdDSA, public: public}
+}
+
+// canSign сообщает, можно ли подписывать токены этим ключом.
+func (k SigningKey) canSign() bool {
+	switch k.Algorithm {
+	case AlgHS256:
+		return len(k.secret) > 0
+	case AlgEdDSA:
+		return k.private != nil
+	default:
+		return false
+	}
+}
+
+// method возвращает метод подписи jwt для алгоритма ключа.
+func (k SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+// signingKey возвращает ключ в виде, который ожидает jwt при подписи.
+func (k SigningKey) signingKey() interface{} {
+	if k.Algorithm == AlgEdDSA {
+		return k.private
+	}
+	return k.secret
+}
+
+// verificationKey возвращает ключ в виде, который ожидает jwt при проверке подписи.
+func (k SigningKey) verificationKey() interface{} {
+	if k.Algorithm == AlgEdDSA {
+		return k.public
+	}
+	return k.secret
+}
+
+// KeySet - набор ключей токенов. Новые токены подписываются активным ключом,
+// а проверяются любым ключом набора по идентификатору kid из заголовка токена.
+// Благодаря этому ключ можно сменить, не разлогинивая пользователей:
+// новый ключ становится активным, а старый остается в наборе, пока не истекут выпущенные им токены.
+type KeySet struct {
+	active SigningKey
+	keys   map[string]SigningKey
+}
+
+// NewKeySet создает набор ключей keys, в котором токены подписываются ключом activeID.
+func NewKeySet(activeID string, keys ...SigningKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]SigningKey, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("key ID is required")
+		}
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		if key.Algorithm != AlgHS256 && key.Algorithm != AlgEdDSA {
+			return nil, fmt.Errorf("key %q: unsupported algorithm %q", key.ID, key.Algorithm)
+		}
+		if key.Algorithm == AlgHS256 && len(key.secret) == 0 {
+			return nil, fmt.Errorf("key %q: secret is required", key.ID)
+		}
+		if key.Algorithm == AlgEdDSA && len(key.public) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q: invalid Ed25519 key", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeID)
+	}
+	if !active.canSign() {
+		return nil, fmt.Errorf("active key %q cannot sign tokens", activeID)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+// GenerateKeySet создает набор из одного случайного ключа HS256.
+// Токены, подписанные им, перестают действовать после перезапуска сервера.
+func GenerateKeySet() (*KeySet, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return NewKeySet(ephemeralKeyID, NewHMACKey(ephemeralKeyID, secret))
+}
+
+// keyFile - формат файла ключей JWT_KEYS_FILE.
+type keyFile struct {
+	Active string          `json:"active"`
+	Keys   []keyFileRecord `json:"keys"`
+}
+
+// keyFileRecord описывает ключ в файле ключей.
+// Пути к PEM-файлам ключей Ed25519 указываются относительно каталога файла ключей.
+type keyFileRecord struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+// LoadKeySetFile загружает набор ключей из JSON-файла path.
+func LoadKeySetFile(path string) (*KeySet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]SigningKey, 0, len(file.Keys))
+	for _, record := range file.Keys {
+		key, err := record.load(dir)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", record.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(file.Active, keys...)
+}
+
+// load создает ключ по описанию из файла ключей.
+func (r keyFileRecord) load(dir string) (SigningKey, error) {
+	switch r.Algorithm {
+	case AlgHS256:
+		return NewHMACKey(r.ID, []byte(r.Secret)), nil
+	case AlgEdDSA:
+		if r.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(resolvePath(dir, r.PrivateKeyFile))
+			if err != nil {
+				return SigningKey{}, fmt.Errorf("failed to read private key: %w", err)
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return SigningKey{}, fmt.Errorf("failed to parse private key: %w", err)
+			}
+			return NewEd25519Key(r.ID, private.(ed25519.PrivateKey)), nil
+		}
+		if r.PublicKeyFile != "" {
+			pem, err := os.ReadFile(resolvePath(dir, r.PublicKeyFile))
+			if err != nil {
+				return SigningKey{}, fmt.Errorf("failed to read public key: %w", err)
+			}
+			public, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return SigningKey{}, fmt.Errorf("failed to parse public key: %w", err)
+			}
+			return NewEd25519PublicKey(r.ID, public.(ed25519.PublicKey)), nil
+		}
+		return SigningKey{}, fmt.Errorf("private_key_file or public_key_file is required")
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported algorithm %q", r.Algorithm)
+	}
+}
+
+// resolvePath возвращает путь name относительно каталога dir, если он не абсолютный.
+func resolvePath(dir, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// CreateToken создает новый токен доступа пользователя userID с именем user в сессии sessionID,
+// подписанный активным ключом набора. Каждый токен получает собственный ID (jti).
+func (k *KeySet) CreateToken(userID int64, user string, sessionID string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenExp)),
+		},
+		UserID:    userID,
+		User:      user,
+		SessionID: sessionID,
+	}
+	token := jwt.NewWithClaims(k.active.method(), claims)
+	token.Header["kid"] = k.active.ID
+
+	tokenString, err := token.SignedString(k.active.signingKey())
+	if err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// VerifyToken проверяет JWT токен ключом, указанным в его заголовке kid, и возвращает его утверждения.
+func (k *KeySet) VerifyToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, k.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing token: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("token is not valid")
+	}
+
+	return claims, nil
+}
+
+// keyFunc выбирает ключ проверки токена по kid и сверяет алгоритм подписи с алгоритмом ключа.
+func (k *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+
+	return key.verificationKey(), nil
+}
+
+// JSONWebKey - открытый ключ проверки токенов в формате JWK (RFC 8037).
+type JSONWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	X         string `json:"x"`
+}
+
+// PublicKeys возвращает открытые ключи Ed25519 набора, отсортированные по kid,
+// чтобы сторонние сервисы могли проверять токены сами.
+// Секреты HS256 не публикуются.
+func (k *KeySet) PublicKeys() []JSONWebKey {
+	keys := make([]JSONWebKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.Algorithm != AlgEdDSA {
+			continue
+		}
+		keys = append(keys, JSONWebKey{
+			KeyID:     key.ID,
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			Algorithm: AlgEdDSA,
+			Use:       "sig",
+			X:         base64.RawURLEncoding.EncodeToString(key.public),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+
+	return keys
+}
@@ -0,0 +1,244 @@
+package interceptors
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_CreateToken(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  SigningKey
+		alg  string
+	}{
+		{name: "HS256", key: NewHMACKey("hmac", []byte("secret")), alg: AlgHS256},
+		{name: "EdDSA", key: NewEd25519Key("ed", private), alg: AlgEdDSA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeySet(tt.key.ID, tt.key)
+			require.NoError(t, err)
+
+			token, err := keys.CreateToken(1, "testuser", "session")
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.key.ID, parsed.Header["kid"])
+			assert.Equal(t, tt.alg, parsed.Header["alg"])
+
+			claims, err := keys.VerifyToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), claims.UserID)
+			assert.Equal(t, "testuser", claims.User)
+			assert.Equal(t, "session", claims.SessionID)
+			assert.NotEmpty(t, claims.ID)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	_, oldPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	before, err := NewKeySet("old", NewEd25519Key("old", oldPrivate))
+	require.NoError(t, err)
+	oldToken, err := before.CreateToken(1, "testuser", "session")
+	require.NoError(t, err)
+
+	after, err := NewKeySet("new",
+		NewEd25519Key("new", newPrivate),
+		NewEd25519PublicKey("old", oldPrivate.Public().(ed25519.PublicKey)),
+	)
+	require.NoError(t, err)
+
+	t.Run("token of retired key is still accepted", func(t *testing.T) {
+		claims, err := after.VerifyToken(oldToken)
+		require.NoError(t, err)
+		assert.Equal(t, "testuser", claims.User)
+	})
+
+	t.Run("token of removed key is rejected", func(t *testing.T) {
+		newToken, err := after.CreateToken(1, "testuser", "session")
+		require.NoError(t, err)
+
+		_, err = before.VerifyToken(newToken)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown key ID "new"`)
+	})
+}
+
+func TestKeySet_VerifyToken(t *testing.T) {
+	keys, err := NewKeySet("test", NewHMACKey("test", []byte("secret")))
+	require.NoError(t, err)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		User:             "testuser",
+	}
+
+	t.Run("token without key ID", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = keys.VerifyToken(token)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "token has no key ID")
+	})
+
+	t.Run("token signed with another secret", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString([]byte("JWT_SECRET"))
+		require.NoError(t, err)
+
+		_, err = keys.VerifyToken(signed)
+		require.Error(t, err)
+	})
+
+	t.Run("algorithm does not match key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = keys.VerifyToken(signed)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected signing method")
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := claims
+		expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, expired)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = keys.VerifyToken(signed)
+		require.Error(t, err)
+	})
+}
+
+func TestNewKeySet_Errors(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	public := NewEd25519PublicKey("public", private.Public().(ed25519.PublicKey))
+
+	tests := []struct {
+		name    string
+		active  string
+		keys    []SigningKey
+		wantErr string
+	}{
+		{name: "unknown active key", active: "missing", keys: []SigningKey{NewHMACKey("a", []byte("s"))}, wantErr: "active key \"missing\" not found"},
+		{name: "verification-only active key", active: "public", keys: []SigningKey{public}, wantErr: "cannot sign tokens"},
+		{name: "duplicate key ID", active: "a", keys: []SigningKey{NewHMACKey("a", []byte("s")), NewHMACKey("a", []byte("t"))}, wantErr: "duplicate key ID"},
+		{name: "empty secret", active: "a", keys: []SigningKey{NewHMACKey("a", nil)}, wantErr: "secret is required"},
+		{name: "empty key ID", active: "", keys: []SigningKey{NewHMACKey("", []byte("s"))}, wantErr: "key ID is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(tt.active, tt.keys...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadKeySetFile(t *testing.T) {
+	dir := t.TempDir()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600))
+
+	_, retired, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(retired.Public())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "retired.pub.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600))
+
+	path := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"active": "current",
+		"keys": [
+			{"kid": "current", "alg": "EdDSA", "private_key_file": "current.pem"},
+			{"kid": "retired", "alg": "EdDSA", "public_key_file": "retired.pub.pem"},
+			{"kid": "legacy", "alg": "HS256", "secret": "secret"}
+		]
+	}`), 0600))
+
+	keys, err := LoadKeySetFile(path)
+	require.NoError(t, err)
+
+	token, err := keys.CreateToken(1, "testuser", "session")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "current", parsed.Header["kid"])
+
+	retiredKeys, err := NewKeySet("retired", NewEd25519Key("retired", retired))
+	require.NoError(t, err)
+	retiredToken, err := retiredKeys.CreateToken(1, "testuser", "session")
+	require.NoError(t, err)
+	claims, err := keys.VerifyToken(retiredToken)
+	require.NoError(t, err)
+	assert.Equal(t, "testuser", claims.User)
+
+	jwks := keys.PublicKeys()
+	require.Len(t, jwks, 2)
+	assert.Equal(t, "current", jwks[0].KeyID)
+	assert.Equal(t, "retired", jwks[1].KeyID)
+
+	t.Run("missing key file", func(t *testing.T) {
+		_, err := LoadKeySetFile(filepath.Join(dir, "missing.json"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read key file")
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.json")
+		require.NoError(t, os.WriteFile(bad, []byte(`{"active":"a","keys":[{"kid":"a","alg":"RS256"}]}`), 0600))
+
+		_, err := LoadKeySetFile(bad)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported algorithm "RS256"`)
+	})
+}
+
+func TestGenerateKeySet(t *testing.T) {
+	first, err := GenerateKeySet()
+	require.NoError(t, err)
+	second, err := GenerateKeySet()
+	require.NoError(t, err)
+
+	token, err := first.CreateToken(1, "testuser", "session")
+	require.NoError(t, err)
+
+	_, err = first.VerifyToken(token)
+	require.NoError(t, err)
+	_, err = second.VerifyToken(token)
+	require.Error(t, err)
+}
@@ -48,3 +48,26 @@ func LoggingInterceptor(log logging.ILogger) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+// LoggingStreamInterceptor - интерцептор для логирования потоковых запросов.
+func LoggingStreamInterceptor(log logging.ILogger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		log.Info("gRPC stream %s called", info.FullMethod)
+
+		err := handler(srv, ss)
+
+		duration := time.Since(start)
+
+		if err != nil {
+			st, _ := status.FromError(err)
+			log.Info("gRPC stream %s failed with code %s: %s, "+
+				"duration: %s", info.FullMethod, st.Code(), st.Message(), duration)
+			return err
+		}
+
+		log.Info("gRPC stream %s completed successfully, duration: %s", info.FullMethod, duration)
+
+		return nil
+	}
+}
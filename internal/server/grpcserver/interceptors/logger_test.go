@@ -86,3 +86,32 @@ func TestLoggingInterceptor_Error(t *testing.T) {
 
 	assert.True(t, duration > 0)
 }
+
+func TestLoggingStreamInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mlogger.NewMockILogger(ctrl)
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Stream"}
+
+	gomock.InOrder(
+		mockLogger.EXPECT().Info("gRPC stream %s called", "/test.Stream").Times(1),
+		mockLogger.EXPECT().Info("gRPC stream %s completed successfully, duration: %s",
+			"/test.Stream", gomock.Any()).Times(1),
+		mockLogger.EXPECT().Info("gRPC stream %s called", "/test.Stream").Times(1),
+		mockLogger.EXPECT().Info("gRPC stream %s failed with code %s: %s, duration: %s",
+			"/test.Stream", codes.Internal, "internal error", gomock.Any()).Times(1),
+	)
+
+	interceptor := LoggingStreamInterceptor(mockLogger)
+
+	err := interceptor(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = interceptor(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return status.Error(codes.Internal, "internal error")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
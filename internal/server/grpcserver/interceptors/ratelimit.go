@@ -0,0 +1,251 @@
+package interceptors
+
+import (
+	"context"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// sweepInterval - как часто из памяти удаляются записи о клиентах, которые давно не обращались к серверу.
+const sweepInterval = time.Minute
+
+// RateLimitConfig задает ограничения частоты запросов к методам без аутентификации
+// и защиту входа от подбора пароля. Нулевое значение поля отключает соответствующее ограничение.
+type RateLimitConfig struct {
+	IPRate       float64       // запросов в секунду с одного IP к Login, Register и RefreshToken
+	IPBurst      int           // сколько таких запросов с одного IP допускается подряд
+	UserRate     float64       // попыток входа в секунду под одним именем пользователя
+	UserBurst    int           // сколько попыток входа под одним именем допускается подряд
+	MaxFailures  int           // неудачных попыток входа подряд, после которых вход блокируется
+	FailureDelay time.Duration // пауза после первой неудачной попытки, удваивается с каждой следующей
+	Lockout      time.Duration // время блокировки входа; столько же хранится счетчик неудачных попыток
+}
+
+// RateLimiter хранит состояние ограничений частоты запросов для каждого IP и имени пользователя.
+type RateLimiter struct {
+	conf RateLimitConfig
+	now  func() time.Time
+
+	mu        sync.Mutex
+	ips       map[string]*rate.Limiter
+	users     map[string]*rate.Limiter
+	failures  map[string]*loginFailures
+	lastSweep time.Time
+}
+
+// loginFailures - неудачные попытки входа под одним именем пользователя.
+type loginFailures struct {
+	count        int       // неудачных попыток подряд
+	last         time.Time // время последней неудачной попытки
+	blockedUntil time.Time // до этого времени попытки входа отклоняются
+}
+
+// NewRateLimiter создает ограничитель частоты запросов с настройками conf.
+func NewRateLimiter(conf RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		conf:     conf,
+		now:      time.Now,
+		ips:      make(map[string]*rate.Limiter),
+		users:    make(map[string]*rate.Limiter),
+		failures: make(map[string]*loginFailures),
+	}
+}
+
+// RateLimitInterceptor ограничивает частоту запросов к методам Login, Register и RefreshToken с одного IP,
+// частоту попыток входа под одним именем пользователя и блокирует вход после серии неудачных попыток.
+// Остальные методы требуют токен доступа и не ограничиваются.
+func RateLimitInterceptor(limiter *RateLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		if method != "Login" && method != "Register" && method != "RefreshToken" {
+			return handler(ctx, req)
+		}
+
+		if !limiter.allowIP(peerIP(ctx)) {
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, try again later")
+		}
+
+		login, ok := req.(*proto.LoginRequest)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if wait := limiter.loginBlocked(login.Username); wait > 0 {
+			return nil, retryLater(wait)
+		}
+		if !limiter.allowUser(login.Username) {
+			return nil, status.Errorf(codes.ResourceExhausted, "too many login attempts, try again later")
+		}
+
+		// Ответ с запросом кода двухфакторной аутентификации не выдает токенов и не сбрасывает неудачные попытки,
+		// иначе чередованием входа без кода и с подобранным кодом можно обойти блокировку.
+		resp, err := handler(ctx, req)
+		switch status.Code(err) {
+		case codes.OK:
+			if loginResp, ok := resp.(*proto.LoginResponse); ok && loginResp.Token != "" {
+				limiter.loginSucceeded(login.Username)
+			}
+		case codes.Unauthenticated:
+			limiter.loginFailed(login.Username)
+		}
+
+		return resp, err
+	}
+}
+
+// retryLater возвращает ошибку RESOURCE_EXHAUSTED с временем, через которое можно повторить вход.
+func retryLater(wait time.Duration) error {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	st := status.Newf(codes.ResourceExhausted, "too many failed login attempts, try again in %d s", seconds)
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Duration(seconds) * time.Second),
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// peerIP возвращает IP клиента, от которого пришел запрос.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// allowIP сообщает, не превышен ли лимит запросов с IP ip.
+func (l *RateLimiter) allowIP(ip string) bool {
+	return l.allow(l.ips, ip, l.conf.IPRate, l.conf.IPBurst)
+}
+
+// allowUser сообщает, не превышен ли лимит попыток входа под именем username.
+func (l *RateLimiter) allowUser(username string) bool {
+	return l.allow(l.users, username, l.conf.UserRate, l.conf.UserBurst)
+}
+
+// allow забирает токен из корзины key в buckets, создавая корзину при первом обращении.
+func (l *RateLimiter) allow(buckets map[string]*rate.Limiter, key string, limit float64, burst int) bool {
+	if limit <= 0 || burst <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limit), burst)
+		buckets[key] = bucket
+	}
+	return bucket.AllowN(now, 1)
+}
+
+// loginBlocked возвращает, сколько осталось ждать до следующей попытки входа под именем username.
+func (l *RateLimiter) loginBlocked(username string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, ok := l.failures[username]
+	if !ok {
+		return 0
+	}
+
+	wait := f.blockedUntil.Sub(l.now())
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// loginFailed учитывает неудачную попытку входа под именем username: следующая попытка
+// откладывается на FailureDelay, удваиваемую с каждой неудачей, а после MaxFailures неудач подряд
+// вход блокируется на Lockout. Неудачи забываются, если их не было дольше Lockout.
+func (l *RateLimiter) loginFailed(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	f, ok := l.failures[username]
+	if !ok || now.Sub(f.last) > l.conf.Lockout {
+		f = &loginFailures{}
+		l.failures[username] = f
+	}
+	f.count++
+	f.last = now
+
+	if l.conf.MaxFailures > 0 && f.count >= l.conf.MaxFailures {
+		f.count = 0
+		f.blockedUntil = now.Add(l.conf.Lockout)
+		return
+	}
+
+	if l.conf.FailureDelay > 0 {
+		delay := l.conf.FailureDelay << min(f.count-1, 16)
+		if l.conf.Lockout > 0 && delay > l.conf.Lockout {
+			delay = l.conf.Lockout
+		}
+		f.blockedUntil = now.Add(delay)
+	}
+}
+
+// loginSucceeded сбрасывает счетчик неудачных попыток входа под именем username.
+func (l *RateLimiter) loginSucceeded(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, username)
+}
+
+// sweep не чаще раза в sweepInterval удаляет заполненные корзины, которые не отличаются от новых,
+// и неудачные попытки входа, которые уже не учитываются. Вызывается под мьютексом.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for _, buckets := range []map[string]*rate.Limiter{l.ips, l.users} {
+		for key, bucket := range buckets {
+			if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+				delete(buckets, key)
+			}
+		}
+	}
+
+	for username, f := range l.failures {
+		if now.After(f.blockedUntil) && now.Sub(f.last) > l.conf.Lockout {
+			delete(l.failures, username)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// testClock - управляемые часы для ограничителя частоты запросов.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestLimiter создает ограничитель с настройками conf и управляемыми часами.
+func newTestLimiter(conf RateLimitConfig) (*RateLimiter, *testClock) {
+	clock := &testClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewRateLimiter(conf)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+// peerContext возвращает контекст запроса от клиента с адресом ip.
+func peerContext(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000},
+	})
+}
+
+var (
+	loginInfo    = &grpc.UnaryServerInfo{FullMethod: "/keeper.GophKeeper/Login"}
+	registerInfo = &grpc.UnaryServerInfo{FullMethod: "/keeper.GophKeeper/Register"}
+	listInfo     = &grpc.UnaryServerInfo{FullMethod: "/keeper.GophKeeper/ListData"}
+)
+
+func okHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return "success", nil
+}
+
+func loginHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return &proto.LoginResponse{Token: "Bearer token"}, nil
+}
+
+func totpRequiredHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return &proto.LoginResponse{TotpRequired: true, Message: "two-factor code required"}, nil
+}
+
+func failedTOTPHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, status.Errorf(codes.Unauthenticated, "invalid two-factor code")
+}
+
+func failedLoginHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, status.Errorf(codes.Unauthenticated, "invalid username or password")
+}
+
+func TestRateLimitInterceptor_IP(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{IPRate: 1, IPBurst: 2})
+	interceptor := RateLimitInterceptor(limiter)
+	ctx := peerContext("10.0.0.1")
+
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(ctx, &proto.RegisterRequest{}, registerInfo, okHandler)
+		require.NoError(t, err)
+	}
+
+	_, err := interceptor(ctx, &proto.RegisterRequest{}, registerInfo, okHandler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	t.Run("other IP is not limited", func(t *testing.T) {
+		_, err := interceptor(peerContext("10.0.0.2"), &proto.RegisterRequest{}, registerInfo, okHandler)
+		assert.NoError(t, err)
+	})
+
+	t.Run("authenticated methods are not limited", func(t *testing.T) {
+		resp, err := interceptor(ctx, &proto.ListDataRequest{}, listInfo, okHandler)
+		assert.NoError(t, err)
+		assert.Equal(t, "success", resp)
+	})
+
+	t.Run("bucket refills over time", func(t *testing.T) {
+		clock.Advance(time.Second)
+
+		_, err := interceptor(ctx, &proto.RegisterRequest{}, registerInfo, okHandler)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRateLimitInterceptor_Username(t *testing.T) {
+	limiter, _ := newTestLimiter(RateLimitConfig{UserRate: 0.1, UserBurst: 2})
+	interceptor := RateLimitInterceptor(limiter)
+
+	// Попытки входа под одним именем с разных IP учитываются вместе.
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		_, err := interceptor(peerContext(ip), &proto.LoginRequest{Username: "alice"}, loginInfo, okHandler)
+		require.NoError(t, err, "attempt %d", i)
+	}
+
+	_, err := interceptor(peerContext("10.0.0.3"), &proto.LoginRequest{Username: "alice"}, loginInfo, okHandler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = interceptor(peerContext("10.0.0.3"), &proto.LoginRequest{Username: "bob"}, loginInfo, okHandler)
+	assert.NoError(t, err)
+}
+
+func TestRateLimitInterceptor_FailedLogins(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{
+		MaxFailures:  3,
+		FailureDelay: time.Second,
+		Lockout:      time.Minute,
+	})
+	interceptor := RateLimitInterceptor(limiter)
+	ctx := peerContext("10.0.0.1")
+	req := &proto.LoginRequest{Username: "alice", Password: "wrong"}
+
+	t.Run("delay doubles after each failure", func(t *testing.T) {
+		_, err := interceptor(ctx, req, loginInfo, failedLoginHandler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = interceptor(ctx, req, loginInfo, okHandler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Contains(t, err.Error(), "try again in 1 s")
+
+		clock.Advance(time.Second)
+		_, err = interceptor(ctx, req, loginInfo, failedLoginHandler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = interceptor(ctx, req, loginInfo, okHandler)
+		assert.Contains(t, err.Error(), "try again in 2 s")
+	})
+
+	t.Run("account is locked after max failures", func(t *testing.T) {
+		clock.Advance(2 * time.Second)
+		_, err := interceptor(ctx, req, loginInfo, failedLoginHandler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		clock.Advance(30 * time.Second)
+		_, err = interceptor(ctx, req, loginInfo, okHandler)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Contains(t, err.Error(), "try again in 30 s")
+
+		var retry *errdetails.RetryInfo
+		for _, detail := range status.Convert(err).Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok {
+				retry = info
+			}
+		}
+		require.NotNil(t, retry)
+		assert.Equal(t, 30*time.Second, retry.RetryDelay.AsDuration())
+
+		// Блокировка действует и на другие IP.
+		_, err = interceptor(peerContext("10.0.0.2"), req, loginInfo, okHandler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("other users are not locked", func(t *testing.T) {
+		_, err := interceptor(ctx, &proto.LoginRequest{Username: "bob"}, loginInfo, okHandler)
+		assert.NoError(t, err)
+	})
+
+	t.Run("successful login resets failures", func(t *testing.T) {
+		clock.Advance(30 * time.Second)
+		_, err := interceptor(ctx, req, loginInfo, loginHandler)
+		require.NoError(t, err)
+
+		_, err = interceptor(ctx, req, loginInfo, failedLoginHandler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = interceptor(ctx, req, loginInfo, okHandler)
+		assert.Contains(t, err.Error(), "try again in 1 s")
+	})
+
+	t.Run("internal errors are not counted", func(t *testing.T) {
+		clock.Advance(time.Minute + time.Second)
+		internal := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Errorf(codes.Internal, "db error")
+		}
+
+		for i := 0; i < 5; i++ {
+			_, err := interceptor(ctx, req, loginInfo, internal)
+			assert.Equal(t, codes.Internal, status.Code(err))
+		}
+	})
+}
+
+func TestRateLimitInterceptor_TOTPRequiredDoesNotResetFailures(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{
+		MaxFailures:  3,
+		FailureDelay: time.Second,
+		Lockout:      time.Minute,
+	})
+	interceptor := RateLimitInterceptor(limiter)
+	ctx := peerContext("10.0.0.1")
+	withoutCode := &proto.LoginRequest{Username: "alice", Password: "password"}
+	withCode := &proto.LoginRequest{Username: "alice", Password: "password", TotpCode: "000000"}
+
+	// Верный пароль без кода и подобранный код чередуются: запрос кода не считается успешным входом.
+	for i := 0; i < 3; i++ {
+		resp, err := interceptor(ctx, withoutCode, loginInfo, totpRequiredHandler)
+		require.NoError(t, err, "attempt %d", i)
+		assert.True(t, resp.(*proto.LoginResponse).TotpRequired)
+
+		_, err = interceptor(ctx, withCode, loginInfo, failedTOTPHandler)
+		require.Equal(t, codes.Unauthenticated, status.Code(err), "attempt %d", i)
+
+		clock.Advance(time.Duration(1<<i) * time.Second)
+	}
+
+	_, err := interceptor(ctx, withoutCode, loginInfo, totpRequiredHandler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "too many failed login attempts")
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	limiter, clock := newTestLimiter(RateLimitConfig{
+		IPRate:       1,
+		IPBurst:      1,
+		MaxFailures:  5,
+		FailureDelay: time.Second,
+		Lockout:      time.Minute,
+	})
+
+	assert.True(t, limiter.allowIP("10.0.0.1"))
+	limiter.loginFailed("alice")
+	assert.Len(t, limiter.ips, 1)
+	assert.Len(t, limiter.failures, 1)
+
+	clock.Advance(2 * time.Minute)
+	assert.True(t, limiter.allowIP("10.0.0.2"))
+
+	assert.NotContains(t, limiter.ips, "10.0.0.1")
+	assert.Empty(t, limiter.failures)
+}
+
+func TestPeerIP(t *testing.T) {
+	assert.Equal(t, "10.0.0.1", peerIP(peerContext("10.0.0.1")))
+	assert.Equal(t, "", peerIP(context.Background()))
+}
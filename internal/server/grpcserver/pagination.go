@@ -0,0 +1,90 @@
+package grpcserver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+const (
+	defaultPageSize = 50  // размер страницы, если клиент его не указал
+	maxPageSize     = 500 // максимальный размер страницы
+)
+
+// toDataFilter формирует фильтр выборки данных из запроса ListData.
+func toDataFilter(req *proto.ListDataRequest) (models.DataFilter, error) {
+	filter := models.DataFilter{
+		Limit:    pageLimit(req.PageSize),
+		Metadata: req.Metadata,
+	}
+
+	afterID, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return models.DataFilter{}, err
+	}
+	filter.AfterID = afterID
+
+	if req.DataType != proto.DataType_UNKNOWN {
+		dataType, err := models.GetModelType(req.DataType)
+		if err != nil {
+			return models.DataFilter{}, fmt.Errorf("invalid data type: %w", err)
+		}
+		filter.DataType = dataType
+	}
+
+	if req.UpdatedAfter != "" {
+		updatedAfter, err := time.Parse(time.RFC3339, req.UpdatedAfter)
+		if err != nil {
+			return models.DataFilter{}, fmt.Errorf("invalid updated_after: %w", err)
+		}
+		filter.UpdatedAfter = updatedAfter
+	}
+
+	return filter, nil
+}
+
+// pageLimit возвращает размер страницы, запрошенный клиентом, ограниченный maxPageSize.
+// Для неуказанного размера возвращает defaultPageSize.
+func pageLimit(size int32) int {
+	if size <= 0 {
+		return defaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return int(size)
+}
+
+// encodePageToken кодирует ID последней записи страницы в токен следующей страницы.
+// Для пустого ID возвращает пустой токен.
+func encodePageToken(lastID string) string {
+	if lastID == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+// decodePageToken возвращает ID последней записи предыдущей страницы из токена.
+// Пустой токен соответствует первой странице.
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("invalid page token")
+	}
+
+	lastID, err := uuid.Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid page token")
+	}
+
+	return lastID.String(), nil
+}
@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// EnableTOTP обрабатывает gRPC запрос для начала настройки двухфакторной аутентификации.
+// Возвращает секрет и ссылку otpauth:// для приложения-аутентификатора.
+func (s *gophKeeperServer) EnableTOTP(ctx context.Context, req *proto.EnableTOTPRequest) (*proto.EnableTOTPResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	enrollment, err := s.server.GetService().EnableTOTP(ctx, principal.UserID, principal.Username)
+	if err != nil {
+		if errors.Is(err, utils.ErrTOTPEnabled) {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication already enabled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication: %v", err)
+	}
+
+	return &proto.EnableTOTPResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUri: enrollment.URI,
+	}, nil
+}
+
+// ConfirmTOTP обрабатывает gRPC запрос для включения двухфакторной аутентификации по коду из приложения.
+// Возвращает одноразовые коды восстановления.
+func (s *gophKeeperServer) ConfirmTOTP(ctx context.Context, req *proto.ConfirmTOTPRequest) (*proto.ConfirmTOTPResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+	if req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	recoveryCodes, err := s.server.GetService().ConfirmTOTP(ctx, principal.UserID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrInvalidTOTPCode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid two-factor code")
+		case errors.Is(err, utils.ErrTOTPEnabled), errors.Is(err, utils.ErrTOTPNotEnrolled):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to confirm two-factor authentication: %v", err)
+	}
+
+	return &proto.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+		Message:       "Two-factor authentication enabled",
+	}, nil
+}
+
+// DisableTOTP обрабатывает gRPC запрос для выключения двухфакторной аутентификации.
+func (s *gophKeeperServer) DisableTOTP(ctx context.Context, req *proto.DisableTOTPRequest) (*proto.DisableTOTPResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	err := s.server.GetService().DisableTOTP(ctx, principal.UserID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrInvalidTOTPCode):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid two-factor code")
+		case errors.Is(err, utils.ErrTOTPNotEnrolled):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to disable two-factor authentication: %v", err)
+	}
+
+	return &proto.DisableTOTPResponse{Message: "Two-factor authentication disabled"}, nil
+}
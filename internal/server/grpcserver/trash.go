@@ -0,0 +1,87 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// ListTrash возвращает данные текущего пользователя, находящиеся в корзине.
+func (s *gophKeeperServer) ListTrash(ctx context.Context, _ *proto.ListTrashRequest) (*proto.ListTrashResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	data, err := s.server.GetService().ListTrash(ctx, principal.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list trash: %v", err)
+	}
+
+	items, err := toProtoDataItems(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.ListTrashResponse{Data: items}, nil
+}
+
+// RestoreData восстанавливает данные с указанным ID из корзины текущего пользователя.
+// Возвращает восстановленные данные с новыми ревизией и версией.
+func (s *gophKeeperServer) RestoreData(ctx context.Context, req *proto.RestoreDataRequest) (*proto.RestoreDataResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if err := validateDataID(req.DataId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	data, err := s.server.GetService().RestoreData(ctx, req.DataId, principal.UserID)
+	if errors.Is(err, utils.ErrUserDataNotFound) {
+		return nil, status.Errorf(codes.NotFound, "данные с ID %s не найдены в корзине", req.DataId)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to restore data: %v", err)
+	}
+
+	items, err := toProtoDataItems([]models.Data{*data})
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.RestoreDataResponse{Data: items[0]}, nil
+}
+
+// PurgeData окончательно удаляет данные с указанным ID из корзины текущего пользователя.
+// Если ID равен 0, корзина очищается полностью. Возвращает количество удаленных записей.
+func (s *gophKeeperServer) PurgeData(ctx context.Context, req *proto.PurgeDataRequest) (*proto.PurgeDataResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	if req.DataId != "" {
+		if err := validateDataID(req.DataId); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
+
+	purged, err := s.server.GetService().PurgeData(ctx, principal.UserID, req.DataId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to purge data: %v", err)
+	}
+
+	if req.DataId != "" && purged == 0 {
+		return nil, status.Errorf(codes.NotFound, "данные с ID %s не найдены в корзине", req.DataId)
+	}
+
+	return &proto.PurgeDataResponse{Purged: purged}, nil
+}
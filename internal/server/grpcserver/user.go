@@ -43,6 +43,8 @@ func (s *gophKeeperServer) Register(ctx context.Context, req *proto.RegisterRequ
 }
 
 // Login обрабытвает gRPC запрос для входа пользователя.
+// Если у пользователя включена двухфакторная аутентификация, а код не передан,
+// возвращается ответ без токенов с признаком totp_required.
 func (s *gophKeeperServer) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
 	user := &models.User{
 		Username: req.Username,
@@ -51,19 +53,80 @@ func (s *gophKeeperServer) Login(ctx context.Context, req *proto.LoginRequest) (
 	if len(user.Username) == 0 && len(user.Password) == 0 {
 		return nil, status.Errorf(codes.InvalidArgument, "empty credentials")
 	}
-	token, err := s.server.GetService().LoginUser(ctx, user)
+	tokens, err := s.server.GetService().LoginUser(ctx, user, req.TotpCode)
+	if errors.Is(err, utils.ErrInvalidCredentials) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid username or password")
+	}
+	if errors.Is(err, utils.ErrTOTPRequired) {
+		return &proto.LoginResponse{TotpRequired: true, Message: "two-factor code required"}, nil
+	}
+	if errors.Is(err, utils.ErrInvalidTOTPCode) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid two-factor code")
+	}
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "failed to login: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to login: %v", err)
 	}
 
-	userID, err := s.server.GetService().GetUserIDByUsername(ctx, user.Username)
+	return &proto.LoginResponse{
+		UserId:       tokens.UserID,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		Message:      "Login successful",
+	}, nil
+}
+
+// RefreshToken обрабатывает gRPC запрос для получения нового токена доступа по токену обновления.
+// Токен обновления при этом заменяется новым.
+func (s *gophKeeperServer) RefreshToken(ctx context.Context, req *proto.RefreshTokenRequest) (*proto.RefreshTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "refresh token is required")
+	}
+
+	tokens, err := s.server.GetService().RefreshToken(ctx, req.RefreshToken)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get user ID: %v", err)
+		if errors.Is(err, utils.ErrSessionNotFound) || errors.Is(err, utils.ErrTokenReused) {
+			return nil, status.Errorf(codes.Unauthenticated, "refresh token is invalid or revoked, please log in again")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to refresh token: %v", err)
 	}
 
-	return &proto.LoginResponse{
-		UserId:  userID,
-		Token:   token,
-		Message: "Login successful",
+	return &proto.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
 	}, nil
 }
+
+// Logout обрабатывает gRPC запрос для выхода: сессия текущего токена отзывается.
+func (s *gophKeeperServer) Logout(ctx context.Context, req *proto.LogoutRequest) (*proto.LogoutResponse, error) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok || principal.SessionID == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "session not found in context")
+	}
+
+	if err := s.server.GetService().Logout(ctx, principal.SessionID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to logout: %v", err)
+	}
+
+	return &proto.LogoutResponse{Message: "Logout successful"}, nil
+}
+
+// GetJWKS возвращает открытые ключи, которыми проверяются токены, подписанные ключами Ed25519.
+func (s *gophKeeperServer) GetJWKS(ctx context.Context, req *proto.GetJWKSRequest) (*proto.GetJWKSResponse, error) {
+	keys := s.server.GetKeySet().PublicKeys()
+
+	resp := &proto.GetJWKSResponse{Keys: make([]*proto.JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, &proto.JSONWebKey{
+			Kid: key.KeyID,
+			Kty: key.KeyType,
+			Crv: key.Curve,
+			Alg: key.Algorithm,
+			Use: key.Use,
+			X:   key.X,
+		})
+	}
+
+	return resp, nil
+}
@@ -2,17 +2,23 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 	amock "github.com/Sofja96/GophKeeper.git/internal/server/app/mocks"
+	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	smock "github.com/Sofja96/GophKeeper.git/internal/server/service/mocks"
 	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 	"github.com/Sofja96/GophKeeper.git/proto"
@@ -23,6 +29,16 @@ type mocks struct {
 	service *smock.MockService
 }
 
+// withPrincipal возвращает контекст с пользователем testuser с ID userID в сессии session,
+// как его сохраняет AuthInterceptor.
+func withPrincipal(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, models.ContextKeyPrincipal, models.Principal{
+		UserID:    userID,
+		Username:  "testuser",
+		SessionID: "session",
+	})
+}
+
 func TestRegister(t *testing.T) {
 	type (
 		args struct {
@@ -162,11 +178,13 @@ func TestLogin(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				m.app.EXPECT().GetService().Return(m.service).Times(2)
-				m.service.EXPECT().GetUserIDByUsername(gomock.Any(), "testuser").
-					Return(int64(1), nil)
-				m.service.EXPECT().LoginUser(gomock.Any(), args.user).Return("Bearer mock_token",
-					nil)
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().LoginUser(gomock.Any(), args.user, "").Return(&models.TokenPair{
+					UserID:       1,
+					AccessToken:  "Bearer mock_token",
+					RefreshToken: "mock_refresh",
+					ExpiresIn:    15 * time.Minute,
+				}, nil)
 			},
 			expectedError:   nil,
 			expectedToken:   "Bearer mock_token",
@@ -186,10 +204,10 @@ func TestLogin(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().LoginUser(gomock.Any(), args.user).
-					Return("", fmt.Errorf("users not found, please to registration"))
+				m.service.EXPECT().LoginUser(gomock.Any(), args.user, "").
+					Return(nil, utils.ErrInvalidCredentials)
 			},
-			expectedError:   status.Errorf(codes.Unauthenticated, "failed to login: users not found, please to registration"),
+			expectedError:   status.Errorf(codes.Unauthenticated, "invalid username or password"),
 			expectedToken:   "",
 			expectedMessage: "",
 		},
@@ -207,10 +225,30 @@ func TestLogin(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				m.app.EXPECT().GetService().Return(m.service)
-				m.service.EXPECT().LoginUser(gomock.Any(), args.user).
-					Return("", fmt.Errorf("invalid password"))
+				m.service.EXPECT().LoginUser(gomock.Any(), args.user, "").
+					Return(nil, utils.ErrInvalidCredentials)
 			},
-			expectedError:   status.Errorf(codes.Unauthenticated, "failed to login: invalid password"),
+			expectedError:   status.Errorf(codes.Unauthenticated, "invalid username or password"),
+			expectedMessage: "",
+		},
+		{
+			name: "TestLoginInternalError",
+			req: &proto.LoginRequest{
+				Username: "testuser",
+				Password: "password123",
+			},
+			args: args{
+				user: &models.User{
+					Username: "testuser",
+					Password: "password123",
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().LoginUser(gomock.Any(), args.user, "").
+					Return(nil, fmt.Errorf("db error"))
+			},
+			expectedError:   status.Errorf(codes.Internal, "failed to login: db error"),
 			expectedMessage: "",
 		},
 		{
@@ -252,12 +290,320 @@ func TestLogin(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedMessage, resp.Message)
 				assert.Equal(t, tt.expectedToken, resp.Token)
+				assert.Equal(t, "mock_refresh", resp.RefreshToken)
+				assert.Equal(t, int64(900), resp.ExpiresIn)
+				assert.Equal(t, int64(1), resp.UserId)
 			}
 
 		})
 	}
 }
 
+func TestLoginTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		app:     amock.NewMockServer(ctrl),
+		service: smock.NewMockService(ctrl),
+	}
+	server := &gophKeeperServer{server: m.app}
+	user := &models.User{Username: "testuser", Password: "password123"}
+
+	t.Run("code required", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().LoginUser(gomock.Any(), user, "").Return(nil, utils.ErrTOTPRequired)
+
+		resp, err := server.Login(context.Background(), &proto.LoginRequest{Username: "testuser", Password: "password123"})
+		require.NoError(t, err)
+		assert.True(t, resp.TotpRequired)
+		assert.Empty(t, resp.Token)
+		assert.Empty(t, resp.RefreshToken)
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().LoginUser(gomock.Any(), user, "123456").Return(&models.TokenPair{
+			UserID:       1,
+			AccessToken:  "Bearer mock_token",
+			RefreshToken: "mock_refresh",
+			ExpiresIn:    15 * time.Minute,
+		}, nil)
+
+		resp, err := server.Login(context.Background(), &proto.LoginRequest{
+			Username: "testuser",
+			Password: "password123",
+			TotpCode: "123456",
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.TotpRequired)
+		assert.Equal(t, "Bearer mock_token", resp.Token)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().LoginUser(gomock.Any(), user, "000000").Return(nil, utils.ErrInvalidTOTPCode)
+
+		_, err := server.Login(context.Background(), &proto.LoginRequest{
+			Username: "testuser",
+			Password: "password123",
+			TotpCode: "000000",
+		})
+		assert.Equal(t, status.Errorf(codes.Unauthenticated, "invalid two-factor code").Error(), err.Error())
+	})
+}
+
+func TestEnableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		app:     amock.NewMockServer(ctrl),
+		service: smock.NewMockService(ctrl),
+	}
+	server := &gophKeeperServer{server: m.app}
+	ctx := withPrincipal(context.Background(), 1)
+
+	t.Run("returns secret and otpauth URI", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().EnableTOTP(gomock.Any(), int64(1), "testuser").Return(&models.TOTPEnrollment{
+			Secret: "SECRET",
+			URI:    "otpauth://totp/GophKeeper:testuser?secret=SECRET",
+		}, nil)
+
+		resp, err := server.EnableTOTP(ctx, &proto.EnableTOTPRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "SECRET", resp.Secret)
+		assert.Equal(t, "otpauth://totp/GophKeeper:testuser?secret=SECRET", resp.OtpauthUri)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().EnableTOTP(gomock.Any(), int64(1), "testuser").Return(nil, utils.ErrTOTPEnabled)
+
+		_, err := server.EnableTOTP(ctx, &proto.EnableTOTPRequest{})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("no user in context", func(t *testing.T) {
+		_, err := server.EnableTOTP(context.Background(), &proto.EnableTOTPRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		app:     amock.NewMockServer(ctrl),
+		service: smock.NewMockService(ctrl),
+	}
+	server := &gophKeeperServer{server: m.app}
+	ctx := withPrincipal(context.Background(), 1)
+
+	t.Run("returns recovery codes", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().ConfirmTOTP(gomock.Any(), int64(1), "123456").Return([]string{"aaaa-bbbb-cccc-dddd"}, nil)
+
+		resp, err := server.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{Code: "123456"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"aaaa-bbbb-cccc-dddd"}, resp.RecoveryCodes)
+	})
+
+	t.Run("empty code", func(t *testing.T) {
+		_, err := server.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().ConfirmTOTP(gomock.Any(), int64(1), "000000").Return(nil, utils.ErrInvalidTOTPCode)
+
+		_, err := server.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{Code: "000000"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().ConfirmTOTP(gomock.Any(), int64(1), "123456").Return(nil, utils.ErrTOTPNotEnrolled)
+
+		_, err := server.ConfirmTOTP(ctx, &proto.ConfirmTOTPRequest{Code: "123456"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestDisableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		app:     amock.NewMockServer(ctrl),
+		service: smock.NewMockService(ctrl),
+	}
+	server := &gophKeeperServer{server: m.app}
+	ctx := withPrincipal(context.Background(), 1)
+
+	t.Run("disables two-factor authentication", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().DisableTOTP(gomock.Any(), int64(1), "123456").Return(nil)
+
+		resp, err := server.DisableTOTP(ctx, &proto.DisableTOTPRequest{Code: "123456"})
+		require.NoError(t, err)
+		assert.Equal(t, "Two-factor authentication disabled", resp.Message)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().DisableTOTP(gomock.Any(), int64(1), "000000").Return(utils.ErrInvalidTOTPCode)
+
+		_, err := server.DisableTOTP(ctx, &proto.DisableTOTPRequest{Code: "000000"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().DisableTOTP(gomock.Any(), int64(1), "123456").Return(errors.New("db error"))
+
+		_, err := server.DisableTOTP(ctx, &proto.DisableTOTPRequest{Code: "123456"})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           *proto.RefreshTokenRequest
+		mockBehavior  func(m *mocks)
+		expectedError error
+	}{
+		{
+			name: "TestRefreshTokenSuccess",
+			req:  &proto.RefreshTokenRequest{RefreshToken: "refresh"},
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().RefreshToken(gomock.Any(), "refresh").Return(&models.TokenPair{
+					AccessToken:  "Bearer new_token",
+					RefreshToken: "new_refresh",
+					ExpiresIn:    15 * time.Minute,
+				}, nil)
+			},
+		},
+		{
+			name:          "TestRefreshTokenEmpty",
+			req:           &proto.RefreshTokenRequest{},
+			mockBehavior:  func(m *mocks) {},
+			expectedError: status.Errorf(codes.InvalidArgument, "refresh token is required"),
+		},
+		{
+			name: "TestRefreshTokenReused",
+			req:  &proto.RefreshTokenRequest{RefreshToken: "refresh"},
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().RefreshToken(gomock.Any(), "refresh").Return(nil, utils.ErrTokenReused)
+			},
+			expectedError: status.Errorf(codes.Unauthenticated, "refresh token is invalid or revoked, please log in again"),
+		},
+		{
+			name: "TestRefreshTokenInternalError",
+			req:  &proto.RefreshTokenRequest{RefreshToken: "refresh"},
+			mockBehavior: func(m *mocks) {
+				m.app.EXPECT().GetService().Return(m.service)
+				m.service.EXPECT().RefreshToken(gomock.Any(), "refresh").Return(nil, errors.New("db error"))
+			},
+			expectedError: status.Errorf(codes.Internal, "failed to refresh token: db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				app:     amock.NewMockServer(ctrl),
+				service: smock.NewMockService(ctrl),
+			}
+			tt.mockBehavior(m)
+
+			server := &gophKeeperServer{server: m.app}
+
+			resp, err := server.RefreshToken(context.Background(), tt.req)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Bearer new_token", resp.Token)
+			assert.Equal(t, "new_refresh", resp.RefreshToken)
+			assert.Equal(t, int64(900), resp.ExpiresIn)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := &mocks{
+		app:     amock.NewMockServer(ctrl),
+		service: smock.NewMockService(ctrl),
+	}
+	server := &gophKeeperServer{server: m.app}
+
+	t.Run("revokes session of the token", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().Logout(gomock.Any(), "session").Return(nil)
+
+		ctx := withPrincipal(context.Background(), 1)
+		resp, err := server.Logout(ctx, &proto.LogoutRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "Logout successful", resp.Message)
+	})
+
+	t.Run("no session in context", func(t *testing.T) {
+		_, err := server.Logout(context.Background(), &proto.LogoutRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		m.app.EXPECT().GetService().Return(m.service)
+		m.service.EXPECT().Logout(gomock.Any(), "session").Return(errors.New("db error"))
+
+		ctx := withPrincipal(context.Background(), 1)
+		_, err := server.Logout(ctx, &proto.LogoutRequest{})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestGetJWKS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApp := amock.NewMockServer(ctrl)
+	server := &gophKeeperServer{server: mockApp}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := interceptors.NewKeySet("new",
+		interceptors.NewEd25519Key("new", private),
+		interceptors.NewHMACKey("old", []byte("secret")),
+	)
+	require.NoError(t, err)
+
+	mockApp.EXPECT().GetKeySet().Return(keys)
+
+	resp, err := server.GetJWKS(context.Background(), &proto.GetJWKSRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Keys, 1)
+	assert.Equal(t, "new", resp.Keys[0].Kid)
+	assert.Equal(t, "OKP", resp.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", resp.Keys[0].Crv)
+	assert.Equal(t, interceptors.AlgEdDSA, resp.Keys[0].Alg)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(private.Public().(ed25519.PublicKey)), resp.Keys[0].X)
+}
+
 func TestNewGophKeeperServer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
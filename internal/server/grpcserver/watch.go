@@ -0,0 +1,46 @@
+package grpcserver
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/proto"
+)
+
+// WatchChanges отправляет клиенту события об изменении данных текущего пользователя по мере их появления.
+// Поток завершается при отключении клиента или прекращении рассылки событий на сервере,
+// а AuthStreamInterceptor закрывает его, когда истекает токен доступа или отзывается сессия.
+func (s *gophKeeperServer) WatchChanges(_ *proto.WatchChangesRequest, stream proto.GophKeeper_WatchChangesServer) error {
+	ctx := stream.Context()
+
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "invalid user authentication")
+	}
+
+	events, err := s.server.GetService().WatchChanges(ctx, principal.UserID)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to watch changes: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "change notifications stopped")
+			}
+
+			err := stream.Send(&proto.ChangeEvent{
+				DataId:   event.DataID,
+				Revision: event.Revision,
+				Deleted:  event.Deleted,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// BatchMutate атомарно выполняет операции пакетного изменения данных пользователя.
+//
+// Файлы бинарных данных из операций создания и обновления загружаются в хранилище файлов до начала транзакции,
+// а все операции с базой данных выполняются в одной транзакции. Если любая операция не выполнена,
+// возвращается *utils.OperationError с номером операции. Загруженные файлы остаются зарегистрированными
+// как ожидающие удаления: если на них не ссылается ни одна запись, их удаляет обработчик отложенных операций.
+// Возвращает состояния записей после выполнения операций в порядке операций.
+func (s *service) BatchMutate(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+	prepared := make([]models.Mutation, len(mutations))
+
+	for i, mutation := range mutations {
+		if err := s.prepareMutation(ctx, userId, &mutation); err != nil {
+			return nil, &utils.OperationError{Index: i, Err: err}
+		}
+		prepared[i] = mutation
+	}
+
+	return s.dbAdapter.ApplyMutations(ctx, userId, prepared)
+}
+
+// prepareMutation проверяет операцию и загружает в хранилище файлов файл бинарных данных, если он передан в операции.
+// Для обновления проверяются владелец и версия записи; тип данных берется из записи в базе данных.
+// Для создания файл не загружается, если запись с ID или ключом идемпотентности операции уже создана.
+func (s *service) prepareMutation(ctx context.Context, userId int64, mutation *models.Mutation) error {
+	data := &mutation.Data
+
+	switch mutation.Type {
+	case models.MutationCreate:
+		created, err := s.findCreated(ctx, userId, data.ID, data.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+		if created != nil {
+			// Запись уже создана: транзакция вернет ее по ID или ключу идемпотентности без повторной загрузки файла
+			data.DataContent = nil
+			return nil
+		}
+	case models.MutationUpdate:
+		oldData, err := s.ownedData(ctx, data.ID, userId)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(oldData, data.Version); err != nil {
+			return err
+		}
+		data.DataType = oldData.DataType
+	default:
+		return nil
+	}
+
+	if data.DataType != models.BinaryData {
+		return nil
+	}
+
+	blobKey, blobSize, err := s.uploadFile(ctx, userId, data.DataContent)
+	if err != nil {
+		return err
+	}
+
+	data.BlobKey = blobKey
+	data.BlobSize = blobSize
+	data.DataContent = nil
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// CreateBlob потоково загружает бинарные данные в хранилище файлов и создает для них запись в базе данных.
+// Запись ссылается на загруженный файл по ключу объекта, присвоенные записи ревизия и версия сохраняются в data.Revision и data.Version.
+// Если запись с ID data.ID или ключом идемпотентности data.IdempotencyKey уже создана,
+// файл не загружается и возвращается ее ID. Если запись не сохранится, файл будет удален обработчиком отложенных операций.
+func (s *service) CreateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) (string, error) {
+	created, err := s.findCreated(ctx, data.UserID, data.ID, data.IdempotencyKey)
+	if err != nil {
+		return "", err
+	}
+	if created != nil {
+		data.Revision = created.Revision
+		data.Version = created.Version
+		return created.ID, nil
+	}
+
+	blobKey, blobSize, err := s.uploadStream(ctx, data.UserID, content, size)
+	if err != nil {
+		s.logger.Error("ошибка загрузки в хранилище файлов: %v", err)
+		return "", err
+	}
+
+	putData := *data
+	putData.DataType = models.BinaryData
+	putData.DataContent = nil
+	putData.BlobKey = blobKey
+	putData.BlobSize = blobSize
+
+	id, err := s.dbAdapter.CreateData(ctx, &putData)
+	if err != nil {
+		return "", err
+	}
+
+	data.Revision = putData.Revision
+	data.Version = putData.Version
+	return id, nil
+}
+
+// UpdateBlob потоково заменяет файл бинарных данных в хранилище файлов и обновляет запись в базе данных.
+// На старый файл продолжает ссылаться история изменений, из хранилища файлов он удаляется при очистке корзины,
+// когда на него не остается ссылок.
+// Если data.Version не равен 0 и не совпадает с текущей версией данных, возвращается *utils.VersionConflictError.
+func (s *service) UpdateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) error {
+	oldData, err := s.ownedData(ctx, data.ID, data.UserID)
+	if err != nil {
+		return err
+	}
+
+	if oldData.DataType != models.BinaryData {
+		return utils.ErrNotBinaryData
+	}
+
+	if err := checkVersion(oldData, data.Version); err != nil {
+		return err
+	}
+
+	blobKey, blobSize, err := s.uploadStream(ctx, data.UserID, content, size)
+	if err != nil {
+		return err
+	}
+
+	data.DataContent = nil
+	data.BlobKey = blobKey
+	data.BlobSize = blobSize
+
+	return s.dbAdapter.UpdateData(ctx, data)
+}
+
+// FetchBlob потоково записывает в writer файл пользователя с ID blobId из описания файла бинарных данных.
+// Возвращает ErrBlobNotFound, если файл не найден или принадлежит другому пользователю.
+func (s *service) FetchBlob(ctx context.Context, userId int64, blobId string, writer io.Writer) error {
+	keys, err := s.dbAdapter.GetBlobKeys(ctx, userId, []string{blobId})
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return utils.ErrBlobNotFound
+	}
+
+	return s.blobStore.DownloadStream(ctx, blobId, writer)
+}
+
+// setBlobManifest заполняет описание файла бинарных данных записи по ключу, имени и размеру файла.
+// ID файла совпадает с ключом в хранилище файлов, а хеш содержимого берется из ключа.
+func setBlobManifest(data *models.Data) {
+	if data.DataType != models.BinaryData || data.BlobKey == "" {
+		return
+	}
+
+	data.Blob = &models.BlobManifest{
+		ID:          data.BlobKey,
+		FileName:    data.FileName,
+		Size:        data.BlobSize,
+		ContentHash: blobstore.ContentHash(data.BlobKey),
+	}
+}
+
+// DownloadBlob потоково записывает содержимое бинарных данных пользователя в writer.
+// Возвращает ErrUserDataNotFound, если данные не найдены или принадлежат другому пользователю.
+func (s *service) DownloadBlob(ctx context.Context, dataId string, userId int64, writer io.Writer) error {
+	data, err := s.ownedData(ctx, dataId, userId)
+	if err != nil {
+		return err
+	}
+
+	if data.DataType != models.BinaryData {
+		return utils.ErrNotBinaryData
+	}
+
+	if data.BlobKey == "" {
+		return fmt.Errorf("файл бинарных данных не найден")
+	}
+
+	return s.blobStore.DownloadStream(ctx, data.BlobKey, writer)
+}
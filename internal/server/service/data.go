@@ -2,36 +2,78 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 )
 
-// CreateData создает новые данные в базе данных и (если необходимо) загружает бинарные данные в MinIO.
-// Если данные являются бинарными, файл загружается в MinIO, и его URL сохраняется в метаданных.
-func (s *service) CreateData(ctx context.Context, data *models.Data) (int64, error) {
+// CreateData создает новые данные в базе данных и (если необходимо) загружает бинарные данные в хранилище файлов.
+// Если данные являются бинарными, файл загружается в хранилище файлов, и запись ссылается на него по ключу объекта;
+// если запись не сохранится, файл будет удален обработчиком отложенных операций.
+// Если запись с ID data.ID или ключом идемпотентности data.IdempotencyKey уже создана,
+// возвращается ее ID без повторного создания.
+func (s *service) CreateData(ctx context.Context, data *models.Data) (string, error) {
+	created, err := s.findCreated(ctx, data.UserID, data.ID, data.IdempotencyKey)
+	if err != nil {
+		return "", err
+	}
+	if created != nil {
+		data.Revision = created.Revision
+		data.Version = created.Version
+		return created.ID, nil
+	}
+
 	putData := *data
 
 	if putData.DataType == models.BinaryData {
-		fileURL, err := s.minioClient.UploadFile(ctx, data.FileName, data.DataContent)
+		blobKey, blobSize, err := s.uploadFile(ctx, data.UserID, data.DataContent)
 		if err != nil {
-			s.logger.Error("ошибка загрузки в Minio: %v", err)
-			return 0, err
+			s.logger.Error("ошибка загрузки в хранилище файлов: %v", err)
+			return "", err
 		}
 
-		putData.SetMetadata("file_url", fileURL)
+		putData.BlobKey = blobKey
+		putData.BlobSize = blobSize
 
 		putData.DataContent = nil
 	} else {
 		putData.DataContent = data.DataContent
 	}
 
-	return s.dbAdapter.CreateData(ctx, &putData)
+	id, err := s.dbAdapter.CreateData(ctx, &putData)
+	if err != nil {
+		return "", err
+	}
+
+	data.Revision = putData.Revision
+	data.Version = putData.Version
+	return id, nil
+}
+
+// findCreated возвращает запись пользователя, ранее созданную с ID dataId или ключом идемпотентности key,
+// или nil, если ни ID, ни ключ не заданы или такая запись еще не создавалась.
+// Проверка выполняется до загрузки файлов в хранилище файлов, чтобы повторный запрос не оставлял в хранилище файлов лишних файлов.
+func (s *service) findCreated(ctx context.Context, userId int64, dataId string, key string) (*models.Data, error) {
+	if dataId == "" && key == "" {
+		return nil, nil
+	}
+
+	data, err := s.dbAdapter.GetCreatedData(ctx, userId, dataId, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
 }
 
-// GetData получает все данные для указанного пользователя. Если данные являются бинарными,
-// они загружаются из MinIO с использованием URL, сохраненного в метаданных.
+// GetData получает все данные для указанного пользователя. Содержимое бинарных данных из хранилища файлов
+// не загружается: вместо него запись содержит описание файла, а клиент получает файл через FetchBlob.
 func (s *service) GetData(ctx context.Context, userId int64) ([]models.Data, error) {
 	data, err := s.dbAdapter.GetData(ctx, userId)
 	if len(data) == 0 && err == nil {
@@ -39,53 +81,87 @@ func (s *service) GetData(ctx context.Context, userId int64) ([]models.Data, err
 	}
 
 	for i := range data {
-		if data[i].DataType == models.BinaryData {
-			fileURLValue, ok := data[i].GetMetadata("file_url")
-			if !ok {
-				s.logger.Error("file_url not found in metadata for binary data")
-				continue
-			}
-
-			fileURL, ok := fileURLValue.(string)
-			if !ok || fileURL == "" {
-				s.logger.Error("file_url is not a valid string")
-				continue
-			}
-
-			fileContent, err := s.minioClient.GetFile(ctx, fileURL)
-			if err != nil {
-				s.logger.Error("failed to load file from MinIO: %v", err)
-				continue
-			}
-
-			data[i].DataContent = fileContent
-		}
+		setBlobManifest(&data[i])
 	}
 
 	return data, err
 }
 
-// DeleteData удаляет данные с заданным идентификатором (dataId) для указанного пользователя (userId).
-// Если данные бинарные, соответствующий файл также удаляется из MinIO.
-func (s *service) DeleteData(ctx context.Context, dataId int64, userId int64) (bool, error) {
-	data, err := s.dbAdapter.GetDataByID(ctx, dataId)
+// ListData возвращает страницу данных пользователя согласно фильтру.
+// Вторым значением возвращается ID последней записи страницы, с которого продолжается выборка,
+// либо пустая строка, если следующей страницы нет. Содержимое бинарных данных из хранилища файлов не загружается,
+// записи содержат только описание файла.
+func (s *service) ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, string, error) {
+	pageSize := filter.Limit
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница.
+	filter.Limit = pageSize + 1
+
+	data, err := s.dbAdapter.ListData(ctx, userId, filter)
 	if err != nil {
-		return false, err
+		return nil, "", err
 	}
 
-	if data.DataType == models.BinaryData {
-		fileURL, ok := data.Metadata["file_url"].(string)
-		if !ok || fileURL == "" {
-			return false, fmt.Errorf("file_url не найден в метаданных")
-		}
+	for i := range data {
+		setBlobManifest(&data[i])
+	}
 
-		err := s.minioClient.DeleteFile(ctx, fileURL)
-		if err != nil {
-			return false, fmt.Errorf("ошибка удаления файла из MinIO: %w", err)
+	if len(data) <= pageSize {
+		return data, "", nil
+	}
+
+	data = data[:pageSize]
+	return data, data[pageSize-1].ID, nil
+}
+
+// GetChanges возвращает не более limit изменений данных пользователя с ревизией больше since.
+// Вторым значением возвращается признак того, что после этой страницы есть еще изменения.
+// Содержимое бинарных данных из хранилища файлов не загружается: изменения содержат описание файла,
+// а клиент получает файл через FetchBlob.
+func (s *service) GetChanges(ctx context.Context, userId int64, since int64, limit int) ([]models.DataChange, bool, error) {
+	// Запрашиваем на одно изменение больше, чтобы узнать, есть ли следующая страница.
+	changes, err := s.dbAdapter.GetChanges(ctx, userId, since, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := range changes {
+		if !changes[i].Deleted {
+			setBlobManifest(&changes[i].Data)
 		}
 	}
 
-	success, err := s.dbAdapter.DeleteData(ctx, dataId, userId)
+	if len(changes) <= limit {
+		return changes, false, nil
+	}
+
+	return changes[:limit], true, nil
+}
+
+// WatchChanges подписывает на события об изменении данных пользователя на любом экземпляре сервера.
+// Канал событий закрывается после отмены ctx.
+func (s *service) WatchChanges(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error) {
+	return s.dbAdapter.Subscribe(ctx, userId)
+}
+
+// DeleteData перемещает данные с заданным идентификатором (dataId) для указанного пользователя (userId) в корзину.
+// Файл бинарных данных остается в хранилище файлов до окончательного удаления данных из корзины.
+// Если expectedVersion не равен 0 и не совпадает с текущей версией данных,
+// возвращается *utils.VersionConflictError, а данные не удаляются.
+func (s *service) DeleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (bool, error) {
+	data, err := s.ownedData(ctx, dataId, userId)
+	if err != nil {
+		return false, err
+	}
+
+	if err := checkVersion(data, expectedVersion); err != nil {
+		return false, err
+	}
+
+	success, err := s.dbAdapter.DeleteData(ctx, dataId, userId, expectedVersion)
+	if errors.Is(err, utils.ErrVersionConflict) {
+		return false, err
+	}
 	if err != nil {
 		return false, fmt.Errorf("ошибка удаления данных из базы данных: %w", err)
 	}
@@ -98,25 +174,26 @@ func (s *service) DeleteData(ctx context.Context, dataId int64, userId int64) (b
 }
 
 // UpdateData обновляет данные с заданным идентификатором (dataId) для указанного пользователя.
-// Если данные бинарные, файл обновляется в MinIO.
+// Если данные бинарные, новый файл загружается в хранилище файлов, а на старый продолжает ссылаться история изменений.
+// Если data.Version не равен 0 и не совпадает с текущей версией данных, возвращается *utils.VersionConflictError.
 func (s *service) UpdateData(ctx context.Context, data *models.Data) error {
-	oldData, err := s.dbAdapter.GetDataByID(ctx, data.ID)
+	oldData, err := s.ownedData(ctx, data.ID, data.UserID)
 	if err != nil {
 		return err
 	}
 
-	if oldData.DataType == models.BinaryData {
-		OldFileURL, ok := oldData.Metadata["file_url"].(string)
-		if !ok || OldFileURL == "" {
-			return fmt.Errorf("file_url не найден в метаданных")
-		}
+	if err := checkVersion(oldData, data.Version); err != nil {
+		return err
+	}
 
-		minioUrl, err := s.minioClient.UpdateFile(ctx, OldFileURL, data.FileName, data.DataContent)
+	if oldData.DataType == models.BinaryData {
+		blobKey, blobSize, err := s.uploadFile(ctx, data.UserID, data.DataContent)
 		if err != nil {
 			return err
 		}
 
-		data.SetMetadata("file_url", minioUrl)
+		data.BlobKey = blobKey
+		data.BlobSize = blobSize
 		data.DataContent = nil
 	}
 
@@ -126,5 +203,35 @@ func (s *service) UpdateData(ctx context.Context, data *models.Data) error {
 	}
 
 	return nil
+}
+
+// ownedData возвращает запись dataId, только если она принадлежит пользователю userId.
+// Все операции сервиса с существующей записью получают ее здесь до обращения к хранилищу файлов.
+// Для отсутствующих и чужих записей возвращается utils.ErrUserDataNotFound,
+// чтобы по ответу нельзя было узнать о существовании чужих данных.
+func (s *service) ownedData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	data, err := s.dbAdapter.GetDataByID(ctx, dataId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrUserDataNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	if data.UserID != userId {
+		return nil, utils.ErrUserDataNotFound
+	}
+
+	return data, nil
+}
+
+// checkVersion проверяет, что ожидаемая версия совпадает с текущей версией данных.
+// Нулевая ожидаемая версия означает запись без проверки версии.
+// Проверка выполняется до изменения файлов в хранилище файлов; окончательно версия сверяется в базе данных.
+func checkVersion(data *models.Data, expectedVersion int64) error {
+	if expectedVersion != 0 && data.Version != expectedVersion {
+		return &utils.VersionConflictError{CurrentVersion: data.Version}
+	}
+
+	return nil
 }
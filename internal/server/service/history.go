@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// GetDataHistory возвращает предыдущие состояния данных пользователя, начиная с последнего.
+// Содержимое бинарных данных из хранилища файлов не загружается: в метаданных каждого состояния хранится URL его файла.
+func (s *service) GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error) {
+	return s.dbAdapter.GetDataHistory(ctx, dataId, userId)
+}
+
+// RestoreRevision возвращает данные пользователя к состоянию с ревизией revision из истории изменений.
+// Файлы бинарных данных предыдущих состояний хранятся в хранилище файлов под отдельными ключами, поэтому восстанавливается только запись.
+// Если expectedVersion не равен 0 и не совпадает с текущей версией данных, возвращается *utils.VersionConflictError,
+// если данных или ревизии нет - utils.ErrUserDataNotFound.
+func (s *service) RestoreRevision(ctx context.Context, dataId string, userId int64, revision int64,
+	expectedVersion int64) (*models.Data, error) {
+	return s.dbAdapter.RestoreRevision(ctx, dataId, userId, revision, expectedVersion)
+}
@@ -6,7 +6,9 @@ package mock_service
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -36,11 +38,71 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// BatchMutate mocks base method.
+func (m *MockService) BatchMutate(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchMutate", ctx, userId, mutations)
+	ret0, _ := ret[0].([]models.MutationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchMutate indicates an expected call of BatchMutate.
+func (mr *MockServiceMockRecorder) BatchMutate(ctx, userId, mutations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchMutate", reflect.TypeOf((*MockService)(nil).BatchMutate), ctx, userId, mutations)
+}
+
+// CollectBlobGarbage mocks base method.
+func (m *MockService) CollectBlobGarbage(ctx context.Context, minAge time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectBlobGarbage", ctx, minAge)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CollectBlobGarbage indicates an expected call of CollectBlobGarbage.
+func (mr *MockServiceMockRecorder) CollectBlobGarbage(ctx, minAge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectBlobGarbage", reflect.TypeOf((*MockService)(nil).CollectBlobGarbage), ctx, minAge)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockService) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, userID, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockServiceMockRecorder) ConfirmTOTP(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockService)(nil).ConfirmTOTP), ctx, userID, code)
+}
+
+// CreateBlob mocks base method.
+func (m *MockService) CreateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBlob", ctx, data, content, size)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBlob indicates an expected call of CreateBlob.
+func (mr *MockServiceMockRecorder) CreateBlob(ctx, data, content, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBlob", reflect.TypeOf((*MockService)(nil).CreateBlob), ctx, data, content, size)
+}
+
 // CreateData mocks base method.
-func (m *MockService) CreateData(ctx context.Context, data *models.Data) (int64, error) {
+func (m *MockService) CreateData(ctx context.Context, data *models.Data) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateData", ctx, data)
-	ret0, _ := ret[0].(int64)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -52,18 +114,91 @@ func (mr *MockServiceMockRecorder) CreateData(ctx, data interface{}) *gomock.Cal
 }
 
 // DeleteData mocks base method.
-func (m *MockService) DeleteData(ctx context.Context, dataId, userId int64) (bool, error) {
+func (m *MockService) DeleteData(ctx context.Context, dataId string, userId, expectedVersion int64) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteData", ctx, dataId, userId)
+	ret := m.ctrl.Call(m, "DeleteData", ctx, dataId, userId, expectedVersion)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteData indicates an expected call of DeleteData.
-func (mr *MockServiceMockRecorder) DeleteData(ctx, dataId, userId interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) DeleteData(ctx, dataId, userId, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockService)(nil).DeleteData), ctx, dataId, userId, expectedVersion)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockServiceMockRecorder) DisableTOTP(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockService)(nil).DisableTOTP), ctx, userID, code)
+}
+
+// DownloadBlob mocks base method.
+func (m *MockService) DownloadBlob(ctx context.Context, dataId string, userId int64, writer io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadBlob", ctx, dataId, userId, writer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadBlob indicates an expected call of DownloadBlob.
+func (mr *MockServiceMockRecorder) DownloadBlob(ctx, dataId, userId, writer interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockService)(nil).DeleteData), ctx, dataId, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBlob", reflect.TypeOf((*MockService)(nil).DownloadBlob), ctx, dataId, userId, writer)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockService) EnableTOTP(ctx context.Context, userID int64, username string) (*models.TOTPEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, userID, username)
+	ret0, _ := ret[0].(*models.TOTPEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockServiceMockRecorder) EnableTOTP(ctx, userID, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockService)(nil).EnableTOTP), ctx, userID, username)
+}
+
+// FetchBlob mocks base method.
+func (m *MockService) FetchBlob(ctx context.Context, userId int64, blobId string, writer io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchBlob", ctx, userId, blobId, writer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchBlob indicates an expected call of FetchBlob.
+func (mr *MockServiceMockRecorder) FetchBlob(ctx, userId, blobId, writer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlob", reflect.TypeOf((*MockService)(nil).FetchBlob), ctx, userId, blobId, writer)
+}
+
+// GetChanges mocks base method.
+func (m *MockService) GetChanges(ctx context.Context, userId, since int64, limit int) ([]models.DataChange, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, userId, since, limit)
+	ret0, _ := ret[0].([]models.DataChange)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockServiceMockRecorder) GetChanges(ctx, userId, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockService)(nil).GetChanges), ctx, userId, since, limit)
 }
 
 // GetData mocks base method.
@@ -81,34 +216,169 @@ func (mr *MockServiceMockRecorder) GetData(ctx, userId interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetData", reflect.TypeOf((*MockService)(nil).GetData), ctx, userId)
 }
 
-// GetUserIDByUsername mocks base method.
-func (m *MockService) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+// GetDataHistory mocks base method.
+func (m *MockService) GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIDByUsername", ctx, username)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "GetDataHistory", ctx, dataId, userId)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataHistory indicates an expected call of GetDataHistory.
+func (mr *MockServiceMockRecorder) GetDataHistory(ctx, dataId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataHistory", reflect.TypeOf((*MockService)(nil).GetDataHistory), ctx, dataId, userId)
+}
+
+// IsSessionActive mocks base method.
+func (m *MockService) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionActive", ctx, sessionID)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIDByUsername indicates an expected call of GetUserIDByUsername.
-func (mr *MockServiceMockRecorder) GetUserIDByUsername(ctx, username interface{}) *gomock.Call {
+// IsSessionActive indicates an expected call of IsSessionActive.
+func (mr *MockServiceMockRecorder) IsSessionActive(ctx, sessionID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByUsername", reflect.TypeOf((*MockService)(nil).GetUserIDByUsername), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionActive", reflect.TypeOf((*MockService)(nil).IsSessionActive), ctx, sessionID)
+}
+
+// ListData mocks base method.
+func (m *MockService) ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListData", ctx, userId, filter)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListData indicates an expected call of ListData.
+func (mr *MockServiceMockRecorder) ListData(ctx, userId, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockService)(nil).ListData), ctx, userId, filter)
+}
+
+// ListTrash mocks base method.
+func (m *MockService) ListTrash(ctx context.Context, userId int64) ([]models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", ctx, userId)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockServiceMockRecorder) ListTrash(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockService)(nil).ListTrash), ctx, userId)
 }
 
 // LoginUser mocks base method.
-func (m *MockService) LoginUser(ctx context.Context, user *models.User) (string, error) {
+func (m *MockService) LoginUser(ctx context.Context, user *models.User, totpCode string) (*models.TokenPair, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LoginUser", ctx, user)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "LoginUser", ctx, user, totpCode)
+	ret0, _ := ret[0].(*models.TokenPair)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // LoginUser indicates an expected call of LoginUser.
-func (mr *MockServiceMockRecorder) LoginUser(ctx, user interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) LoginUser(ctx, user, totpCode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginUser", reflect.TypeOf((*MockService)(nil).LoginUser), ctx, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginUser", reflect.TypeOf((*MockService)(nil).LoginUser), ctx, user, totpCode)
+}
+
+// Logout mocks base method.
+func (m *MockService) Logout(ctx context.Context, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockServiceMockRecorder) Logout(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockService)(nil).Logout), ctx, sessionID)
+}
+
+// MigrateLegacyBlobs mocks base method.
+func (m *MockService) MigrateLegacyBlobs(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateLegacyBlobs", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateLegacyBlobs indicates an expected call of MigrateLegacyBlobs.
+func (mr *MockServiceMockRecorder) MigrateLegacyBlobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateLegacyBlobs", reflect.TypeOf((*MockService)(nil).MigrateLegacyBlobs), ctx)
+}
+
+// ProcessBlobOperations mocks base method.
+func (m *MockService) ProcessBlobOperations(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessBlobOperations", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessBlobOperations indicates an expected call of ProcessBlobOperations.
+func (mr *MockServiceMockRecorder) ProcessBlobOperations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessBlobOperations", reflect.TypeOf((*MockService)(nil).ProcessBlobOperations), ctx)
+}
+
+// PurgeData mocks base method.
+func (m *MockService) PurgeData(ctx context.Context, userId int64, dataId string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeData", ctx, userId, dataId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeData indicates an expected call of PurgeData.
+func (mr *MockServiceMockRecorder) PurgeData(ctx, userId, dataId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeData", reflect.TypeOf((*MockService)(nil).PurgeData), ctx, userId, dataId)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockServiceMockRecorder) PurgeTrash(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockService)(nil).PurgeTrash), ctx, retention)
+}
+
+// RefreshToken mocks base method.
+func (m *MockService) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(*models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockServiceMockRecorder) RefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockService)(nil).RefreshToken), ctx, refreshToken)
 }
 
 // RegisterUser mocks base method.
@@ -126,6 +396,86 @@ func (mr *MockServiceMockRecorder) RegisterUser(ctx, user interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockService)(nil).RegisterUser), ctx, user)
 }
 
+// RestoreData mocks base method.
+func (m *MockService) RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreData", ctx, dataId, userId)
+	ret0, _ := ret[0].(*models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreData indicates an expected call of RestoreData.
+func (mr *MockServiceMockRecorder) RestoreData(ctx, dataId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreData", reflect.TypeOf((*MockService)(nil).RestoreData), ctx, dataId, userId)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockService) RestoreRevision(ctx context.Context, dataId string, userId, revision, expectedVersion int64) (*models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", ctx, dataId, userId, revision, expectedVersion)
+	ret0, _ := ret[0].(*models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockServiceMockRecorder) RestoreRevision(ctx, dataId, userId, revision, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockService)(nil).RestoreRevision), ctx, dataId, userId, revision, expectedVersion)
+}
+
+// RunBlobGC mocks base method.
+func (m *MockService) RunBlobGC(ctx context.Context, minAge, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunBlobGC", ctx, minAge, interval)
+}
+
+// RunBlobGC indicates an expected call of RunBlobGC.
+func (mr *MockServiceMockRecorder) RunBlobGC(ctx, minAge, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunBlobGC", reflect.TypeOf((*MockService)(nil).RunBlobGC), ctx, minAge, interval)
+}
+
+// RunBlobOutbox mocks base method.
+func (m *MockService) RunBlobOutbox(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunBlobOutbox", ctx, interval)
+}
+
+// RunBlobOutbox indicates an expected call of RunBlobOutbox.
+func (mr *MockServiceMockRecorder) RunBlobOutbox(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunBlobOutbox", reflect.TypeOf((*MockService)(nil).RunBlobOutbox), ctx, interval)
+}
+
+// RunTrashPurge mocks base method.
+func (m *MockService) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunTrashPurge", ctx, retention, interval)
+}
+
+// RunTrashPurge indicates an expected call of RunTrashPurge.
+func (mr *MockServiceMockRecorder) RunTrashPurge(ctx, retention, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunTrashPurge", reflect.TypeOf((*MockService)(nil).RunTrashPurge), ctx, retention, interval)
+}
+
+// UpdateBlob mocks base method.
+func (m *MockService) UpdateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBlob", ctx, data, content, size)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBlob indicates an expected call of UpdateBlob.
+func (mr *MockServiceMockRecorder) UpdateBlob(ctx, data, content, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBlob", reflect.TypeOf((*MockService)(nil).UpdateBlob), ctx, data, content, size)
+}
+
 // UpdateData mocks base method.
 func (m *MockService) UpdateData(ctx context.Context, data *models.Data) error {
 	m.ctrl.T.Helper()
@@ -139,3 +489,18 @@ func (mr *MockServiceMockRecorder) UpdateData(ctx, data interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockService)(nil).UpdateData), ctx, data)
 }
+
+// WatchChanges mocks base method.
+func (m *MockService) WatchChanges(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchChanges", ctx, userId)
+	ret0, _ := ret[0].(<-chan models.ChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchChanges indicates an expected call of WatchChanges.
+func (mr *MockServiceMockRecorder) WatchChanges(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchChanges", reflect.TypeOf((*MockService)(nil).WatchChanges), ctx, userId)
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+const (
+	// pendingBlobTimeout - время, после которого загруженный файл удаляется, если ссылающаяся на него запись так и не сохранена.
+	pendingBlobTimeout = 5 * time.Minute
+	// blobOperationRetry - время, после которого повторяется отложенная операция, не выполненная с первой попытки.
+	blobOperationRetry = 5 * time.Minute
+	// blobBatchSize - количество отложенных операций или файлов, обрабатываемых за один запрос к базе данных.
+	blobBatchSize = 100
+)
+
+// uploadFile загружает файл пользователя в хранилище файлов и записывает его отложенное удаление,
+// которое отменяется сохранением ссылающейся на файл записи. Если запись не сохранится,
+// файл будет удален обработчиком отложенных операций. Если тот же файл был удален из хранилища
+// до записи операции, он загружается повторно. Возвращает ключ и размер загруженного файла.
+func (s *service) uploadFile(ctx context.Context, userId int64, fileContent []byte) (string, int64, error) {
+	blobKey, err := s.blobStore.UploadFile(ctx, userId, fileContent)
+	if err != nil {
+		return "", 0, err
+	}
+
+	exists, err := s.addPendingBlob(ctx, userId, blobKey)
+	if err != nil {
+		return "", 0, err
+	}
+	if !exists {
+		// После записи операции файл уже не удаляется, поэтому повторная загрузка под тем же ключом сохранится
+		blobKey, err = s.blobStore.UploadFile(ctx, userId, fileContent)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	return blobKey, int64(len(fileContent)), nil
+}
+
+// uploadStream потоково загружает файл пользователя в хранилище файлов и записывает его отложенное удаление,
+// как uploadFile. Размер файла считается по прочитанным из content байтам. Если тот же файл был удален
+// из хранилища до записи операции, возвращается ошибка. Возвращает ключ и размер загруженного файла.
+func (s *service) uploadStream(ctx context.Context, userId int64, content io.Reader, size int64) (string, int64, error) {
+	counter := &countingReader{reader: content}
+	blobKey, err := s.blobStore.UploadStream(ctx, userId, counter, size)
+	if err != nil {
+		return "", 0, err
+	}
+
+	exists, err := s.addPendingBlob(ctx, userId, blobKey)
+	if err != nil {
+		return "", 0, err
+	}
+	if !exists {
+		// Поток уже прочитан, поэтому загрузить файл повторно может только клиент
+		return "", 0, fmt.Errorf("файл удален из хранилища во время загрузки, повторите загрузку")
+	}
+
+	return blobKey, counter.count, nil
+}
+
+// countingReader считает байты, прочитанные из reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read читает данные из reader и увеличивает счетчик прочитанных байтов.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// addPendingBlob записывает отложенное удаление только что загруженного файла и сообщает, есть ли файл в хранилище.
+// Ключ файла зависит только от содержимого, поэтому загрузка могла вернуть ключ файла, который удалялся
+// одновременно с ней. Удаление и запись операции выполняются под блокировкой файла, и после записи операции
+// файл больше не удаляется, поэтому проверки наличия файла после нее достаточно.
+// Если записать операцию не удалось, файл без ссылок удалит сборка мусора в хранилище файлов.
+func (s *service) addPendingBlob(ctx context.Context, userId int64, blobKey string) (bool, error) {
+	err := s.dbAdapter.AddPendingBlob(ctx, userId, blobKey, time.Now().Add(pendingBlobTimeout))
+	if err != nil {
+		return false, fmt.Errorf("ошибка регистрации загруженного файла: %w", err)
+	}
+
+	exists, err := s.blobStore.FileExists(ctx, blobKey)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки загруженного файла: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ProcessBlobOperations выполняет отложенные операции с хранилищем файлов, время которых наступило:
+// удаляет файлы, на которые так и не сослалась ни одна запись или больше не ссылается ни одна запись.
+// Не выполненные операции повторяются позже. Возвращает количество выполненных операций.
+func (s *service) ProcessBlobOperations(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	operations, err := s.dbAdapter.ClaimBlobOperations(ctx, now, now.Add(blobOperationRetry), blobBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.runBlobOperations(ctx, operations), nil
+}
+
+// runBlobOperations удаляет файлы отложенных операций из хранилища файлов и отмечает операции выполненными.
+// Файл не удаляется, если на него снова ссылается запись или его загрузили повторно после записи операции.
+// Ошибки записываются в лог и в операцию, которая будет повторена позже. Возвращает количество выполненных операций.
+func (s *service) runBlobOperations(ctx context.Context, operations []models.BlobOperation) int {
+	completed := 0
+
+	for _, operation := range operations {
+		_, err := s.dbAdapter.DeleteUnreferencedBlob(ctx, operation.BlobKey, operation.ID, func() error {
+			return s.blobStore.DeleteFile(ctx, operation.BlobKey)
+		})
+		if err != nil {
+			s.logger.Error("failed to delete file %s: %v", operation.BlobKey, err)
+			if err := s.dbAdapter.FailBlobOperation(ctx, operation.ID, err.Error()); err != nil {
+				s.logger.Error("failed to save blob operation %d error: %v", operation.ID, err)
+			}
+			continue
+		}
+
+		if err := s.dbAdapter.CompleteBlobOperation(ctx, operation.ID); err != nil {
+			s.logger.Error("failed to complete blob operation %d: %v", operation.ID, err)
+			continue
+		}
+		completed++
+	}
+
+	return completed
+}
+
+// CollectBlobGarbage удаляет из хранилища файлов файлы старше minAge, на которые не ссылается ни одна запись
+// и для которых нет отложенных операций. Такие файлы остаются, если сервер остановился после загрузки файла,
+// но до записи его отложенного удаления. Возвращает количество удаленных файлов.
+func (s *service) CollectBlobGarbage(ctx context.Context, minAge time.Duration) (int, error) {
+	deleted := 0
+	batch := make([]string, 0, blobBatchSize)
+
+	flush := func() error {
+		unreferenced, err := s.dbAdapter.GetUnreferencedBlobKeys(ctx, batch)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+
+		for _, key := range unreferenced {
+			// Пока файл проверялся, его могли загрузить повторно, поэтому перед удалением ссылки проверяются снова
+			removed, err := s.dbAdapter.DeleteUnreferencedBlob(ctx, key, 0, func() error {
+				return s.blobStore.DeleteFile(ctx, key)
+			})
+			if err != nil {
+				s.logger.Error("failed to delete unreferenced file %s: %v", key, err)
+				continue
+			}
+			if removed {
+				deleted++
+			}
+		}
+		return nil
+	}
+
+	err := s.blobStore.ListFiles(ctx, time.Now().Add(-minAge), func(key string) error {
+		batch = append(batch, key)
+		if len(batch) < blobBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		return deleted, fmt.Errorf("ошибка сборки мусора в хранилище файлов: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// MigrateLegacyBlobs копирует файлы, загруженные до хранения по хешу содержимого под общими ключами uploads/<имя файла>,
+// под ключи пользователей по хешу содержимого и переводит на копии записи пользователей.
+// Прежний файл удаляет обработчик отложенных операций, когда его скопируют все пользователи.
+// Файлы, которые не удалось скопировать, записываются в лог и копируются при следующем запуске.
+// Возвращает количество скопированных файлов.
+func (s *service) MigrateLegacyBlobs(ctx context.Context) (int, error) {
+	blobs, err := s.dbAdapter.GetLegacyBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, blob := range blobs {
+		content, err := s.blobStore.GetFile(ctx, blob.SourceKey)
+		if err != nil {
+			s.logger.Error("failed to read legacy file %s: %v", blob.SourceKey, err)
+			continue
+		}
+
+		key, size, err := s.uploadFile(ctx, blob.UserID, content)
+		if err != nil {
+			s.logger.Error("failed to copy legacy file %s: %v", blob.SourceKey, err)
+			continue
+		}
+
+		if err := s.dbAdapter.ReplaceLegacyBlob(ctx, blob, key, size); err != nil {
+			s.logger.Error("failed to replace legacy file %s: %v", blob.SourceKey, err)
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
+// RunBlobOutbox с периодом interval выполняет отложенные операции с хранилищем файлов до отмены ctx.
+// Ошибки записываются в лог и не прерывают работу. Неположительный interval отключает обработку.
+func (s *service) RunBlobOutbox(ctx context.Context, interval time.Duration) {
+	runEvery(ctx, interval, func() {
+		count, err := s.ProcessBlobOperations(ctx)
+		if err != nil {
+			s.logger.Error("failed to process blob operations: %v", err)
+			return
+		}
+		if count > 0 {
+			s.logger.Info("processed %d blob operations", count)
+		}
+	})
+}
+
+// RunBlobGC с периодом interval удаляет из хранилища файлов файлы старше minAge без ссылок до отмены ctx.
+// Ошибки записываются в лог и не прерывают работу. Неположительный interval отключает сборку мусора.
+func (s *service) RunBlobGC(ctx context.Context, minAge, interval time.Duration) {
+	runEvery(ctx, interval, func() {
+		count, err := s.CollectBlobGarbage(ctx, minAge)
+		if err != nil {
+			s.logger.Error("failed to collect blob garbage: %v", err)
+			return
+		}
+		if count > 0 {
+			s.logger.Info("deleted %d unreferenced files", count)
+		}
+	})
+}
+
+// runEvery вызывает fn с периодом interval до отмены ctx. Неположительный interval отключает вызовы.
+func runEvery(ctx context.Context, interval time.Duration, fn func()) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
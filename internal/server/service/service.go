@@ -2,37 +2,67 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	logging "github.com/Sofja96/GophKeeper.git/internal/server/logger"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
 	"github.com/Sofja96/GophKeeper.git/internal/server/storage/db"
-	"github.com/Sofja96/GophKeeper.git/internal/server/storage/minio"
 )
 
 // Service интерфейс предоставляет методы для работы с пользователями и данными.
 // Он включает операции для регистрации, авторизации, создания, получения, удаления и обновления данных.
 type Service interface {
 	RegisterUser(ctx context.Context, user *models.User) (*models.User, error)
-	LoginUser(ctx context.Context, user *models.User) (string, error)
-	CreateData(ctx context.Context, data *models.Data) (int64, error)
-	GetUserIDByUsername(ctx context.Context, username string) (int64, error)
+	LoginUser(ctx context.Context, user *models.User, totpCode string) (*models.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, sessionID string) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	EnableTOTP(ctx context.Context, userID int64, username string) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	CreateData(ctx context.Context, data *models.Data) (string, error)
 	GetData(ctx context.Context, userId int64) ([]models.Data, error)
-	DeleteData(ctx context.Context, dataId int64, userId int64) (bool, error)
+	ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, string, error)
+	GetChanges(ctx context.Context, userId int64, since int64, limit int) ([]models.DataChange, bool, error)
+	WatchChanges(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error)
+	DeleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (bool, error)
 	UpdateData(ctx context.Context, data *models.Data) error
+	BatchMutate(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error)
+	CreateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) (string, error)
+	UpdateBlob(ctx context.Context, data *models.Data, content io.Reader, size int64) error
+	DownloadBlob(ctx context.Context, dataId string, userId int64, writer io.Writer) error
+	FetchBlob(ctx context.Context, userId int64, blobId string, writer io.Writer) error
+	GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error)
+	RestoreRevision(ctx context.Context, dataId string, userId int64, revision int64, expectedVersion int64) (*models.Data, error)
+	ListTrash(ctx context.Context, userId int64) ([]models.Data, error)
+	RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error)
+	PurgeData(ctx context.Context, userId int64, dataId string) (int64, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	RunTrashPurge(ctx context.Context, retention, interval time.Duration)
+	ProcessBlobOperations(ctx context.Context) (int, error)
+	CollectBlobGarbage(ctx context.Context, minAge time.Duration) (int, error)
+	RunBlobOutbox(ctx context.Context, interval time.Duration)
+	MigrateLegacyBlobs(ctx context.Context) (int, error)
+	RunBlobGC(ctx context.Context, minAge, interval time.Duration)
 }
 
 type service struct {
-	dbAdapter   db.Adapter
-	minioClient minio.Client
-	logger      logging.ILogger
+	dbAdapter db.Adapter
+	blobStore blobstore.Store
+	logger    logging.ILogger
+	keySet    *interceptors.KeySet
 }
 
 // New создаёт новый экземпляр service с переданными зависимостями.
 // Возвращает интерфейс Service, который можно использовать для работы с данными и пользователями.
-func New(dbAdapter db.Adapter, minioClient minio.Client, logger logging.ILogger) Service {
+func New(dbAdapter db.Adapter, blobStore blobstore.Store, logger logging.ILogger, keySet *interceptors.KeySet) Service {
 	return &service{
-		dbAdapter:   dbAdapter,
-		minioClient: minioClient,
-		logger:      logger,
+		dbAdapter: dbAdapter,
+		blobStore: blobStore,
+		logger:    logger,
+		keySet:    keySet,
 	}
 }
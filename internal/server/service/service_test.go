@@ -1,18 +1,25 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp/totp"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	mlogger "github.com/Sofja96/GophKeeper.git/internal/server/logger/mocks"
+	mockblob "github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore/mocks"
 	mockdb "github.com/Sofja96/GophKeeper.git/internal/server/storage/db/mocks"
-	mockminio "github.com/Sofja96/GophKeeper.git/internal/server/storage/minio/mocks"
 	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 )
 
@@ -21,7 +28,7 @@ func TestService_RegisterUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	service := New(mockDB, nil, nil)
+	service := New(mockDB, nil, nil, nil)
 
 	ctx := context.Background()
 	user := &models.User{
@@ -68,8 +75,11 @@ func TestService_LoginUser(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	keys, err := interceptors.NewKeySet("test", interceptors.NewHMACKey("test", []byte("secret")))
+	assert.NoError(t, err)
+
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	service := New(mockDB, nil, nil)
+	service := New(mockDB, nil, nil, keys)
 
 	ctx := context.Background()
 	user := &models.User{
@@ -81,24 +91,68 @@ func TestService_LoginUser(t *testing.T) {
 		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).Return(true, nil)
 		mockDB.EXPECT().GetUserHashPassword(ctx, user.Username).
 			Return("$2a$10$k8sLGTcrvuI36ZsTddy7EOgarUqltq2nlu5qv2ZG1IiZbqzvYAqjG", nil)
+		mockDB.EXPECT().GetUserID(ctx, user.Username).Return(int64(1), nil)
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(nil, utils.ErrTOTPNotEnrolled)
+
+		var session *models.Session
+		mockDB.EXPECT().CreateSession(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, s *models.Session) error {
+				session = s
+				return nil
+			})
+
+		tokens, err := service.LoginUser(ctx, user, "")
+		assert.NoError(t, err)
+		assert.Contains(t, tokens.AccessToken, "Bearer ")
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.Equal(t, interceptors.AccessTokenExp, tokens.ExpiresIn)
+
+		// В базе данных хранится только хеш токена обновления
+		assert.Equal(t, int64(1), session.UserID)
+		assert.Equal(t, hashRefreshToken(tokens.RefreshToken), session.TokenHash)
+		assert.NotEqual(t, tokens.RefreshToken, session.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(refreshTokenExp), session.ExpiresAt, time.Minute)
 
-		token, err := service.LoginUser(ctx, user)
+		claims, err := keys.VerifyToken(strings.TrimPrefix(tokens.AccessToken, "Bearer "))
 		assert.NoError(t, err)
-		assert.Contains(t, token, "Bearer ")
+		assert.Equal(t, int64(1), claims.UserID)
+		assert.Equal(t, int64(1), tokens.UserID)
+		assert.Equal(t, user.Username, claims.User)
+		assert.Equal(t, session.ID, claims.SessionID)
+	})
+
+	t.Run("error creating session", func(t *testing.T) {
+		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).Return(true, nil)
+		mockDB.EXPECT().GetUserHashPassword(ctx, user.Username).
+			Return("$2a$10$k8sLGTcrvuI36ZsTddy7EOgarUqltq2nlu5qv2ZG1IiZbqzvYAqjG", nil)
+		mockDB.EXPECT().GetUserID(ctx, user.Username).Return(int64(1), nil)
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(nil, utils.ErrTOTPNotEnrolled)
+		mockDB.EXPECT().CreateSession(ctx, gomock.Any()).Return(errors.New("failed to create session"))
+
+		_, err := service.LoginUser(ctx, user, "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create session")
 	})
 
 	t.Run("user not found", func(t *testing.T) {
 		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).Return(false, nil)
 
-		_, err := service.LoginUser(ctx, user)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "users not found")
+		_, err := service.LoginUser(ctx, user, "")
+		assert.ErrorIs(t, err, utils.ErrInvalidCredentials)
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).Return(true, nil)
+		mockDB.EXPECT().GetUserHashPassword(ctx, user.Username).Return(dummyPasswordHash, nil)
+
+		_, err := service.LoginUser(ctx, user, "")
+		assert.ErrorIs(t, err, utils.ErrInvalidCredentials)
 	})
 	t.Run("error checking user", func(t *testing.T) {
 		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).
 			Return(false, fmt.Errorf("error checking existing user"))
 
-		_, err := service.LoginUser(ctx, user)
+		_, err := service.LoginUser(ctx, user, "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "error checking existing user")
 	})
@@ -108,42 +162,312 @@ func TestService_LoginUser(t *testing.T) {
 		mockDB.EXPECT().GetUserHashPassword(ctx, user.Username).
 			Return("", fmt.Errorf("error getting password on user"))
 
-		_, err := service.LoginUser(ctx, user)
+		_, err := service.LoginUser(ctx, user, "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "error getting password on user")
 	})
 }
 
-func TestGetUserIDByUsername(t *testing.T) {
+func TestService_LoginUserTOTP(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	keys, err := interceptors.NewKeySet("test", interceptors.NewHMACKey("test", []byte("secret")))
+	assert.NoError(t, err)
+
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	service := New(mockDB, nil, nil)
+	service := New(mockDB, nil, nil, keys)
 
 	ctx := context.Background()
 	user := &models.User{
 		Username: "testuser",
+		Password: "password123",
 	}
+	secret := "JBSWY3DPEHPK3PXP"
+	enabled := &models.TOTP{Secret: secret, Enabled: true}
 
-	t.Run("successful get user", func(t *testing.T) {
+	expectPassword := func() {
+		mockDB.EXPECT().GetUserIDByName(ctx, user.Username).Return(true, nil)
+		mockDB.EXPECT().GetUserHashPassword(ctx, user.Username).
+			Return("$2a$10$k8sLGTcrvuI36ZsTddy7EOgarUqltq2nlu5qv2ZG1IiZbqzvYAqjG", nil)
 		mockDB.EXPECT().GetUserID(ctx, user.Username).Return(int64(1), nil)
+	}
+
+	t.Run("code required", func(t *testing.T) {
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(enabled, nil)
+
+		_, err := service.LoginUser(ctx, user, "")
+		assert.ErrorIs(t, err, utils.ErrTOTPRequired)
+	})
+
+	t.Run("unconfirmed setup does not require code", func(t *testing.T) {
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret}, nil)
+		mockDB.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil)
+
+		_, err := service.LoginUser(ctx, user, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now())
+		assert.NoError(t, err)
+
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(enabled, nil)
+		mockDB.EXPECT().UseTOTPCounter(ctx, int64(1), gomock.Any()).Return(true, nil)
+		mockDB.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil)
+
+		tokens, err := service.LoginUser(ctx, user, code)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+
+	t.Run("replayed code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now())
+		assert.NoError(t, err)
+
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(enabled, nil)
+		mockDB.EXPECT().UseTOTPCounter(ctx, int64(1), gomock.Any()).Return(false, nil)
+
+		_, err = service.LoginUser(ctx, user, code)
+		assert.ErrorIs(t, err, utils.ErrInvalidTOTPCode)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(enabled, nil)
+		mockDB.EXPECT().UseRecoveryCode(ctx, int64(1), hashRecoveryCode("abcd-efgh-ijkl-mnop")).Return(true, nil)
+		mockDB.EXPECT().CreateSession(ctx, gomock.Any()).Return(nil)
+
+		_, err := service.LoginUser(ctx, user, "ABCD EFGH IJKL MNOP")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		expectPassword()
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(enabled, nil)
+		mockDB.EXPECT().UseRecoveryCode(ctx, int64(1), gomock.Any()).Return(false, nil)
+
+		_, err := service.LoginUser(ctx, user, "wrong")
+		assert.ErrorIs(t, err, utils.ErrInvalidTOTPCode)
+	})
+}
+
+func TestService_EnableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	service := New(mockDB, nil, nil, nil)
+	ctx := context.Background()
+
+	t.Run("saves new secret", func(t *testing.T) {
+		var saved string
+		mockDB.EXPECT().SaveTOTPSecret(ctx, int64(1), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ int64, secret string) error {
+				saved = secret
+				return nil
+			})
+
+		enrollment, err := service.EnableTOTP(ctx, 1, "testuser")
+		assert.NoError(t, err)
+		assert.Equal(t, saved, enrollment.Secret)
+		assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/GophKeeper:testuser?"))
+		assert.Contains(t, enrollment.URI, "secret="+saved)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		mockDB.EXPECT().SaveTOTPSecret(ctx, int64(1), gomock.Any()).Return(utils.ErrTOTPEnabled)
+
+		_, err := service.EnableTOTP(ctx, 1, "testuser")
+		assert.ErrorIs(t, err, utils.ErrTOTPEnabled)
+	})
+}
+
+func TestService_ConfirmTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	service := New(mockDB, nil, nil, nil)
+	ctx := context.Background()
+	secret := "JBSWY3DPEHPK3PXP"
+
+	t.Run("enables and returns recovery codes", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now())
+		assert.NoError(t, err)
+
+		var (
+			counter int64
+			hashes  []string
+		)
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret}, nil)
+		mockDB.EXPECT().EnableTOTP(ctx, int64(1), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ int64, c int64, recoveryHashes []string) error {
+				counter = c
+				hashes = recoveryHashes
+				return nil
+			})
+
+		codes, err := service.ConfirmTOTP(ctx, 1, code)
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+		assert.InDelta(t, time.Now().Unix()/30, counter, 1)
+
+		// В базе данных хранятся только хеши кодов восстановления
+		assert.Len(t, hashes, recoveryCodeCount)
+		for i, recoveryCode := range codes {
+			assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, recoveryCode)
+			assert.Equal(t, hashRecoveryCode(recoveryCode), hashes[i])
+		}
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret}, nil)
+
+		_, err := service.ConfirmTOTP(ctx, 1, "000000x")
+		assert.ErrorIs(t, err, utils.ErrInvalidTOTPCode)
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil)
+
+		_, err := service.ConfirmTOTP(ctx, 1, "123456")
+		assert.ErrorIs(t, err, utils.ErrTOTPEnabled)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(nil, utils.ErrTOTPNotEnrolled)
+
+		_, err := service.ConfirmTOTP(ctx, 1, "123456")
+		assert.ErrorIs(t, err, utils.ErrTOTPNotEnrolled)
+	})
+}
+
+func TestService_DisableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	service := New(mockDB, nil, nil, nil)
+	ctx := context.Background()
+	secret := "JBSWY3DPEHPK3PXP"
+
+	t.Run("valid code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now())
+		assert.NoError(t, err)
+
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil)
+		mockDB.EXPECT().UseTOTPCounter(ctx, int64(1), gomock.Any()).Return(true, nil)
+		mockDB.EXPECT().DisableTOTP(ctx, int64(1)).Return(nil)
 
-		userID, err := service.GetUserIDByUsername(ctx, user.Username)
+		assert.NoError(t, service.DisableTOTP(ctx, 1, code))
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret, Enabled: true}, nil)
+
+		err := service.DisableTOTP(ctx, 1, "")
+		assert.ErrorIs(t, err, utils.ErrInvalidTOTPCode)
+	})
+
+	t.Run("unconfirmed setup is canceled without code", func(t *testing.T) {
+		mockDB.EXPECT().GetTOTP(ctx, int64(1)).Return(&models.TOTP{Secret: secret}, nil)
+		mockDB.EXPECT().DisableTOTP(ctx, int64(1)).Return(nil)
+
+		assert.NoError(t, service.DisableTOTP(ctx, 1, ""))
+	})
+}
+
+func TestMatchTOTPCode(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXP"
+	now := time.Unix(1_700_000_000, 0)
+
+	for _, offset := range []time.Duration{-totpPeriod, 0, totpPeriod} {
+		code, err := totp.GenerateCode(secret, now.Add(offset))
+		assert.NoError(t, err)
+
+		counter, ok := matchTOTPCode(secret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, now.Add(offset).Unix()/30, counter)
+	}
+
+	code, err := totp.GenerateCode(secret, now.Add(-3*totpPeriod))
+	assert.NoError(t, err)
+	_, ok := matchTOTPCode(secret, code, now)
+	assert.False(t, ok)
+}
+
+func TestService_RefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keys, err := interceptors.NewKeySet("test", interceptors.NewHMACKey("test", []byte("secret")))
+	assert.NoError(t, err)
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	service := New(mockDB, nil, nil, keys)
+	ctx := context.Background()
+
+	t.Run("successful refresh", func(t *testing.T) {
+		var newHash string
+		mockDB.EXPECT().RotateSession(ctx, hashRefreshToken("refresh"), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, hash string, expiresAt time.Time) (*models.Session, error) {
+				newHash = hash
+				assert.WithinDuration(t, time.Now().Add(refreshTokenExp), expiresAt, time.Minute)
+				return &models.Session{ID: "session", UserID: 1, Username: "testuser", TokenHash: hash, ExpiresAt: expiresAt}, nil
+			})
+
+		tokens, err := service.RefreshToken(ctx, "refresh")
 		assert.NoError(t, err)
-		assert.Equal(t, int64(1), userID)
+		assert.NotEqual(t, "refresh", tokens.RefreshToken)
+		assert.Equal(t, hashRefreshToken(tokens.RefreshToken), newHash)
+
+		claims, err := keys.VerifyToken(strings.TrimPrefix(tokens.AccessToken, "Bearer "))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), claims.UserID)
+		assert.Equal(t, "testuser", claims.User)
+		assert.Equal(t, "session", claims.SessionID)
+	})
+
+	t.Run("reused token", func(t *testing.T) {
+		mockDB.EXPECT().RotateSession(ctx, hashRefreshToken("refresh"), gomock.Any(), gomock.Any()).
+			Return(nil, utils.ErrTokenReused)
+
+		_, err := service.RefreshToken(ctx, "refresh")
+		assert.ErrorIs(t, err, utils.ErrTokenReused)
 	})
 }
 
+func TestService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	service := New(mockDB, nil, nil, nil)
+	ctx := context.Background()
+
+	mockDB.EXPECT().RevokeSession(ctx, "session").Return(nil)
+	assert.NoError(t, service.Logout(ctx, "session"))
+
+	mockDB.EXPECT().IsSessionActive(ctx, "session").Return(false, nil)
+	active, err := service.IsSessionActive(ctx, "session")
+	assert.NoError(t, err)
+	assert.False(t, active)
+}
+
 func TestCreateData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	mockMinio := mockminio.NewMockClient(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
 	mockLogger := mlogger.NewMockILogger(ctrl)
 
-	s := New(mockDB, mockMinio, mockLogger)
+	s := New(mockDB, mockBlob, mockLogger, nil)
 
 	t.Run("successful creation of binary data", func(t *testing.T) {
 		data := &models.Data{
@@ -152,25 +476,49 @@ func TestCreateData(t *testing.T) {
 			DataContent: []byte("test content"),
 		}
 
-		mockMinio.EXPECT().UploadFile(gomock.Any(), data.FileName, data.DataContent).
-			Return("file_url", nil)
-		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		mockBlob.EXPECT().UploadFile(gomock.Any(), data.UserID, data.DataContent).
+			Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), data.UserID, "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *models.Data) (string, error) {
+				assert.Equal(t, "users/1/abc", d.BlobKey)
+				assert.Nil(t, d.DataContent)
+				return "00000000-0000-0000-0000-000000000001", nil
+			})
 
 		id, err := s.CreateData(context.Background(), data)
 		assert.NoError(t, err)
-		assert.Equal(t, int64(1), id)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", id)
+	})
+
+	t.Run("uploads file again when it was deleted before registration", func(t *testing.T) {
+		data := &models.Data{
+			DataType:    models.BinaryData,
+			FileName:    "testfile",
+			DataContent: []byte("test content"),
+		}
+
+		mockBlob.EXPECT().UploadFile(gomock.Any(), data.UserID, data.DataContent).
+			Return("users/1/abc", nil).Times(2)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), data.UserID, "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(false, nil)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("00000000-0000-0000-0000-000000000001", nil)
+
+		_, err := s.CreateData(context.Background(), data)
+		assert.NoError(t, err)
 	})
 
-	t.Run("failed to upload binary data to MinIO", func(t *testing.T) {
+	t.Run("failed to upload binary data to blob storage", func(t *testing.T) {
 		data := &models.Data{
 			DataType:    models.BinaryData,
 			FileName:    "testfile",
 			DataContent: []byte("test content"),
 		}
 
-		mockMinio.EXPECT().UploadFile(gomock.Any(), data.FileName, data.DataContent).
+		mockBlob.EXPECT().UploadFile(gomock.Any(), data.UserID, data.DataContent).
 			Return("", errors.New("upload failed"))
-		mockLogger.EXPECT().Error("ошибка загрузки в Minio: %v", gomock.Any()).Times(1)
+		mockLogger.EXPECT().Error("ошибка загрузки в хранилище файлов: %v", gomock.Any()).Times(1)
 
 		_, err := s.CreateData(context.Background(), data)
 		assert.Error(t, err)
@@ -182,11 +530,64 @@ func TestCreateData(t *testing.T) {
 			DataContent: []byte("test content"),
 		}
 
-		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("00000000-0000-0000-0000-000000000001", nil)
+
+		id, err := s.CreateData(context.Background(), data)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", id)
+	})
+
+	t.Run("returns data created with the same idempotency key", func(t *testing.T) {
+		data := &models.Data{
+			UserID:         1,
+			DataType:       models.BinaryData,
+			FileName:       "testfile",
+			DataContent:    []byte("test content"),
+			IdempotencyKey: "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192",
+		}
+
+		mockDB.EXPECT().GetCreatedData(gomock.Any(), int64(1), "", data.IdempotencyKey).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000007", Revision: 12, Version: 2}, nil)
+
+		id, err := s.CreateData(context.Background(), data)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000007", id)
+		assert.Equal(t, int64(12), data.Revision)
+		assert.Equal(t, int64(2), data.Version)
+	})
+
+	t.Run("creates data with a new idempotency key", func(t *testing.T) {
+		data := &models.Data{
+			UserID:         1,
+			DataType:       models.TextData,
+			DataContent:    []byte("test content"),
+			IdempotencyKey: "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192",
+		}
+
+		mockDB.EXPECT().GetCreatedData(gomock.Any(), int64(1), "", data.IdempotencyKey).
+			Return(nil, sql.ErrNoRows)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("00000000-0000-0000-0000-000000000001", nil)
+
+		id, err := s.CreateData(context.Background(), data)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", id)
+	})
+
+	t.Run("returns data created with the same client ID", func(t *testing.T) {
+		data := &models.Data{
+			ID:          "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191",
+			UserID:      1,
+			DataType:    models.TextData,
+			DataContent: []byte("test content"),
+		}
+
+		mockDB.EXPECT().GetCreatedData(gomock.Any(), int64(1), data.ID, "").
+			Return(&models.Data{ID: data.ID, Revision: 12, Version: 1}, nil)
 
 		id, err := s.CreateData(context.Background(), data)
 		assert.NoError(t, err)
-		assert.Equal(t, int64(1), id)
+		assert.Equal(t, data.ID, id)
+		assert.Equal(t, int64(12), data.Revision)
 	})
 }
 
@@ -195,15 +596,15 @@ func TestGetData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	mockMinio := mockminio.NewMockClient(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
 	mockLogger := mlogger.NewMockILogger(ctrl)
 
-	s := New(mockDB, mockMinio, mockLogger)
+	s := New(mockDB, mockBlob, mockLogger, nil)
 
 	t.Run("successful retrieval of data", func(t *testing.T) {
 		data := []models.Data{
 			{
-				ID:          1,
+				ID:          "00000000-0000-0000-0000-000000000001",
 				DataType:    models.TextData,
 				DataContent: []byte("test content"),
 			},
@@ -216,40 +617,45 @@ func TestGetData(t *testing.T) {
 		assert.Equal(t, data, result)
 	})
 
-	t.Run("successful retrieval of binary data", func(t *testing.T) {
+	t.Run("binary data is returned as manifest without content", func(t *testing.T) {
+		hash := strings.Repeat("ab", 32)
 		data := []models.Data{
 			{
-				ID:       1,
+				ID:       "00000000-0000-0000-0000-000000000001",
 				DataType: models.BinaryData,
-				Metadata: map[string]interface{}{"file_url": "file_url"},
+				BlobKey:  "users/1/" + hash,
+				BlobSize: 1024,
+				FileName: "report.pdf",
 			},
 		}
 
 		mockDB.EXPECT().GetData(gomock.Any(), int64(1)).Return(data, nil)
-		mockMinio.EXPECT().GetFile(gomock.Any(), "file_url").Return([]byte("test content"), nil)
 
 		result, err := s.GetData(context.Background(), 1)
 		assert.NoError(t, err)
-		assert.Equal(t, []byte("test content"), result[0].DataContent)
+		assert.Nil(t, result[0].DataContent)
+		assert.Equal(t, &models.BlobManifest{
+			ID:          "users/1/" + hash,
+			FileName:    "report.pdf",
+			Size:        1024,
+			ContentHash: hash,
+		}, result[0].Blob)
 	})
 
-	t.Run("failed to retrieve binary data from MinIO", func(t *testing.T) {
+	t.Run("legacy blob key has no content hash", func(t *testing.T) {
 		data := []models.Data{
 			{
-				ID:       1,
+				ID:       "00000000-0000-0000-0000-000000000001",
 				DataType: models.BinaryData,
-				Metadata: map[string]interface{}{"file_url": "file_url"},
+				BlobKey:  "uploads/file.bin",
 			},
 		}
 
 		mockDB.EXPECT().GetData(gomock.Any(), int64(1)).Return(data, nil)
-		mockMinio.EXPECT().GetFile(gomock.Any(), "file_url").
-			Return(nil, errors.New("failed to get file"))
-		mockLogger.EXPECT().Error("failed to load file from MinIO: %v", gomock.Any()).Times(1)
 
 		result, err := s.GetData(context.Background(), 1)
 		assert.NoError(t, err)
-		assert.Nil(t, result[0].DataContent)
+		assert.Equal(t, &models.BlobManifest{ID: "uploads/file.bin"}, result[0].Blob)
 	})
 
 	t.Run("no data found", func(t *testing.T) {
@@ -260,37 +666,20 @@ func TestGetData(t *testing.T) {
 		assert.Equal(t, utils.ErrUserDataNotFound, err)
 	})
 
-	t.Run("file_url not found in metadata for binary data", func(t *testing.T) {
+	t.Run("binary data without blob key has no manifest", func(t *testing.T) {
 		data := []models.Data{
 			{
-				ID:       1,
+				ID:       "00000000-0000-0000-0000-000000000001",
 				DataType: models.BinaryData,
 				Metadata: map[string]interface{}{},
 			},
 		}
 
 		mockDB.EXPECT().GetData(gomock.Any(), int64(1)).Return(data, nil)
-		mockLogger.EXPECT().Error("file_url not found in metadata for binary data").Times(1)
-
-		result, err := s.GetData(context.Background(), 1)
-		assert.NoError(t, err)
-		assert.Nil(t, result[0].DataContent)
-	})
-	t.Run("file_url is not a valid string", func(t *testing.T) {
-		data := []models.Data{
-			{
-				ID:       1,
-				DataType: models.BinaryData,
-				Metadata: map[string]interface{}{"file_url": 123},
-			},
-		}
-
-		mockDB.EXPECT().GetData(gomock.Any(), int64(1)).Return(data, nil)
-		mockLogger.EXPECT().Error("file_url is not a valid string").Times(1)
 
 		result, err := s.GetData(context.Background(), 1)
 		assert.NoError(t, err)
-		assert.Nil(t, result[0].DataContent)
+		assert.Nil(t, result[0].Blob)
 	})
 }
 
@@ -299,106 +688,114 @@ func TestDeleteData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	mockMinio := mockminio.NewMockClient(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
 	mockLogger := mlogger.NewMockILogger(ctrl)
 
-	s := New(mockDB, mockMinio, mockLogger)
+	s := New(mockDB, mockBlob, mockLogger, nil)
 
-	t.Run("successful deletion of binary data", func(t *testing.T) {
+	t.Run("binary data moved to trash keeps file in blob storage", func(t *testing.T) {
 		data := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   3,
 			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{"file_url": "file_url"},
+			BlobKey:  "users/3/abc",
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
-		mockMinio.EXPECT().DeleteFile(gomock.Any(), "file_url").Return(nil)
-		mockDB.EXPECT().DeleteData(gomock.Any(), int64(1), int64(3)).Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+		mockDB.EXPECT().DeleteData(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3), int64(0)).Return(true, nil)
 
-		success, err := s.DeleteData(context.Background(), 1, 3)
+		success, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
 		assert.NoError(t, err)
 		assert.True(t, success)
 	})
 
-	t.Run("failed to delete binary data from MinIO", func(t *testing.T) {
-		data := &models.Data{
-			ID:       1,
-			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{"file_url": "file_url"},
-		}
-
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
-		mockMinio.EXPECT().DeleteFile(gomock.Any(), "file_url").
-			Return(errors.New("failed to delete file"))
-
-		_, err := s.DeleteData(context.Background(), 1, 3)
-		assert.Error(t, err)
-	})
-
 	t.Run("successful deletion of non-binary data", func(t *testing.T) {
 		data := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   3,
 			DataType: models.TextData,
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
-		mockDB.EXPECT().DeleteData(gomock.Any(), int64(1), int64(3)).Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+		mockDB.EXPECT().DeleteData(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3), int64(0)).Return(true, nil)
 
-		success, err := s.DeleteData(context.Background(), 1, 3)
+		success, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
 		assert.NoError(t, err)
 		assert.True(t, success)
 	})
 
 	t.Run("data not found", func(t *testing.T) {
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).
 			Return(nil, errors.New("data not found"))
 
-		_, err := s.DeleteData(context.Background(), 1, 3)
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
 		assert.Error(t, err)
 	})
 
-	t.Run("file_url not found in metadata for binary data", func(t *testing.T) {
-		data := &models.Data{
-			ID:       1,
-			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{},
-		}
-
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
+	t.Run("data of another user is not deleted", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).
+			Return(nil, sql.ErrNoRows)
 
-		_, err := s.DeleteData(context.Background(), 1, 3)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "file_url не найден в метаданных")
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
 	})
 
 	t.Run("failed to delete data from database", func(t *testing.T) {
 		data := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   3,
 			DataType: models.TextData,
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
-		mockDB.EXPECT().DeleteData(gomock.Any(), int64(1), int64(3)).
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+		mockDB.EXPECT().DeleteData(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3), int64(0)).
 			Return(false, fmt.Errorf("ошибка удаления данных из базы данных"))
 
-		_, err := s.DeleteData(context.Background(), 1, 3)
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ошибка удаления данных из базы данных")
 	})
 
 	t.Run("data not found in database", func(t *testing.T) {
 		data := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   3,
 			DataType: models.TextData,
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(data, nil)
-		mockDB.EXPECT().DeleteData(gomock.Any(), int64(1), int64(3)).Return(false, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+		mockDB.EXPECT().DeleteData(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3), int64(0)).Return(false, nil)
 
-		_, err := s.DeleteData(context.Background(), 1, 3)
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 0)
 		assert.Error(t, err)
 		assert.Equal(t, utils.ErrUserDataNotFound, err)
 	})
+
+	t.Run("version conflict before deleting data", func(t *testing.T) {
+		data := &models.Data{
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   3,
+			DataType: models.BinaryData,
+			BlobKey:  "users/3/abc",
+			Version:  3,
+		}
+
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 2)
+		assert.Equal(t, &utils.VersionConflictError{CurrentVersion: 3}, err)
+	})
+
+	t.Run("version conflict in database", func(t *testing.T) {
+		data := &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 3, DataType: models.TextData, Version: 2}
+
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3)).Return(data, nil)
+		mockDB.EXPECT().DeleteData(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(3), int64(2)).
+			Return(false, &utils.VersionConflictError{CurrentVersion: 4})
+
+		_, err := s.DeleteData(context.Background(), "00000000-0000-0000-0000-000000000001", 3, 2)
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+	})
 }
 
 func TestUpdateData(t *testing.T) {
@@ -406,52 +803,53 @@ func TestUpdateData(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockDB := mockdb.NewMockAdapter(ctrl)
-	mockMinio := mockminio.NewMockClient(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
 	mockLogger := mlogger.NewMockILogger(ctrl)
 
-	s := New(mockDB, mockMinio, mockLogger)
+	s := New(mockDB, mockBlob, mockLogger, nil)
 
 	t.Run("successful update of binary data", func(t *testing.T) {
 		oldData := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
 			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{"file_url": "old_file_url"},
+			BlobKey:  "users/1/old",
 		}
 
 		newData := &models.Data{
-			ID:          1,
+			ID:          "00000000-0000-0000-0000-000000000001",
 			DataType:    models.BinaryData,
 			FileName:    "new_file",
 			DataContent: []byte("new content"),
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(oldData, nil)
-		mockMinio.EXPECT().UpdateFile(gomock.Any(),
-			"old_file_url", newData.FileName, newData.DataContent).
-			Return("new_file_url", nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).Return(oldData, nil)
+		mockBlob.EXPECT().UploadFile(gomock.Any(), newData.UserID, newData.DataContent).
+			Return("users/1/new", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), newData.UserID, "users/1/new", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/new").Return(true, nil)
 		mockDB.EXPECT().UpdateData(gomock.Any(), newData).Return(nil)
 
 		err := s.UpdateData(context.Background(), newData)
 		assert.NoError(t, err)
+		assert.Equal(t, "users/1/new", newData.BlobKey)
 	})
 
-	t.Run("failed to update binary data in MinIO", func(t *testing.T) {
+	t.Run("failed to update binary data in blob storage", func(t *testing.T) {
 		oldData := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
 			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{"file_url": "old_file_url"},
+			BlobKey:  "users/1/old",
 		}
 
 		newData := &models.Data{
-			ID:          1,
+			ID:          "00000000-0000-0000-0000-000000000001",
 			DataType:    models.BinaryData,
 			FileName:    "new_file",
 			DataContent: []byte("new content"),
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(oldData, nil)
-		mockMinio.EXPECT().UpdateFile(gomock.Any(),
-			"old_file_url", newData.FileName, newData.DataContent).
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).Return(oldData, nil)
+		mockBlob.EXPECT().UploadFile(gomock.Any(), newData.UserID, newData.DataContent).
 			Return("", errors.New("failed to update file"))
 
 		err := s.UpdateData(context.Background(), newData)
@@ -460,17 +858,17 @@ func TestUpdateData(t *testing.T) {
 
 	t.Run("successful update of non-binary data", func(t *testing.T) {
 		oldData := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
 			DataType: models.TextData,
 		}
 
 		newData := &models.Data{
-			ID:          1,
+			ID:          "00000000-0000-0000-0000-000000000001",
 			DataType:    models.TextData,
 			DataContent: []byte("new content"),
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(oldData, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).Return(oldData, nil)
 		mockDB.EXPECT().UpdateData(gomock.Any(), newData).Return(nil)
 
 		err := s.UpdateData(context.Background(), newData)
@@ -478,25 +876,38 @@ func TestUpdateData(t *testing.T) {
 	})
 
 	t.Run("data not found", func(t *testing.T) {
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).
 			Return(nil, errors.New("data not found"))
 
-		err := s.UpdateData(context.Background(), &models.Data{ID: 1})
+		err := s.UpdateData(context.Background(), &models.Data{ID: "00000000-0000-0000-0000-000000000001"})
 		assert.Error(t, err)
 	})
+
+	t.Run("file of another user is not overwritten", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(2)).
+			Return(nil, sql.ErrNoRows)
+
+		err := s.UpdateData(context.Background(), &models.Data{
+			ID:          "00000000-0000-0000-0000-000000000001",
+			UserID:      2,
+			FileName:    "new_file",
+			DataContent: []byte("new content"),
+		})
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
 	t.Run("failed to update data from database", func(t *testing.T) {
 		oldData := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
 			DataType: models.TextData,
 		}
 
 		newData := &models.Data{
-			ID:          1,
+			ID:          "00000000-0000-0000-0000-000000000001",
 			DataType:    models.TextData,
 			DataContent: []byte("new content"),
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(oldData, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).Return(oldData, nil)
 		mockDB.EXPECT().UpdateData(gomock.Any(), newData).
 			Return(errors.New("error update update data"))
 
@@ -504,24 +915,858 @@ func TestUpdateData(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("file_url not found in metadata for binary data", func(t *testing.T) {
+	t.Run("version conflict before updating file", func(t *testing.T) {
 		oldData := &models.Data{
-			ID:       1,
+			ID:       "00000000-0000-0000-0000-000000000001",
 			DataType: models.BinaryData,
-			Metadata: map[string]interface{}{},
+			BlobKey:  "users/1/old",
+			Version:  4,
 		}
 
 		newData := &models.Data{
-			ID:          1,
+			ID:          "00000000-0000-0000-0000-000000000001",
 			DataType:    models.BinaryData,
 			FileName:    "new_file",
 			DataContent: []byte("new content"),
+			Version:     3,
 		}
 
-		mockDB.EXPECT().GetDataByID(gomock.Any(), int64(1)).Return(oldData, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(0)).Return(oldData, nil)
 
 		err := s.UpdateData(context.Background(), newData)
+		assert.Equal(t, &utils.VersionConflictError{CurrentVersion: 4}, err)
+	})
+}
+
+func TestCreateBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	data := &models.Data{
+		UserID:   1,
+		FileName: "big_file",
+		Metadata: map[string]interface{}{"key": "value"},
+	}
+	content := strings.NewReader("file content")
+
+	t.Run("successful blob upload", func(t *testing.T) {
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(12)).
+			DoAndReturn(func(_ context.Context, _ int64, reader io.Reader, _ int64) (string, error) {
+				_, err := io.Copy(io.Discard, reader)
+				return "users/1/abc", err
+			})
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, d *models.Data) (string, error) {
+				assert.Equal(t, models.BinaryData, d.DataType)
+				assert.Nil(t, d.DataContent)
+				assert.Equal(t, "users/1/abc", d.BlobKey)
+				assert.Equal(t, int64(12), d.BlobSize)
+				assert.Equal(t, "value", d.Metadata["key"])
+				return "00000000-0000-0000-0000-000000000001", nil
+			})
+
+		id, err := s.CreateBlob(context.Background(), data, content, 12)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", id)
+	})
+
+	t.Run("failed to upload blob to blob storage", func(t *testing.T) {
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(12)).
+			Return("", errors.New("upload error"))
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		_, err := s.CreateBlob(context.Background(), data, content, 12)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "file_url не найден в метаданных")
 	})
+
+	t.Run("leaves uploaded blob to blob outbox when data is not saved", func(t *testing.T) {
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(12)).
+			Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int64, _ string, processAfter time.Time) error {
+				assert.WithinDuration(t, time.Now().Add(pendingBlobTimeout), processAfter, time.Minute)
+				return nil
+			})
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().CreateData(gomock.Any(), gomock.Any()).Return("", errors.New("db error"))
+
+		_, err := s.CreateBlob(context.Background(), data, content, 12)
+		assert.ErrorContains(t, err, "db error")
+	})
+
+	t.Run("blob deleted before registration", func(t *testing.T) {
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(12)).
+			Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(false, nil)
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		_, err := s.CreateBlob(context.Background(), data, content, 12)
+		assert.ErrorContains(t, err, "повторите загрузку")
+	})
+
+	t.Run("failed to register uploaded blob", func(t *testing.T) {
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(12)).
+			Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(errors.New("db error"))
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		_, err := s.CreateBlob(context.Background(), data, content, 12)
+		assert.ErrorContains(t, err, "ошибка регистрации загруженного файла")
+	})
+
+	t.Run("does not upload blob created with the same idempotency key", func(t *testing.T) {
+		replayed := &models.Data{UserID: 1, FileName: "big_file", IdempotencyKey: "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192"}
+		mockDB.EXPECT().GetCreatedData(gomock.Any(), int64(1), "", replayed.IdempotencyKey).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000007", Revision: 12, Version: 1}, nil)
+
+		id, err := s.CreateBlob(context.Background(), replayed, content, 12)
+		assert.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000007", id)
+		assert.Equal(t, int64(12), replayed.Revision)
+	})
+}
+
+func TestUpdateBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	oldData := &models.Data{
+		ID:       "00000000-0000-0000-0000-000000000001",
+		UserID:   1,
+		DataType: models.BinaryData,
+		BlobKey:  "users/1/old",
+	}
+	content := strings.NewReader("new content")
+
+	t.Run("successful blob update", func(t *testing.T) {
+		newData := &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, DataType: models.BinaryData, FileName: "new_file"}
+
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).Return(oldData, nil)
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(-1)).
+			DoAndReturn(func(_ context.Context, _ int64, reader io.Reader, _ int64) (string, error) {
+				_, err := io.Copy(io.Discard, reader)
+				return "users/1/new", err
+			})
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/new", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/new").Return(true, nil)
+		mockDB.EXPECT().UpdateData(gomock.Any(), newData).Return(nil)
+
+		err := s.UpdateBlob(context.Background(), newData, content, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, "users/1/new", newData.BlobKey)
+		assert.Equal(t, int64(11), newData.BlobSize)
+	})
+
+	t.Run("data not found", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).Return(nil, sql.ErrNoRows)
+
+		err := s.UpdateBlob(context.Background(), &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1}, content, -1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("data belongs to another user", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(2)).Return(oldData, nil)
+
+		err := s.UpdateBlob(context.Background(), &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 2}, content, -1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("data is not binary", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, DataType: models.TextData}, nil)
+
+		err := s.UpdateBlob(context.Background(), &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1}, content, -1)
+		assert.ErrorIs(t, err, utils.ErrNotBinaryData)
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, DataType: models.BinaryData, Version: 5, BlobKey: "users/1/old"}, nil)
+
+		err := s.UpdateBlob(context.Background(), &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, Version: 4}, content, -1)
+		assert.Equal(t, &utils.VersionConflictError{CurrentVersion: 5}, err)
+	})
+
+	t.Run("failed to upload blob to blob storage", func(t *testing.T) {
+		newData := &models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, DataType: models.BinaryData, FileName: "new_file"}
+
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).Return(oldData, nil)
+		mockBlob.EXPECT().UploadStream(gomock.Any(), int64(1), gomock.Any(), int64(-1)).
+			Return("", errors.New("upload error"))
+
+		err := s.UpdateBlob(context.Background(), newData, content, -1)
+		assert.Error(t, err)
+	})
+}
+
+func TestFetchBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("successful blob fetch", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		mockDB.EXPECT().GetBlobKeys(gomock.Any(), int64(1), []string{"users/1/abc"}).Return([]string{"users/1/abc"}, nil)
+		mockBlob.EXPECT().DownloadStream(gomock.Any(), "users/1/abc", &buf).
+			DoAndReturn(func(_ context.Context, _ string, writer io.Writer) error {
+				_, err := writer.Write([]byte("content"))
+				return err
+			})
+
+		err := s.FetchBlob(context.Background(), 1, "users/1/abc", &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "content", buf.String())
+	})
+
+	t.Run("blob belongs to another user", func(t *testing.T) {
+		mockDB.EXPECT().GetBlobKeys(gomock.Any(), int64(2), []string{"users/1/abc"}).Return([]string{}, nil)
+
+		err := s.FetchBlob(context.Background(), 2, "users/1/abc", io.Discard)
+		assert.ErrorIs(t, err, utils.ErrBlobNotFound)
+	})
+
+	t.Run("failed to check blob owner", func(t *testing.T) {
+		mockDB.EXPECT().GetBlobKeys(gomock.Any(), int64(1), []string{"users/1/abc"}).Return(nil, errors.New("db error"))
+
+		err := s.FetchBlob(context.Background(), 1, "users/1/abc", io.Discard)
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestDownloadBlob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("successful blob download", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).Return(&models.Data{
+			ID:       "00000000-0000-0000-0000-000000000001",
+			UserID:   1,
+			DataType: models.BinaryData,
+			BlobKey:  "users/1/abc",
+		}, nil)
+		mockBlob.EXPECT().DownloadStream(gomock.Any(), "users/1/abc", &buf).
+			DoAndReturn(func(_ context.Context, _ string, w io.Writer) error {
+				_, err := w.Write([]byte("file content"))
+				return err
+			})
+
+		err := s.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001", 1, &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "file content", buf.String())
+	})
+
+	t.Run("data belongs to another user", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 2, DataType: models.BinaryData}, nil)
+
+		err := s.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001", 1, io.Discard)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("blob key not found", func(t *testing.T) {
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000001", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000001", UserID: 1, DataType: models.BinaryData}, nil)
+
+		err := s.DownloadBlob(context.Background(), "00000000-0000-0000-0000-000000000001", 1, io.Discard)
+		assert.Error(t, err)
+	})
+}
+
+func TestListData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("returns last id when next page exists", func(t *testing.T) {
+		mockDB.EXPECT().ListData(gomock.Any(), int64(1), models.DataFilter{Limit: 3}).
+			Return([]models.Data{{ID: "00000000-0000-0000-0000-000000000001"}, {ID: "00000000-0000-0000-0000-000000000002"}, {ID: "00000000-0000-0000-0000-000000000003"}}, nil)
+
+		data, lastID, err := s.ListData(context.Background(), 1, models.DataFilter{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Data{{ID: "00000000-0000-0000-0000-000000000001"}, {ID: "00000000-0000-0000-0000-000000000002"}}, data)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000002", lastID)
+	})
+
+	t.Run("returns zero last id on the last page", func(t *testing.T) {
+		mockDB.EXPECT().ListData(gomock.Any(), int64(1), models.DataFilter{AfterID: "00000000-0000-0000-0000-000000000002", Limit: 3}).
+			Return([]models.Data{{ID: "00000000-0000-0000-0000-000000000003"}}, nil)
+
+		data, lastID, err := s.ListData(context.Background(), 1, models.DataFilter{AfterID: "00000000-0000-0000-0000-000000000002", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Data{{ID: "00000000-0000-0000-0000-000000000003"}}, data)
+		assert.Empty(t, lastID)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().ListData(gomock.Any(), int64(1), gomock.Any()).
+			Return(nil, errors.New("db error"))
+
+		_, _, err := s.ListData(context.Background(), 1, models.DataFilter{Limit: 2})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("reports more changes", func(t *testing.T) {
+		mockDB.EXPECT().GetChanges(gomock.Any(), int64(1), int64(10), 3).
+			Return([]models.DataChange{
+				{Data: models.Data{ID: "00000000-0000-0000-0000-000000000001", Revision: 11}},
+				{Data: models.Data{ID: "00000000-0000-0000-0000-000000000002", Revision: 12}, Deleted: true},
+				{Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", Revision: 13}},
+			}, nil)
+
+		changes, hasMore, err := s.GetChanges(context.Background(), 1, 10, 2)
+		assert.NoError(t, err)
+		assert.True(t, hasMore)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, int64(12), changes[1].Revision)
+	})
+
+	t.Run("last page of changes", func(t *testing.T) {
+		mockDB.EXPECT().GetChanges(gomock.Any(), int64(1), int64(12), 3).
+			Return([]models.DataChange{{Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", Revision: 13}}}, nil)
+
+		changes, hasMore, err := s.GetChanges(context.Background(), 1, 12, 2)
+		assert.NoError(t, err)
+		assert.False(t, hasMore)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().GetChanges(gomock.Any(), int64(1), int64(0), 3).
+			Return(nil, errors.New("db error"))
+
+		_, _, err := s.GetChanges(context.Background(), 1, 0, 2)
+		assert.Error(t, err)
+	})
+}
+
+func TestWatchChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("subscribes to user changes", func(t *testing.T) {
+		events := make(chan models.ChangeEvent, 1)
+		events <- models.ChangeEvent{UserID: 1, DataID: "00000000-0000-0000-0000-000000000002", Revision: 3}
+
+		mockDB.EXPECT().Subscribe(gomock.Any(), int64(1)).Return(events, nil)
+
+		ch, err := s.WatchChanges(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ChangeEvent{UserID: 1, DataID: "00000000-0000-0000-0000-000000000002", Revision: 3}, <-ch)
+	})
+
+	t.Run("subscribe error", func(t *testing.T) {
+		mockDB.EXPECT().Subscribe(gomock.Any(), int64(1)).Return(nil, errors.New("listener error"))
+
+		_, err := s.WatchChanges(context.Background(), 1)
+		assert.Error(t, err)
+	})
+}
+
+func TestListTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	mockDB.EXPECT().ListTrash(gomock.Any(), int64(1)).
+		Return([]models.Data{{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData}}, nil)
+
+	data, err := s.ListTrash(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, data, 1)
+}
+
+func TestRestoreData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("restores data from trash", func(t *testing.T) {
+		mockDB.EXPECT().RestoreData(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", Revision: 15, Version: 3}, nil)
+
+		data, err := s.RestoreData(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15), data.Revision)
+	})
+
+	t.Run("data not in trash", func(t *testing.T) {
+		mockDB.EXPECT().RestoreData(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).Return(nil, utils.ErrUserDataNotFound)
+
+		_, err := s.RestoreData(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+}
+
+func TestGetDataHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("returns data history", func(t *testing.T) {
+		history := []models.Data{{ID: "00000000-0000-0000-0000-000000000003", Revision: 12, Version: 2}, {ID: "00000000-0000-0000-0000-000000000003", Revision: 10, Version: 1}}
+		mockDB.EXPECT().GetDataHistory(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).Return(history, nil)
+
+		data, err := s.GetDataHistory(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, history, data)
+	})
+
+	t.Run("data not found", func(t *testing.T) {
+		mockDB.EXPECT().GetDataHistory(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).Return(nil, utils.ErrUserDataNotFound)
+
+		_, err := s.GetDataHistory(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+}
+
+func TestRestoreRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("restores data revision", func(t *testing.T) {
+		mockDB.EXPECT().RestoreRevision(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(2)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", Revision: 15, Version: 3}, nil)
+
+		data, err := s.RestoreRevision(context.Background(), "00000000-0000-0000-0000-000000000003", 1, 10, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15), data.Revision)
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		mockDB.EXPECT().RestoreRevision(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(1)).
+			Return(nil, &utils.VersionConflictError{CurrentVersion: 2})
+
+		_, err := s.RestoreRevision(context.Background(), "00000000-0000-0000-0000-000000000003", 1, 10, 1)
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+	})
+}
+
+func TestBatchMutate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	newMutations := func() []models.Mutation {
+		return []models.Mutation{
+			{Type: models.MutationCreate, Data: models.Data{
+				DataType: models.BinaryData, FileName: "file.txt", DataContent: []byte("content"),
+			}},
+			{Type: models.MutationUpdate, Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", DataContent: []byte("changed"), Version: 2}},
+		}
+	}
+
+	t.Run("uploads files and applies mutations", func(t *testing.T) {
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(1), []byte("content")).Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", UserID: 1, DataType: models.TextData, Version: 2}, nil)
+		mockDB.EXPECT().ApplyMutations(gomock.Any(), int64(1), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+				assert.Equal(t, "users/1/abc", mutations[0].Data.BlobKey)
+				assert.Nil(t, mutations[0].Data.DataContent)
+				assert.Equal(t, models.TextData, mutations[1].Data.DataType)
+				return []models.MutationResult{{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1}, {ID: "00000000-0000-0000-0000-000000000003", Revision: 21, Version: 3}}, nil
+			})
+
+		results, err := s.BatchMutate(context.Background(), 1, newMutations())
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+	})
+
+	t.Run("leaves uploaded files to outbox on version conflict", func(t *testing.T) {
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(1), []byte("content")).Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", UserID: 1, DataType: models.TextData, Version: 5}, nil)
+
+		_, err := s.BatchMutate(context.Background(), 1, newMutations())
+		var opErr *utils.OperationError
+		if assert.ErrorAs(t, err, &opErr) {
+			assert.Equal(t, 1, opErr.Index)
+		}
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+	})
+
+	t.Run("does not update data of another user", func(t *testing.T) {
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(1), []byte("content")).Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", UserID: 2, DataType: models.TextData}, nil)
+
+		_, err := s.BatchMutate(context.Background(), 1, newMutations())
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("does not upload files of replayed creation", func(t *testing.T) {
+		mutations := newMutations()[:1]
+		mutations[0].Data.IdempotencyKey = "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192"
+
+		mockDB.EXPECT().GetCreatedData(gomock.Any(), int64(1), "", mutations[0].Data.IdempotencyKey).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1}, nil)
+		mockDB.EXPECT().ApplyMutations(gomock.Any(), int64(1), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+				assert.Nil(t, mutations[0].Data.DataContent)
+				return []models.MutationResult{{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1}}, nil
+			})
+
+		results, err := s.BatchMutate(context.Background(), 1, mutations)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.MutationResult{{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1}}, results)
+	})
+
+	t.Run("leaves uploaded files to outbox on transaction error", func(t *testing.T) {
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(1), []byte("content")).Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().GetDataByID(gomock.Any(), "00000000-0000-0000-0000-000000000003", int64(1)).
+			Return(&models.Data{ID: "00000000-0000-0000-0000-000000000003", UserID: 1, DataType: models.TextData, Version: 2}, nil)
+		mockDB.EXPECT().ApplyMutations(gomock.Any(), int64(1), gomock.Any()).
+			Return(nil, &utils.OperationError{Index: 0, Err: errors.New("db error")})
+
+		_, err := s.BatchMutate(context.Background(), 1, newMutations())
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestPurgeData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("purges data and unreferenced files", func(t *testing.T) {
+		mockDB.EXPECT().PurgeData(gomock.Any(), int64(1), "").
+			Return(models.PurgeResult{Count: 2, BlobOperations: []models.BlobOperation{
+				{ID: 5, UserID: 1, BlobKey: "users/1/abc"},
+				{ID: 6, UserID: 1, BlobKey: "users/1/old"},
+			}}, nil)
+		expectDeleteUnreferencedBlob(mockDB, "users/1/abc", 5)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/abc").Return(nil)
+		mockDB.EXPECT().CompleteBlobOperation(gomock.Any(), int64(5)).Return(nil)
+		expectDeleteUnreferencedBlob(mockDB, "users/1/old", 6)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/old").Return(nil)
+		mockDB.EXPECT().CompleteBlobOperation(gomock.Any(), int64(6)).Return(nil)
+
+		count, err := s.PurgeData(context.Background(), 1, "")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("keeps files referenced by other data", func(t *testing.T) {
+		mockDB.EXPECT().PurgeData(gomock.Any(), int64(1), "00000000-0000-0000-0000-000000000003").
+			Return(models.PurgeResult{Count: 1, BlobOperations: []models.BlobOperation{}}, nil)
+
+		count, err := s.PurgeData(context.Background(), 1, "00000000-0000-0000-0000-000000000003")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("leaves file deletion to blob outbox on storage error", func(t *testing.T) {
+		mockDB.EXPECT().PurgeData(gomock.Any(), int64(1), "00000000-0000-0000-0000-000000000003").
+			Return(models.PurgeResult{Count: 1, BlobOperations: []models.BlobOperation{{ID: 5, UserID: 1, BlobKey: "users/1/abc"}}}, nil)
+		expectDeleteUnreferencedBlob(mockDB, "users/1/abc", 5)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/abc").Return(errors.New("minio error"))
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+		mockDB.EXPECT().FailBlobOperation(gomock.Any(), int64(5), "minio error").Return(nil)
+
+		count, err := s.PurgeData(context.Background(), 1, "00000000-0000-0000-0000-000000000003")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().PurgeData(gomock.Any(), int64(1), "").Return(models.PurgeResult{}, errors.New("db error"))
+
+		_, err := s.PurgeData(context.Background(), 1, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("purges data older than retention", func(t *testing.T) {
+		retention := 24 * time.Hour
+		mockDB.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, before time.Time) (models.PurgeResult, error) {
+				assert.WithinDuration(t, time.Now().Add(-retention), before, time.Minute)
+				return models.PurgeResult{Count: 1, BlobOperations: []models.BlobOperation{{ID: 5, UserID: 1, BlobKey: "users/1/abc"}}}, nil
+			})
+		expectDeleteUnreferencedBlob(mockDB, "users/1/abc", 5)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/abc").Return(nil)
+		mockDB.EXPECT().CompleteBlobOperation(gomock.Any(), int64(5)).Return(nil)
+
+		count, err := s.PurgeTrash(context.Background(), retention)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().PurgeTrash(gomock.Any(), gomock.Any()).Return(models.PurgeResult{}, errors.New("db error"))
+
+		_, err := s.PurgeTrash(context.Background(), time.Hour)
+		assert.Error(t, err)
+	})
+}
+
+func TestProcessBlobOperations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("deletes files and completes operations", func(t *testing.T) {
+		mockDB.EXPECT().ClaimBlobOperations(gomock.Any(), gomock.Any(), gomock.Any(), blobBatchSize).
+			DoAndReturn(func(_ context.Context, now, retryAt time.Time, _ int) ([]models.BlobOperation, error) {
+				assert.WithinDuration(t, time.Now(), now, time.Minute)
+				assert.Equal(t, blobOperationRetry, retryAt.Sub(now))
+				return []models.BlobOperation{
+					{ID: 5, UserID: 1, BlobKey: "users/1/abc"},
+					{ID: 6, UserID: 1, BlobKey: "users/1/def"},
+				}, nil
+			})
+		expectDeleteUnreferencedBlob(mockDB, "users/1/abc", 5)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/abc").Return(nil)
+		mockDB.EXPECT().CompleteBlobOperation(gomock.Any(), int64(5)).Return(nil)
+		expectDeleteUnreferencedBlob(mockDB, "users/1/def", 6)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), "users/1/def").Return(errors.New("minio error"))
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		mockDB.EXPECT().FailBlobOperation(gomock.Any(), int64(6), "minio error").Return(nil)
+
+		count, err := s.ProcessBlobOperations(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("keeps file registered again after operation", func(t *testing.T) {
+		mockDB.EXPECT().ClaimBlobOperations(gomock.Any(), gomock.Any(), gomock.Any(), blobBatchSize).
+			Return([]models.BlobOperation{{ID: 5, UserID: 1, BlobKey: "users/1/abc"}}, nil)
+		mockDB.EXPECT().DeleteUnreferencedBlob(gomock.Any(), "users/1/abc", int64(5), gomock.Any()).Return(false, nil)
+		mockDB.EXPECT().CompleteBlobOperation(gomock.Any(), int64(5)).Return(nil)
+
+		count, err := s.ProcessBlobOperations(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().ClaimBlobOperations(gomock.Any(), gomock.Any(), gomock.Any(), blobBatchSize).
+			Return(nil, errors.New("db error"))
+
+		_, err := s.ProcessBlobOperations(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrateLegacyBlobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("copies files under content-addressed keys", func(t *testing.T) {
+		first := models.LegacyBlob{BlobKey: "users/1/legacy/uploads/a.txt", SourceKey: "uploads/a.txt", UserID: 1}
+		second := models.LegacyBlob{BlobKey: "users/2/legacy/uploads/a.txt", SourceKey: "uploads/a.txt", UserID: 2}
+		missing := models.LegacyBlob{BlobKey: "users/2/legacy/uploads/b.txt", SourceKey: "uploads/b.txt", UserID: 2}
+
+		mockDB.EXPECT().GetLegacyBlobs(gomock.Any()).Return([]models.LegacyBlob{first, second, missing}, nil)
+
+		mockBlob.EXPECT().GetFile(gomock.Any(), "uploads/a.txt").Return([]byte("content"), nil).Times(2)
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(1), []byte("content")).Return("users/1/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(1), "users/1/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/1/abc").Return(true, nil)
+		mockDB.EXPECT().ReplaceLegacyBlob(gomock.Any(), first, "users/1/abc", int64(7)).Return(nil)
+		mockBlob.EXPECT().UploadFile(gomock.Any(), int64(2), []byte("content")).Return("users/2/abc", nil)
+		mockDB.EXPECT().AddPendingBlob(gomock.Any(), int64(2), "users/2/abc", gomock.Any()).Return(nil)
+		mockBlob.EXPECT().FileExists(gomock.Any(), "users/2/abc").Return(true, nil)
+		mockDB.EXPECT().ReplaceLegacyBlob(gomock.Any(), second, "users/2/abc", int64(7)).Return(nil)
+
+		mockBlob.EXPECT().GetFile(gomock.Any(), "uploads/b.txt").Return(nil, errors.New("not found"))
+		mockLogger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+
+		count, err := s.MigrateLegacyBlobs(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB.EXPECT().GetLegacyBlobs(gomock.Any()).Return(nil, errors.New("db error"))
+
+		_, err := s.MigrateLegacyBlobs(context.Background())
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+func TestCollectBlobGarbage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := mockdb.NewMockAdapter(ctrl)
+	mockBlob := mockblob.NewMockStore(ctrl)
+	mockLogger := mlogger.NewMockILogger(ctrl)
+
+	s := New(mockDB, mockBlob, mockLogger, nil)
+
+	t.Run("deletes unreferenced files older than min age", func(t *testing.T) {
+		keys := make([]string, blobBatchSize+1)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("users/1/%03d", i)
+		}
+
+		mockBlob.EXPECT().ListFiles(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, modifiedBefore time.Time, fn func(string) error) error {
+				assert.WithinDuration(t, time.Now().Add(-24*time.Hour), modifiedBefore, time.Minute)
+				for _, key := range keys {
+					if err := fn(key); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		mockDB.EXPECT().GetUnreferencedBlobKeys(gomock.Any(), keys[:blobBatchSize]).Return([]string{keys[0]}, nil)
+		expectDeleteUnreferencedBlob(mockDB, keys[0], 0)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), keys[0]).Return(nil)
+		mockDB.EXPECT().GetUnreferencedBlobKeys(gomock.Any(), keys[blobBatchSize:]).Return(keys[blobBatchSize:], nil)
+		expectDeleteUnreferencedBlob(mockDB, keys[blobBatchSize], 0)
+		mockBlob.EXPECT().DeleteFile(gomock.Any(), keys[blobBatchSize]).Return(nil)
+
+		count, err := s.CollectBlobGarbage(context.Background(), 24*time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("keeps files registered during collection", func(t *testing.T) {
+		mockBlob.EXPECT().ListFiles(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ time.Time, fn func(string) error) error {
+				return fn("users/1/abc")
+			})
+		mockDB.EXPECT().GetUnreferencedBlobKeys(gomock.Any(), []string{"users/1/abc"}).Return([]string{"users/1/abc"}, nil)
+		mockDB.EXPECT().DeleteUnreferencedBlob(gomock.Any(), "users/1/abc", int64(0), gomock.Any()).Return(false, nil)
+
+		count, err := s.CollectBlobGarbage(context.Background(), time.Hour)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockBlob.EXPECT().ListFiles(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ time.Time, fn func(string) error) error {
+				return fn("users/1/abc")
+			})
+		mockDB.EXPECT().GetUnreferencedBlobKeys(gomock.Any(), []string{"users/1/abc"}).Return(nil, errors.New("db error"))
+
+		_, err := s.CollectBlobGarbage(context.Background(), time.Hour)
+		assert.ErrorContains(t, err, "db error")
+	})
+}
+
+// expectDeleteUnreferencedBlob ожидает удаление файла с ключом key по операции operationID под блокировкой файла
+// и вызывает переданную функцию удаления, как адаптер базы данных, когда на файл нет ссылок.
+func expectDeleteUnreferencedBlob(mockDB *mockdb.MockAdapter, key string, operationID int64) {
+	mockDB.EXPECT().DeleteUnreferencedBlob(gomock.Any(), key, operationID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ int64, deleteFile func() error) (bool, error) {
+			if err := deleteFile(); err != nil {
+				return false, err
+			}
+			return true, nil
+		})
 }
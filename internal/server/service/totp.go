@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+const (
+	// totpIssuer - название сервиса, под которым аккаунт отображается в приложении-аутентификаторе.
+	totpIssuer = "GophKeeper"
+
+	// totpPeriod - длительность интервала, в течение которого действует один код.
+	totpPeriod = 30 * time.Second
+
+	// totpSkew - на сколько интервалов назад и вперед принимаются коды, чтобы допустить расхождение часов.
+	totpSkew = 1
+
+	// recoveryCodeCount - сколько кодов восстановления выдается при включении двухфакторной аутентификации.
+	recoveryCodeCount = 10
+)
+
+// EnableTOTP начинает настройку двухфакторной аутентификации пользователя userID с именем username:
+// создает новый секрет и возвращает его вместе со ссылкой otpauth:// для приложения-аутентификатора.
+// Настройка действует только после подтверждения кодом из приложения в ConfirmTOTP.
+func (s *service) EnableTOTP(ctx context.Context, userID int64, username string) (*models.TOTPEnrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: username,
+		Period:      uint(totpPeriod.Seconds()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	if err := s.dbAdapter.SaveTOTPSecret(ctx, userID, key.Secret()); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{Secret: key.Secret(), URI: key.URL()}, nil
+}
+
+// ConfirmTOTP включает двухфакторную аутентификацию, если code - верный код из приложения,
+// и возвращает новые одноразовые коды восстановления. Сервер хранит только их хеши.
+func (s *service) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	settings, err := s.dbAdapter.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Enabled {
+		return nil, utils.ErrTOTPEnabled
+	}
+
+	counter, ok := matchTOTPCode(settings.Secret, code, time.Now())
+	if !ok {
+		return nil, utils.ErrInvalidTOTPCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.dbAdapter.EnableTOTP(ctx, userID, counter, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP выключает двухфакторную аутентификацию, если code - верный код из приложения или код восстановления.
+// Неподтвержденная настройка отменяется без кода.
+func (s *service) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	settings, err := s.dbAdapter.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if settings.Enabled {
+		if err := s.verifySecondFactor(ctx, userID, settings, code); err != nil {
+			return err
+		}
+	}
+
+	return s.dbAdapter.DisableTOTP(ctx, userID)
+}
+
+// checkSecondFactor проверяет второй фактор при входе пользователя userID,
+// если у него включена двухфакторная аутентификация.
+// Если код не передан, возвращается ErrTOTPRequired.
+func (s *service) checkSecondFactor(ctx context.Context, userID int64, code string) error {
+	settings, err := s.dbAdapter.GetTOTP(ctx, userID)
+	if errors.Is(err, utils.ErrTOTPNotEnrolled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	if strings.TrimSpace(code) == "" {
+		return utils.ErrTOTPRequired
+	}
+	return s.verifySecondFactor(ctx, userID, settings, code)
+}
+
+// verifySecondFactor принимает код из приложения или код восстановления.
+// Каждый из них действует один раз; иначе возвращается ErrInvalidTOTPCode.
+func (s *service) verifySecondFactor(ctx context.Context, userID int64, settings *models.TOTP, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return utils.ErrInvalidTOTPCode
+	}
+
+	if counter, ok := matchTOTPCode(settings.Secret, code, time.Now()); ok {
+		used, err := s.dbAdapter.UseTOTPCounter(ctx, userID, counter)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return utils.ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	used, err := s.dbAdapter.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return utils.ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// matchTOTPCode ищет интервал, в котором code - верный код для секрета secret,
+// среди текущего интервала и totpSkew соседних, и возвращает его номер.
+func matchTOTPCode(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		expected, err := hotp.GenerateCodeCustom(secret, uint64(counter), hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRecoveryCode создает случайный код восстановления вида xxxx-xxxx-xxxx-xxxx.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// hashRecoveryCode возвращает SHA-256 кода восстановления в шестнадцатеричном виде.
+// Перед хешированием из кода убираются дефисы и пробелы, а буквы приводятся к нижнему регистру.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// ListTrash возвращает данные пользователя, находящиеся в корзине.
+// Содержимое бинарных данных из хранилища файлов не загружается.
+func (s *service) ListTrash(ctx context.Context, userId int64) ([]models.Data, error) {
+	return s.dbAdapter.ListTrash(ctx, userId)
+}
+
+// RestoreData возвращает данные пользователя из корзины. Файл бинарных данных остается в хранилище файлов,
+// пока данные находятся в корзине, поэтому восстанавливается только запись.
+func (s *service) RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	return s.dbAdapter.RestoreData(ctx, dataId, userId)
+}
+
+// PurgeData окончательно удаляет данные пользователя из корзины, как db.Adapter.PurgeData, и сразу выполняет
+// полученные операции удаления файлов из хранилища файлов; невыполненные операции повторит обработчик
+// отложенных операций. Возвращает количество удаленных записей.
+func (s *service) PurgeData(ctx context.Context, userId int64, dataId string) (int64, error) {
+	purged, err := s.dbAdapter.PurgeData(ctx, userId, dataId)
+	if err != nil {
+		return 0, err
+	}
+
+	s.runBlobOperations(ctx, purged.BlobOperations)
+
+	return purged.Count, nil
+}
+
+// PurgeTrash окончательно удаляет данные, находящиеся в корзине дольше retention, вместе с файлами в хранилище файлов,
+// на которые больше не ссылаются другие записи, как PurgeData. Возвращает количество удаленных записей.
+func (s *service) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.dbAdapter.PurgeTrash(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	s.runBlobOperations(ctx, purged.BlobOperations)
+
+	return purged.Count, nil
+}
+
+// RunTrashPurge с периодом interval удаляет данные, находящиеся в корзине дольше retention, до отмены ctx.
+// Ошибки очистки записываются в лог и не прерывают работу. Неположительный interval отключает очистку.
+func (s *service) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	runEvery(ctx, interval, func() {
+		count, err := s.PurgeTrash(ctx, retention)
+		if err != nil {
+			s.logger.Error("failed to purge trash: %v", err)
+			return
+		}
+		if count > 0 {
+			s.logger.Info("purged %d items from trash", count)
+		}
+	})
+}
@@ -2,13 +2,26 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 	"github.com/Sofja96/GophKeeper.git/internal/server/grpcserver/interceptors"
 	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 )
 
+// refreshTokenExp - время жизни токена обновления. Каждое обновление продлевает сессию на это время.
+const refreshTokenExp = 30 * 24 * time.Hour
+
+// dummyPasswordHash - bcrypt-хеш со стоимостью по умолчанию, с которым сверяется пароль неизвестного пользователя.
+const dummyPasswordHash = "$2a$10$QBpjo73rb15oWd3GCcQwM.H4VWI.Z4xx5hq7Jlzb2.1tLl1W9sweO"
+
 // RegisterUser регистрирует нового пользователя.
 // Проверяет, существует ли уже пользователь с данным именем.
 // Если существует, возвращает ошибку ErrUserExists.
@@ -44,38 +57,116 @@ func (s *service) RegisterUser(ctx context.Context, user *models.User) (*models.
 // LoginUser выполняет аутентификацию пользователя.
 // Проверяет, существует ли пользователь с данным именем.
 // Сравнивает введенный пароль с хешированным паролем в базе данных.
-// Если пароль верный, генерирует JWT токен для аутентифицированного пользователя.
-// Возвращает JWT токен в виде строки или ошибку.
-func (s *service) LoginUser(ctx context.Context, user *models.User) (string, error) {
+// Если у пользователя включена двухфакторная аутентификация, проверяет код totpCode из приложения
+// или код восстановления; без кода возвращается ErrTOTPRequired.
+// Если проверки пройдены, открывает новую сессию и выдает токен доступа и токен обновления.
+//
+// Для неизвестного пользователя и неверного пароля возвращается одна и та же ошибка ErrInvalidCredentials,
+// а пароль неизвестного пользователя сверяется с dummyPasswordHash, чтобы ответы не различались и по времени.
+func (s *service) LoginUser(ctx context.Context, user *models.User, totpCode string) (*models.TokenPair, error) {
 	existingUser, err := s.dbAdapter.GetUserIDByName(ctx, user.Username)
 	if err != nil {
-		return "", fmt.Errorf("error checking existing user: %w", err)
+		return nil, fmt.Errorf("error checking existing user: %w", err)
 	}
 	if !existingUser {
-		return "", fmt.Errorf("users not found, please to registration")
+		_ = utils.CheckPassword(user.Password, dummyPasswordHash)
+		return nil, utils.ErrInvalidCredentials
 	}
 
 	hash, err := s.dbAdapter.GetUserHashPassword(ctx, user.Username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	err = utils.CheckPassword(user.Password, hash)
 	if err != nil {
-		return "", fmt.Errorf("invalid password: %w", err)
+		return nil, utils.ErrInvalidCredentials
+	}
+
+	userID, err := s.dbAdapter.GetUserID(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkSecondFactor(ctx, userID, totpCode); err != nil {
+		return nil, err
+	}
+
+	refreshToken, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Username:  user.Username,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(refreshTokenExp),
+	}
+	if err := s.dbAdapter.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(session, refreshToken)
+}
+
+// RefreshToken выдает новый токен доступа по токену обновления и заменяет токен обновления новым.
+//
+// Если токен обновления неизвестен, отозван или истек, возвращается ErrSessionNotFound.
+// Если предъявлен уже замененный токен обновления, сессия отзывается и возвращается ErrTokenReused.
+func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.dbAdapter.RotateSession(ctx, hashRefreshToken(refreshToken), newHash, time.Now().Add(refreshTokenExp))
+	if err != nil {
+		return nil, err
 	}
-	token, err := interceptors.CreateToken(user.Username)
+
+	return s.issueTokens(session, newToken)
+}
+
+// Logout отзывает сессию sessionID: ее токены доступа и обновления перестают действовать.
+func (s *service) Logout(ctx context.Context, sessionID string) error {
+	return s.dbAdapter.RevokeSession(ctx, sessionID)
+}
+
+// IsSessionActive сообщает, что сессия sessionID не отозвана и не истекла.
+func (s *service) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return s.dbAdapter.IsSessionActive(ctx, sessionID)
+}
+
+// issueTokens создает токен доступа сессии session и возвращает его вместе с токеном обновления refreshToken.
+func (s *service) issueTokens(session *models.Session, refreshToken string) (*models.TokenPair, error) {
+	token, err := s.keySet.CreateToken(session.UserID, session.Username, session.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate JWT token: %w", err)
+		return nil, fmt.Errorf("failed to generate JWT token: %w", err)
 	}
-	var bearer = "Bearer " + token
 
-	return bearer, nil
+	return &models.TokenPair{
+		UserID:       session.UserID,
+		AccessToken:  interceptors.BearerSchema + token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    interceptors.AccessTokenExp,
+	}, nil
+}
+
+// newRefreshToken создает случайный токен обновления и возвращает его вместе с хешем для хранения в базе данных.
+func newRefreshToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
 
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashRefreshToken(token), nil
 }
 
-// GetUserIDByUsername возвращает ID пользователя по его имени.
-// Используется для получения уникального идентификатора пользователя из базы данных.
-func (s *service) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
-	return s.dbAdapter.GetUserID(ctx, username)
+// hashRefreshToken возвращает SHA-256 токена обновления в шестнадцатеричном виде.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
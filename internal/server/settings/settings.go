@@ -2,6 +2,7 @@ package settings
 
 import (
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,7 +12,13 @@ const (
 	envKeyServerHost      = "SERVER_HOST"
 	envKeyServerPort      = "SERVER_PORT"
 	envKeyDbDsn           = "DB_DSN"
+	envKeyDbMigrationDsn  = "DB_MIGRATION_DSN"
 	envKeyDbAutoMigration = "DB_AUTO_MIGRATION"
+	envKeyBlobBackend     = "BLOB_BACKEND"
+	envKeyBlobFSPath      = "BLOB_FS_PATH"
+	envKeyBlobOutbox      = "BLOB_OUTBOX_INTERVAL"
+	envKeyBlobGC          = "BLOB_GC_INTERVAL"
+	envKeyBlobGCMinAge    = "BLOB_GC_MIN_AGE"
 	envKeyMinioEndpoint   = "MINIO_ENDPOINT"
 	envKeyMinioUser       = "MINIO_ROOT_USER"
 	envKeyMinioPassword   = "MINIO_ROOT_PASSWORD"
@@ -19,22 +26,52 @@ const (
 	envKeyMinioUseSsl     = "MINIO_USE_SSL"
 	envKeyPathCert        = "CERT_PATH"
 	envKeyPathKey         = "KEY_PATH"
+	envKeyTrashRetention  = "TRASH_RETENTION_DAYS"
+	envKeyTrashPurge      = "TRASH_PURGE_INTERVAL"
+	envKeyJwtKeysFile     = "JWT_KEYS_FILE"
+	envKeyJwtSecret       = "JWT_SECRET"
+	envKeyJwtKeyID        = "JWT_KEY_ID"
+	envKeyRateIP          = "RATE_LIMIT_IP_RATE"
+	envKeyRateIPBurst     = "RATE_LIMIT_IP_BURST"
+	envKeyRateUser        = "RATE_LIMIT_USER_RATE"
+	envKeyRateUserBurst   = "RATE_LIMIT_USER_BURST"
+	envKeyLoginFailures   = "LOGIN_MAX_FAILURES"
+	envKeyLoginDelay      = "LOGIN_FAILURE_DELAY"
+	envKeyLoginLockout    = "LOGIN_LOCKOUT"
 )
 
 type Settings struct {
-	Debug           bool
-	Host            string
-	Port            string
-	DbDsn           string
-	DbSource        string
-	DbAutoMigration bool
-	MinioUser       string
-	MinioPassword   string
-	PathCert        string
-	PathKey         string
-	MinioEndpoint   string
-	MinioUseSsl     bool
-	MinioBucketName string
+	Debug              bool
+	Host               string
+	Port               string
+	DbDsn              string
+	DbMigrationDsn     string
+	DbSource           string
+	DbAutoMigration    bool
+	BlobBackend        string
+	BlobFSPath         string
+	BlobOutboxInterval time.Duration
+	BlobGCInterval     time.Duration
+	BlobGCMinAge       time.Duration
+	MinioUser          string
+	MinioPassword      string
+	PathCert           string
+	PathKey            string
+	MinioEndpoint      string
+	MinioUseSsl        bool
+	MinioBucketName    string
+	TrashRetentionDays int
+	TrashPurgeInterval time.Duration
+	JwtKeysFile        string
+	JwtSecret          string
+	JwtKeyID           string
+	RateLimitIPRate    float64
+	RateLimitIPBurst   int
+	RateLimitUserRate  float64
+	RateLimitUserBurst int
+	LoginMaxFailures   int
+	LoginFailureDelay  time.Duration
+	LoginLockout       time.Duration
 }
 
 // GetSettings загружает настройки из .env файла и переменных окружения,
@@ -45,7 +82,13 @@ func GetSettings() (*Settings, error) {
 		setEnv(envKeyServerHost, "0.0.0.0"),
 		setEnv(envKeyServerPort, "8080"),
 		setEnv(envKeyDbDsn, ""),
+		setEnv(envKeyDbMigrationDsn, ""),
 		setEnv(envKeyDbAutoMigration, true),
+		setEnv(envKeyBlobBackend, "minio"),
+		setEnv(envKeyBlobFSPath, "./blobs"),
+		setEnv(envKeyBlobOutbox, time.Minute),
+		setEnv(envKeyBlobGC, 24*time.Hour),
+		setEnv(envKeyBlobGCMinAge, 24*time.Hour),
 		setEnv(envKeyMinioUser, ""),
 		setEnv(envKeyMinioPassword, ""),
 		setEnv(envKeyPathCert, ""),
@@ -53,6 +96,18 @@ func GetSettings() (*Settings, error) {
 		setEnv(envKeyMinioEndpoint, "0.0.0.0:9000"),
 		setEnv(envKeyMinioUseSsl, false),
 		setEnv(envMinioBucketName, ""),
+		setEnv(envKeyTrashRetention, 30),
+		setEnv(envKeyTrashPurge, time.Hour),
+		setEnv(envKeyJwtKeysFile, ""),
+		setEnv(envKeyJwtSecret, ""),
+		setEnv(envKeyJwtKeyID, "default"),
+		setEnv(envKeyRateIP, 5.0),
+		setEnv(envKeyRateIPBurst, 20),
+		setEnv(envKeyRateUser, 0.1),
+		setEnv(envKeyRateUserBurst, 5),
+		setEnv(envKeyLoginFailures, 5),
+		setEnv(envKeyLoginDelay, time.Second),
+		setEnv(envKeyLoginLockout, 15*time.Minute),
 	}
 
 	for _, f := range setEnvFunc {
@@ -80,7 +135,10 @@ func newSettings() *Settings {
 		Host:            viper.GetString(envKeyServerHost),
 		Port:            viper.GetString(envKeyServerPort),
 		DbDsn:           viper.GetString(envKeyDbDsn),
+		DbMigrationDsn:  viper.GetString(envKeyDbMigrationDsn),
 		DbAutoMigration: viper.GetBool(envKeyDbAutoMigration),
+		BlobBackend:     viper.GetString(envKeyBlobBackend),
+		BlobFSPath:      viper.GetString(envKeyBlobFSPath),
 		MinioUser:       viper.GetString(envKeyMinioUser),
 		MinioPassword:   viper.GetString(envKeyMinioPassword),
 		PathCert:        viper.GetString(envKeyPathCert),
@@ -88,6 +146,24 @@ func newSettings() *Settings {
 		MinioEndpoint:   viper.GetString(envKeyMinioEndpoint),
 		MinioUseSsl:     viper.GetBool(envKeyMinioUseSsl),
 		MinioBucketName: viper.GetString(envMinioBucketName),
+
+		TrashRetentionDays: viper.GetInt(envKeyTrashRetention),
+		TrashPurgeInterval: viper.GetDuration(envKeyTrashPurge),
+		BlobOutboxInterval: viper.GetDuration(envKeyBlobOutbox),
+		BlobGCInterval:     viper.GetDuration(envKeyBlobGC),
+		BlobGCMinAge:       viper.GetDuration(envKeyBlobGCMinAge),
+
+		JwtKeysFile: viper.GetString(envKeyJwtKeysFile),
+		JwtSecret:   viper.GetString(envKeyJwtSecret),
+		JwtKeyID:    viper.GetString(envKeyJwtKeyID),
+
+		RateLimitIPRate:    viper.GetFloat64(envKeyRateIP),
+		RateLimitIPBurst:   viper.GetInt(envKeyRateIPBurst),
+		RateLimitUserRate:  viper.GetFloat64(envKeyRateUser),
+		RateLimitUserBurst: viper.GetInt(envKeyRateUserBurst),
+		LoginMaxFailures:   viper.GetInt(envKeyLoginFailures),
+		LoginFailureDelay:  viper.GetDuration(envKeyLoginDelay),
+		LoginLockout:       viper.GetDuration(envKeyLoginLockout),
 	}
 }
 
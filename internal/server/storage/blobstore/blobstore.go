@@ -0,0 +1,51 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// Поддерживаемые хранилища файлов бинарных данных, выбираемые настройкой BLOB_BACKEND.
+const (
+	BackendMinio      = "minio"
+	BackendFilesystem = "fs"
+	BackendPostgres   = "postgres"
+	BackendMemory     = "memory"
+)
+
+// Store представляет интерфейс хранилища файлов бинарных данных: загрузка, получение и удаление файлов.
+//
+// Файлы хранятся под ключами users/<ID пользователя>/<SHA-256 содержимого>, поэтому одинаковые файлы
+// пользователя хранятся один раз, а файлы разных пользователей не пересекаются.
+// Удаление отсутствующего файла не считается ошибкой. FileExists сообщает, есть ли в хранилище файл с ключом key.
+// ListFiles вызывает fn для ключа каждого файла, сохраненного раньше modifiedBefore, в произвольном порядке;
+// ошибка fn прерывает обход и возвращается вызывающему коду.
+type Store interface {
+	UploadFile(ctx context.Context, userID int64, fileContent []byte) (string, error)
+	DeleteFile(ctx context.Context, key string) error
+	FileExists(ctx context.Context, key string) (bool, error)
+	GetFile(ctx context.Context, key string) ([]byte, error)
+	UploadStream(ctx context.Context, userID int64, reader io.Reader, size int64) (string, error)
+	DownloadStream(ctx context.Context, key string, writer io.Writer) error
+	ListFiles(ctx context.Context, modifiedBefore time.Time, fn func(key string) error) error
+}
+
+// ObjectKey формирует ключ файла пользователя по хешу его содержимого: users/<ID пользователя>/<SHA-256>.
+func ObjectKey(userID int64, hash []byte) string {
+	return fmt.Sprintf("users/%d/%s", userID, hex.EncodeToString(hash))
+}
+
+// ContentHash возвращает SHA-256 содержимого файла в шестнадцатеричном виде из ключа, сформированного ObjectKey.
+// Для ключей файлов, загруженных до хранения по хешу содержимого, возвращает пустую строку.
+func ContentHash(key string) string {
+	hash := path.Base(key)
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha256.Size {
+		return ""
+	}
+	return hash
+}
@@ -0,0 +1,210 @@
+// Package blobstoretest содержит общий набор тестов, которому должна соответствовать каждая реализация blobstore.Store.
+package blobstoretest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+)
+
+// Run проверяет, что store соответствует контракту blobstore.Store.
+// Файлы загружаются от имени пользователей с ID 1 и 2, поэтому хранилище может быть общим с другими тестами
+// только если они не удаляют файлы этих пользователей.
+func Run(t *testing.T, store blobstore.Store) {
+	ctx := context.Background()
+
+	t.Run("upload returns content-addressed key", func(t *testing.T) {
+		content := []byte("conformance: upload")
+		hash := sha256.Sum256(content)
+
+		key, err := store.UploadFile(ctx, 1, content)
+		require.NoError(t, err)
+		assert.Equal(t, blobstore.ObjectKey(1, hash[:]), key)
+
+		stored, err := store.GetFile(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+	})
+
+	t.Run("same content is stored under one key per user", func(t *testing.T) {
+		content := []byte("conformance: dedupe")
+
+		first, err := store.UploadFile(ctx, 1, content)
+		require.NoError(t, err)
+		second, err := store.UploadFile(ctx, 1, content)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+
+		streamed, err := store.UploadStream(ctx, 1, bytes.NewReader(content), int64(len(content)))
+		require.NoError(t, err)
+		assert.Equal(t, first, streamed)
+
+		otherUser, err := store.UploadFile(ctx, 2, content)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, otherUser)
+		assert.True(t, strings.HasPrefix(otherUser, "users/2/"))
+	})
+
+	t.Run("different content is stored under different keys", func(t *testing.T) {
+		oldKey, err := store.UploadFile(ctx, 1, []byte("conformance: old"))
+		require.NoError(t, err)
+		newKey, err := store.UploadFile(ctx, 1, []byte("conformance: new"))
+		require.NoError(t, err)
+		assert.NotEqual(t, oldKey, newKey)
+
+		oldContent, err := store.GetFile(ctx, oldKey)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("conformance: old"), oldContent)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		key, err := store.UploadStream(ctx, 1, bytes.NewReader(nil), 0)
+		require.NoError(t, err)
+
+		stored, err := store.GetFile(ctx, key)
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+	})
+
+	for _, tc := range []struct {
+		name string
+		size func(content []byte) int64
+	}{
+		{name: "stream with known size", size: func(content []byte) int64 { return int64(len(content)) }},
+		{name: "stream with unknown size", size: func([]byte) int64 { return -1 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Файл больше частей, которыми хранилища читают и записывают потоки
+			content := bytes.Repeat([]byte(tc.name), (3<<20)/len(tc.name))
+			hash := sha256.Sum256(content)
+
+			key, err := store.UploadStream(ctx, 1, bytes.NewReader(content), tc.size(content))
+			require.NoError(t, err)
+			assert.Equal(t, blobstore.ObjectKey(1, hash[:]), key)
+
+			var buf bytes.Buffer
+			require.NoError(t, store.DownloadStream(ctx, key, &buf))
+			assert.Equal(t, content, buf.Bytes())
+		})
+	}
+
+	t.Run("failed stream is not stored", func(t *testing.T) {
+		_, err := store.UploadStream(ctx, 1, io.MultiReader(strings.NewReader("partial"), failingReader{}), -1)
+		assert.Error(t, err)
+
+		hash := sha256.Sum256([]byte("partial"))
+		_, err = store.GetFile(ctx, blobstore.ObjectKey(1, hash[:]))
+		assert.Error(t, err)
+	})
+
+	t.Run("concurrent uploads of same content", func(t *testing.T) {
+		content := []byte("conformance: concurrent")
+
+		var wg sync.WaitGroup
+		keys := make([]string, 8)
+		errs := make([]error, len(keys))
+		for i := range keys {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				keys[i], errs[i] = store.UploadStream(ctx, 1, bytes.NewReader(content), int64(len(content)))
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range keys {
+			require.NoError(t, errs[i])
+			assert.Equal(t, keys[0], keys[i])
+		}
+
+		stored, err := store.GetFile(ctx, keys[0])
+		require.NoError(t, err)
+		assert.Equal(t, content, stored)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		key, err := store.UploadFile(ctx, 1, []byte("conformance: delete"))
+		require.NoError(t, err)
+
+		exists, err := store.FileExists(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		require.NoError(t, store.DeleteFile(ctx, key))
+
+		exists, err = store.FileExists(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		_, err = store.GetFile(ctx, key)
+		assert.Error(t, err)
+		assert.Error(t, store.DownloadStream(ctx, key, io.Discard))
+
+		// Повторное удаление не считается ошибкой
+		assert.NoError(t, store.DeleteFile(ctx, key))
+	})
+
+	t.Run("list files", func(t *testing.T) {
+		beforeUpload := time.Now().Add(-time.Hour)
+
+		key, err := store.UploadFile(ctx, 1, []byte("conformance: list"))
+		require.NoError(t, err)
+
+		var listed []string
+		err = store.ListFiles(ctx, time.Now().Add(time.Minute), func(key string) error {
+			listed = append(listed, key)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Contains(t, listed, key)
+
+		listed = nil
+		err = store.ListFiles(ctx, beforeUpload, func(key string) error {
+			listed = append(listed, key)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.NotContains(t, listed, key)
+
+		// Ошибка обработчика прерывает обход
+		errStop := errors.New("stop")
+		err = store.ListFiles(ctx, time.Now().Add(time.Minute), func(string) error { return errStop })
+		assert.ErrorIs(t, err, errStop)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		hash := sha256.Sum256([]byte("conformance: missing"))
+		key := blobstore.ObjectKey(1, hash[:])
+
+		_, err := store.GetFile(ctx, key)
+		assert.Error(t, err)
+		assert.Error(t, store.DownloadStream(ctx, key, io.Discard))
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		_, err := store.GetFile(ctx, "")
+		assert.Error(t, err)
+		assert.Error(t, store.DownloadStream(ctx, "", io.Discard))
+
+		_, err = store.FileExists(ctx, "")
+		assert.Error(t, err)
+	})
+}
+
+// failingReader имитирует обрыв потока при загрузке файла.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,166 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/db"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/db/dbtest"
+)
+
+func TestSQLiteAdapter(t *testing.T) {
+	adapter, err := db.NewAdapter(&settings.Settings{
+		DbDsn:           "sqlite://" + filepath.Join(t.TempDir(), "keeper.db"),
+		DbAutoMigration: true,
+	})
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	dbtest.Run(t, adapter)
+}
+
+func TestSQLiteAdapter_InMemory(t *testing.T) {
+	adapter, err := db.NewAdapter(&settings.Settings{
+		DbDsn:           "sqlite://:memory:",
+		DbAutoMigration: true,
+	})
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	dbtest.Run(t, adapter)
+
+	// Каждый адаптер в памяти работает с собственной базой данных.
+	other, err := db.NewAdapter(&settings.Settings{
+		DbDsn:           "sqlite://:memory:",
+		DbAutoMigration: true,
+	})
+	require.NoError(t, err)
+	defer other.Close()
+
+	exists, err := other.GetUserIDByName(context.Background(), "alice")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestSQLiteAdapter_EmptyPath(t *testing.T) {
+	_, err := db.NewAdapter(&settings.Settings{DbDsn: "sqlite://"})
+	require.Error(t, err)
+}
+
+func TestPostgresAdapter(t *testing.T) {
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:16-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "keeper",
+				"POSTGRES_PASSWORD": "secret",
+				"POSTGRES_DB":       "keeper",
+			},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	ownerDsn := fmt.Sprintf("postgres://keeper:secret@%s:%s/keeper?sslmode=disable", host, port.Port())
+	appDsn := fmt.Sprintf("postgres://keeper_app:app_secret@%s:%s/keeper?sslmode=disable", host, port.Port())
+
+	// Роль сервера создается так же, как в deploy/postgres/init.sql, а права на таблицы ей выдают миграции
+	owner, err := sql.Open("postgres", ownerDsn)
+	require.NoError(t, err)
+	defer owner.Close()
+	_, err = owner.ExecContext(ctx, `create role keeper_app login password 'app_secret' nosuperuser nobypassrls`)
+	require.NoError(t, err)
+
+	adapter, err := db.NewAdapter(&settings.Settings{
+		DbDsn:           appDsn,
+		DbMigrationDsn:  ownerDsn,
+		DbAutoMigration: true,
+	})
+	require.NoError(t, err)
+	defer adapter.Close()
+
+	dbtest.Run(t, adapter)
+
+	t.Run("row level security", func(t *testing.T) {
+		alice, err := adapter.GetUserID(ctx, "alice")
+		require.NoError(t, err)
+		bob, err := adapter.GetUserID(ctx, "bob")
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, owner.QueryRowContext(ctx, `select count(*) from data where user_id = $1`, alice).Scan(&count))
+		require.NotZero(t, count)
+
+		app, err := sql.Open("postgres", appDsn)
+		require.NoError(t, err)
+		defer app.Close()
+
+		var privileged bool
+		require.NoError(t, app.QueryRowContext(ctx,
+			`select rolsuper or rolbypassrls from pg_roles where rolname = current_user`).Scan(&privileged))
+		assert.False(t, privileged)
+
+		// Без владельца транзакции записи не видны
+		require.NoError(t, app.QueryRowContext(ctx, `select count(*) from data`).Scan(&count))
+		assert.Zero(t, count)
+		require.NoError(t, app.QueryRowContext(ctx, `select count(*) from data_revisions`).Scan(&count))
+		assert.Zero(t, count)
+
+		tx, err := app.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback() }()
+
+		_, err = tx.ExecContext(ctx, `select set_config('app.user_id', $1, true)`, strconv.FormatInt(bob, 10))
+		require.NoError(t, err)
+
+		// Запрос без условия по user_id видит только записи bob
+		require.NoError(t, tx.QueryRowContext(ctx, `select count(*) from data where user_id = $1`, alice).Scan(&count))
+		assert.Zero(t, count)
+		require.NoError(t, tx.QueryRowContext(ctx, `select count(*) from data_revisions where user_id = $1`, alice).Scan(&count))
+		assert.Zero(t, count)
+		require.NoError(t, tx.QueryRowContext(ctx, `select count(*) from data where user_id <> $1`, bob).Scan(&count))
+		assert.Zero(t, count)
+
+		res, err := tx.ExecContext(ctx, `update data set data_content = 'stolen' where user_id = $1`, alice)
+		require.NoError(t, err)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Zero(t, affected)
+
+		// Таблицу версий миграций роль сервера не читает и не меняет
+		_, err = app.ExecContext(ctx, `delete from migration`)
+		assert.Error(t, err)
+	})
+}
+
+// skipWithoutDocker пропускает тест, если Docker недоступен: testcontainers в этом случае завершается паникой.
+func skipWithoutDocker(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
@@ -3,54 +3,157 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 )
 
-// CreateData создает новую запись данных в базе данных.
-//
-// Эта функция использует транзакцию для создания записи данных. Она вставляет запись в таблицу данных,
-// включая данные пользователя, тип данных, содержимое и метаданные. В случае успеха возвращает ID созданной записи.
-//
-// Если при создании данных происходит ошибка, транзакция будет отменена, и функция вернет ошибку.
-func (db *dbAdapter) CreateData(ctx context.Context, data *models.Data) (int64, error) {
-	tx, err := db.conn.BeginTx(ctx, nil)
+// CreateData создает запись в транзакции от имени пользователя и публикует уведомление через pg_notify.
+func (db *dbAdapter) CreateData(ctx context.Context, data *models.Data) (string, error) {
+	tx, err := db.beginWriteTx(ctx, data.UserID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return "", err
 	}
 
 	defer func() { _ = tx.Rollback() }()
 
-	query := `insert into data(user_id, data_type, data_content, metadata)
-			values ($1, $2, $3, $4) RETURNING id`
-
-	var id int64
-	err = tx.QueryRowContext(ctx, query, data.UserID, data.DataType, data.DataContent, data.Metadata).Scan(&id)
+	id, err := insertData(ctx, tx.Tx, data)
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert data: %w", err)
+		return "", err
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
 	return id, nil
 }
 
-// GetData получает все данные пользователя из базы данных по его ID.
-//
-// Функция извлекает список данных пользователя по его ID, сортируя записи по дате создания.
-// Если пользователь не имеет данных, возвращает пустой срез.
+// insertData вставляет запись данных в транзакции tx и публикует уведомление об изменении.
+// Запись создается с ID data.ID, сгенерированным клиентом; если он пуст, ID генерируется.
+// Если у пользователя уже есть запись с тем же ID или ключом идемпотентности data.IdempotencyKey,
+// новая запись не создается, а возвращается ID существующей записи вместе с ее текущими ревизией и версией.
+// Если запись с таким ID принадлежит другому пользователю, возвращается utils.ErrDataExists.
+func insertData(ctx context.Context, tx *sql.Tx, data *models.Data) (string, error) {
+	if data.ID == "" {
+		data.ID = models.NewDataID()
+	}
+
+	err := saveBlob(ctx, tx, data.BlobKey, data.BlobSize, data.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	query := `insert into data(id, user_id, data_type, data_content, metadata, idempotency_key, blob_key, file_name)
+			values ($1, $2, $3, $4, $5, nullif($6, '')::uuid, nullif($7, ''), nullif($8, ''))
+			on conflict do nothing
+			RETURNING revision, version`
+
+	var revision, version int64
+	err = tx.QueryRowContext(ctx, query, data.ID, data.UserID, data.DataType, data.DataContent, data.Metadata,
+		data.IdempotencyKey, data.BlobKey, data.FileName).Scan(&revision, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return createdData(ctx, tx, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to insert data: %w", err)
+	}
+
+	err = notifyChange(ctx, tx, models.ChangeEvent{UserID: data.UserID, DataID: data.ID, Revision: revision})
+	if err != nil {
+		return "", err
+	}
+
+	data.Revision = revision
+	data.Version = version
+	return data.ID, nil
+}
+
+// saveBlob регистрирует файл пользователя с ключом key и размером size в транзакции tx, если он еще не зарегистрирован,
+// и отменяет отложенные удаления этого файла: на него ссылается сохраняемая запись.
+// Файл блокируется до конца транзакции, поэтому его удаление, начатое позже, увидит регистрацию и файл не удалит.
+// Число ссылок на файл обновляется триггерами при сохранении ссылающихся на него записей. Пустой ключ пропускается.
+func saveBlob(ctx context.Context, tx *sql.Tx, key string, size int64, userId int64) error {
+	if key == "" {
+		return nil
+	}
+
+	if err := lockBlob(ctx, tx, key); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `insert into blobs (object_key, user_id, size) values ($1, $2, $3)
+			 on conflict (object_key) do nothing`, key, userId, size)
+	if err != nil {
+		return fmt.Errorf("failed to save blob: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from blob_outbox where object_key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending blob operations: %w", err)
+	}
+
+	return nil
+}
+
+// createdData возвращает ID записи пользователя, ранее созданной с ID data.ID или ключом идемпотентности
+// data.IdempotencyKey, и сохраняет ее текущие ревизию и версию в data.Revision и data.Version.
+func createdData(ctx context.Context, tx *sql.Tx, data *models.Data) (string, error) {
+	query := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '')::uuid or idempotency_key = nullif($3, '')::uuid) limit 1`
+
+	var id string
+	err := tx.QueryRowContext(ctx, query, data.UserID, data.ID, data.IdempotencyKey).
+		Scan(&id, &data.Revision, &data.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", utils.ErrDataExists
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get created data: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetCreatedData ищет запись в транзакции от имени пользователя.
+func (db *dbAdapter) GetCreatedData(ctx context.Context, userId int64, dataId string, idempotencyKey string) (*models.Data, error) {
+	query := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '')::uuid or idempotency_key = nullif($3, '')::uuid) limit 1`
+
+	var data models.Data
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &data, query, userId, dataId, idempotencyKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// blobColumns - столбцы описания файла бинарных данных записи: ключ, имя и размер файла.
+// Размер хранится в таблице blobs вместе с остальными сведениями о файле.
+const blobColumns = `coalesce(blob_key, '') as blob_key, coalesce(file_name, '') as file_name,
+			 	coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size`
+
+// GetData выбирает записи в транзакции от имени пользователя, где их дополнительно ограничивают политики построчной защиты.
 func (db *dbAdapter) GetData(ctx context.Context, userId int64) ([]models.Data, error) {
 	dataList := make([]models.Data, 0)
 
-	query := `select id, data_type, data_content, metadata, updated_at
-			 from data where user_id = $1 order by created_at`
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, ` + blobColumns + `
+			 from data where user_id = $1 and deleted_at is null order by created_at`
 
-	err := db.conn.SelectContext(ctx, &dataList, query, userId)
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &dataList, query, userId)
+	})
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
@@ -62,16 +165,60 @@ func (db *dbAdapter) GetData(ctx context.Context, userId int64) ([]models.Data,
 	return dataList, err
 }
 
-// GetDataByID получает данные по их ID.
-//
-// Функция извлекает конкретную запись данных по указанному ID из базы данных.
-// Возвращает ошибку, если запись не найдена или произошла другая ошибка при извлечении.
-func (db *dbAdapter) GetDataByID(ctx context.Context, dataID int64) (*models.Data, error) {
-	query := `SELECT id, user_id, data_type, data_content, metadata, updated_at 
-	          FROM data WHERE id = $1`
+// ListData фильтрует записи по метаданным оператором @> с GIN-индексом по столбцу metadata.
+func (db *dbAdapter) ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, error) {
+	dataList := make([]models.Data, 0)
+
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, ` + blobColumns + `
+			 from data where user_id = $1 and deleted_at is null`
+	args := []interface{}{userId}
+
+	if filter.AfterID != "" {
+		args = append(args, filter.AfterID)
+		query += fmt.Sprintf(" and id > $%d", len(args))
+	}
+
+	if filter.DataType != "" {
+		args = append(args, filter.DataType)
+		query += fmt.Sprintf(" and data_type = $%d", len(args))
+	}
+
+	if !filter.UpdatedAfter.IsZero() {
+		args = append(args, filter.UpdatedAfter)
+		query += fmt.Sprintf(" and updated_at > $%d", len(args))
+	}
+
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+		args = append(args, string(metadata))
+		query += fmt.Sprintf(" and metadata @> $%d::jsonb", len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" order by id limit $%d", len(args))
+
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &dataList, query, args...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing data: %w", err)
+	}
+
+	return dataList, nil
+}
+
+// GetDataByID выбирает запись в транзакции от имени пользователя.
+func (db *dbAdapter) GetDataByID(ctx context.Context, dataID string, userId int64) (*models.Data, error) {
+	query := `SELECT id, user_id, data_type, data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key
+	          FROM data WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
 	var data models.Data
-	err := db.conn.GetContext(ctx, &data, query, dataID)
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &data, query, dataID, userId)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting data by ID: %w", err)
 	}
@@ -79,50 +226,444 @@ func (db *dbAdapter) GetDataByID(ctx context.Context, dataID int64) (*models.Dat
 	return &data, nil
 }
 
-// DeleteData удаляет запись данных из базы данных по ID и ID пользователя.
-//
-// Функция удаляет данные, если они принадлежат указанному пользователю (проверка по ID).
-// Возвращает true, если запись была успешно удалена, и false, если запись не найдена или произошла ошибка.
-func (db *dbAdapter) DeleteData(ctx context.Context, dataId int64, userId int64) (bool, error) {
-	query := `delete from data where id = $1 and user_id= $2`
+// DeleteData помечает запись временем удаления; под политиками построчной защиты чужая запись не видна.
+func (db *dbAdapter) DeleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (bool, error) {
+	tx, err := db.beginWriteTx(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	result, err := db.conn.ExecContext(ctx, query, dataId, userId)
+	_, err = deleteData(ctx, tx.Tx, dataId, userId, expectedVersion)
+	if errors.Is(err, utils.ErrUserDataNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return false, fmt.Errorf("error deleting data: %w", err)
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return true, nil
+}
+
+// deleteData перемещает запись пользователя в корзину в транзакции tx и публикует уведомление об изменении.
+// Возвращает новую ревизию записи. Если записи нет, возвращается utils.ErrUserDataNotFound,
+// при несовпадении ненулевой expectedVersion - *utils.VersionConflictError.
+func deleteData(ctx context.Context, tx *sql.Tx, dataId string, userId int64, expectedVersion int64) (int64, error) {
+	query := `update data
+			 set deleted_at = now(), updated_at = now(),
+			     revision = nextval('data_revision_seq'), version = version + 1
+			 where id = $1 and user_id = $2 and deleted_at is null and ($3::bigint = 0 or version = $3)
+			 returning revision`
+
+	var revision int64
+	err := tx.QueryRowContext(ctx, query, dataId, userId, expectedVersion).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, currentVersionError(ctx, tx, dataId, userId)
+	}
 	if err != nil {
-		return false, fmt.Errorf("error get count rows: %w", err)
+		return 0, fmt.Errorf("error deleting data: %w", err)
+	}
+
+	err = notifyChange(ctx, tx, models.ChangeEvent{UserID: userId, DataID: dataId, Revision: revision, Deleted: true})
+	if err != nil {
+		return 0, err
 	}
 
-	return rowsAffected > 0, nil
+	return revision, nil
 }
 
-// UpdateData обновляет существующую запись данных в базе данных.
-//
-// Эта функция использует транзакцию для обновления записи данных. Обновляются поля содержимого данных и метаданных.
-// Если транзакция успешна, изменения сохраняются в базе данных, если произошла ошибка — транзакция откатывается.
+// UpdateData блокирует запись (select for update) на время сохранения ее состояния в истории.
 func (db *dbAdapter) UpdateData(ctx context.Context, data *models.Data) error {
-	tx, err := db.conn.BeginTx(ctx, nil)
+	tx, err := db.beginWriteTx(ctx, data.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	err = updateData(ctx, tx.Tx, data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateData сохраняет текущее состояние записи в истории и обновляет запись в транзакции tx,
+// после чего публикует уведомление об изменении. Новые ревизия и версия сохраняются в data.Revision и data.Version.
+func updateData(ctx context.Context, tx *sql.Tx, data *models.Data) error {
+	err := saveRevision(ctx, tx, data.ID, data.UserID, data.Version)
+	if err != nil {
+		return err
+	}
+
+	err = saveBlob(ctx, tx, data.BlobKey, data.BlobSize, data.UserID)
+	if err != nil {
+		return err
+	}
+
 	query := `update data
-			 set data_content = $1, metadata = $2, updated_at = now()
-             where id = $3 and user_id = $4`
+			 set data_content = $1, metadata = $2, blob_key = nullif($6, ''), file_name = coalesce(nullif($7, ''), file_name), updated_at = now(),
+			     revision = nextval('data_revision_seq'), version = version + 1
+             where id = $3 and user_id = $4 and deleted_at is null and ($5::bigint = 0 or version = $5)
+             returning revision, version`
 
-	_, err = tx.ExecContext(ctx, query, data.DataContent, data.Metadata, data.ID, data.UserID)
+	var revision, version int64
+	err = tx.QueryRowContext(ctx, query, data.DataContent, data.Metadata, data.ID, data.UserID, data.Version, data.BlobKey,
+		data.FileName).Scan(&revision, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return currentVersionError(ctx, tx, data.ID, data.UserID)
+	}
 	if err != nil {
 		return fmt.Errorf("error update update data: %w", err)
 	}
 
-	err = tx.Commit()
+	err = notifyChange(ctx, tx, models.ChangeEvent{UserID: data.UserID, DataID: data.ID, Revision: revision})
 	if err != nil {
 		return err
 	}
 
+	data.Revision = revision
+	data.Version = version
+	return nil
+}
+
+// ApplyMutations выполняет операции в одной транзакции от имени пользователя.
+func (db *dbAdapter) ApplyMutations(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+	tx, err := db.beginWriteTx(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	results := make([]models.MutationResult, len(mutations))
+	for i, mutation := range mutations {
+		data := mutation.Data
+		data.UserID = userId
+
+		switch mutation.Type {
+		case models.MutationCreate:
+			data.ID, err = insertData(ctx, tx.Tx, &data)
+		case models.MutationUpdate:
+			err = updateData(ctx, tx.Tx, &data)
+		case models.MutationDelete:
+			data.Revision, err = deleteData(ctx, tx.Tx, data.ID, userId, data.Version)
+			data.Version = 0
+		default:
+			err = fmt.Errorf("unknown mutation type %d", mutation.Type)
+		}
+		if err != nil {
+			return nil, &utils.OperationError{Index: i, Err: err}
+		}
+
+		results[i] = models.MutationResult{ID: data.ID, Revision: data.Revision, Version: data.Version}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// saveRevision сохраняет текущее состояние записи пользователя в истории изменений перед ее изменением.
+// Если expectedVersion не равен 0, состояние сохраняется только при совпадении версии.
+// Строка записи блокируется до конца транзакции, чтобы сохраненное состояние совпадало с изменяемым.
+func saveRevision(ctx context.Context, tx *sql.Tx, dataId string, userId int64, expectedVersion int64) error {
+	query := `insert into data_revisions (data_id, user_id, revision, version, data_type, data_content, metadata, updated_at,
+			     blob_key, file_name)
+			 select id, user_id, revision, version, data_type, data_content, metadata, updated_at, blob_key, file_name
+			 from (select * from data
+			       where id = $1 and user_id = $2 and deleted_at is null and ($3::bigint = 0 or version = $3)
+			       for update) d
+			 on conflict (data_id, revision) do nothing`
+
+	_, err := tx.ExecContext(ctx, query, dataId, userId, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("error saving data revision: %w", err)
+	}
+
 	return nil
 }
+
+// currentVersionError определяет, почему запись не была изменена: возвращает utils.ErrUserDataNotFound,
+// если записи пользователя нет, иначе *utils.VersionConflictError с ее текущей версией.
+func currentVersionError(ctx context.Context, tx *sql.Tx, dataId string, userId int64) error {
+	var version int64
+	err := tx.QueryRowContext(ctx, `select version from data where id = $1 and user_id = $2 and deleted_at is null`, dataId, userId).
+		Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.ErrUserDataNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error getting data version: %w", err)
+	}
+
+	return &utils.VersionConflictError{CurrentVersion: version}
+}
+
+// GetChanges выбирает изменения в транзакции от имени пользователя.
+func (db *dbAdapter) GetChanges(ctx context.Context, userId int64, since int64, limit int) ([]models.DataChange, error) {
+	changes := make([]models.DataChange, 0)
+
+	query := `select id,
+			 	case when deleted_at is null then data_type else '' end as data_type,
+			 	case when deleted_at is null then data_content end as data_content,
+			 	case when deleted_at is null then metadata else '{}'::jsonb end as metadata,
+			 	updated_at, revision,
+			 	case when deleted_at is null then version else 0 end as version,
+			 	deleted_at is not null as deleted, ` + blobColumns + `
+			 from data where user_id = $1 and revision > $2
+			 order by revision limit $3`
+
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		if since > 0 {
+			var purgedRevision int64
+			err := tx.GetContext(ctx, &purgedRevision, `select purged_revision from users where id = $1`, userId)
+			if err != nil {
+				return fmt.Errorf("error getting purged revision: %w", err)
+			}
+			if since < purgedRevision {
+				return utils.ErrCursorExpired
+			}
+		}
+
+		err := tx.SelectContext(ctx, &changes, query, userId, since, limit)
+		if err != nil {
+			return fmt.Errorf("error getting data changes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetDataHistory выбирает историю в транзакции от имени пользователя.
+func (db *dbAdapter) GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error) {
+	history := make([]models.Data, 0)
+
+	query := `select data_id as id, user_id, data_type, data_content, metadata, updated_at, revision, version
+			 from data_revisions where data_id = $1 and user_id = $2
+			 order by revision desc`
+
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		var exists bool
+		err := tx.GetContext(ctx, &exists,
+			`select exists(select 1 from data where id = $1 and user_id = $2 and deleted_at is null)`, dataId, userId)
+		if err != nil {
+			return fmt.Errorf("error checking data: %w", err)
+		}
+		if !exists {
+			return utils.ErrUserDataNotFound
+		}
+
+		err = tx.SelectContext(ctx, &history, query, dataId, userId)
+		if err != nil {
+			return fmt.Errorf("error getting data history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// RestoreRevision блокирует запись (select for update) на время сохранения ее состояния в истории.
+func (db *dbAdapter) RestoreRevision(ctx context.Context, dataId string, userId int64, revision int64,
+	expectedVersion int64) (*models.Data, error) {
+	tx, err := db.beginWriteTx(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `select exists(select 1 from data_revisions where data_id = $1 and user_id = $2 and revision = $3)`,
+		dataId, userId, revision).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error checking data revision: %w", err)
+	}
+	if !exists {
+		return nil, utils.ErrUserDataNotFound
+	}
+
+	err = saveRevision(ctx, tx.Tx, dataId, userId, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `update data d
+			 set data_content = r.data_content, metadata = r.metadata, blob_key = r.blob_key, file_name = r.file_name,
+			     updated_at = now(),
+			     revision = nextval('data_revision_seq'), version = d.version + 1
+			 from data_revisions r
+			 where d.id = $1 and d.user_id = $2 and d.deleted_at is null and ($4::bigint = 0 or d.version = $4)
+			   and r.data_id = d.id and r.revision = $3
+			 returning d.id, d.user_id, d.data_type, d.data_content, d.metadata, d.updated_at, d.revision, d.version`
+
+	var data models.Data
+	err = tx.QueryRowContext(ctx, query, dataId, userId, revision, expectedVersion).Scan(&data.ID, &data.UserID,
+		&data.DataType, &data.DataContent, &data.Metadata, &data.UpdatedAt, &data.Revision, &data.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, currentVersionError(ctx, tx.Tx, dataId, userId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error restoring data revision: %w", err)
+	}
+
+	err = notifyChange(ctx, tx.Tx, models.ChangeEvent{UserID: userId, DataID: dataId, Revision: data.Revision})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// ListTrash пропускает метки удаления без типа данных, оставшиеся от журнала удалений: восстанавливать в них нечего.
+func (db *dbAdapter) ListTrash(ctx context.Context, userId int64) ([]models.Data, error) {
+	dataList := make([]models.Data, 0)
+
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, deleted_at
+			 from data where user_id = $1 and deleted_at is not null and data_type <> ''
+			 order by deleted_at desc, id`
+
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &dataList, query, userId)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing trash: %w", err)
+	}
+
+	return dataList, nil
+}
+
+// RestoreData снимает отметку удаления в транзакции от имени пользователя.
+func (db *dbAdapter) RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	tx, err := db.beginWriteTx(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `update data
+			 set deleted_at = null, updated_at = now(),
+			     revision = nextval('data_revision_seq'), version = version + 1
+			 where id = $1 and user_id = $2 and deleted_at is not null and data_type <> ''
+			 returning id, user_id, data_type, data_content, metadata, updated_at, revision, version`
+
+	var data models.Data
+	err = tx.QueryRowContext(ctx, query, dataId, userId).Scan(&data.ID, &data.UserID, &data.DataType,
+		&data.DataContent, &data.Metadata, &data.UpdatedAt, &data.Revision, &data.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrUserDataNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error restoring data: %w", err)
+	}
+
+	err = notifyChange(ctx, tx.Tx, models.ChangeEvent{UserID: userId, DataID: dataId, Revision: data.Revision})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// PurgeData удаляет записи в транзакции от имени пользователя.
+func (db *dbAdapter) PurgeData(ctx context.Context, userId int64, dataId string) (models.PurgeResult, error) {
+	tx, err := db.beginUserTx(ctx, userId)
+	if err != nil {
+		return models.PurgeResult{}, err
+	}
+
+	return purge(ctx, tx, `user_id = $1 and ($2 = '' or id = nullif($2, '')::uuid)`, userId, dataId)
+}
+
+// PurgeTrash удаляет записи в служебной транзакции, которой доступны записи всех пользователей.
+func (db *dbAdapter) PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error) {
+	tx, err := db.beginMaintenanceTx(ctx)
+	if err != nil {
+		return models.PurgeResult{}, err
+	}
+
+	return purge(ctx, tx, `deleted_at < $1`, before)
+}
+
+// purge окончательно удаляет записи из корзины, удовлетворяющие условию condition, в транзакции tx
+// и завершает ее.
+//
+// Для каждого пользователя запоминается максимальная ревизия удаленных записей (users.purged_revision),
+// чтобы GetChanges мог сообщить клиентам с более старым курсором о необходимости полной синхронизации.
+// История изменений записей удаляется вместе с ними, а триггеры уменьшают число ссылок на их файлы.
+// Файлы без ссылок удаляются из таблицы blobs, а их удаление из хранилища файлов записывается
+// в отложенные операции в той же транзакции, поэтому файл не потеряется и не останется в хранилище файлов,
+// даже если удалить его сразу не получится.
+func purge(ctx context.Context, tx *sqlx.Tx, condition string, args ...interface{}) (models.PurgeResult, error) {
+	defer func() { _ = tx.Rollback() }()
+
+	var result models.PurgeResult
+
+	query := `with purged as (
+				delete from data where deleted_at is not null and ` + condition + `
+				returning id, user_id, revision
+			 ), marked as (
+				update users u set purged_revision = greatest(u.purged_revision, p.revision)
+				from (select user_id, max(revision) as revision from purged group by user_id) p
+				where u.id = p.user_id
+			 )
+			 select count(*) from purged`
+
+	err := tx.GetContext(ctx, &result.Count, query, args...)
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("error purging trash: %w", err)
+	}
+
+	blobsQuery := `with unreferenced as (
+					  delete from blobs where ref_count = 0 returning object_key, user_id
+				  )
+				  insert into blob_outbox (object_key, user_id) select object_key, user_id from unreferenced
+				  returning id, user_id, object_key, attempts, last_error`
+
+	result.BlobOperations = make([]models.BlobOperation, 0)
+	err = tx.SelectContext(ctx, &result.BlobOperations, blobsQuery)
+	if err != nil {
+		return models.PurgeResult{}, fmt.Errorf("error deleting unreferenced blobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PurgeResult{}, err
+	}
+
+	return result, nil
+}
+
+// GetBlobKeys выбирает ключи в транзакции от имени пользователя.
+func (db *dbAdapter) GetBlobKeys(ctx context.Context, userId int64, keys []string) ([]string, error) {
+	existing := make([]string, 0)
+
+	err := db.inUserTx(ctx, userId, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &existing, `select object_key from blobs where user_id = $1 and object_key = any($2)`,
+			userId, pq.Array(keys))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting blobs: %w", err)
+	}
+
+	return existing, nil
+}
@@ -3,19 +3,46 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
 )
 
+// expectUserTx ожидает начало транзакции от имени пользователя userId.
+func expectUserTx(mock sqlmock.Sqlmock, userId int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`select set_config('app.user_id', $1, true)`)).
+		WithArgs(strconv.FormatInt(userId, 10)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectWriteTx ожидает начало транзакции изменения данных пользователя userId с блокировкой его ревизий.
+func expectWriteTx(mock sqlmock.Sqlmock, userId int64) {
+	expectUserTx(mock, userId)
+	mock.ExpectExec(regexp.QuoteMeta(`select 1 from users where id = $1 for no key update`)).
+		WithArgs(userId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectMaintenanceTx ожидает начало транзакции для фоновых задач над данными всех пользователей.
+func expectMaintenanceTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`select set_config('app.maintenance', 'on', true)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func TestCreateData(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -30,11 +57,19 @@ func TestCreateData(t *testing.T) {
 		mockBehavior func(m *mocks, args args)
 	)
 
+	dataID := "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"
+	insertQuery := `insert into data(id, user_id, data_type, data_content, metadata, idempotency_key, blob_key, file_name)
+			values ($1, $2, $3, $4, $5, nullif($6, '')::uuid, nullif($7, ''), nullif($8, ''))
+			on conflict do nothing
+			RETURNING revision, version`
+	replayQuery := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '')::uuid or idempotency_key = nullif($3, '')::uuid) limit 1`
+
 	tests := []struct {
 		name         string
 		args         args
 		mockBehavior mockBehavior
-		expectedID   int64
+		expectedID   string
 		wantErr      bool
 		err          error
 	}{
@@ -42,6 +77,7 @@ func TestCreateData(t *testing.T) {
 			name: "CreateDataSuccessfully",
 			args: args{
 				data: &models.Data{
+					ID:          dataID,
 					UserID:      10,
 					DataType:    "LOGIN_PASSWORD",
 					DataContent: []byte("$1$212345"),
@@ -51,19 +87,24 @@ func TestCreateData(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `insert into data(user_id, data_type, data_content, metadata)
-			values ($1, $2, $3, $4) RETURNING id`
-				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
 					WithArgs(
+						args.data.ID,
 						args.data.UserID,
 						args.data.DataType,
 						args.data.DataContent,
-						args.data.Metadata).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+						args.data.Metadata,
+						args.data.IdempotencyKey,
+						args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(5, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WithArgs(changesChannel, `{"user_id":10,"data_id":"`+dataID+`","revision":5,"deleted":false}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 			},
-			expectedID: 1,
+			expectedID: dataID,
 			wantErr:    false,
 			err:        nil,
 		},
@@ -71,6 +112,7 @@ func TestCreateData(t *testing.T) {
 			name: "InvalidTransactionStart",
 			args: args{
 				data: &models.Data{
+					ID:          dataID,
 					UserID:      10,
 					DataType:    "LOGIN_PASSWORD",
 					DataContent: []byte("$1$212345"),
@@ -83,7 +125,7 @@ func TestCreateData(t *testing.T) {
 				mock.ExpectBegin().WillReturnError(
 					fmt.Errorf("failed to begin transaction"))
 			},
-			expectedID: 0,
+			expectedID: "",
 			wantErr:    true,
 			err:        fmt.Errorf("failed to begin transaction"),
 		},
@@ -91,6 +133,7 @@ func TestCreateData(t *testing.T) {
 			name: "InvalidCommitTransaction",
 			args: args{
 				data: &models.Data{
+					ID:          dataID,
 					UserID:      10,
 					DataType:    "LOGIN_PASSWORD",
 					DataContent: []byte("$1$212345"),
@@ -100,27 +143,99 @@ func TestCreateData(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `insert into data(user_id, data_type, data_content, metadata)
-			values ($1, $2, $3, $4) RETURNING id`
-				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
 					WithArgs(
+						args.data.ID,
 						args.data.UserID,
 						args.data.DataType,
 						args.data.DataContent,
-						args.data.Metadata).
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+						args.data.Metadata,
+						args.data.IdempotencyKey,
+						args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(5, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WithArgs(changesChannel, `{"user_id":10,"data_id":"`+dataID+`","revision":5,"deleted":false}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 
 				mock.ExpectCommit().WillReturnError(fmt.Errorf("failed to commit transaction"))
 			},
-			expectedID: 0,
+			expectedID: "",
 			wantErr:    true,
 			err:        fmt.Errorf("failed to commit transaction"),
 		},
+		{
+			name: "CreateDataReplayed",
+			args: args{
+				data: &models.Data{
+					ID:             dataID,
+					UserID:         10,
+					DataType:       "LOGIN_PASSWORD",
+					DataContent:    []byte("$1$212345"),
+					IdempotencyKey: "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192",
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
+					WithArgs(
+						args.data.ID,
+						args.data.UserID,
+						args.data.DataType,
+						args.data.DataContent,
+						args.data.Metadata,
+						args.data.IdempotencyKey,
+						args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(replayQuery)).
+					WithArgs(args.data.UserID, args.data.ID, args.data.IdempotencyKey).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "revision", "version"}).
+						AddRow("00000000-0000-0000-0000-000000000007", 12, 2))
+				mock.ExpectCommit()
+			},
+			expectedID: "00000000-0000-0000-0000-000000000007",
+			wantErr:    false,
+			err:        nil,
+		},
+		{
+			name: "CreateDataIDTakenByAnotherUser",
+			args: args{
+				data: &models.Data{
+					ID:          dataID,
+					UserID:      10,
+					DataType:    "LOGIN_PASSWORD",
+					DataContent: []byte("$1$212345"),
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
+					WithArgs(
+						args.data.ID,
+						args.data.UserID,
+						args.data.DataType,
+						args.data.DataContent,
+						args.data.Metadata,
+						args.data.IdempotencyKey,
+						args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(replayQuery)).
+					WithArgs(args.data.UserID, args.data.ID, args.data.IdempotencyKey).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "revision", "version"}))
+				mock.ExpectRollback()
+			},
+			expectedID: "",
+			wantErr:    true,
+			err:        utils.ErrDataExists,
+		},
 		{
 			name: "CreateDataError",
 			args: args{
 				data: &models.Data{
+					ID:          dataID,
 					DataType:    "LOGIN_PASSWORD",
 					DataContent: []byte("$1$212345"),
 					Metadata:    nil,
@@ -129,20 +244,21 @@ func TestCreateData(t *testing.T) {
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `insert into data(user_id, data_type, data_content, metadata)
-			values ($1, $2, $3, $4) RETURNING id`
-				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
 					WithArgs(
+						args.data.ID,
 						args.data.UserID,
 						args.data.DataType,
 						args.data.DataContent,
-						args.data.Metadata).
+						args.data.Metadata,
+						args.data.IdempotencyKey,
+						args.data.BlobKey,
+						args.data.FileName).
 					WillReturnError(fmt.Errorf("failed to insert data"))
-
-				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
-			expectedID: 0,
+			expectedID: "",
 			wantErr:    true,
 			err:        fmt.Errorf("failed to insert data"),
 		},
@@ -162,13 +278,34 @@ func TestCreateData(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				if errors.Is(tt.err, utils.ErrDataExists) {
+					assert.ErrorIs(t, err, utils.ErrDataExists)
+				}
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedID, returnedID, "The returned ID %d does not match the expected ID %d", tt.expectedID, returnedID)
+				assert.Equal(t, tt.expectedID, returnedID, "The returned ID %s does not match the expected ID %s", tt.expectedID, returnedID)
 			}
 
 		})
 	}
+
+	t.Run("CreateDataGeneratesID", func(t *testing.T) {
+		expectWriteTx(mock, 10)
+		mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(5, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+		data := &models.Data{UserID: 10, DataType: models.TextData}
+		returnedID, err := pg.CreateData(context.Background(), data)
+		assert.NoError(t, err)
+		assert.Len(t, returnedID, 36)
+		assert.Equal(t, data.ID, returnedID)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetData(t *testing.T) {
@@ -200,15 +337,19 @@ func TestGetData(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				expectedQuery := `select id, data_type, 
-       								data_content, metadata, updated_at
-			 						from data where user_id = $1 order by created_at`
+       								data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key,
+			 						coalesce(file_name, '') as file_name,
+			 						coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+			 						from data where user_id = $1 and deleted_at is null order by created_at`
+				expectUserTx(mock, args.userId)
 				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
 					WithArgs(args.userId).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).AddRow(1, "LOGIN_PASSWORD"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).AddRow("00000000-0000-0000-0000-000000000001", "LOGIN_PASSWORD"))
+				mock.ExpectCommit()
 			},
 			expectedData: []models.Data{
 				{
-					ID:          1,
+					ID:          "00000000-0000-0000-0000-000000000001",
 					DataType:    "LOGIN_PASSWORD",
 					DataContent: []byte(nil),
 					Metadata:    nil,
@@ -225,11 +366,15 @@ func TestGetData(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				expectedQuery := `select id, data_type, 
-       								data_content, metadata, updated_at
-			 						from data where user_id = $1 order by created_at`
+       								data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key,
+			 						coalesce(file_name, '') as file_name,
+			 						coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+			 						from data where user_id = $1 and deleted_at is null order by created_at`
+				expectUserTx(mock, args.userId)
 				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
 					WithArgs(args.userId).
 					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
 			expectedData: nil,
 			wantErr:      false,
@@ -242,11 +387,15 @@ func TestGetData(t *testing.T) {
 			},
 			mockBehavior: func(m *mocks, args args) {
 				expectedQuery := `select id, data_type, 
-       								data_content, metadata, updated_at
-			 						from data where user_id = $1 order by created_at`
+       								data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key,
+			 						coalesce(file_name, '') as file_name,
+			 						coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+			 						from data where user_id = $1 and deleted_at is null order by created_at`
+				expectUserTx(mock, args.userId)
 				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
 					WithArgs(args.userId).
 					WillReturnError(fmt.Errorf("error getting data info"))
+				mock.ExpectRollback()
 			},
 			expectedData: nil,
 			wantErr:      true,
@@ -275,6 +424,719 @@ func TestGetData(t *testing.T) {
 
 		})
 	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	updatedAfter := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	t.Run("ListDataWithoutFilters", func(t *testing.T) {
+		expectedQuery := `select id, data_type, data_content, metadata, updated_at, revision, version,
+			 coalesce(blob_key, '') as blob_key, coalesce(file_name, '') as file_name,
+			 coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+			 from data where user_id = $1 and deleted_at is null order by id limit $2`
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).
+				AddRow("00000000-0000-0000-0000-000000000001", "LOGIN_PASSWORD").
+				AddRow("00000000-0000-0000-0000-000000000002", "TEXT_DATA"))
+		mock.ExpectCommit()
+
+		data, err := pg.ListData(context.Background(), 1, models.DataFilter{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, data, 2)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000002", data[1].ID)
+	})
+
+	t.Run("ListDataWithAllFilters", func(t *testing.T) {
+		expectedQuery := `select id, data_type, data_content, metadata, updated_at, revision, version,
+			 coalesce(blob_key, '') as blob_key, coalesce(file_name, '') as file_name,
+			 coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+			 from data where user_id = $1 and deleted_at is null and id > $2 and data_type = $3 and updated_at > $4
+			 and metadata @> $5::jsonb order by id limit $6`
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), "00000000-0000-0000-0000-000000000005", models.TextData, updatedAfter, `{"site":"example.com"}`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).AddRow("00000000-0000-0000-0000-000000000006", "TEXT_DATA"))
+		mock.ExpectCommit()
+
+		data, err := pg.ListData(context.Background(), 1, models.DataFilter{
+			DataType:     models.TextData,
+			UpdatedAfter: updatedAfter,
+			Metadata:     map[string]string{"site": "example.com"},
+			AfterID:      "00000000-0000-0000-0000-000000000005",
+			Limit:        10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Data{{ID: "00000000-0000-0000-0000-000000000006", DataType: models.TextData}}, data)
+	})
+
+	t.Run("ListDataError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery("select id").
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.ListData(context.Background(), 1, models.DataFilter{Limit: 10})
+		assert.Error(t, err)
+	})
+
+	t.Run("ListDataSetUserError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`select set_config('app.user_id', $1, true)`)).
+			WithArgs("1").
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.ListData(context.Background(), 1, models.DataFilter{Limit: 10})
+		assert.ErrorContains(t, err, "failed to set transaction user")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	purgedQuery := `select purged_revision from users where id = $1`
+	expectedQuery := `select id,
+			case when deleted_at is null then data_type else '' end as data_type,
+			case when deleted_at is null then data_content end as data_content,
+			case when deleted_at is null then metadata else '{}'::jsonb end as metadata,
+			updated_at, revision,
+			case when deleted_at is null then version else 0 end as version,
+			deleted_at is not null as deleted, coalesce(blob_key, '') as blob_key, coalesce(file_name, '') as file_name,
+			coalesce((select size from blobs where blobs.object_key = data.blob_key), 0) as blob_size
+		 from data where user_id = $1 and revision > $2
+		 order by revision limit $3`
+
+	t.Run("GetChangesSuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(purgedQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"purged_revision"}).AddRow(5))
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), int64(10), 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data_type", "revision", "version", "deleted"}).
+				AddRow("00000000-0000-0000-0000-000000000003", "TEXT_DATA", 11, 2, false).
+				AddRow("00000000-0000-0000-0000-000000000002", "", 12, 0, true))
+		mock.ExpectCommit()
+
+		changes, err := pg.GetChanges(context.Background(), 1, 10, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.DataChange{
+			{Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, Revision: 11, Version: 2}},
+			{Data: models.Data{ID: "00000000-0000-0000-0000-000000000002", Revision: 12}, Deleted: true},
+		}, changes)
+	})
+
+	t.Run("GetChangesFromZeroCursor", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), int64(0), 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data_type", "revision", "version", "deleted"}).
+				AddRow("00000000-0000-0000-0000-000000000003", "TEXT_DATA", 11, 2, false))
+		mock.ExpectCommit()
+
+		changes, err := pg.GetChanges(context.Background(), 1, 0, 100)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("GetChangesCursorExpired", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(purgedQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"purged_revision"}).AddRow(20))
+		mock.ExpectRollback()
+
+		_, err := pg.GetChanges(context.Background(), 1, 10, 100)
+		assert.ErrorIs(t, err, utils.ErrCursorExpired)
+	})
+
+	t.Run("GetChangesError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(purgedQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"purged_revision"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), int64(10), 100).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.GetChanges(context.Background(), 1, 10, 100)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	deletedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	expectedQuery := `select id, data_type, data_content, metadata, updated_at, revision, version, deleted_at
+		 from data where user_id = $1 and deleted_at is not null and data_type <> ''
+		 order by deleted_at desc, id`
+
+	t.Run("ListTrashSuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "data_type", "deleted_at"}).
+				AddRow("00000000-0000-0000-0000-000000000003", "TEXT_DATA", deletedAt))
+		mock.ExpectCommit()
+
+		data, err := pg.ListTrash(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Data{{ID: "00000000-0000-0000-0000-000000000003", DataType: models.TextData, DeletedAt: deletedAt}}, data)
+	})
+
+	t.Run("ListTrashError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.ListTrash(context.Background(), 1)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	updatedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	expectedQuery := `update data
+		 set deleted_at = null, updated_at = now(),
+		     revision = nextval('data_revision_seq'), version = version + 1
+		 where id = $1 and user_id = $2 and deleted_at is not null and data_type <> ''
+		 returning id, user_id, data_type, data_content, metadata, updated_at, revision, version`
+	columns := []string{"id", "user_id", "data_type", "data_content", "metadata", "updated_at", "revision", "version"}
+
+	t.Run("RestoreDataSuccessfully", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("00000000-0000-0000-0000-000000000003", 1, "TEXT_DATA", []byte("content"), []byte(`{"site":"example.com"}`), updatedAt, 15, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WithArgs(changesChannel, `{"user_id":1,"data_id":"00000000-0000-0000-0000-000000000003","revision":15,"deleted":false}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		data, err := pg.RestoreData(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Data{
+			ID:          "00000000-0000-0000-0000-000000000003",
+			UserID:      1,
+			DataType:    models.TextData,
+			DataContent: []byte("content"),
+			Metadata:    models.JSONB{"site": "example.com"},
+			UpdatedAt:   updatedAt,
+			Revision:    15,
+			Version:     3,
+		}, data)
+	})
+
+	t.Run("RestoreDataNotInTrash", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := pg.RestoreData(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("RestoreDataError", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.RestoreData(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDataHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	updatedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	existsQuery := `select exists(select 1 from data where id = $1 and user_id = $2 and deleted_at is null)`
+	historyQuery := `select data_id as id, user_id, data_type, data_content, metadata, updated_at, revision, version
+		 from data_revisions where data_id = $1 and user_id = $2
+		 order by revision desc`
+
+	t.Run("GetDataHistorySuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta(historyQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "data_type", "data_content", "updated_at", "revision", "version"}).
+				AddRow("00000000-0000-0000-0000-000000000003", 1, "TEXT_DATA", []byte("second"), updatedAt, 12, 2).
+				AddRow("00000000-0000-0000-0000-000000000003", 1, "TEXT_DATA", []byte("first"), updatedAt, 10, 1))
+		mock.ExpectCommit()
+
+		history, err := pg.GetDataHistory(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Data{
+			{ID: "00000000-0000-0000-0000-000000000003", UserID: 1, DataType: models.TextData, DataContent: []byte("second"), UpdatedAt: updatedAt, Revision: 12, Version: 2},
+			{ID: "00000000-0000-0000-0000-000000000003", UserID: 1, DataType: models.TextData, DataContent: []byte("first"), UpdatedAt: updatedAt, Revision: 10, Version: 1},
+		}, history)
+	})
+
+	t.Run("GetDataHistoryNotFound", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		_, err := pg.GetDataHistory(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("GetDataHistoryError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta(historyQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.GetDataHistory(context.Background(), "00000000-0000-0000-0000-000000000003", 1)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreRevision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	updatedAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	existsQuery := `select exists(select 1 from data_revisions where data_id = $1 and user_id = $2 and revision = $3)`
+	revisionQuery := `insert into data_revisions (data_id, user_id, revision, version, data_type, data_content, metadata, updated_at,
+			     blob_key, file_name)`
+	restoreQuery := `update data d
+		 set data_content = r.data_content, metadata = r.metadata, blob_key = r.blob_key, file_name = r.file_name,
+		     updated_at = now(),
+		     revision = nextval('data_revision_seq'), version = d.version + 1
+		 from data_revisions r`
+	versionQuery := `select version from data where id = $1 and user_id = $2 and deleted_at is null`
+	columns := []string{"id", "user_id", "data_type", "data_content", "metadata", "updated_at", "revision", "version"}
+
+	t.Run("RestoreRevisionSuccessfully", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(restoreQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(2)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("00000000-0000-0000-0000-000000000003", 1, "TEXT_DATA", []byte("first"), []byte(`{}`), updatedAt, 15, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WithArgs(changesChannel, `{"user_id":1,"data_id":"00000000-0000-0000-0000-000000000003","revision":15,"deleted":false}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		data, err := pg.RestoreRevision(context.Background(), "00000000-0000-0000-0000-000000000003", 1, 10, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Data{
+			ID:          "00000000-0000-0000-0000-000000000003",
+			UserID:      1,
+			DataType:    models.TextData,
+			DataContent: []byte("first"),
+			Metadata:    models.JSONB{},
+			UpdatedAt:   updatedAt,
+			Revision:    15,
+			Version:     3,
+		}, data)
+	})
+
+	t.Run("RestoreRevisionNotFound", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		_, err := pg.RestoreRevision(context.Background(), "00000000-0000-0000-0000-000000000003", 1, 10, 0)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("RestoreRevisionVersionConflict", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(restoreQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(10), int64(1)).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectRollback()
+
+		_, err := pg.RestoreRevision(context.Background(), "00000000-0000-0000-0000-000000000003", 1, 10, 1)
+		assert.Equal(t, &utils.VersionConflictError{CurrentVersion: 2}, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyMutations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	insertQuery := `insert into data(id, user_id, data_type, data_content, metadata, idempotency_key, blob_key, file_name)`
+	revisionQuery := `insert into data_revisions (data_id, user_id, revision, version, data_type, data_content, metadata, updated_at,
+			     blob_key, file_name)`
+	updateQuery := `update data
+			 set data_content = $1, metadata = $2, blob_key = nullif($6, ''), file_name = coalesce(nullif($7, ''), file_name), updated_at = now(),`
+	deleteQuery := `update data
+			 set deleted_at = now(), updated_at = now(),`
+	versionQuery := `select version from data where id = $1 and user_id = $2 and deleted_at is null`
+
+	mutations := []models.Mutation{
+		{Type: models.MutationCreate, Data: models.Data{ID: "00000000-0000-0000-0000-00000000000a", DataType: models.TextData, DataContent: []byte("new")}},
+		{Type: models.MutationUpdate, Data: models.Data{ID: "00000000-0000-0000-0000-000000000003", DataContent: []byte("changed"), Version: 2}},
+		{Type: models.MutationDelete, Data: models.Data{ID: "00000000-0000-0000-0000-000000000004"}},
+	}
+
+	t.Run("ApplyMutationsSuccessfully", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
+			WithArgs("00000000-0000-0000-0000-00000000000a", int64(1), models.TextData, []byte("new"), sqlmock.AnyArg(), "", "", "").
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(20, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WithArgs(changesChannel, `{"user_id":1,"data_id":"00000000-0000-0000-0000-00000000000a","revision":20,"deleted":false}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
+			WithArgs([]byte("changed"), sqlmock.AnyArg(), "00000000-0000-0000-0000-000000000003", int64(1), int64(2), "", "").
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(21, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WithArgs(changesChannel, `{"user_id":1,"data_id":"00000000-0000-0000-0000-000000000003","revision":21,"deleted":false}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(deleteQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000004", int64(1), int64(0)).
+			WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(22))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WithArgs(changesChannel, `{"user_id":1,"data_id":"00000000-0000-0000-0000-000000000004","revision":22,"deleted":true}`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		results, err := pg.ApplyMutations(context.Background(), 1, mutations)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.MutationResult{
+			{ID: "00000000-0000-0000-0000-00000000000a", Revision: 20, Version: 1},
+			{ID: "00000000-0000-0000-0000-000000000003", Revision: 21, Version: 3},
+			{ID: "00000000-0000-0000-0000-000000000004", Revision: 22},
+		}, results)
+	})
+
+	t.Run("ApplyMutationsVersionConflict", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(insertQuery)).
+			WithArgs("00000000-0000-0000-0000-00000000000a", int64(1), models.TextData, []byte("new"), sqlmock.AnyArg(), "", "", "").
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(20, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1), int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
+			WithArgs([]byte("changed"), sqlmock.AnyArg(), "00000000-0000-0000-0000-000000000003", int64(1), int64(2), "", "").
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}))
+		mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000003", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+		mock.ExpectRollback()
+
+		_, err := pg.ApplyMutations(context.Background(), 1, mutations)
+		var opErr *utils.OperationError
+		if assert.ErrorAs(t, err, &opErr) {
+			assert.Equal(t, 1, opErr.Index)
+			assert.Equal(t, &utils.VersionConflictError{CurrentVersion: 5}, opErr.Err)
+		}
+	})
+
+	t.Run("ApplyMutationsNotFound", func(t *testing.T) {
+		expectWriteTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(deleteQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000004", int64(1), int64(0)).
+			WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+		mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+			WithArgs("00000000-0000-0000-0000-000000000004", int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+
+		_, err := pg.ApplyMutations(context.Background(), 1, mutations[2:])
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	expectedQuery := `with purged as (
+			delete from data where deleted_at is not null and user_id = $1 and ($2 = '' or id = nullif($2, '')::uuid)
+			returning id, user_id, revision
+		 ), marked as (
+			update users u set purged_revision = greatest(u.purged_revision, p.revision)
+			from (select user_id, max(revision) as revision from purged group by user_id) p
+			where u.id = p.user_id
+		 )
+		 select count(*) from purged`
+	blobsQuery := `with unreferenced as (
+			  delete from blobs where ref_count = 0 returning object_key, user_id
+		  )
+		  insert into blob_outbox (object_key, user_id) select object_key, user_id from unreferenced
+		  returning id, user_id, object_key, attempts, last_error`
+
+	t.Run("PurgeDataSuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), "").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(blobsQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "object_key", "attempts", "last_error"}).
+				AddRow(5, 1, "users/1/abc", 0, ""))
+		mock.ExpectCommit()
+
+		purged, err := pg.PurgeData(context.Background(), 1, "")
+		assert.NoError(t, err)
+		assert.Equal(t, models.PurgeResult{Count: 2, BlobOperations: []models.BlobOperation{{ID: 5, UserID: 1, BlobKey: "users/1/abc"}}}, purged)
+	})
+
+	t.Run("PurgeDataBlobsError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), "").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(blobsQuery)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.PurgeData(context.Background(), 1, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("PurgeDataError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), "00000000-0000-0000-0000-000000000003").
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.PurgeData(context.Background(), 1, "00000000-0000-0000-0000-000000000003")
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeTrash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	before := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	expectedQuery := `with purged as (
+			delete from data where deleted_at is not null and deleted_at < $1
+			returning id, user_id, revision
+		 ), marked as (
+			update users u set purged_revision = greatest(u.purged_revision, p.revision)
+			from (select user_id, max(revision) as revision from purged group by user_id) p
+			where u.id = p.user_id
+		 )
+		 select count(*) from purged`
+	blobsQuery := `with unreferenced as (
+			  delete from blobs where ref_count = 0 returning object_key, user_id
+		  )
+		  insert into blob_outbox (object_key, user_id) select object_key, user_id from unreferenced
+		  returning id, user_id, object_key, attempts, last_error`
+
+	t.Run("PurgeTrashSuccessfully", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(before).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(blobsQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "object_key", "attempts", "last_error"}))
+		mock.ExpectCommit()
+
+		purged, err := pg.PurgeTrash(context.Background(), before)
+		assert.NoError(t, err)
+		assert.Equal(t, models.PurgeResult{Count: 2, BlobOperations: []models.BlobOperation{}}, purged)
+	})
+
+	t.Run("PurgeTrashError", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(before).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.PurgeTrash(context.Background(), before)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlobKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	keys := []string{"users/1/abc", "users/1/def"}
+	expectedQuery := `select object_key from blobs where user_id = $1 and object_key = any($2)`
+
+	t.Run("GetBlobKeysSuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), pq.Array(keys)).
+			WillReturnRows(sqlmock.NewRows([]string{"object_key"}).AddRow("users/1/abc"))
+		mock.ExpectCommit()
+
+		existing, err := pg.GetBlobKeys(context.Background(), 1, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"users/1/abc"}, existing)
+	})
+
+	t.Run("GetBlobKeysError", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), pq.Array(keys)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.GetBlobKeys(context.Background(), 1, keys)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCreatedData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	dataID := "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708191"
+	key := "0195f3a4-7c1e-7d2a-9b3c-4d5e6f708192"
+	expectedQuery := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '')::uuid or idempotency_key = nullif($3, '')::uuid) limit 1`
+
+	t.Run("GetCreatedDataSuccessfully", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), dataID, key).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "revision", "version"}).AddRow(dataID, 12, 2))
+		mock.ExpectCommit()
+
+		data, err := pg.GetCreatedData(context.Background(), 1, dataID, key)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Data{ID: dataID, Revision: 12, Version: 2}, data)
+	})
+
+	t.Run("GetCreatedDataNotFound", func(t *testing.T) {
+		expectUserTx(mock, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(int64(1), "", key).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "revision", "version"}))
+		mock.ExpectRollback()
+
+		_, err := pg.GetCreatedData(context.Background(), 1, "", key)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetDataByID(t *testing.T) {
@@ -286,7 +1148,8 @@ func TestGetDataByID(t *testing.T) {
 
 	type (
 		args struct {
-			dataId int64
+			dataId string
+			userId int64
 		}
 		mockBehavior func(m *mocks, args args)
 	)
@@ -302,18 +1165,21 @@ func TestGetDataByID(t *testing.T) {
 		{
 			name: "GetDataSuccessfully",
 			args: args{
-				dataId: 1,
+				dataId: "00000000-0000-0000-0000-000000000001",
+				userId: 1,
 			},
 			mockBehavior: func(m *mocks, args args) {
 				expectedQuery := `SELECT id, user_id, data_type, 
-               				data_content, metadata, updated_at 
-	          				FROM data WHERE id = $1`
+               				data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key
+	          				FROM data WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+				expectUserTx(mock, args.userId)
 				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-					WithArgs(args.dataId).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).AddRow(1, "LOGIN_PASSWORD"))
+					WithArgs(args.dataId, args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}).AddRow("00000000-0000-0000-0000-000000000001", "LOGIN_PASSWORD"))
+				mock.ExpectCommit()
 			},
 			expectedData: &models.Data{
-				ID:          1,
+				ID:          "00000000-0000-0000-0000-000000000001",
 				DataType:    "LOGIN_PASSWORD",
 				DataContent: []byte(nil),
 				Metadata:    nil,
@@ -325,20 +1191,42 @@ func TestGetDataByID(t *testing.T) {
 		{
 			name: "GetDataError",
 			args: args{
-				dataId: 11,
+				dataId: "00000000-0000-0000-0000-00000000000b",
+				userId: 1,
 			},
 			mockBehavior: func(m *mocks, args args) {
 				expectedQuery := `SELECT id, user_id, data_type, 
-               				data_content, metadata, updated_at 
-	          				FROM data WHERE id = $1`
+               				data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key
+	          				FROM data WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+				expectUserTx(mock, args.userId)
 				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-					WithArgs(args.dataId).
+					WithArgs(args.dataId, args.userId).
 					WillReturnError(fmt.Errorf("error getting data by ID"))
+				mock.ExpectRollback()
 			},
 			expectedData: nil,
 			wantErr:      true,
 			err:          fmt.Errorf("error getting data by ID"),
 		},
+		{
+			name: "DataOfAnotherUser",
+			args: args{
+				dataId: "00000000-0000-0000-0000-000000000001",
+				userId: 2,
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectedQuery := `SELECT id, user_id, data_type, data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key
+	          				FROM data WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+				expectUserTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "data_type"}))
+				mock.ExpectRollback()
+			},
+			expectedData: nil,
+			wantErr:      true,
+			err:          sql.ErrNoRows,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -351,10 +1239,13 @@ func TestGetDataByID(t *testing.T) {
 			pg := dbAdapter{conn: m.db}
 
 			tt.mockBehavior(m, tt.args)
-			returnedData, err := pg.GetDataByID(context.Background(), tt.args.dataId)
+			returnedData, err := pg.GetDataByID(context.Background(), tt.args.dataId, tt.args.userId)
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				if errors.Is(tt.err, sql.ErrNoRows) {
+					assert.ErrorIs(t, err, sql.ErrNoRows)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedData, returnedData, "The returned Data does not match the expected Data")
@@ -362,6 +1253,8 @@ func TestGetDataByID(t *testing.T) {
 
 		})
 	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestDeleteData(t *testing.T) {
@@ -371,10 +1264,18 @@ func TestDeleteData(t *testing.T) {
 	}
 	defer db.Close()
 
+	expectedQuery := `update data
+		 set deleted_at = now(), updated_at = now(),
+		     revision = nextval('data_revision_seq'), version = version + 1
+		 where id = $1 and user_id = $2 and deleted_at is null and ($3::bigint = 0 or version = $3)
+		 returning revision`
+	versionQuery := `select version from data where id = $1 and user_id = $2 and deleted_at is null`
+
 	type (
 		args struct {
-			dataId int64
-			userId int64
+			dataId          string
+			userId          int64
+			expectedVersion int64
 		}
 		mockBehavior func(m *mocks, args args)
 	)
@@ -390,48 +1291,98 @@ func TestDeleteData(t *testing.T) {
 		{
 			name: "DeleteDataSuccessfully",
 			args: args{
-				dataId: 1,
+				dataId: "00000000-0000-0000-0000-000000000001",
 				userId: 3,
 			},
 			mockBehavior: func(m *mocks, args args) {
-				expectedQuery := `delete from data where id = $1 and user_id= $2`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
-					WithArgs(args.dataId, args.userId).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				expectWriteTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId, args.expectedVersion).
+					WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(9))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WithArgs(changesChannel, `{"user_id":3,"data_id":"00000000-0000-0000-0000-000000000001","revision":9,"deleted":true}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
 			expectedDel: true,
 			wantErr:     false,
 			err:         nil,
 		},
 		{
-			name: "DeleteDataError",
+			name: "DeleteDataNotFound",
+			args: args{
+				dataId: "00000000-0000-0000-0000-000000000001",
+				userId: 3,
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId, args.expectedVersion).
+					WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+				mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+					WithArgs(args.dataId, args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}))
+				mock.ExpectRollback()
+			},
+			expectedDel: false,
+			wantErr:     false,
+			err:         nil,
+		},
+		{
+			name: "DeleteDataVersionConflict",
 			args: args{
-				dataId: 1,
+				dataId:          "00000000-0000-0000-0000-000000000001",
+				userId:          3,
+				expectedVersion: 2,
 			},
 			mockBehavior: func(m *mocks, args args) {
-				expectedQuery := `delete from data where id = $1 and user_id= $2`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId, args.expectedVersion).
+					WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+				mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
 					WithArgs(args.dataId, args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+				mock.ExpectRollback()
+			},
+			expectedDel: false,
+			wantErr:     true,
+			err:         &utils.VersionConflictError{CurrentVersion: 4},
+		},
+		{
+			name: "DeleteDataError",
+			args: args{
+				dataId: "00000000-0000-0000-0000-000000000001",
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId, args.expectedVersion).
 					WillReturnError(fmt.Errorf("error deleting data"))
+				mock.ExpectRollback()
 			},
 			expectedDel: false,
 			wantErr:     true,
 			err:         fmt.Errorf("error deleting data"),
 		},
 		{
-			name: "DeleteDataErrorRowsAffected",
+			name: "DeleteDataErrorNotify",
 			args: args{
-				dataId: 1,
+				dataId: "00000000-0000-0000-0000-000000000001",
+				userId: 3,
 			},
 			mockBehavior: func(m *mocks, args args) {
-				expectedQuery := `delete from data where id = $1 and user_id= $2`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
-					WithArgs(args.dataId, args.userId).
-					WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("error getting rows affected")))
+				expectWriteTx(mock, args.userId)
+				mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+					WithArgs(args.dataId, args.userId, args.expectedVersion).
+					WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(9))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WillReturnError(fmt.Errorf("connection error"))
+				mock.ExpectRollback()
 			},
 			expectedDel: false,
 			wantErr:     true,
-			err:         fmt.Errorf("error get count rows: error getting rows affected"),
+			err:         fmt.Errorf("failed to notify change: connection error"),
 		},
 	}
 	for _, tt := range tests {
@@ -445,10 +1396,13 @@ func TestDeleteData(t *testing.T) {
 			pg := dbAdapter{conn: m.db}
 
 			tt.mockBehavior(m, tt.args)
-			returned, err := pg.DeleteData(context.Background(), tt.args.dataId, tt.args.userId)
+			returned, err := pg.DeleteData(context.Background(), tt.args.dataId, tt.args.userId, tt.args.expectedVersion)
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				if errors.Is(tt.err, utils.ErrVersionConflict) {
+					assert.Equal(t, tt.err, err)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedDel, returned, "The returned Data does not match the expected Data")
@@ -465,6 +1419,15 @@ func TestUpdateData(t *testing.T) {
 	}
 	defer db.Close()
 
+	updateQuery := `update data
+		 set data_content = $1, metadata = $2, blob_key = nullif($6, ''), file_name = coalesce(nullif($7, ''), file_name), updated_at = now(),
+		     revision = nextval('data_revision_seq'), version = version + 1
+		 where id = $3 and user_id = $4 and deleted_at is null and ($5::bigint = 0 or version = $5)
+		 returning revision, version`
+	versionQuery := `select version from data where id = $1 and user_id = $2 and deleted_at is null`
+	revisionQuery := `insert into data_revisions (data_id, user_id, revision, version, data_type, data_content, metadata, updated_at,
+			     blob_key, file_name)`
+
 	type (
 		args struct {
 			data *models.Data
@@ -486,19 +1449,23 @@ func TestUpdateData(t *testing.T) {
 					DataContent: []byte("$1$212345"),
 					Metadata:    nil,
 					UpdatedAt:   time.Time{},
-					ID:          1,
+					ID:          "00000000-0000-0000-0000-000000000001",
 					UserID:      2,
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `update data
-					 set data_content = $1, metadata = $2, updated_at = now()
-					 where id = $3 and user_id = $4`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
 					WithArgs(args.data.DataContent,
-						args.data.Metadata, args.data.ID, args.data.UserID).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+						args.data.Metadata, args.data.ID, args.data.UserID, args.data.Version, args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(7, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WithArgs(changesChannel, `{"user_id":2,"data_id":"00000000-0000-0000-0000-000000000001","revision":7,"deleted":false}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectCommit()
 
 			},
@@ -512,7 +1479,7 @@ func TestUpdateData(t *testing.T) {
 					DataContent: []byte("$1$212345"),
 					Metadata:    nil,
 					UpdatedAt:   time.Time{},
-					ID:          1,
+					ID:          "00000000-0000-0000-0000-000000000001",
 					UserID:      2,
 				},
 			},
@@ -530,25 +1497,103 @@ func TestUpdateData(t *testing.T) {
 					DataContent: []byte("$1$212345"),
 					Metadata:    nil,
 					UpdatedAt:   time.Time{},
-					ID:          1,
+					ID:          "00000000-0000-0000-0000-000000000001",
 					UserID:      2,
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `update data
-					 set data_content = $1, metadata = $2, updated_at = now()
-					 where id = $3 and user_id = $4`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
 					WithArgs(args.data.DataContent,
-						args.data.Metadata, args.data.ID, args.data.UserID).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+						args.data.Metadata, args.data.ID, args.data.UserID, args.data.Version, args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}).AddRow(7, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`select pg_notify($1, $2)`)).
+					WithArgs(changesChannel, `{"user_id":2,"data_id":"00000000-0000-0000-0000-000000000001","revision":7,"deleted":false}`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 
 				mock.ExpectCommit().WillReturnError(fmt.Errorf("failed to commit transaction"))
 			},
 			wantErr: true,
 			err:     fmt.Errorf("failed to commit transaction"),
 		},
+		{
+			name: "SaveRevisionError",
+			args: args{
+				data: &models.Data{
+					DataContent: []byte("$1$212345"),
+					ID:          "00000000-0000-0000-0000-000000000001",
+					UserID:      2,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnError(fmt.Errorf("insert error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			err:     fmt.Errorf("error saving data revision"),
+		},
+		{
+			name: "UpdateDataVersionConflict",
+			args: args{
+				data: &models.Data{
+					DataContent: []byte("$1$212345"),
+					ID:          "00000000-0000-0000-0000-000000000001",
+					UserID:      2,
+					Version:     1,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
+					WithArgs(args.data.DataContent,
+						args.data.Metadata, args.data.ID, args.data.UserID, args.data.Version, args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+					WithArgs(args.data.ID, args.data.UserID).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			err:     &utils.VersionConflictError{CurrentVersion: 3},
+		},
+		{
+			name: "UpdateDataNotFound",
+			args: args{
+				data: &models.Data{
+					DataContent: []byte("$1$212345"),
+					ID:          "00000000-0000-0000-0000-000000000001",
+					UserID:      2,
+				},
+			},
+			mockBehavior: func(m *mocks, args args) {
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
+					WithArgs(args.data.DataContent,
+						args.data.Metadata, args.data.ID, args.data.UserID, args.data.Version, args.data.BlobKey,
+						args.data.FileName).
+					WillReturnRows(sqlmock.NewRows([]string{"revision", "version"}))
+				mock.ExpectQuery(regexp.QuoteMeta(versionQuery)).
+					WithArgs(args.data.ID, args.data.UserID).
+					WillReturnRows(sqlmock.NewRows([]string{"version"}))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			err:     utils.ErrUserDataNotFound,
+		},
 		{
 			name: "DeleteDataError",
 			args: args{
@@ -556,18 +1601,19 @@ func TestUpdateData(t *testing.T) {
 					DataContent: []byte("$1$212345"),
 					Metadata:    nil,
 					UpdatedAt:   time.Time{},
-					ID:          1,
+					ID:          "00000000-0000-0000-0000-000000000001",
 					UserID:      2,
 				},
 			},
 			mockBehavior: func(m *mocks, args args) {
-				mock.ExpectBegin()
-				expectedQuery := `update data
-					 set data_content = $1, metadata = $2, updated_at = now()
-					 where id = $3 and user_id = $4`
-				mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+				expectWriteTx(mock, args.data.UserID)
+				mock.ExpectExec(regexp.QuoteMeta(revisionQuery)).
+					WithArgs(args.data.ID, args.data.UserID, args.data.Version).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)).
 					WithArgs(args.data.DataContent,
-						args.data.Metadata, args.data.ID, args.data.UserID).
+						args.data.Metadata, args.data.ID, args.data.UserID, args.data.Version, args.data.BlobKey,
+						args.data.FileName).
 					WillReturnError(fmt.Errorf("error update update data"))
 				mock.ExpectCommit()
 
@@ -591,6 +1637,9 @@ func TestUpdateData(t *testing.T) {
 
 			if tt.wantErr {
 				assert.Error(t, err)
+				if errors.Is(tt.err, utils.ErrVersionConflict) || errors.Is(tt.err, utils.ErrUserDataNotFound) {
+					assert.Equal(t, tt.err, err)
+				}
 			} else {
 				assert.NoError(t, err)
 			}
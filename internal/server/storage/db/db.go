@@ -3,54 +3,206 @@ package db
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/Sofja96/GophKeeper.git/internal/models"
 	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
 )
 
+// migrations содержит миграции схемы базы данных: PostgreSQL в каталоге migrations, SQLite - в migrations/sqlite.
+// Миграции встроены в исполняемый файл, поэтому сервер не зависит от рабочего каталога.
+//
+//go:embed migrations/*.sql migrations/sqlite/*.sql
+var migrations embed.FS
+
 type dbAdapter struct {
-	conn *sqlx.DB
+	conn     *sqlx.DB
+	hub      *changeHub
+	listener *pq.Listener
 }
 
-// Close закрывает подключение к базе данных.
+// Close закрывает подключение к базе данных и слушателя уведомлений об изменениях.
 func (db *dbAdapter) Close() {
+	if db.listener != nil {
+		_ = db.listener.Close()
+	}
 	_ = db.conn.Close()
 }
 
 // Adapter предоставляет абстракцию для работы с базой данных, включая операции с пользователями и данными.
+//
+// Контракт методов описан здесь один раз: адаптеры PostgreSQL и SQLite выполняют его одинаково,
+// а комментарии их методов описывают только особенности конкретной базы данных.
 type Adapter interface {
+	// Close закрывает подключение к базе данных и каналы подписчиков на изменения.
 	Close()
+
+	// CreateUser создает нового пользователя. Если пользователь с таким именем уже существует, его пароль обновляется.
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	// GetUserIDByName сообщает, существует ли пользователь с именем username.
 	GetUserIDByName(ctx context.Context, username string) (bool, error)
+	// GetUserHashPassword возвращает хеш пароля пользователя с именем username или ошибку, если пользователя нет.
 	GetUserHashPassword(ctx context.Context, username string) (string, error)
-	CreateData(ctx context.Context, data *models.Data) (int64, error)
+	// GetUserID возвращает ID пользователя с именем username или ошибку, если пользователя нет.
 	GetUserID(ctx context.Context, username string) (int64, error)
+
+	// CreateData создает запись данных и возвращает ее ID.
+	// Присвоенные записи ревизия и версия сохраняются в data.Revision и data.Version.
+	// Подписчики получают уведомление после фиксации транзакции.
+	CreateData(ctx context.Context, data *models.Data) (string, error)
+	// GetData возвращает все данные пользователя вне корзины, упорядоченные по времени создания.
 	GetData(ctx context.Context, userId int64) ([]models.Data, error)
-	DeleteData(ctx context.Context, dataId int64, userId int64) (bool, error)
-	GetDataByID(ctx context.Context, dataID int64) (*models.Data, error)
+	// ListData возвращает страницу данных пользователя вне корзины, подходящих под фильтр.
+	// Записи упорядочены по ID и выбираются начиная с записи, следующей за filter.AfterID (keyset-пагинация).
+	// Запись подходит под фильтр по метаданным, если ее метаданные содержат все пары ключ-значение фильтра.
+	ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, error)
+	// DeleteData перемещает запись пользователя в корзину: запись остается в базе данных с временем удаления
+	// и новой ревизией, чтобы клиенты узнали об удалении при синхронизации, а подписчики получают уведомление.
+	// Если expectedVersion не равен 0 и не совпадает с текущей версией, возвращается *utils.VersionConflictError.
+	// Возвращает false, если записи пользователя нет.
+	DeleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (bool, error)
+	// GetDataByID возвращает запись dataID вне корзины, если она принадлежит пользователю userId, иначе ошибку.
+	GetDataByID(ctx context.Context, dataID string, userId int64) (*models.Data, error)
+	// GetCreatedData возвращает ID, ревизию и версию записи пользователя, созданной с ID dataId
+	// или ключом идемпотентности idempotencyKey. Пустые значения не используются при поиске.
+	// Если записи нет, возвращается sql.ErrNoRows.
+	GetCreatedData(ctx context.Context, userId int64, dataId string, idempotencyKey string) (*models.Data, error)
+	// UpdateData сохраняет текущее состояние записи в истории и заменяет содержимое и метаданные записи.
+	// Присвоенные записи ревизия и версия сохраняются в data.Revision и data.Version, а подписчики получают уведомление.
+	// Если data.Version не равен 0 и не совпадает с текущей версией, возвращается *utils.VersionConflictError.
+	// Если записи нет, возвращается utils.ErrUserDataNotFound.
 	UpdateData(ctx context.Context, data *models.Data) error
+	// ApplyMutations выполняет операции пакетного изменения данных пользователя по порядку в одной транзакции.
+	// Если любая из операций не выполнена, транзакция откатывается целиком и возвращается *utils.OperationError
+	// с номером операции и ошибкой utils.ErrUserDataNotFound или *utils.VersionConflictError.
+	// Подписчики получают уведомления только после фиксации транзакции.
+	// Возвращает состояния записей после выполнения операций в порядке операций.
+	ApplyMutations(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error)
+	// GetChanges возвращает не более limit созданных, измененных и удаленных записей пользователя с ревизией больше since,
+	// упорядоченных по возрастанию ревизии. Для удаленных записей заполнены только ID, время удаления и ревизия.
+	// Если since не равен 0 и удаленные после since записи уже удалены из корзины окончательно,
+	// возвращается utils.ErrCursorExpired: клиент должен выполнить полную синхронизацию.
+	// Изменения одного пользователя фиксируются в порядке ревизий, поэтому изменение с ревизией меньше курсора,
+	// уже возвращенного клиенту, появиться не может.
+	GetChanges(ctx context.Context, userId int64, since int64, limit int) ([]models.DataChange, error)
+	// GetDataHistory возвращает предыдущие состояния записи пользователя, начиная с последнего.
+	// Текущее состояние в историю не входит. Если записи пользователя нет, возвращается utils.ErrUserDataNotFound.
+	GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error)
+	// RestoreRevision возвращает запись пользователя к состоянию с ревизией revision из истории.
+	// Текущее состояние сохраняется в истории, записи присваиваются новые ревизия и версия, а подписчики получают уведомление.
+	// Если expectedVersion не равен 0 и не совпадает с текущей версией, возвращается *utils.VersionConflictError;
+	// если записи или ревизии нет - utils.ErrUserDataNotFound.
+	RestoreRevision(ctx context.Context, dataId string, userId int64, revision int64, expectedVersion int64) (*models.Data, error)
+	// ListTrash возвращает записи пользователя в корзине, начиная с удаленных последними.
+	ListTrash(ctx context.Context, userId int64) ([]models.Data, error)
+	// RestoreData возвращает запись пользователя из корзины с новыми ревизией и версией, а подписчики получают уведомление.
+	// Если записи пользователя нет в корзине, возвращается utils.ErrUserDataNotFound.
+	RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error)
+	// PurgeData окончательно удаляет записи пользователя из корзины: запись dataId или, если он пуст, все записи.
+	// Файлы, на которые больше никто не ссылается, удаляются из таблицы blobs, а их удаление из хранилища файлов
+	// записывается в отложенные операции в той же транзакции.
+	// Возвращает количество удаленных записей и эти отложенные операции.
+	PurgeData(ctx context.Context, userId int64, dataId string) (models.PurgeResult, error)
+	// PurgeTrash окончательно удаляет записи всех пользователей, перемещенные в корзину раньше before, как PurgeData.
+	PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error)
+
+	// GetBlobKeys возвращает ключи из keys, под которыми у пользователя зарегистрированы файлы.
+	GetBlobKeys(ctx context.Context, userId int64, keys []string) ([]string, error)
+	// AddPendingBlob записывает отложенное удаление файла пользователя с ключом key, которое выполняется
+	// после processAfter, если к этому времени на файл не ссылается ни одна запись.
+	// Вызывается после загрузки файла в хранилище файлов и до сохранения ссылающейся на него записи:
+	// сохранение записи отменяет операцию в той же транзакции.
+	AddPendingBlob(ctx context.Context, userId int64, key string, processAfter time.Time) error
+	// ClaimBlobOperations выбирает не более limit отложенных операций, время выполнения которых наступило к now,
+	// и откладывает их повторное выполнение до retryAt на случай, если обработчик не завершит их.
+	// Операции с файлами, на которые снова ссылаются записи, удаляются без выполнения.
+	ClaimBlobOperations(ctx context.Context, now time.Time, retryAt time.Time, limit int) ([]models.BlobOperation, error)
+	// CompleteBlobOperation удаляет выполненную отложенную операцию.
+	CompleteBlobOperation(ctx context.Context, id int64) error
+	// FailBlobOperation сохраняет ошибку неудачной попытки выполнения отложенной операции.
+	// Операция будет выполнена повторно после времени, назначенного при ее выборе.
+	FailBlobOperation(ctx context.Context, id int64, reason string) error
+	// DeleteUnreferencedBlob удаляет файл с ключом key вызовом deleteFile, если на файл не ссылается ни одна запись
+	// и после отложенной операции с ID operationID не записано новых операций с этим файлом. Сборка мусора передает
+	// operationID 0: тогда файл удаляется, только если операций с ним нет совсем. Проверка и удаление выполняются
+	// под блокировкой файла, которую берут также AddPendingBlob и сохранение ссылающейся на файл записи, поэтому
+	// повторно загруженный файл не удаляется после регистрации. Сообщает, удален ли файл.
+	DeleteUnreferencedBlob(ctx context.Context, key string, operationID int64, deleteFile func() error) (bool, error)
+	// GetUnreferencedBlobKeys возвращает ключи из keys, которые не зарегистрированы в таблице blobs
+	// и для которых нет отложенных операций. Используется при сборке мусора в хранилище файлов.
+	GetUnreferencedBlobKeys(ctx context.Context, keys []string) ([]string, error)
+	// GetLegacyBlobs возвращает файлы всех пользователей, загруженные до хранения по хешу содержимого
+	// и еще не скопированные под ключ по хешу.
+	GetLegacyBlobs(ctx context.Context) ([]models.LegacyBlob, error)
+	// ReplaceLegacyBlob переводит записи пользователя со старого файла blob на его копию с ключом key и размером size.
+	// Когда копию получили все пользователи старого файла, его удаление из хранилища файлов записывается
+	// в отложенные операции в той же транзакции.
+	ReplaceLegacyBlob(ctx context.Context, blob models.LegacyBlob, key string, size int64) error
+
+	// Subscribe подписывает на события об изменении данных пользователя.
+	// Канал событий закрывается после отмены ctx или закрытия адаптера.
+	Subscribe(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error)
+
+	// CreateSession сохраняет новую сессию пользователя и удаляет его истекшие и отозванные сессии.
+	CreateSession(ctx context.Context, session *models.Session) error
+	// RotateSession заменяет токен обновления сессии с хешем tokenHash токеном с хешем newHash,
+	// который действует до expiresAt, и возвращает сессию.
+	// Если сессии с таким токеном нет или она истекла, возвращается ErrSessionNotFound.
+	// Если предъявлен уже замененный токен сессии, сессия отзывается и возвращается ErrTokenReused.
+	RotateSession(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (*models.Session, error)
+	// RevokeSession отзывает сессию: ее токены доступа и обновления перестают действовать.
+	RevokeSession(ctx context.Context, sessionID string) error
+	// IsSessionActive сообщает, что сессия существует, не отозвана и не истекла.
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+
+	// GetTOTP возвращает настройку двухфакторной аутентификации пользователя.
+	// Если пользователь ее не начинал настраивать, возвращается ErrTOTPNotEnrolled.
+	GetTOTP(ctx context.Context, userId int64) (*models.TOTP, error)
+	// SaveTOTPSecret сохраняет секрет неподтвержденной настройки двухфакторной аутентификации,
+	// заменяя секрет предыдущей неподтвержденной настройки.
+	// Если двухфакторная аутентификация уже включена, возвращается ErrTOTPEnabled.
+	SaveTOTPSecret(ctx context.Context, userId int64, secret string) error
+	// EnableTOTP включает двухфакторную аутентификацию, подтвержденную кодом из интервала counter,
+	// и заменяет коды восстановления пользователя кодами с хешами recoveryHashes.
+	// Если неподтвержденной настройки нет, возвращается ErrTOTPNotEnrolled.
+	EnableTOTP(ctx context.Context, userId int64, counter int64, recoveryHashes []string) error
+	// DisableTOTP выключает двухфакторную аутентификацию и удаляет коды восстановления пользователя.
+	DisableTOTP(ctx context.Context, userId int64) error
+	// UseTOTPCounter отмечает интервал counter как использованный и сообщает, что код из него еще не принимался,
+	// так что один и тот же код нельзя предъявить дважды, даже одновременно.
+	UseTOTPCounter(ctx context.Context, userId int64, counter int64) (bool, error)
+	// UseRecoveryCode удаляет код восстановления с хешем codeHash и сообщает, что такой код был.
+	UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error)
 }
 
 // NewAdapter создает и инициализирует новый адаптер для работы с базой данных.
 //
 // Эта функция выполняет подключение к базе данных с использованием строки подключения,
-// а также выполняет миграции, если указано в настройках.
+// а также выполняет миграции, если указано в настройках,
+// и подписывается на уведомления об изменениях данных пользователей.
+// Строка подключения со схемой sqlite:// выбирает адаптер SQLite, остальные строки подключения - PostgreSQL.
 func NewAdapter(settings *settings.Settings) (Adapter, error) {
+	if isSQLiteDsn(settings.DbDsn) {
+		return newSQLiteAdapter(settings)
+	}
+
 	db, err := sqlx.Connect("postgres", settings.DbDsn)
 	if err != nil {
 		return nil, err
 	}
 
-	dbClient := dbAdapter{conn: db}
+	dbClient := dbAdapter{conn: db, hub: newChangeHub()}
 
 	if settings.DbAutoMigration {
 		err = dbClient.migration(settings)
@@ -58,11 +210,109 @@ func NewAdapter(settings *settings.Settings) (Adapter, error) {
 			return nil, err
 		}
 	}
-	return &dbClient, err
+
+	dbClient.listener, err = dbClient.hub.listen(settings.DbDsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbClient, nil
+}
+
+// beginUserTx начинает транзакцию от имени пользователя userId.
+//
+// Политики построчной защиты таблиц data и data_revisions пропускают в такой транзакции
+// только записи этого пользователя, даже если в запросе забыто условие по user_id.
+func (db *dbAdapter) beginUserTx(ctx context.Context, userId int64) (*sqlx.Tx, error) {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `select set_config('app.user_id', $1, true)`, strconv.FormatInt(userId, 10))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to set transaction user: %w", err)
+	}
+
+	return tx, nil
 }
 
+// beginWriteTx начинает транзакцию изменения данных пользователя userId и блокирует строку пользователя
+// в таблице users до ее завершения.
+//
+// Последовательность выдает ревизию при выполнении запроса, а не при фиксации транзакции, поэтому две параллельные
+// транзакции пользователя могли бы зафиксироваться не в порядке своих ревизий, и клиент, курсор которого уже
+// прошел большую ревизию, не получил бы меньшую. Блокировка берется до выдачи ревизий, так что изменения
+// одного пользователя фиксируются в порядке ревизий.
+func (db *dbAdapter) beginWriteTx(ctx context.Context, userId int64) (*sqlx.Tx, error) {
+	tx, err := db.beginUserTx(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `select 1 from users where id = $1 for no key update`, userId)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to lock user revisions: %w", err)
+	}
+
+	return tx, nil
+}
+
+// inUserTx выполняет fn в транзакции от имени пользователя userId и фиксирует транзакцию,
+// если fn завершилась без ошибки.
+func (db *dbAdapter) inUserTx(ctx context.Context, userId int64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.beginUserTx(ctx, userId)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// beginMaintenanceTx начинает транзакцию для фоновых задач, которым нужны записи всех пользователей,
+// например для очистки корзины.
+func (db *dbAdapter) beginMaintenanceTx(ctx context.Context) (*sqlx.Tx, error) {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `select set_config('app.maintenance', 'on', true)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+
+	return tx, nil
+}
+
+// Subscribe подписывает на события, которые приходят всем экземплярам сервера через LISTEN/NOTIFY.
+func (db *dbAdapter) Subscribe(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error) {
+	if db.hub == nil {
+		return nil, fmt.Errorf("change notifications are not available")
+	}
+
+	return db.hub.subscribe(ctx, userId), nil
+}
+
+// migration применяет миграции схемы базы данных.
+//
+// Миграции выполняются под строкой подключения DbMigrationDsn владельца таблиц, если она задана, а сервер
+// работает под ролью из DbDsn, для которой действуют политики построчной защиты.
 func (db *dbAdapter) migration(settings *settings.Settings) error {
-	dbInstance, err := sql.Open("postgres", settings.DbDsn)
+	dsn := settings.DbMigrationDsn
+	if dsn == "" {
+		dsn = settings.DbDsn
+	}
+
+	dbInstance, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -73,9 +323,14 @@ func (db *dbAdapter) migration(settings *settings.Settings) error {
 		return err
 	}
 
-	dbDsnParse := strings.Split(settings.DbDsn, "/")
+	dbDsnParse := strings.Split(dsn, "/")
 	lastPart := strings.Split(dbDsnParse[len(dbDsnParse)-1], "?")
-	m, err := migrate.NewWithDatabaseInstance("file:./internal/server/storage/db/migrations", lastPart[0], driver)
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, lastPart[0], driver)
 	if err != nil {
 		return fmt.Errorf("error creaate migrate: %w", err)
 	}
@@ -0,0 +1,775 @@
+// Package dbtest содержит общий набор тестов, которому должна соответствовать каждая реализация db.Adapter.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/db"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// Run проверяет, что adapter соответствует контракту db.Adapter.
+// Адаптер должен быть подключен к пустой базе данных с примененными миграциями.
+func Run(t *testing.T, adapter db.Adapter) {
+	ctx := context.Background()
+
+	alice := createUser(t, adapter, "alice")
+	bob := createUser(t, adapter, "bob")
+
+	t.Run("users", func(t *testing.T) {
+		exists, err := adapter.GetUserIDByName(ctx, "alice")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = adapter.GetUserIDByName(ctx, "nobody")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		password, err := adapter.GetUserHashPassword(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, "alice-hash", password)
+
+		_, err = adapter.GetUserHashPassword(ctx, "nobody")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = adapter.GetUserID(ctx, "nobody")
+		assert.Error(t, err)
+
+		// Повторная регистрация обновляет пароль, ID пользователя не меняется
+		_, err = adapter.CreateUser(ctx, &models.User{Username: "alice", Password: "new-hash"})
+		require.NoError(t, err)
+		password, err = adapter.GetUserHashPassword(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", password)
+		id, err := adapter.GetUserID(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, alice, id)
+	})
+
+	t.Run("create and get data", func(t *testing.T) {
+		data := newData(alice, models.TextData, "note")
+		id, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+		assert.Equal(t, data.ID, id)
+		assert.Positive(t, data.Revision)
+		assert.Equal(t, int64(1), data.Version)
+
+		stored, err := adapter.GetDataByID(ctx, id, alice)
+		require.NoError(t, err)
+		assert.Equal(t, alice, stored.UserID)
+		assert.Equal(t, models.TextData, stored.DataType)
+		assert.Equal(t, []byte("note"), stored.DataContent)
+		assert.Equal(t, "note", stored.Metadata["name"])
+		assert.Equal(t, data.Revision, stored.Revision)
+		assert.WithinDuration(t, time.Now(), stored.UpdatedAt, time.Minute)
+
+		all, err := adapter.GetData(ctx, alice)
+		require.NoError(t, err)
+		assert.Contains(t, ids(all), id)
+
+		// Данные другого пользователя недоступны
+		_, err = adapter.GetDataByID(ctx, id, bob)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		all, err = adapter.GetData(ctx, bob)
+		require.NoError(t, err)
+		assert.NotContains(t, ids(all), id)
+	})
+
+	t.Run("repeated create returns existing data", func(t *testing.T) {
+		data := newData(alice, models.TextData, "idempotent")
+		data.IdempotencyKey = models.NewDataID()
+		id, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		retry := newData(alice, models.TextData, "idempotent")
+		retry.ID = id
+		retryID, err := adapter.CreateData(ctx, retry)
+		require.NoError(t, err)
+		assert.Equal(t, id, retryID)
+		assert.Equal(t, data.Revision, retry.Revision)
+
+		byKey := newData(alice, models.TextData, "idempotent")
+		byKey.IdempotencyKey = data.IdempotencyKey
+		keyID, err := adapter.CreateData(ctx, byKey)
+		require.NoError(t, err)
+		assert.Equal(t, id, keyID)
+
+		created, err := adapter.GetCreatedData(ctx, alice, "", data.IdempotencyKey)
+		require.NoError(t, err)
+		assert.Equal(t, id, created.ID)
+		assert.Equal(t, data.Version, created.Version)
+
+		_, err = adapter.GetCreatedData(ctx, alice, models.NewDataID(), "")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// ID записи другого пользователя занят
+		other := newData(bob, models.TextData, "idempotent")
+		other.ID = id
+		_, err = adapter.CreateData(ctx, other)
+		assert.ErrorIs(t, err, utils.ErrDataExists)
+	})
+
+	t.Run("list data", func(t *testing.T) {
+		user := createUser(t, adapter, "list")
+		start := time.Now().Add(-time.Minute)
+
+		first := newData(user, models.LoginPassword, "first")
+		first.Metadata["site"] = "example.com"
+		second := newData(user, models.TextData, "second")
+		third := newData(user, models.LoginPassword, "third")
+		for _, data := range []*models.Data{first, second, third} {
+			_, err := adapter.CreateData(ctx, data)
+			require.NoError(t, err)
+		}
+
+		page, err := adapter.ListData(ctx, user, models.DataFilter{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.ID, second.ID}, ids(page))
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{AfterID: second.ID, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{third.ID}, ids(page))
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{DataType: models.LoginPassword, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.ID, third.ID}, ids(page))
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{Metadata: map[string]string{"site": "example.com"}, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.ID}, ids(page))
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{
+			Metadata: map[string]string{"site": "example.com", "name": "third"}, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page)
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{UpdatedAfter: start, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, page, 3)
+
+		page, err = adapter.ListData(ctx, user, models.DataFilter{UpdatedAfter: time.Now().Add(time.Minute), Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, page)
+	})
+
+	t.Run("update data and history", func(t *testing.T) {
+		data := newData(alice, models.TextData, "v1")
+		id, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+		created := data.Revision
+
+		update := newData(alice, models.TextData, "v2")
+		update.ID = id
+		update.Version = 2
+		err = adapter.UpdateData(ctx, update)
+		var conflict *utils.VersionConflictError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, int64(1), conflict.CurrentVersion)
+
+		update.Version = 1
+		require.NoError(t, adapter.UpdateData(ctx, update))
+		assert.Equal(t, int64(2), update.Version)
+		assert.Greater(t, update.Revision, created)
+
+		stored, err := adapter.GetDataByID(ctx, id, alice)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v2"), stored.DataContent)
+
+		history, err := adapter.GetDataHistory(ctx, id, alice)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, []byte("v1"), history[0].DataContent)
+		assert.Equal(t, created, history[0].Revision)
+
+		restored, err := adapter.RestoreRevision(ctx, id, alice, created, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("v1"), restored.DataContent)
+		assert.Equal(t, int64(3), restored.Version)
+		assert.Greater(t, restored.Revision, update.Revision)
+
+		_, err = adapter.RestoreRevision(ctx, id, alice, created, 2)
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+		_, err = adapter.RestoreRevision(ctx, id, alice, restored.Revision+1000, 0)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+
+		missing := newData(alice, models.TextData, "missing")
+		assert.ErrorIs(t, adapter.UpdateData(ctx, missing), utils.ErrUserDataNotFound)
+		_, err = adapter.GetDataHistory(ctx, id, bob)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		data := newData(alice, models.TextData, "trash")
+		id, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		deleted, err := adapter.DeleteData(ctx, id, bob, 0)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		_, err = adapter.DeleteData(ctx, id, alice, 5)
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+
+		deleted, err = adapter.DeleteData(ctx, id, alice, 1)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		_, err = adapter.GetDataByID(ctx, id, alice)
+		assert.Error(t, err)
+
+		trash, err := adapter.ListTrash(ctx, alice)
+		require.NoError(t, err)
+		assert.Contains(t, ids(trash), id)
+
+		restored, err := adapter.RestoreData(ctx, id, alice)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("trash"), restored.DataContent)
+		assert.Equal(t, int64(3), restored.Version)
+
+		_, err = adapter.RestoreData(ctx, id, alice)
+		assert.ErrorIs(t, err, utils.ErrUserDataNotFound)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		user := createUser(t, adapter, "changes")
+
+		kept := newData(user, models.TextData, "kept")
+		_, err := adapter.CreateData(ctx, kept)
+		require.NoError(t, err)
+		removed := newData(user, models.TextData, "removed")
+		_, err = adapter.CreateData(ctx, removed)
+		require.NoError(t, err)
+		_, err = adapter.DeleteData(ctx, removed.ID, user, 0)
+		require.NoError(t, err)
+
+		changes, err := adapter.GetChanges(ctx, user, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, kept.ID, changes[0].ID)
+		assert.False(t, changes[0].Deleted)
+		assert.Equal(t, []byte("kept"), changes[0].DataContent)
+		assert.Equal(t, removed.ID, changes[1].ID)
+		assert.True(t, changes[1].Deleted)
+		assert.Empty(t, changes[1].DataType)
+		assert.Greater(t, changes[1].Revision, changes[0].Revision)
+
+		changes, err = adapter.GetChanges(ctx, user, changes[0].Revision, 10)
+		require.NoError(t, err)
+		assert.Len(t, changes, 1)
+
+		result, err := adapter.PurgeData(ctx, user, "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Count)
+
+		_, err = adapter.GetChanges(ctx, user, kept.Revision, 10)
+		assert.ErrorIs(t, err, utils.ErrCursorExpired)
+		changes, err = adapter.GetChanges(ctx, user, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{kept.ID}, changeIDs(changes))
+	})
+
+	t.Run("concurrent changes", func(t *testing.T) {
+		user := createUser(t, adapter, "concurrent")
+
+		// Клиент, который синхронизируется во время параллельных изменений, должен получить каждое из них:
+		// ревизии, меньшие курсора, не могут появиться после того, как курсор их прошел.
+		const writers, perWriter = 4, 15
+		written := make(chan string, writers*perWriter)
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWriter; i++ {
+					data := newData(user, models.TextData, "concurrent")
+					if _, err := adapter.CreateData(ctx, data); !assert.NoError(t, err) {
+						return
+					}
+					data.Version = 0
+					data.DataContent = []byte("concurrent update")
+					if !assert.NoError(t, adapter.UpdateData(ctx, data)) {
+						return
+					}
+					written <- data.ID
+				}
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		received := make(map[string]int64)
+		var cursor int64
+		poll := func() {
+			changes, err := adapter.GetChanges(ctx, user, cursor, 1000)
+			require.NoError(t, err)
+			for _, change := range changes {
+				received[change.ID] = change.Revision
+				cursor = change.Revision
+			}
+		}
+		for polling := true; polling; {
+			select {
+			case <-done:
+				polling = false
+			default:
+				poll()
+			}
+		}
+		poll()
+
+		close(written)
+		for id := range written {
+			stored, err := adapter.GetDataByID(ctx, id, user)
+			require.NoError(t, err)
+			assert.Equal(t, stored.Revision, received[id], "change of %s was not received", id)
+		}
+	})
+
+	t.Run("mutations", func(t *testing.T) {
+		existing := newData(alice, models.TextData, "batch")
+		_, err := adapter.CreateData(ctx, existing)
+		require.NoError(t, err)
+
+		update := newData(alice, models.TextData, "batch updated")
+		update.ID = existing.ID
+		update.Version = 1
+		created := newData(alice, models.BankCard, "card")
+
+		results, err := adapter.ApplyMutations(ctx, alice, []models.Mutation{
+			{Type: models.MutationCreate, Data: *created},
+			{Type: models.MutationUpdate, Data: *update},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, created.ID, results[0].ID)
+		assert.Equal(t, int64(2), results[1].Version)
+
+		// Неудавшаяся операция откатывает весь пакет
+		rolledBack := newData(alice, models.TextData, "rolled back")
+		_, err = adapter.ApplyMutations(ctx, alice, []models.Mutation{
+			{Type: models.MutationCreate, Data: *rolledBack},
+			{Type: models.MutationDelete, Data: models.Data{ID: existing.ID, Version: 1}},
+		})
+		var opErr *utils.OperationError
+		require.ErrorAs(t, err, &opErr)
+		assert.Equal(t, 1, opErr.Index)
+		assert.ErrorIs(t, err, utils.ErrVersionConflict)
+
+		_, err = adapter.GetDataByID(ctx, rolledBack.ID, alice)
+		assert.Error(t, err)
+	})
+
+	t.Run("blobs", func(t *testing.T) {
+		user := createUser(t, adapter, "blobs")
+
+		data := newData(user, models.BinaryData, "")
+		data.BlobKey = "users/blobs/old"
+		_, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		// Тот же файл у другой записи
+		copied := newData(user, models.BinaryData, "")
+		copied.BlobKey = "users/blobs/old"
+		_, err = adapter.CreateData(ctx, copied)
+		require.NoError(t, err)
+
+		update := newData(user, models.BinaryData, "")
+		update.ID = data.ID
+		update.BlobKey = "users/blobs/new"
+		require.NoError(t, adapter.UpdateData(ctx, update))
+
+		stored, err := adapter.GetDataByID(ctx, data.ID, user)
+		require.NoError(t, err)
+		assert.Equal(t, "users/blobs/new", stored.BlobKey)
+
+		keys, err := adapter.GetBlobKeys(ctx, user, []string{"users/blobs/old", "users/blobs/new", "users/blobs/missing"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"users/blobs/old", "users/blobs/new"}, keys)
+		keys, err = adapter.GetBlobKeys(ctx, bob, []string{"users/blobs/old"})
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		// Старый файл нужен истории записи и другой записи
+		_, err = adapter.DeleteData(ctx, data.ID, user, 0)
+		require.NoError(t, err)
+		result, err := adapter.PurgeData(ctx, user, data.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Count)
+		assert.ElementsMatch(t, []string{"users/blobs/new"}, blobKeys(result.BlobOperations))
+
+		_, err = adapter.DeleteData(ctx, copied.ID, user, 0)
+		require.NoError(t, err)
+		result, err = adapter.PurgeTrash(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, result.Count, int64(1))
+		assert.Contains(t, blobKeys(result.BlobOperations), "users/blobs/old")
+
+		keys, err = adapter.GetBlobKeys(ctx, user, []string{"users/blobs/old", "users/blobs/new"})
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("blob manifest", func(t *testing.T) {
+		user := createUser(t, adapter, "manifest")
+
+		data := newData(user, models.BinaryData, "")
+		data.BlobKey = "users/manifest/first"
+		data.BlobSize = 42
+		data.FileName = "report.pdf"
+		_, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		dataList, err := adapter.GetData(ctx, user)
+		require.NoError(t, err)
+		require.Len(t, dataList, 1)
+		assert.Equal(t, "users/manifest/first", dataList[0].BlobKey)
+		assert.Equal(t, int64(42), dataList[0].BlobSize)
+		assert.Equal(t, "report.pdf", dataList[0].FileName)
+
+		// Обновление без имени файла сохраняет прежнее имя
+		update := newData(user, models.BinaryData, "")
+		update.ID = data.ID
+		update.BlobKey = "users/manifest/second"
+		update.BlobSize = 7
+		require.NoError(t, adapter.UpdateData(ctx, update))
+
+		page, err := adapter.ListData(ctx, user, models.DataFilter{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, "users/manifest/second", page[0].BlobKey)
+		assert.Equal(t, int64(7), page[0].BlobSize)
+		assert.Equal(t, "report.pdf", page[0].FileName)
+
+		changes, err := adapter.GetChanges(ctx, user, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "users/manifest/second", changes[0].BlobKey)
+		assert.Equal(t, int64(7), changes[0].BlobSize)
+		assert.Equal(t, "report.pdf", changes[0].FileName)
+	})
+
+	t.Run("blob outbox", func(t *testing.T) {
+		user := createUser(t, adapter, "outbox")
+		now := time.Now()
+
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/outbox/saved", now.Add(-time.Second)))
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/outbox/orphan", now.Add(-time.Second)))
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/outbox/later", now.Add(time.Hour)))
+
+		// Сохранение записи отменяет отложенное удаление ее файла
+		data := newData(user, models.BinaryData, "")
+		data.BlobKey = "users/outbox/saved"
+		_, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		unreferenced, err := adapter.GetUnreferencedBlobKeys(ctx,
+			[]string{"users/outbox/saved", "users/outbox/orphan", "users/outbox/later", "users/outbox/lost"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users/outbox/lost"}, unreferenced)
+
+		operations, err := adapter.ClaimBlobOperations(ctx, now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		keys := blobKeys(operations)
+		assert.Contains(t, keys, "users/outbox/orphan")
+		assert.NotContains(t, keys, "users/outbox/saved")
+		assert.NotContains(t, keys, "users/outbox/later")
+		orphan := operations[indexOf(keys, "users/outbox/orphan")]
+		assert.Equal(t, 1, orphan.Attempts)
+		assert.Equal(t, user, orphan.UserID)
+
+		// Выбранная операция не выполняется повторно до назначенного времени
+		operations, err = adapter.ClaimBlobOperations(ctx, now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		assert.NotContains(t, blobKeys(operations), "users/outbox/orphan")
+
+		require.NoError(t, adapter.FailBlobOperation(ctx, orphan.ID, "storage unavailable"))
+		operations, err = adapter.ClaimBlobOperations(ctx, now.Add(2*time.Minute), now.Add(3*time.Minute), 100)
+		require.NoError(t, err)
+		keys = blobKeys(operations)
+		require.Contains(t, keys, "users/outbox/orphan")
+		orphan = operations[indexOf(keys, "users/outbox/orphan")]
+		assert.Equal(t, 2, orphan.Attempts)
+		assert.Equal(t, "storage unavailable", orphan.LastError)
+
+		for _, operation := range operations {
+			require.NoError(t, adapter.CompleteBlobOperation(ctx, operation.ID))
+		}
+
+		// Отложенное удаление файла, на который уже ссылается запись, не выполняется
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/outbox/saved", now.Add(-time.Second)))
+
+		operations, err = adapter.ClaimBlobOperations(ctx, now.Add(2*time.Hour), now.Add(3*time.Hour), 100)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users/outbox/later"}, blobKeys(operations))
+		require.NoError(t, adapter.CompleteBlobOperation(ctx, operations[0].ID))
+
+		operations, err = adapter.ClaimBlobOperations(ctx, now.Add(4*time.Hour), now.Add(5*time.Hour), 100)
+		require.NoError(t, err)
+		assert.Empty(t, operations)
+	})
+
+	t.Run("delete unreferenced blob", func(t *testing.T) {
+		user := createUser(t, adapter, "unreferenced")
+		now := time.Now()
+		var deleted []string
+		deleteFile := func(key string) func() error {
+			return func() error {
+				deleted = append(deleted, key)
+				return nil
+			}
+		}
+
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/unreferenced/file", now.Add(-time.Second)))
+		operations, err := adapter.ClaimBlobOperations(ctx, now, now.Add(time.Minute), 100)
+		require.NoError(t, err)
+		keys := blobKeys(operations)
+		require.Contains(t, keys, "users/unreferenced/file")
+		claimed := operations[indexOf(keys, "users/unreferenced/file")]
+
+		// Файл загружен повторно после выбора операции: ни операция, ни сборка мусора его не удаляют
+		require.NoError(t, adapter.AddPendingBlob(ctx, user, "users/unreferenced/file", now.Add(time.Hour)))
+		removed, err := adapter.DeleteUnreferencedBlob(ctx, "users/unreferenced/file", claimed.ID, deleteFile("users/unreferenced/file"))
+		require.NoError(t, err)
+		assert.False(t, removed)
+		removed, err = adapter.DeleteUnreferencedBlob(ctx, "users/unreferenced/file", 0, deleteFile("users/unreferenced/file"))
+		require.NoError(t, err)
+		assert.False(t, removed)
+		require.NoError(t, adapter.CompleteBlobOperation(ctx, claimed.ID))
+
+		// Файл, на который ссылается запись, не удаляется
+		data := newData(user, models.BinaryData, "")
+		data.BlobKey = "users/unreferenced/file"
+		_, err = adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+		removed, err = adapter.DeleteUnreferencedBlob(ctx, "users/unreferenced/file", 0, deleteFile("users/unreferenced/file"))
+		require.NoError(t, err)
+		assert.False(t, removed)
+
+		removed, err = adapter.DeleteUnreferencedBlob(ctx, "users/unreferenced/orphan", 0, deleteFile("users/unreferenced/orphan"))
+		require.NoError(t, err)
+		assert.True(t, removed)
+		assert.Equal(t, []string{"users/unreferenced/orphan"}, deleted)
+
+		errStorage := errors.New("storage unavailable")
+		_, err = adapter.DeleteUnreferencedBlob(ctx, "users/unreferenced/orphan", 0, func() error { return errStorage })
+		assert.ErrorIs(t, err, errStorage)
+	})
+
+	t.Run("purge keeps recent trash", func(t *testing.T) {
+		data := newData(alice, models.TextData, "recent")
+		_, err := adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+		_, err = adapter.DeleteData(ctx, data.ID, alice, 0)
+		require.NoError(t, err)
+
+		_, err = adapter.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+
+		trash, err := adapter.ListTrash(ctx, alice)
+		require.NoError(t, err)
+		assert.Contains(t, ids(trash), data.ID)
+	})
+
+	t.Run("sessions", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		session := &models.Session{ID: "session-1", UserID: alice, TokenHash: "hash-1", ExpiresAt: expiresAt}
+		require.NoError(t, adapter.CreateSession(ctx, session))
+
+		active, err := adapter.IsSessionActive(ctx, session.ID)
+		require.NoError(t, err)
+		assert.True(t, active)
+
+		active, err = adapter.IsSessionActive(ctx, "missing")
+		require.NoError(t, err)
+		assert.False(t, active)
+
+		// Токен обновления заменяется новым, старый токен больше не действует
+		rotated, err := adapter.RotateSession(ctx, "hash-1", "hash-2", expiresAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, rotated.ID)
+		assert.Equal(t, alice, rotated.UserID)
+		assert.Equal(t, "alice", rotated.Username)
+		assert.Equal(t, "hash-2", rotated.TokenHash)
+		assert.WithinDuration(t, expiresAt.Add(time.Hour), rotated.ExpiresAt, time.Second)
+
+		_, err = adapter.RotateSession(ctx, "unknown", "hash-3", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrSessionNotFound)
+
+		// Повторное предъявление замененного токена отзывает сессию
+		_, err = adapter.RotateSession(ctx, "hash-1", "hash-3", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrTokenReused)
+		active, err = adapter.IsSessionActive(ctx, session.ID)
+		require.NoError(t, err)
+		assert.False(t, active)
+		_, err = adapter.RotateSession(ctx, "hash-2", "hash-3", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrSessionNotFound)
+
+		// Выход отзывает сессию
+		other := &models.Session{ID: "session-2", UserID: bob, TokenHash: "hash-4", ExpiresAt: expiresAt}
+		require.NoError(t, adapter.CreateSession(ctx, other))
+		require.NoError(t, adapter.RevokeSession(ctx, other.ID))
+		active, err = adapter.IsSessionActive(ctx, other.ID)
+		require.NoError(t, err)
+		assert.False(t, active)
+		_, err = adapter.RotateSession(ctx, "hash-4", "hash-5", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrSessionNotFound)
+
+		// Истекшая сессия не действует
+		expired := &models.Session{ID: "session-3", UserID: bob, TokenHash: "hash-6", ExpiresAt: time.Now().Add(-time.Minute)}
+		require.NoError(t, adapter.CreateSession(ctx, expired))
+		active, err = adapter.IsSessionActive(ctx, expired.ID)
+		require.NoError(t, err)
+		assert.False(t, active)
+		_, err = adapter.RotateSession(ctx, "hash-6", "hash-7", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrSessionNotFound)
+
+		// Новая сессия удаляет истекшие и отозванные сессии пользователя, и их токены можно выдать снова
+		require.NoError(t, adapter.CreateSession(ctx, &models.Session{ID: "session-4", UserID: bob, TokenHash: "hash-8", ExpiresAt: expiresAt}))
+		require.NoError(t, adapter.CreateSession(ctx, &models.Session{ID: "session-2", UserID: bob, TokenHash: "hash-4", ExpiresAt: expiresAt}))
+	})
+
+	t.Run("totp", func(t *testing.T) {
+		_, err := adapter.GetTOTP(ctx, alice)
+		assert.ErrorIs(t, err, utils.ErrTOTPNotEnrolled)
+		assert.ErrorIs(t, adapter.EnableTOTP(ctx, alice, 1, nil), utils.ErrTOTPNotEnrolled)
+
+		// Неподтвержденную настройку можно начать заново с новым секретом
+		require.NoError(t, adapter.SaveTOTPSecret(ctx, alice, "FIRST"))
+		require.NoError(t, adapter.SaveTOTPSecret(ctx, alice, "SECOND"))
+		totp, err := adapter.GetTOTP(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, models.TOTP{Secret: "SECOND"}, *totp)
+
+		// До подтверждения коды не принимаются
+		used, err := adapter.UseTOTPCounter(ctx, alice, 100)
+		require.NoError(t, err)
+		assert.False(t, used)
+
+		require.NoError(t, adapter.EnableTOTP(ctx, alice, 100, []string{"code-1", "code-2"}))
+		totp, err = adapter.GetTOTP(ctx, alice)
+		require.NoError(t, err)
+		assert.Equal(t, models.TOTP{Secret: "SECOND", Enabled: true, LastCounter: 100}, *totp)
+		assert.ErrorIs(t, adapter.SaveTOTPSecret(ctx, alice, "THIRD"), utils.ErrTOTPEnabled)
+
+		// Код из уже использованного интервала повторно не принимается
+		used, err = adapter.UseTOTPCounter(ctx, alice, 100)
+		require.NoError(t, err)
+		assert.False(t, used)
+		used, err = adapter.UseTOTPCounter(ctx, alice, 101)
+		require.NoError(t, err)
+		assert.True(t, used)
+
+		// Код восстановления действует один раз и только для своего пользователя
+		used, err = adapter.UseRecoveryCode(ctx, bob, "code-1")
+		require.NoError(t, err)
+		assert.False(t, used)
+		used, err = adapter.UseRecoveryCode(ctx, alice, "code-1")
+		require.NoError(t, err)
+		assert.True(t, used)
+		used, err = adapter.UseRecoveryCode(ctx, alice, "code-1")
+		require.NoError(t, err)
+		assert.False(t, used)
+
+		require.NoError(t, adapter.DisableTOTP(ctx, alice))
+		_, err = adapter.GetTOTP(ctx, alice)
+		assert.ErrorIs(t, err, utils.ErrTOTPNotEnrolled)
+		used, err = adapter.UseRecoveryCode(ctx, alice, "code-2")
+		require.NoError(t, err)
+		assert.False(t, used)
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, err := adapter.Subscribe(subCtx, bob)
+		require.NoError(t, err)
+
+		data := newData(bob, models.TextData, "event")
+		_, err = adapter.CreateData(ctx, data)
+		require.NoError(t, err)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, models.ChangeEvent{UserID: bob, DataID: data.ID, Revision: data.Revision}, event)
+		case <-time.After(5 * time.Second):
+			t.Fatal("change event was not received")
+		}
+
+		cancel()
+		assert.Eventually(t, func() bool {
+			_, ok := <-events
+			return !ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// createUser регистрирует пользователя и возвращает его ID.
+func createUser(t *testing.T, adapter db.Adapter, username string) int64 {
+	_, err := adapter.CreateUser(context.Background(), &models.User{Username: username, Password: username + "-hash"})
+	require.NoError(t, err)
+
+	id, err := adapter.GetUserID(context.Background(), username)
+	require.NoError(t, err)
+	return id
+}
+
+// newData создает запись пользователя userId с содержимым content и именем в метаданных.
+func newData(userId int64, dataType models.DataType, content string) *models.Data {
+	return &models.Data{
+		ID:          models.NewDataID(),
+		UserID:      userId,
+		DataType:    dataType,
+		DataContent: []byte(content),
+		Metadata:    models.JSONB{"name": content},
+	}
+}
+
+// ids возвращает ID записей в порядке следования.
+func ids(dataList []models.Data) []string {
+	result := make([]string, len(dataList))
+	for i, data := range dataList {
+		result[i] = data.ID
+	}
+	return result
+}
+
+// blobKeys возвращает ключи файлов отложенных операций в порядке следования.
+func blobKeys(operations []models.BlobOperation) []string {
+	result := make([]string, len(operations))
+	for i, operation := range operations {
+		result[i] = operation.BlobKey
+	}
+	return result
+}
+
+// indexOf возвращает индекс значения value в values или -1, если его нет.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// changeIDs возвращает ID измененных записей в порядке следования.
+func changeIDs(changes []models.DataChange) []string {
+	result := make([]string, len(changes))
+	for i, change := range changes {
+		result[i] = change.ID
+	}
+	return result
+}
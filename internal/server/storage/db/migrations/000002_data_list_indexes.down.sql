@@ -0,0 +1,3 @@
+drop index if exists data_metadata_gin_idx;
+
+drop index if exists data_user_id_id_idx;
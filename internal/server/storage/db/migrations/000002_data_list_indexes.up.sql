@@ -0,0 +1,3 @@
+create index if not exists data_user_id_id_idx on data (user_id, id);
+
+create index if not exists data_metadata_gin_idx on data using gin (metadata jsonb_path_ops);
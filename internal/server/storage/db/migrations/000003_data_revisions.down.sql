@@ -0,0 +1,7 @@
+drop table if exists data_deletions;
+
+drop index if exists data_user_id_revision_idx;
+
+alter table data drop column if exists revision;
+
+drop sequence if exists data_revision_seq;
@@ -0,0 +1,16 @@
+create sequence if not exists data_revision_seq;
+
+-- Ревизия увеличивается при каждом создании и изменении записи
+alter table data add column if not exists revision bigint not null default nextval('data_revision_seq');
+
+create index if not exists data_user_id_revision_idx on data (user_id, revision);
+
+-- Журнал удалений, чтобы клиенты узнавали об удаленных данных при синхронизации
+create table if not exists data_deletions (
+    data_id bigint primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    revision bigint not null default nextval('data_revision_seq'),
+    deleted_at timestamp with time zone default now() not null
+);
+
+create index if not exists data_deletions_user_id_revision_idx on data_deletions (user_id, revision);
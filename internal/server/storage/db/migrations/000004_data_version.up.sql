@@ -0,0 +1,2 @@
+-- Версия записи увеличивается при каждом изменении и используется для оптимистичной блокировки
+alter table data add column if not exists version bigint not null default 1;
@@ -0,0 +1,21 @@
+alter table users drop column if exists purged_revision;
+
+create table if not exists data_deletions (
+    data_id bigint primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    revision bigint not null default nextval('data_revision_seq'),
+    deleted_at timestamp with time zone default now() not null
+);
+
+create index if not exists data_deletions_user_id_revision_idx on data_deletions (user_id, revision);
+
+insert into data_deletions (data_id, user_id, revision, deleted_at)
+select id, user_id, revision, deleted_at
+from data
+where deleted_at is not null;
+
+delete from data where deleted_at is not null;
+
+drop index if exists data_deleted_at_idx;
+
+alter table data drop column if exists deleted_at;
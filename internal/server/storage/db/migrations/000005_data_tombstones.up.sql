@@ -0,0 +1,15 @@
+-- Удаленные записи остаются в таблице data как метки удаления до истечения срока хранения,
+-- чтобы клиенты узнавали об удалениях при синхронизации
+alter table data add column if not exists deleted_at timestamp with time zone;
+
+insert into data (id, user_id, data_type, data_content, metadata, updated_at, revision, deleted_at)
+select data_id, user_id, '', ''::bytea, '{}'::jsonb, deleted_at, revision, deleted_at
+from data_deletions
+on conflict (id) do nothing;
+
+drop table if exists data_deletions;
+
+create index if not exists data_deleted_at_idx on data (deleted_at) where deleted_at is not null;
+
+-- Максимальная ревизия удаленных окончательно меток удаления: клиенту с более старым курсором нужна полная синхронизация
+alter table users add column if not exists purged_revision bigint not null default 0;
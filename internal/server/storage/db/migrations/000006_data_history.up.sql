@@ -0,0 +1,12 @@
+-- Предыдущие состояния записей: перед каждым изменением текущее состояние записи копируется в историю
+create table if not exists data_revisions (
+    data_id bigint not null references data(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    revision bigint not null,
+    version bigint not null,
+    data_type varchar not null,
+    data_content BYTEA,
+    metadata JSONB,
+    updated_at timestamp with time zone not null,
+    primary key (data_id, revision)
+);
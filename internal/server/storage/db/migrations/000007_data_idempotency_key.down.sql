@@ -0,0 +1,3 @@
+drop index if exists data_user_id_idempotency_key_idx;
+
+alter table data drop column if exists idempotency_key;
@@ -0,0 +1,4 @@
+-- Ключ идемпотентности создания записи: повторный запрос с тем же ключом возвращает ранее созданную запись
+alter table data add column if not exists idempotency_key uuid;
+
+create unique index if not exists data_user_id_idempotency_key_idx on data (user_id, idempotency_key);
@@ -0,0 +1,18 @@
+-- ID возвращаются к числовым по младшим 64 битам UUID: преобразованные ранее ID восстанавливаются без изменений,
+-- а UUID, сгенерированные клиентами, совпадут только при совпадении младших 64 бит.
+alter table data_revisions drop constraint if exists data_revisions_data_id_fkey;
+
+alter table data alter column id type bigint
+    using ('x' || right(replace(id::text, '-', ''), 16))::bit(64)::bigint;
+
+alter table data_revisions alter column data_id type bigint
+    using ('x' || right(replace(data_id::text, '-', ''), 16))::bit(64)::bigint;
+
+alter table data_revisions add constraint data_revisions_data_id_fkey
+    foreign key (data_id) references data(id) on delete cascade;
+
+create sequence if not exists data_id_seq owned by data.id;
+
+select setval('data_id_seq', greatest((select max(id) from data), 1));
+
+alter table data alter column id set default nextval('data_id_seq');
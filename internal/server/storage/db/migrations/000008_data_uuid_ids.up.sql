@@ -0,0 +1,17 @@
+-- ID записей генерируются клиентами в виде UUID, чтобы созданные без связи с сервером записи не требовали перенумерации.
+-- Числовые ID преобразуются в UUID вида 00000000-0000-0000-XXXX-XXXXXXXXXXXX, где X - шестнадцатеричная запись числа;
+-- клиенты выполняют то же преобразование для локальных записей.
+alter table data_revisions drop constraint if exists data_revisions_data_id_fkey;
+
+alter table data alter column id drop default;
+
+alter table data alter column id type uuid
+    using ('00000000-0000-0000-' || substr(lpad(to_hex(id), 16, '0'), 1, 4) || '-' || substr(lpad(to_hex(id), 16, '0'), 5))::uuid;
+
+alter table data_revisions alter column data_id type uuid
+    using ('00000000-0000-0000-' || substr(lpad(to_hex(data_id), 16, '0'), 1, 4) || '-' || substr(lpad(to_hex(data_id), 16, '0'), 5))::uuid;
+
+alter table data_revisions add constraint data_revisions_data_id_fkey
+    foreign key (data_id) references data(id) on delete cascade;
+
+drop sequence if exists data_id_seq;
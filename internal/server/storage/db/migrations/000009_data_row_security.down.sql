@@ -0,0 +1,9 @@
+drop policy if exists data_revisions_owner on data_revisions;
+
+alter table data_revisions no force row level security;
+alter table data_revisions disable row level security;
+
+drop policy if exists data_owner on data;
+
+alter table data no force row level security;
+alter table data disable row level security;
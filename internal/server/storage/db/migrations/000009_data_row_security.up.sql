@@ -0,0 +1,22 @@
+-- Построчная защита данных пользователей: сервер задает владельца транзакции в app.user_id,
+-- и запросы видят и изменяют только его записи, даже если в запросе нет условия по user_id.
+-- Фоновые задачи, которым нужны записи всех пользователей, включают app.maintenance.
+-- Политики применяются и к владельцу таблиц (force), но не действуют для суперпользователя
+-- и ролей с bypassrls, поэтому сервер должен подключаться к базе данных под обычной ролью.
+alter table data enable row level security;
+alter table data force row level security;
+
+create policy data_owner on data
+    using (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+           or current_setting('app.maintenance', true) = 'on')
+    with check (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+                or current_setting('app.maintenance', true) = 'on');
+
+alter table data_revisions enable row level security;
+alter table data_revisions force row level security;
+
+create policy data_revisions_owner on data_revisions
+    using (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+           or current_setting('app.maintenance', true) = 'on')
+    with check (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+                or current_setting('app.maintenance', true) = 'on');
@@ -0,0 +1,18 @@
+drop trigger if exists data_revisions_blob_ref_count on data_revisions;
+drop trigger if exists data_blob_ref_count on data;
+drop function if exists update_blob_ref_count();
+
+-- Ключ объекта возвращается в metadata.file_url: прежний код отбрасывает из URL только адрес MinIO и bucket,
+-- поэтому ключ без них используется как есть.
+select set_config('app.maintenance', 'on', true);
+
+update data set metadata = coalesce(metadata, '{}'::jsonb) || jsonb_build_object('file_url', blob_key)
+where blob_key is not null;
+
+update data_revisions set metadata = coalesce(metadata, '{}'::jsonb) || jsonb_build_object('file_url', blob_key)
+where blob_key is not null;
+
+alter table data_revisions drop column if exists blob_key;
+alter table data drop column if exists blob_key;
+
+drop table if exists blobs;
@@ -0,0 +1,63 @@
+-- Файлы бинарных данных: ключ объекта в MinIO и число ссылок на него из записей и их истории.
+-- Одинаковые файлы пользователя хранятся под одним ключом, файл удаляется из MinIO, когда ссылок не остается.
+create table if not exists blobs (
+    object_key varchar primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    ref_count bigint not null default 0,
+    created_at timestamp with time zone default now() not null
+);
+
+create index if not exists blobs_unreferenced_idx on blobs (object_key) where ref_count = 0;
+
+alter table blobs enable row level security;
+alter table blobs force row level security;
+
+create policy blobs_owner on blobs
+    using (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+           or current_setting('app.maintenance', true) = 'on')
+    with check (user_id = nullif(current_setting('app.user_id', true), '')::bigint
+                or current_setting('app.maintenance', true) = 'on');
+
+alter table data add column if not exists blob_key varchar references blobs(object_key);
+alter table data_revisions add column if not exists blob_key varchar references blobs(object_key);
+
+-- Прежние записи хранили полный URL файла в metadata.file_url: ключ объекта - часть URL после имени bucket.
+select set_config('app.maintenance', 'on', true);
+
+insert into blobs (object_key, user_id)
+select distinct substring(metadata->>'file_url' from '/(uploads/.+)$'), user_id from data
+where metadata ? 'file_url' and substring(metadata->>'file_url' from '/(uploads/.+)$') is not null
+union
+select distinct substring(metadata->>'file_url' from '/(uploads/.+)$'), user_id from data_revisions
+where metadata ? 'file_url' and substring(metadata->>'file_url' from '/(uploads/.+)$') is not null
+on conflict (object_key) do nothing;
+
+update data set blob_key = substring(metadata->>'file_url' from '/(uploads/.+)$'), metadata = metadata - 'file_url'
+where metadata ? 'file_url';
+
+update data_revisions set blob_key = substring(metadata->>'file_url' from '/(uploads/.+)$'), metadata = metadata - 'file_url'
+where metadata ? 'file_url';
+
+update blobs b set ref_count = (select count(*) from data where blob_key = b.object_key)
+    + (select count(*) from data_revisions where blob_key = b.object_key);
+
+-- Число ссылок поддерживается триггерами при любом изменении ссылок из записей и их истории
+create or replace function update_blob_ref_count() returns trigger as $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') and old.blob_key is not null then
+        update blobs set ref_count = ref_count - 1 where object_key = old.blob_key;
+    end if;
+    if tg_op in ('INSERT', 'UPDATE') and new.blob_key is not null then
+        update blobs set ref_count = ref_count + 1 where object_key = new.blob_key;
+    end if;
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger data_blob_ref_count
+    after insert or delete or update of blob_key on data
+    for each row execute function update_blob_ref_count();
+
+create trigger data_revisions_blob_ref_count
+    after insert or delete or update of blob_key on data_revisions
+    for each row execute function update_blob_ref_count();
@@ -0,0 +1,3 @@
+select lo_unlink(content_oid) from blob_contents;
+
+drop table if exists blob_contents;
@@ -0,0 +1,8 @@
+-- Содержимое файлов бинарных данных для хранилища в PostgreSQL (BLOB_BACKEND=postgres).
+-- Файл хранится как large object, что позволяет читать и записывать его частями, не загружая целиком в память.
+create table if not exists blob_contents (
+    object_key varchar primary key,
+    content_oid oid not null,
+    size bigint not null,
+    created_at timestamp with time zone default now() not null
+);
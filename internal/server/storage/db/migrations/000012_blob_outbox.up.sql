@@ -0,0 +1,15 @@
+-- Отложенные операции с хранилищем файлов: удаление файла, если на него не ссылается ни одна запись.
+-- Операция записывается в одной транзакции с изменением записей (очистка корзины) или до него (загрузка файла),
+-- а фоновый обработчик выполняет ее в хранилище файлов и повторяет при ошибке.
+create table if not exists blob_outbox (
+    id bigserial primary key,
+    object_key varchar not null,
+    user_id bigint not null,
+    attempts integer not null default 0,
+    last_error text not null default '',
+    process_after timestamp with time zone default now() not null,
+    created_at timestamp with time zone default now() not null
+);
+
+create index if not exists blob_outbox_object_key_idx on blob_outbox (object_key);
+create index if not exists blob_outbox_process_after_idx on blob_outbox (process_after);
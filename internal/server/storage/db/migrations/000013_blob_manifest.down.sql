@@ -0,0 +1,4 @@
+alter table data_revisions drop column if exists file_name;
+alter table data drop column if exists file_name;
+
+alter table blobs drop column if exists size;
@@ -0,0 +1,7 @@
+-- Описание файлов бинарных данных: размер файла и имя, под которым его сохранил пользователь.
+-- Клиенты получают описание вместе со списком данных, а содержимое файла запрашивают отдельно.
+-- Размер файлов, загруженных раньше, неизвестен и остается равным 0.
+alter table blobs add column if not exists size bigint not null default 0;
+
+alter table data add column if not exists file_name varchar;
+alter table data_revisions add column if not exists file_name varchar;
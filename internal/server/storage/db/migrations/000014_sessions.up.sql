@@ -0,0 +1,16 @@
+-- Сессии пользователей: открываются при входе и закрываются при выходе.
+-- Токен обновления хранится только в виде хеша SHA-256 и заменяется новым при каждом обновлении.
+-- previous_hash - хеш предыдущего токена: его повторное предъявление означает, что токен мог быть украден,
+-- и сессия отзывается.
+create table if not exists sessions (
+    id varchar primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    token_hash varchar not null unique,
+    previous_hash varchar,
+    expires_at timestamp with time zone not null,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone default now() not null
+);
+
+create index if not exists sessions_previous_hash_idx on sessions (previous_hash);
+create index if not exists sessions_user_id_idx on sessions (user_id);
@@ -0,0 +1,19 @@
+-- Двухфакторная аутентификация по TOTP (RFC 6238).
+-- Секрет хранится открыто: по нему сервер вычисляет ожидаемые коды.
+-- Пока enabled = false, настройка не подтверждена кодом из приложения и при входе не учитывается.
+-- last_counter - номер последнего принятого 30-секундного интервала: коды из него и более ранних
+-- интервалов повторно не принимаются.
+create table if not exists user_totp (
+    user_id bigint primary key references users(id) on delete cascade,
+    secret varchar not null,
+    enabled boolean not null default false,
+    last_counter bigint not null default 0,
+    created_at timestamp with time zone default now() not null
+);
+
+-- Одноразовые коды восстановления на случай потери приложения; хранятся только хеши SHA-256.
+create table if not exists recovery_codes (
+    user_id bigint not null references users(id) on delete cascade,
+    code_hash varchar not null,
+    primary key (user_id, code_hash)
+);
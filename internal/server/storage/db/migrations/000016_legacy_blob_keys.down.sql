@@ -0,0 +1,15 @@
+-- Записи, файлы которых еще не скопированы, снова ссылаются на общие ключи uploads/<имя файла>.
+select set_config('app.maintenance', 'on', true);
+
+insert into blobs (object_key, user_id, size)
+select distinct on (l.source_key) l.source_key, l.user_id, b.size
+from legacy_blobs l join blobs b on b.object_key = l.object_key
+order by l.source_key, l.user_id
+on conflict (object_key) do nothing;
+
+update data d set blob_key = l.source_key from legacy_blobs l where d.blob_key = l.object_key;
+update data_revisions r set blob_key = l.source_key from legacy_blobs l where r.blob_key = l.object_key;
+
+delete from blobs where object_key in (select object_key from legacy_blobs);
+
+drop table if exists legacy_blobs;
@@ -0,0 +1,36 @@
+-- Файлы, загруженные до хранения по хешу содержимого, лежат под общими для всех пользователей ключами uploads/<имя файла>.
+-- Миграция 000010 отдала каждый такой ключ первому пользователю, у которого он встретился, поэтому у остальных
+-- пользователей строка файла скрыта политикой доступа и число ссылок на нее не меняется.
+-- Каждая пара (пользователь, прежний ключ) получает собственный файл с ключом users/<ID пользователя>/legacy/<прежний ключ>.
+-- Сервер при запуске копирует содержимое под ключ users/<ID пользователя>/<SHA-256> и удаляет строку из legacy_blobs,
+-- а прежний файл удаляется из хранилища, когда его скопируют все пользователи.
+select set_config('app.maintenance', 'on', true);
+
+create table if not exists legacy_blobs (
+    object_key varchar primary key references blobs(object_key) on delete cascade,
+    source_key varchar not null,
+    user_id bigint not null references users(id) on delete cascade
+);
+
+create index if not exists legacy_blobs_source_key_idx on legacy_blobs (source_key);
+
+insert into blobs (object_key, user_id, size)
+select 'users/' || r.user_id || '/legacy/' || r.blob_key, r.user_id, coalesce(b.size, 0)
+from (select user_id, blob_key from data where blob_key like 'uploads/%'
+      union
+      select user_id, blob_key from data_revisions where blob_key like 'uploads/%') r
+left join blobs b on b.object_key = r.blob_key
+on conflict (object_key) do nothing;
+
+insert into legacy_blobs (object_key, source_key, user_id)
+select object_key, substring(object_key from '^users/[0-9]+/legacy/(.+)$'), user_id from blobs
+where object_key ~ '^users/[0-9]+/legacy/uploads/'
+on conflict (object_key) do nothing;
+
+-- Число ссылок на новые файлы увеличивают триггеры
+update data set blob_key = 'users/' || user_id || '/legacy/' || blob_key where blob_key like 'uploads/%';
+update data_revisions set blob_key = 'users/' || user_id || '/legacy/' || blob_key where blob_key like 'uploads/%';
+
+-- Прежние файлы нужны до копирования, поэтому их отложенные удаления отменяются
+delete from blob_outbox where object_key like 'uploads/%';
+delete from blobs where object_key like 'uploads/%';
@@ -0,0 +1,16 @@
+do $$
+declare
+    content oid;
+begin
+    for content in select content_oid from blob_contents where content_oid in (select oid from pg_largeobject_metadata) loop
+        execute format('alter large object %s owner to %I', content, current_user);
+    end loop;
+end
+$$;
+
+alter default privileges in schema public revoke select, insert, update, delete on tables from keeper_app;
+alter default privileges in schema public revoke usage, select on sequences from keeper_app;
+
+revoke all on all sequences in schema public from keeper_app;
+revoke all on all tables in schema public from keeper_app;
+revoke usage on schema public from keeper_app;
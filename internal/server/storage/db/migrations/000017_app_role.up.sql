@@ -0,0 +1,33 @@
+-- Роль сервера keeper_app: политики построчной защиты не действуют для суперпользователя и ролей с bypassrls,
+-- поэтому сервер подключается к базе данных под этой ролью, а миграции выполняет владелец таблиц.
+-- Роль может быть создана заранее с правом входа и паролем (см. deploy/postgres/init.sql), иначе она создается
+-- без права входа, и право входа выдает администратор базы данных.
+do $$
+begin
+    if not exists (select 1 from pg_roles where rolname = 'keeper_app') then
+        create role keeper_app nologin nosuperuser nobypassrls;
+    end if;
+end
+$$;
+
+grant usage on schema public to keeper_app;
+grant select, insert, update, delete on all tables in schema public to keeper_app;
+grant usage, select on all sequences in schema public to keeper_app;
+
+-- Таблицы и последовательности следующих миграций доступны роли сервера без отдельной выдачи прав
+alter default privileges in schema public grant select, insert, update, delete on tables to keeper_app;
+alter default privileges in schema public grant usage, select on sequences to keeper_app;
+
+-- Версию схемы меняют только миграции
+revoke all on migration from keeper_app;
+
+-- Сервер удаляет large object файла при его удалении, а удалить large object может только его владелец
+do $$
+declare
+    content oid;
+begin
+    for content in select content_oid from blob_contents where content_oid in (select oid from pg_largeobject_metadata) loop
+        execute format('alter large object %s owner to keeper_app', content);
+    end loop;
+end
+$$;
@@ -0,0 +1,9 @@
+drop table if exists data_revisions;
+
+drop table if exists data;
+
+drop table if exists blobs;
+
+drop table if exists revision_seq;
+
+drop table if exists users;
@@ -0,0 +1,114 @@
+-- Схема SQLite повторяет итоговую схему PostgreSQL с поправками на возможности SQLite:
+-- время хранится в UTC в текстовом виде, UUID и JSON - как текст и байты,
+-- а последовательность ревизий заменена счетчиком в таблице revision_seq.
+-- Построчной защиты в SQLite нет, поэтому все запросы адаптера явно ограничены пользователем.
+create table if not exists users
+(
+    id integer primary key autoincrement,
+    username varchar unique not null,
+    password varchar not null,
+    purged_revision bigint not null default 0,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null,
+    updated_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null
+);
+
+-- Последняя выданная ревизия: увеличивается при каждом создании, изменении и удалении записи
+create table if not exists revision_seq
+(
+    value bigint not null
+);
+
+insert into revision_seq (value) select 0 where not exists (select 1 from revision_seq);
+
+create table if not exists blobs
+(
+    object_key varchar primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    ref_count bigint not null default 0,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null
+);
+
+create index if not exists blobs_unreferenced_idx on blobs (object_key) where ref_count = 0;
+
+create table if not exists data
+(
+    id varchar primary key,            -- UUID, сгенерированный клиентом
+    user_id bigint not null references users(id) on delete cascade,
+    data_type varchar not null,
+    data_content blob not null,
+    metadata blob,                     -- JSON
+    idempotency_key varchar,
+    blob_key varchar references blobs(object_key),
+    revision bigint not null,
+    version bigint not null default 1,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null,
+    updated_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null,
+    deleted_at timestamp
+);
+
+create index if not exists data_user_id_id_idx on data (user_id, id);
+
+create index if not exists data_user_id_revision_idx on data (user_id, revision);
+
+create index if not exists data_deleted_at_idx on data (deleted_at) where deleted_at is not null;
+
+create unique index if not exists data_user_id_idempotency_key_idx on data (user_id, idempotency_key);
+
+create table if not exists data_revisions
+(
+    data_id varchar not null references data(id) on delete cascade,
+    user_id bigint not null references users(id) on delete cascade,
+    revision bigint not null,
+    version bigint not null,
+    data_type varchar not null,
+    data_content blob,
+    metadata blob,
+    updated_at timestamp not null,
+    blob_key varchar references blobs(object_key),
+    primary key (data_id, revision)
+);
+
+-- Число ссылок на файлы поддерживается триггерами при любом изменении ссылок из записей и их истории
+create trigger if not exists data_blob_ref_count_insert
+    after insert on data
+    for each row when new.blob_key is not null
+begin
+    update blobs set ref_count = ref_count + 1 where object_key = new.blob_key;
+end;
+
+create trigger if not exists data_blob_ref_count_update
+    after update of blob_key on data
+    for each row
+begin
+    update blobs set ref_count = ref_count - 1 where object_key = old.blob_key;
+    update blobs set ref_count = ref_count + 1 where object_key = new.blob_key;
+end;
+
+create trigger if not exists data_blob_ref_count_delete
+    after delete on data
+    for each row when old.blob_key is not null
+begin
+    update blobs set ref_count = ref_count - 1 where object_key = old.blob_key;
+end;
+
+create trigger if not exists data_revisions_blob_ref_count_insert
+    after insert on data_revisions
+    for each row when new.blob_key is not null
+begin
+    update blobs set ref_count = ref_count + 1 where object_key = new.blob_key;
+end;
+
+create trigger if not exists data_revisions_blob_ref_count_update
+    after update of blob_key on data_revisions
+    for each row
+begin
+    update blobs set ref_count = ref_count - 1 where object_key = old.blob_key;
+    update blobs set ref_count = ref_count + 1 where object_key = new.blob_key;
+end;
+
+create trigger if not exists data_revisions_blob_ref_count_delete
+    after delete on data_revisions
+    for each row when old.blob_key is not null
+begin
+    update blobs set ref_count = ref_count - 1 where object_key = old.blob_key;
+end;
@@ -0,0 +1,14 @@
+-- Отложенные операции с хранилищем файлов: удаление файла, если на него не ссылается ни одна запись.
+create table if not exists blob_outbox
+(
+    id integer primary key autoincrement,
+    object_key varchar not null,
+    user_id bigint not null,
+    attempts integer not null default 0,
+    last_error text not null default '',
+    process_after timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null
+);
+
+create index if not exists blob_outbox_object_key_idx on blob_outbox (object_key);
+create index if not exists blob_outbox_process_after_idx on blob_outbox (process_after);
@@ -0,0 +1,4 @@
+alter table data_revisions drop column file_name;
+alter table data drop column file_name;
+
+alter table blobs drop column size;
@@ -0,0 +1,5 @@
+-- Описание файлов бинарных данных: размер файла и имя, под которым его сохранил пользователь.
+alter table blobs add column size bigint not null default 0;
+
+alter table data add column file_name varchar;
+alter table data_revisions add column file_name varchar;
@@ -0,0 +1,14 @@
+-- Сессии пользователей с хешами текущего и предыдущего токенов обновления.
+create table if not exists sessions
+(
+    id varchar primary key,
+    user_id bigint not null references users(id) on delete cascade,
+    token_hash varchar not null unique,
+    previous_hash varchar,
+    expires_at timestamp not null,
+    revoked_at timestamp,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null
+);
+
+create index if not exists sessions_previous_hash_idx on sessions (previous_hash);
+create index if not exists sessions_user_id_idx on sessions (user_id);
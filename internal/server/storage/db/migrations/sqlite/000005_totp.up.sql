@@ -0,0 +1,16 @@
+-- Настройки двухфакторной аутентификации по TOTP и хеши одноразовых кодов восстановления.
+create table if not exists user_totp
+(
+    user_id bigint primary key references users(id) on delete cascade,
+    secret varchar not null,
+    enabled boolean not null default false,
+    last_counter bigint not null default 0,
+    created_at timestamp default (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')) not null
+);
+
+create table if not exists recovery_codes
+(
+    user_id bigint not null references users(id) on delete cascade,
+    code_hash varchar not null,
+    primary key (user_id, code_hash)
+);
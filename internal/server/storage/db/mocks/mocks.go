@@ -7,6 +7,7 @@ package mock_db
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -36,6 +37,50 @@ func (m *MockAdapter) EXPECT() *MockAdapterMockRecorder {
 	return m.recorder
 }
 
+// AddPendingBlob mocks base method.
+func (m *MockAdapter) AddPendingBlob(ctx context.Context, userId int64, key string, processAfter time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPendingBlob", ctx, userId, key, processAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPendingBlob indicates an expected call of AddPendingBlob.
+func (mr *MockAdapterMockRecorder) AddPendingBlob(ctx, userId, key, processAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPendingBlob", reflect.TypeOf((*MockAdapter)(nil).AddPendingBlob), ctx, userId, key, processAfter)
+}
+
+// ApplyMutations mocks base method.
+func (m *MockAdapter) ApplyMutations(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyMutations", ctx, userId, mutations)
+	ret0, _ := ret[0].([]models.MutationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyMutations indicates an expected call of ApplyMutations.
+func (mr *MockAdapterMockRecorder) ApplyMutations(ctx, userId, mutations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyMutations", reflect.TypeOf((*MockAdapter)(nil).ApplyMutations), ctx, userId, mutations)
+}
+
+// ClaimBlobOperations mocks base method.
+func (m *MockAdapter) ClaimBlobOperations(ctx context.Context, now, retryAt time.Time, limit int) ([]models.BlobOperation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimBlobOperations", ctx, now, retryAt, limit)
+	ret0, _ := ret[0].([]models.BlobOperation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimBlobOperations indicates an expected call of ClaimBlobOperations.
+func (mr *MockAdapterMockRecorder) ClaimBlobOperations(ctx, now, retryAt, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimBlobOperations", reflect.TypeOf((*MockAdapter)(nil).ClaimBlobOperations), ctx, now, retryAt, limit)
+}
+
 // Close mocks base method.
 func (m *MockAdapter) Close() {
 	m.ctrl.T.Helper()
@@ -48,11 +93,25 @@ func (mr *MockAdapterMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockAdapter)(nil).Close))
 }
 
+// CompleteBlobOperation mocks base method.
+func (m *MockAdapter) CompleteBlobOperation(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteBlobOperation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteBlobOperation indicates an expected call of CompleteBlobOperation.
+func (mr *MockAdapterMockRecorder) CompleteBlobOperation(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteBlobOperation", reflect.TypeOf((*MockAdapter)(nil).CompleteBlobOperation), ctx, id)
+}
+
 // CreateData mocks base method.
-func (m *MockAdapter) CreateData(ctx context.Context, data *models.Data) (int64, error) {
+func (m *MockAdapter) CreateData(ctx context.Context, data *models.Data) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateData", ctx, data)
-	ret0, _ := ret[0].(int64)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -63,6 +122,20 @@ func (mr *MockAdapterMockRecorder) CreateData(ctx, data interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateData", reflect.TypeOf((*MockAdapter)(nil).CreateData), ctx, data)
 }
 
+// CreateSession mocks base method.
+func (m *MockAdapter) CreateSession(ctx context.Context, session *models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockAdapterMockRecorder) CreateSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockAdapter)(nil).CreateSession), ctx, session)
+}
+
 // CreateUser mocks base method.
 func (m *MockAdapter) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	m.ctrl.T.Helper()
@@ -79,18 +152,120 @@ func (mr *MockAdapterMockRecorder) CreateUser(ctx, user interface{}) *gomock.Cal
 }
 
 // DeleteData mocks base method.
-func (m *MockAdapter) DeleteData(ctx context.Context, dataId, userId int64) (bool, error) {
+func (m *MockAdapter) DeleteData(ctx context.Context, dataId string, userId, expectedVersion int64) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteData", ctx, dataId, userId)
+	ret := m.ctrl.Call(m, "DeleteData", ctx, dataId, userId, expectedVersion)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteData indicates an expected call of DeleteData.
-func (mr *MockAdapterMockRecorder) DeleteData(ctx, dataId, userId interface{}) *gomock.Call {
+func (mr *MockAdapterMockRecorder) DeleteData(ctx, dataId, userId, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockAdapter)(nil).DeleteData), ctx, dataId, userId, expectedVersion)
+}
+
+// DeleteUnreferencedBlob mocks base method.
+func (m *MockAdapter) DeleteUnreferencedBlob(ctx context.Context, key string, operationID int64, deleteFile func() error) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnreferencedBlob", ctx, key, operationID, deleteFile)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUnreferencedBlob indicates an expected call of DeleteUnreferencedBlob.
+func (mr *MockAdapterMockRecorder) DeleteUnreferencedBlob(ctx, key, operationID, deleteFile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnreferencedBlob", reflect.TypeOf((*MockAdapter)(nil).DeleteUnreferencedBlob), ctx, key, operationID, deleteFile)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockAdapter) DisableTOTP(ctx context.Context, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockAdapterMockRecorder) DisableTOTP(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockAdapter)(nil).DisableTOTP), ctx, userId)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockAdapter) EnableTOTP(ctx context.Context, userId, counter int64, recoveryHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, userId, counter, recoveryHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockAdapterMockRecorder) EnableTOTP(ctx, userId, counter, recoveryHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockAdapter)(nil).EnableTOTP), ctx, userId, counter, recoveryHashes)
+}
+
+// FailBlobOperation mocks base method.
+func (m *MockAdapter) FailBlobOperation(ctx context.Context, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailBlobOperation", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailBlobOperation indicates an expected call of FailBlobOperation.
+func (mr *MockAdapterMockRecorder) FailBlobOperation(ctx, id, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailBlobOperation", reflect.TypeOf((*MockAdapter)(nil).FailBlobOperation), ctx, id, reason)
+}
+
+// GetBlobKeys mocks base method.
+func (m *MockAdapter) GetBlobKeys(ctx context.Context, userId int64, keys []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlobKeys", ctx, userId, keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlobKeys indicates an expected call of GetBlobKeys.
+func (mr *MockAdapterMockRecorder) GetBlobKeys(ctx, userId, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlobKeys", reflect.TypeOf((*MockAdapter)(nil).GetBlobKeys), ctx, userId, keys)
+}
+
+// GetChanges mocks base method.
+func (m *MockAdapter) GetChanges(ctx context.Context, userId, since int64, limit int) ([]models.DataChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", ctx, userId, since, limit)
+	ret0, _ := ret[0].([]models.DataChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockAdapterMockRecorder) GetChanges(ctx, userId, since, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockAdapter)(nil).DeleteData), ctx, dataId, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockAdapter)(nil).GetChanges), ctx, userId, since, limit)
+}
+
+// GetCreatedData mocks base method.
+func (m *MockAdapter) GetCreatedData(ctx context.Context, userId int64, dataId, idempotencyKey string) (*models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCreatedData", ctx, userId, dataId, idempotencyKey)
+	ret0, _ := ret[0].(*models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCreatedData indicates an expected call of GetCreatedData.
+func (mr *MockAdapterMockRecorder) GetCreatedData(ctx, userId, dataId, idempotencyKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCreatedData", reflect.TypeOf((*MockAdapter)(nil).GetCreatedData), ctx, userId, dataId, idempotencyKey)
 }
 
 // GetData mocks base method.
@@ -109,18 +284,78 @@ func (mr *MockAdapterMockRecorder) GetData(ctx, userId interface{}) *gomock.Call
 }
 
 // GetDataByID mocks base method.
-func (m *MockAdapter) GetDataByID(ctx context.Context, dataID int64) (*models.Data, error) {
+func (m *MockAdapter) GetDataByID(ctx context.Context, dataID string, userId int64) (*models.Data, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDataByID", ctx, dataID)
+	ret := m.ctrl.Call(m, "GetDataByID", ctx, dataID, userId)
 	ret0, _ := ret[0].(*models.Data)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDataByID indicates an expected call of GetDataByID.
-func (mr *MockAdapterMockRecorder) GetDataByID(ctx, dataID interface{}) *gomock.Call {
+func (mr *MockAdapterMockRecorder) GetDataByID(ctx, dataID, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataByID", reflect.TypeOf((*MockAdapter)(nil).GetDataByID), ctx, dataID, userId)
+}
+
+// GetDataHistory mocks base method.
+func (m *MockAdapter) GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataHistory", ctx, dataId, userId)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataHistory indicates an expected call of GetDataHistory.
+func (mr *MockAdapterMockRecorder) GetDataHistory(ctx, dataId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataHistory", reflect.TypeOf((*MockAdapter)(nil).GetDataHistory), ctx, dataId, userId)
+}
+
+// GetLegacyBlobs mocks base method.
+func (m *MockAdapter) GetLegacyBlobs(ctx context.Context) ([]models.LegacyBlob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLegacyBlobs", ctx)
+	ret0, _ := ret[0].([]models.LegacyBlob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLegacyBlobs indicates an expected call of GetLegacyBlobs.
+func (mr *MockAdapterMockRecorder) GetLegacyBlobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLegacyBlobs", reflect.TypeOf((*MockAdapter)(nil).GetLegacyBlobs), ctx)
+}
+
+// GetTOTP mocks base method.
+func (m *MockAdapter) GetTOTP(ctx context.Context, userId int64) (*models.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", ctx, userId)
+	ret0, _ := ret[0].(*models.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockAdapterMockRecorder) GetTOTP(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockAdapter)(nil).GetTOTP), ctx, userId)
+}
+
+// GetUnreferencedBlobKeys mocks base method.
+func (m *MockAdapter) GetUnreferencedBlobKeys(ctx context.Context, keys []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreferencedBlobKeys", ctx, keys)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreferencedBlobKeys indicates an expected call of GetUnreferencedBlobKeys.
+func (mr *MockAdapterMockRecorder) GetUnreferencedBlobKeys(ctx, keys interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataByID", reflect.TypeOf((*MockAdapter)(nil).GetDataByID), ctx, dataID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreferencedBlobKeys", reflect.TypeOf((*MockAdapter)(nil).GetUnreferencedBlobKeys), ctx, keys)
 }
 
 // GetUserHashPassword mocks base method.
@@ -168,6 +403,183 @@ func (mr *MockAdapterMockRecorder) GetUserIDByName(ctx, username interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByName", reflect.TypeOf((*MockAdapter)(nil).GetUserIDByName), ctx, username)
 }
 
+// IsSessionActive mocks base method.
+func (m *MockAdapter) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionActive", ctx, sessionID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSessionActive indicates an expected call of IsSessionActive.
+func (mr *MockAdapterMockRecorder) IsSessionActive(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionActive", reflect.TypeOf((*MockAdapter)(nil).IsSessionActive), ctx, sessionID)
+}
+
+// ListData mocks base method.
+func (m *MockAdapter) ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListData", ctx, userId, filter)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListData indicates an expected call of ListData.
+func (mr *MockAdapterMockRecorder) ListData(ctx, userId, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockAdapter)(nil).ListData), ctx, userId, filter)
+}
+
+// ListTrash mocks base method.
+func (m *MockAdapter) ListTrash(ctx context.Context, userId int64) ([]models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", ctx, userId)
+	ret0, _ := ret[0].([]models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockAdapterMockRecorder) ListTrash(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockAdapter)(nil).ListTrash), ctx, userId)
+}
+
+// PurgeData mocks base method.
+func (m *MockAdapter) PurgeData(ctx context.Context, userId int64, dataId string) (models.PurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeData", ctx, userId, dataId)
+	ret0, _ := ret[0].(models.PurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeData indicates an expected call of PurgeData.
+func (mr *MockAdapterMockRecorder) PurgeData(ctx, userId, dataId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeData", reflect.TypeOf((*MockAdapter)(nil).PurgeData), ctx, userId, dataId)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockAdapter) PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, before)
+	ret0, _ := ret[0].(models.PurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockAdapterMockRecorder) PurgeTrash(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockAdapter)(nil).PurgeTrash), ctx, before)
+}
+
+// ReplaceLegacyBlob mocks base method.
+func (m *MockAdapter) ReplaceLegacyBlob(ctx context.Context, blob models.LegacyBlob, key string, size int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceLegacyBlob", ctx, blob, key, size)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceLegacyBlob indicates an expected call of ReplaceLegacyBlob.
+func (mr *MockAdapterMockRecorder) ReplaceLegacyBlob(ctx, blob, key, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceLegacyBlob", reflect.TypeOf((*MockAdapter)(nil).ReplaceLegacyBlob), ctx, blob, key, size)
+}
+
+// RestoreData mocks base method.
+func (m *MockAdapter) RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreData", ctx, dataId, userId)
+	ret0, _ := ret[0].(*models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreData indicates an expected call of RestoreData.
+func (mr *MockAdapterMockRecorder) RestoreData(ctx, dataId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreData", reflect.TypeOf((*MockAdapter)(nil).RestoreData), ctx, dataId, userId)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockAdapter) RestoreRevision(ctx context.Context, dataId string, userId, revision, expectedVersion int64) (*models.Data, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", ctx, dataId, userId, revision, expectedVersion)
+	ret0, _ := ret[0].(*models.Data)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockAdapterMockRecorder) RestoreRevision(ctx, dataId, userId, revision, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockAdapter)(nil).RestoreRevision), ctx, dataId, userId, revision, expectedVersion)
+}
+
+// RevokeSession mocks base method.
+func (m *MockAdapter) RevokeSession(ctx context.Context, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockAdapterMockRecorder) RevokeSession(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockAdapter)(nil).RevokeSession), ctx, sessionID)
+}
+
+// RotateSession mocks base method.
+func (m *MockAdapter) RotateSession(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSession", ctx, tokenHash, newHash, expiresAt)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSession indicates an expected call of RotateSession.
+func (mr *MockAdapterMockRecorder) RotateSession(ctx, tokenHash, newHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MockAdapter)(nil).RotateSession), ctx, tokenHash, newHash, expiresAt)
+}
+
+// SaveTOTPSecret mocks base method.
+func (m *MockAdapter) SaveTOTPSecret(ctx context.Context, userId int64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTPSecret", ctx, userId, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTOTPSecret indicates an expected call of SaveTOTPSecret.
+func (mr *MockAdapterMockRecorder) SaveTOTPSecret(ctx, userId, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTPSecret", reflect.TypeOf((*MockAdapter)(nil).SaveTOTPSecret), ctx, userId, secret)
+}
+
+// Subscribe mocks base method.
+func (m *MockAdapter) Subscribe(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, userId)
+	ret0, _ := ret[0].(<-chan models.ChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockAdapterMockRecorder) Subscribe(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockAdapter)(nil).Subscribe), ctx, userId)
+}
+
 // UpdateData mocks base method.
 func (m *MockAdapter) UpdateData(ctx context.Context, data *models.Data) error {
 	m.ctrl.T.Helper()
@@ -181,3 +593,33 @@ func (mr *MockAdapterMockRecorder) UpdateData(ctx, data interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockAdapter)(nil).UpdateData), ctx, data)
 }
+
+// UseRecoveryCode mocks base method.
+func (m *MockAdapter) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userId, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockAdapterMockRecorder) UseRecoveryCode(ctx, userId, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockAdapter)(nil).UseRecoveryCode), ctx, userId, codeHash)
+}
+
+// UseTOTPCounter mocks base method.
+func (m *MockAdapter) UseTOTPCounter(ctx context.Context, userId, counter int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPCounter", ctx, userId, counter)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPCounter indicates an expected call of UseTOTPCounter.
+func (mr *MockAdapterMockRecorder) UseTOTPCounter(ctx, userId, counter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPCounter", reflect.TypeOf((*MockAdapter)(nil).UseTOTPCounter), ctx, userId, counter)
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+const (
+	changesChannel       = "data_changes"   // канал PostgreSQL для уведомлений об изменениях данных
+	listenerMinReconnect = 10 * time.Second // минимальная пауза перед переподключением слушателя
+	listenerMaxReconnect = time.Minute      // максимальная пауза перед переподключением слушателя
+	listenerPingInterval = 90 * time.Second // период проверки соединения слушателя при отсутствии уведомлений
+	subscriberBuffer     = 16               // размер буфера канала событий подписчика
+)
+
+// notifyChange публикует событие об изменении данных в канал changesChannel.
+// Уведомление выполняется в транзакции изменения и доставляется слушателям только после ее фиксации.
+func notifyChange(ctx context.Context, tx *sql.Tx, event models.ChangeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change event: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `select pg_notify($1, $2)`, changesChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to notify change: %w", err)
+	}
+
+	return nil
+}
+
+// changeHub получает уведомления об изменениях данных через LISTEN и раздает их подписчикам по ID пользователя.
+// Уведомления PostgreSQL доходят до всех экземпляров сервера, поэтому клиент получает события
+// независимо от того, через какой экземпляр было выполнено изменение.
+type changeHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan models.ChangeEvent]struct{}
+}
+
+// newChangeHub создает пустой хаб подписок.
+func newChangeHub() *changeHub {
+	return &changeHub{subscribers: make(map[int64]map[chan models.ChangeEvent]struct{})}
+}
+
+// listen запускает слушателя канала changesChannel и раздачу уведомлений подписчикам.
+func (h *changeHub) listen(dsn string) (*pq.Listener, error) {
+	listener := pq.NewListener(dsn, listenerMinReconnect, listenerMaxReconnect,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("change listener event %d: %v", event, err)
+			}
+		})
+
+	if err := listener.Listen(changesChannel); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to listen for data changes: %w", err)
+	}
+
+	go h.run(listener)
+
+	return listener, nil
+}
+
+// run читает уведомления слушателя до его закрытия.
+func (h *changeHub) run(listener *pq.Listener) {
+	for {
+		select {
+		case notification, ok := <-listener.Notify:
+			if !ok {
+				h.closeAll()
+				return
+			}
+			h.dispatch(notification)
+		case <-time.After(listenerPingInterval):
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
+
+// dispatch передает уведомление подписчикам пользователя, которому принадлежат измененные данные.
+// Пустое уведомление приходит после переподключения слушателя, когда часть уведомлений могла быть потеряна,
+// поэтому все подписчики получают событие без ревизии и запрашивают изменения самостоятельно.
+func (h *changeHub) dispatch(notification *pq.Notification) {
+	if notification == nil {
+		h.broadcast(models.ChangeEvent{})
+		return
+	}
+
+	var event models.ChangeEvent
+	if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+		log.Printf("invalid change notification %q: %v", notification.Extra, err)
+		return
+	}
+
+	h.publish(event)
+}
+
+// subscribe регистрирует подписчика на события пользователя.
+// Подписка снимается и канал закрывается после отмены ctx.
+func (h *changeHub) subscribe(ctx context.Context, userId int64) <-chan models.ChangeEvent {
+	ch := make(chan models.ChangeEvent, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userId] == nil {
+		h.subscribers[userId] = make(map[chan models.ChangeEvent]struct{})
+	}
+	h.subscribers[userId][ch] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(userId, ch)
+	}()
+
+	return ch
+}
+
+// unsubscribe удаляет подписчика и закрывает его канал, если он еще не закрыт.
+func (h *changeHub) unsubscribe(userId int64, ch chan models.ChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[userId][ch]; !ok {
+		return
+	}
+
+	delete(h.subscribers[userId], ch)
+	if len(h.subscribers[userId]) == 0 {
+		delete(h.subscribers, userId)
+	}
+	close(ch)
+}
+
+// publish отправляет событие подписчикам пользователя.
+// Если буфер подписчика заполнен, событие пропускается: подписчик еще не обработал
+// предыдущие события и получит это изменение, запросив изменения после своего курсора.
+func (h *changeHub) publish(event models.ChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// broadcast отправляет событие всем подписчикам.
+func (h *changeHub) broadcast(event models.ChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// closeAll закрывает каналы всех подписчиков.
+func (h *changeHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userId, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(h.subscribers, userId)
+	}
+}
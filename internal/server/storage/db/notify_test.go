@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+func TestChangeHub(t *testing.T) {
+	hub := newChangeHub()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := hub.subscribe(ctx, 1)
+	second := hub.subscribe(ctx, 2)
+
+	t.Run("DispatchToOwner", func(t *testing.T) {
+		hub.dispatch(&pq.Notification{Extra: `{"user_id":1,"data_id":"00000000-0000-0000-0000-000000000005","revision":10,"deleted":true}`})
+
+		assert.Equal(t, models.ChangeEvent{UserID: 1, DataID: "00000000-0000-0000-0000-000000000005", Revision: 10, Deleted: true}, <-first)
+		assert.Empty(t, second)
+	})
+
+	t.Run("IgnoreInvalidPayload", func(t *testing.T) {
+		hub.dispatch(&pq.Notification{Extra: "invalid"})
+
+		assert.Empty(t, first)
+		assert.Empty(t, second)
+	})
+
+	t.Run("BroadcastAfterReconnect", func(t *testing.T) {
+		hub.dispatch(nil)
+
+		assert.Equal(t, models.ChangeEvent{}, <-first)
+		assert.Equal(t, models.ChangeEvent{}, <-second)
+	})
+
+	t.Run("UnsubscribeOnCancel", func(t *testing.T) {
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			_, ok := <-first
+			return !ok
+		}, time.Second, 10*time.Millisecond)
+
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		assert.NotContains(t, hub.subscribers, int64(1))
+	})
+}
+
+func TestAdapterSubscribeWithoutListener(t *testing.T) {
+	pg := dbAdapter{}
+
+	_, err := pg.Subscribe(context.Background(), 1)
+	assert.Error(t, err)
+}
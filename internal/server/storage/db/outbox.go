@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// AddPendingBlob записывает операцию под блокировкой файла: если файл в это время удаляется,
+// операция записывается после удаления, и вызывающий код может проверить, что файл еще есть в хранилище.
+func (db *dbAdapter) AddPendingBlob(ctx context.Context, userId int64, key string, processAfter time.Time) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockBlob(ctx, tx.Tx, key); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `insert into blob_outbox (object_key, user_id, process_after) values ($1, $2, $3)`,
+		key, userId, processAfter)
+	if err != nil {
+		return fmt.Errorf("failed to add pending blob: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ClaimBlobOperations пропускает операции, которые в это время выбирает другой экземпляр сервера, поэтому
+// экземпляры не ждут друг друга и не выполняют одну операцию одновременно.
+func (db *dbAdapter) ClaimBlobOperations(ctx context.Context, now time.Time, retryAt time.Time, limit int) ([]models.BlobOperation, error) {
+	// Файлы всех пользователей видны только в транзакции фоновых задач
+	tx, err := db.beginMaintenanceTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `delete from blob_outbox o using blobs b where b.object_key = o.object_key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete referenced blob operations: %w", err)
+	}
+
+	query := `update blob_outbox set attempts = attempts + 1, process_after = $2
+			 where id in (select id from blob_outbox where process_after <= $1 order by id limit $3 for update skip locked)
+			 returning id, user_id, object_key, attempts, last_error`
+
+	operations := make([]models.BlobOperation, 0)
+	err = tx.SelectContext(ctx, &operations, query, now, retryAt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim blob operations: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
+// CompleteBlobOperation удаляет операцию по ID, поэтому операция с тем же файлом, записанная во время выполнения,
+// остается в очереди.
+func (db *dbAdapter) CompleteBlobOperation(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx, `delete from blob_outbox where id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete blob operation: %w", err)
+	}
+
+	return nil
+}
+
+// FailBlobOperation не меняет время повторного выполнения: его уже отложила ClaimBlobOperations.
+func (db *dbAdapter) FailBlobOperation(ctx context.Context, id int64, reason string) error {
+	_, err := db.conn.ExecContext(ctx, `update blob_outbox set last_error = $2 where id = $1`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to save blob operation error: %w", err)
+	}
+
+	return nil
+}
+
+// unreferencedBlobQuery проверяет, что на файл с ключом $1 не ссылается ни одна запись и что после операции
+// с ID $2 не записано новых отложенных операций с ним: новая операция означает повторную загрузку файла.
+const unreferencedBlobQuery = `select not exists (select 1 from blobs where object_key = $1)
+			 and not exists (select 1 from blob_outbox where object_key = $1 and id > $2)`
+
+// DeleteUnreferencedBlob проверяет ссылки на файл и удаляет его в служебной транзакции, которой доступны файлы
+// всех пользователей. Блокировка файла держится до конца транзакции, то есть и во время удаления из хранилища файлов.
+func (db *dbAdapter) DeleteUnreferencedBlob(ctx context.Context, key string, operationID int64, deleteFile func() error) (bool, error) {
+	tx, err := db.beginMaintenanceTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockBlob(ctx, tx.Tx, key); err != nil {
+		return false, err
+	}
+
+	var unreferenced bool
+	err = tx.GetContext(ctx, &unreferenced, unreferencedBlobQuery, key, operationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blob references: %w", err)
+	}
+	if !unreferenced {
+		return false, nil
+	}
+
+	if err := deleteFile(); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// lockBlob блокирует файл с ключом key до конца транзакции tx. Ключ файла зависит только от содержимого,
+// поэтому повторная загрузка того же файла и его удаление должны выполняться по очереди.
+func lockBlob(ctx context.Context, tx *sql.Tx, key string) error {
+	_, err := tx.ExecContext(ctx, `select pg_advisory_xact_lock(hashtext($1))`, key)
+	if err != nil {
+		return fmt.Errorf("failed to lock blob: %w", err)
+	}
+
+	return nil
+}
+
+// GetUnreferencedBlobKeys проверяет ключи в служебной транзакции, которой доступны файлы всех пользователей.
+func (db *dbAdapter) GetUnreferencedBlobKeys(ctx context.Context, keys []string) ([]string, error) {
+	tx, err := db.beginMaintenanceTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `select k.key from unnest($1::varchar[]) as k(key)
+			 where not exists (select 1 from blobs where object_key = k.key)
+			   and not exists (select 1 from blob_outbox where object_key = k.key)`
+
+	unreferenced := make([]string, 0)
+	err = tx.SelectContext(ctx, &unreferenced, query, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("error getting unreferenced blobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return unreferenced, nil
+}
+
+// GetLegacyBlobs не использует служебную транзакцию: на legacy_blobs политики построчной защиты
+// не распространяются.
+func (db *dbAdapter) GetLegacyBlobs(ctx context.Context) ([]models.LegacyBlob, error) {
+	blobs := make([]models.LegacyBlob, 0)
+	err := db.conn.SelectContext(ctx, &blobs, `select object_key, source_key, user_id from legacy_blobs order by object_key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get legacy blobs: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// ReplaceLegacyBlob переводит записи и историю в служебной транзакции, которой доступны записи всех пользователей.
+func (db *dbAdapter) ReplaceLegacyBlob(ctx context.Context, blob models.LegacyBlob, key string, size int64) error {
+	tx, err := db.beginMaintenanceTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := saveBlob(ctx, tx.Tx, key, size, blob.UserID); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"data", "data_revisions"} {
+		_, err = tx.ExecContext(ctx, `update `+table+` set blob_key = $2 where blob_key = $1`, blob.BlobKey, key)
+		if err != nil {
+			return fmt.Errorf("failed to replace legacy blob: %w", err)
+		}
+	}
+
+	// Строка в legacy_blobs удаляется каскадно
+	_, err = tx.ExecContext(ctx, `delete from blobs where object_key = $1`, blob.BlobKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete legacy blob: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `insert into blob_outbox (object_key, user_id)
+			 select $1, $2 where not exists (select 1 from legacy_blobs where source_key = $1)`, blob.SourceKey, blob.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to add legacy blob operation: %w", err)
+	}
+
+	return tx.Commit()
+}
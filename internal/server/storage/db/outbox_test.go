@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+func TestAddPendingBlob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	processAfter := time.Date(2025, 3, 2, 0, 5, 0, 0, time.UTC)
+	expectedQuery := `insert into blob_outbox (object_key, user_id, process_after) values ($1, $2, $3)`
+
+	t.Run("AddPendingBlobSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+			WithArgs("users/1/abc", int64(1), processAfter).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := pg.AddPendingBlob(context.Background(), 1, "users/1/abc", processAfter)
+		assert.NoError(t, err)
+	})
+
+	t.Run("AddPendingBlobError", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).
+			WithArgs("users/1/abc", int64(1), processAfter).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		err := pg.AddPendingBlob(context.Background(), 1, "users/1/abc", processAfter)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimBlobOperations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	now := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	retryAt := now.Add(time.Minute)
+
+	referencedQuery := `delete from blob_outbox o using blobs b where b.object_key = o.object_key`
+	claimQuery := `update blob_outbox set attempts = attempts + 1, process_after = $2
+		 where id in (select id from blob_outbox where process_after <= $1 order by id limit $3 for update skip locked)
+		 returning id, user_id, object_key, attempts, last_error`
+
+	t.Run("ClaimBlobOperationsSuccessfully", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectExec(regexp.QuoteMeta(referencedQuery)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+			WithArgs(now, retryAt, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "object_key", "attempts", "last_error"}).
+				AddRow(5, 1, "users/1/abc", 2, "storage unavailable"))
+		mock.ExpectCommit()
+
+		operations, err := pg.ClaimBlobOperations(context.Background(), now, retryAt, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlobOperation{
+			{ID: 5, UserID: 1, BlobKey: "users/1/abc", Attempts: 2, LastError: "storage unavailable"},
+		}, operations)
+	})
+
+	t.Run("ClaimBlobOperationsError", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectExec(regexp.QuoteMeta(referencedQuery)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+			WithArgs(now, retryAt, 10).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.ClaimBlobOperations(context.Background(), now, retryAt, 10)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteBlobOperation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	mock.ExpectExec(regexp.QuoteMeta(`delete from blob_outbox where id = $1`)).
+		WithArgs(int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`update blob_outbox set last_error = $2 where id = $1`)).
+		WithArgs(int64(6), "storage unavailable").
+		WillReturnError(fmt.Errorf("connection error"))
+
+	assert.NoError(t, pg.CompleteBlobOperation(context.Background(), 5))
+	assert.Error(t, pg.FailBlobOperation(context.Background(), 6, "storage unavailable"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUnreferencedBlob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	t.Run("DeleteUnreferencedBlobSuccessfully", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectQuery(regexp.QuoteMeta(unreferencedBlobQuery)).
+			WithArgs("users/1/abc", int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"unreferenced"}).AddRow(true))
+		mock.ExpectCommit()
+
+		called := false
+		deleted, err := pg.DeleteUnreferencedBlob(context.Background(), "users/1/abc", 5, func() error {
+			called = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+		assert.True(t, called)
+	})
+
+	t.Run("DeleteUnreferencedBlobReferenced", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectQuery(regexp.QuoteMeta(unreferencedBlobQuery)).
+			WithArgs("users/1/abc", int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"unreferenced"}).AddRow(false))
+		mock.ExpectRollback()
+
+		deleted, err := pg.DeleteUnreferencedBlob(context.Background(), "users/1/abc", 5, func() error {
+			t.Error("referenced file must not be deleted")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	t.Run("DeleteUnreferencedBlobStorageError", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectQuery(regexp.QuoteMeta(unreferencedBlobQuery)).
+			WithArgs("users/1/abc", int64(0)).
+			WillReturnRows(sqlmock.NewRows([]string{"unreferenced"}).AddRow(true))
+		mock.ExpectRollback()
+
+		_, err := pg.DeleteUnreferencedBlob(context.Background(), "users/1/abc", 0, func() error {
+			return fmt.Errorf("storage unavailable")
+		})
+		assert.ErrorContains(t, err, "storage unavailable")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectBlobLock ожидает блокировку файла с ключом key до конца транзакции.
+func expectBlobLock(mock sqlmock.Sqlmock, key string) {
+	mock.ExpectExec(regexp.QuoteMeta(`select pg_advisory_xact_lock(hashtext($1))`)).
+		WithArgs(key).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestGetUnreferencedBlobKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	keys := []string{"users/1/abc", "users/1/def"}
+	expectedQuery := `select k.key from unnest($1::varchar[]) as k(key)
+		 where not exists (select 1 from blobs where object_key = k.key)
+		   and not exists (select 1 from blob_outbox where object_key = k.key)`
+
+	t.Run("GetUnreferencedBlobKeysSuccessfully", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(pq.Array(keys)).
+			WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("users/1/def"))
+		mock.ExpectCommit()
+
+		unreferenced, err := pg.GetUnreferencedBlobKeys(context.Background(), keys)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"users/1/def"}, unreferenced)
+	})
+
+	t.Run("GetUnreferencedBlobKeysError", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+			WithArgs(pq.Array(keys)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.GetUnreferencedBlobKeys(context.Background(), keys)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLegacyBlobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`select object_key, source_key, user_id from legacy_blobs order by object_key`)).
+		WillReturnRows(sqlmock.NewRows([]string{"object_key", "source_key", "user_id"}).
+			AddRow("users/1/legacy/uploads/a.txt", "uploads/a.txt", 1).
+			AddRow("users/2/legacy/uploads/a.txt", "uploads/a.txt", 2))
+
+	blobs, err := pg.GetLegacyBlobs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LegacyBlob{
+		{BlobKey: "users/1/legacy/uploads/a.txt", SourceKey: "uploads/a.txt", UserID: 1},
+		{BlobKey: "users/2/legacy/uploads/a.txt", SourceKey: "uploads/a.txt", UserID: 2},
+	}, blobs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceLegacyBlob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	blob := models.LegacyBlob{BlobKey: "users/1/legacy/uploads/a.txt", SourceKey: "uploads/a.txt", UserID: 1}
+
+	t.Run("ReplaceLegacyBlobSuccessfully", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectExec(regexp.QuoteMeta(`insert into blobs (object_key, user_id, size) values ($1, $2, $3)
+			 on conflict (object_key) do nothing`)).
+			WithArgs("users/1/abc", int64(1), int64(10)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`delete from blob_outbox where object_key = $1`)).
+			WithArgs("users/1/abc").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`update data set blob_key = $2 where blob_key = $1`)).
+			WithArgs(blob.BlobKey, "users/1/abc").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`update data_revisions set blob_key = $2 where blob_key = $1`)).
+			WithArgs(blob.BlobKey, "users/1/abc").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(`delete from blobs where object_key = $1`)).
+			WithArgs(blob.BlobKey).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`insert into blob_outbox (object_key, user_id)
+			 select $1, $2 where not exists (select 1 from legacy_blobs where source_key = $1)`)).
+			WithArgs("uploads/a.txt", int64(1)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := pg.ReplaceLegacyBlob(context.Background(), blob, "users/1/abc", 10)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReplaceLegacyBlobError", func(t *testing.T) {
+		expectMaintenanceTx(mock)
+		expectBlobLock(mock, "users/1/abc")
+		mock.ExpectExec(regexp.QuoteMeta(`insert into blobs (object_key, user_id, size) values ($1, $2, $3)
+			 on conflict (object_key) do nothing`)).
+			WithArgs("users/1/abc", int64(1), int64(10)).WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		err := pg.ReplaceLegacyBlob(context.Background(), blob, "users/1/abc", 10)
+		assert.ErrorContains(t, err, "failed to save blob")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
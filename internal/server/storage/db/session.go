@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// CreateSession сохраняет сессию в одной транзакции с удалением старых сессий.
+func (db *dbAdapter) CreateSession(ctx context.Context, session *models.Session) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `delete from sessions where user_id = $1 and (expires_at <= now() or revoked_at is not null)`,
+		session.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `insert into sessions (id, user_id, token_hash, expires_at) values ($1, $2, $3, $4)`,
+		session.ID, session.UserID, session.TokenHash, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RotateSession сравнивает срок действия сессии со временем сервера базы данных (now()).
+func (db *dbAdapter) RotateSession(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `update sessions s set previous_hash = s.token_hash, token_hash = $2, expires_at = $3
+			 from users u
+			 where u.id = s.user_id and s.token_hash = $1 and s.revoked_at is null and s.expires_at > now()
+			 returning s.id, s.user_id, u.username, s.token_hash, s.expires_at`
+
+	var session models.Session
+	err = tx.GetContext(ctx, &session, query, tokenHash, newHash, expiresAt)
+	if err == nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &session, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `update sessions set revoked_at = now() where previous_hash = $1 and revoked_at is null`,
+		tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if revoked > 0 {
+		return nil, utils.ErrTokenReused
+	}
+	return nil, utils.ErrSessionNotFound
+}
+
+// RevokeSession отмечает время отзыва сессии временем сервера базы данных.
+func (db *dbAdapter) RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := db.conn.ExecContext(ctx, `update sessions set revoked_at = now() where id = $1 and revoked_at is null`,
+		sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionActive сравнивает срок действия сессии со временем сервера базы данных.
+func (db *dbAdapter) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+	query := `select exists (select 1 from sessions where id = $1 and revoked_at is null and expires_at > now())`
+
+	err := db.conn.GetContext(ctx, &active, query, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+func TestCreateSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	session := &models.Session{ID: "session-1", UserID: 1, TokenHash: "hash", ExpiresAt: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)}
+	deleteQuery := `delete from sessions where user_id = $1 and (expires_at <= now() or revoked_at is not null)`
+	insertQuery := `insert into sessions (id, user_id, token_hash, expires_at) values ($1, $2, $3, $4)`
+
+	t.Run("CreateSessionSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+			WithArgs("session-1", int64(1), "hash", session.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := pg.CreateSession(context.Background(), session)
+		assert.NoError(t, err)
+	})
+
+	t.Run("CreateSessionError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+			WithArgs("session-1", int64(1), "hash", session.ExpiresAt).
+			WillReturnError(fmt.Errorf("duplicate key"))
+		mock.ExpectRollback()
+
+		err := pg.CreateSession(context.Background(), session)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create session")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	expiresAt := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	rotateQuery := `update sessions s set previous_hash = s.token_hash, token_hash = $2, expires_at = $3
+			 from users u
+			 where u.id = s.user_id and s.token_hash = $1 and s.revoked_at is null and s.expires_at > now()
+			 returning s.id, s.user_id, u.username, s.token_hash, s.expires_at`
+	revokeQuery := `update sessions set revoked_at = now() where previous_hash = $1 and revoked_at is null`
+
+	t.Run("RotateSessionSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(rotateQuery)).
+			WithArgs("old", "new", expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "token_hash", "expires_at"}).
+				AddRow("session-1", int64(1), "testuser", "new", expiresAt))
+		mock.ExpectCommit()
+
+		session, err := pg.RotateSession(context.Background(), "old", "new", expiresAt)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Session{ID: "session-1", UserID: 1, Username: "testuser", TokenHash: "new", ExpiresAt: expiresAt}, session)
+	})
+
+	t.Run("RotateSessionReused", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(rotateQuery)).
+			WithArgs("old", "new", expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "token_hash", "expires_at"}))
+		mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).WithArgs("old").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := pg.RotateSession(context.Background(), "old", "new", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrTokenReused)
+	})
+
+	t.Run("RotateSessionNotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(rotateQuery)).
+			WithArgs("old", "new", expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "token_hash", "expires_at"}))
+		mock.ExpectExec(regexp.QuoteMeta(revokeQuery)).WithArgs("old").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		_, err := pg.RotateSession(context.Background(), "old", "new", expiresAt)
+		assert.ErrorIs(t, err, utils.ErrSessionNotFound)
+	})
+
+	t.Run("RotateSessionError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(rotateQuery)).
+			WithArgs("old", "new", expiresAt).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := pg.RotateSession(context.Background(), "old", "new", expiresAt)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to rotate session")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	expectedQuery := `update sessions set revoked_at = now() where id = $1 and revoked_at is null`
+
+	t.Run("RevokeSessionSuccessfully", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).WithArgs("session-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := pg.RevokeSession(context.Background(), "session-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("RevokeSessionError", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).WithArgs("session-1").WillReturnError(fmt.Errorf("connection error"))
+
+		err := pg.RevokeSession(context.Background(), "session-1")
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsSessionActive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	expectedQuery := `select exists (select 1 from sessions where id = $1 and revoked_at is null and expires_at > now())`
+
+	t.Run("SessionActive", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs("session-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		active, err := pg.IsSessionActive(context.Background(), "session-1")
+		assert.NoError(t, err)
+		assert.True(t, active)
+	})
+
+	t.Run("IsSessionActiveError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs("session-1").WillReturnError(fmt.Errorf("connection error"))
+
+		_, err := pg.IsSessionActive(context.Background(), "session-1")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to check session")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
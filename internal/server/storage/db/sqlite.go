@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+)
+
+const (
+	sqliteScheme = "sqlite://" // схема строки подключения к SQLite: sqlite://<путь к файлу базы данных>
+	sqliteMemory = ":memory:"  // путь базы данных SQLite, которая хранится только в памяти процесса
+
+	// sqliteOptions - параметры подключения к SQLite: проверка внешних ключей, ожидание блокировки,
+	// журнал WAL и запись времени в формате, который сравнивается как строка.
+	sqliteOptions = "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+	// sqliteNow - текущее время в UTC в формате, в котором SQLite хранит время.
+	sqliteNow = `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')`
+)
+
+// sqliteAdapter реализует Adapter на SQLite для запуска сервера одним процессом без внешней базы данных.
+//
+// Запросы выполняются через единственное соединение, поэтому транзакции не конкурируют за блокировку файла,
+// а уведомления об изменениях раздаются подписчикам этого же процесса после фиксации транзакции.
+type sqliteAdapter struct {
+	conn *sqlx.DB
+	hub  *changeHub
+}
+
+// memoryDbCounter нумерует базы данных в памяти, чтобы каждый адаптер получал собственную базу.
+var memoryDbCounter atomic.Int64
+
+// isSQLiteDsn сообщает, указывает ли строка подключения на базу данных SQLite.
+func isSQLiteDsn(dsn string) bool {
+	return strings.HasPrefix(dsn, sqliteScheme)
+}
+
+// newSQLiteAdapter подключается к файлу базы данных SQLite из строки подключения и выполняет миграции,
+// если указано в настройках.
+func newSQLiteAdapter(settings *settings.Settings) (Adapter, error) {
+	path := strings.TrimPrefix(settings.DbDsn, sqliteScheme)
+	if path == "" {
+		return nil, errors.New("sqlite database path is empty")
+	}
+
+	// База в памяти открывается с общим кэшем под уникальным именем, чтобы соединение миграций
+	// работало с той же базой, пока открыто основное соединение адаптера.
+	if path == sqliteMemory {
+		path = fmt.Sprintf("file:gophkeeper-%d?mode=memory&cache=shared", memoryDbCounter.Add(1))
+	}
+
+	conn, err := sqlx.Connect("sqlite", sqliteConnString(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if settings.DbAutoMigration {
+		if err := migrateSQLite(path); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	return &sqliteAdapter{conn: conn, hub: newChangeHub()}, nil
+}
+
+// migrateSQLite применяет миграции SQLite к файлу базы данных path.
+func migrateSQLite(path string) error {
+	dbInstance, err := sql.Open("sqlite", sqliteConnString(path))
+	if err != nil {
+		return fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	driver, err := sqlite.WithInstance(dbInstance, &sqlite.Config{MigrationsTable: "migration"})
+	if err != nil {
+		_ = dbInstance.Close()
+		return err
+	}
+
+	source, err := iofs.New(migrations, "migrations/sqlite")
+	if err != nil {
+		_ = driver.Close()
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		_ = driver.Close()
+		return fmt.Errorf("failed to create migrate: %w", err)
+	}
+
+	defer func() { _, _ = m.Close() }()
+
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	log.Println("Database migration completed successfully.")
+
+	return nil
+}
+
+// sqliteConnString добавляет к пути базы данных параметры подключения sqliteOptions.
+func sqliteConnString(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + strings.TrimPrefix(sqliteOptions, "?")
+	}
+
+	return path + sqliteOptions
+}
+
+// Close закрывает подключение к базе данных и каналы подписчиков на изменения.
+func (db *sqliteAdapter) Close() {
+	db.hub.closeAll()
+	_ = db.conn.Close()
+}
+
+// Subscribe подписывает на события, которые адаптер рассылает подписчикам этого же процесса.
+func (db *sqliteAdapter) Subscribe(ctx context.Context, userId int64) (<-chan models.ChangeEvent, error) {
+	return db.hub.subscribe(ctx, userId), nil
+}
+
+// sqliteTx - транзакция SQLite, которая накапливает события об изменениях данных
+// для рассылки подписчикам после фиксации.
+type sqliteTx struct {
+	*sqlx.Tx
+	events []models.ChangeEvent
+}
+
+// inTx выполняет fn в транзакции и фиксирует ее, если fn завершилась без ошибки.
+// События об изменениях, накопленные в транзакции, рассылаются подписчикам только после фиксации.
+func (db *sqliteAdapter) inTx(ctx context.Context, fn func(tx *sqliteTx) error) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stx := &sqliteTx{Tx: tx}
+	if err := fn(stx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, event := range stx.events {
+		db.hub.publish(event)
+	}
+
+	return nil
+}
+
+// notifyChange запоминает событие об изменении данных для рассылки после фиксации транзакции.
+func (tx *sqliteTx) notifyChange(event models.ChangeEvent) {
+	tx.events = append(tx.events, event)
+}
+
+// nextRevision выдает следующую ревизию записи. Счетчик изменяется в транзакции tx,
+// а SQLite выполняет пишущие транзакции по очереди, поэтому ревизии фиксируются в порядке возрастания.
+func (tx *sqliteTx) nextRevision(ctx context.Context) (int64, error) {
+	var revision int64
+	err := tx.GetContext(ctx, &revision, `update revision_seq set value = value + 1 returning value`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get next revision: %w", err)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,665 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// dataColumns - столбцы записи, возвращаемые после ее изменения.
+const dataColumns = `id, user_id, data_type, data_content, metadata, updated_at, revision, version`
+
+// CreateData присваивает ревизию счетчиком nextRevision; уведомление рассылается подписчикам процесса после фиксации.
+func (db *sqliteAdapter) CreateData(ctx context.Context, data *models.Data) (string, error) {
+	var id string
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		var err error
+		id, err = tx.insertData(ctx, data)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// insertData вставляет запись данных в транзакции tx и запоминает событие об изменении.
+// Запись создается с ID data.ID, сгенерированным клиентом; если он пуст, ID генерируется.
+// Если у пользователя уже есть запись с тем же ID или ключом идемпотентности data.IdempotencyKey,
+// новая запись не создается, а возвращается ID существующей записи вместе с ее текущими ревизией и версией.
+// Если запись с таким ID принадлежит другому пользователю, возвращается utils.ErrDataExists.
+func (tx *sqliteTx) insertData(ctx context.Context, data *models.Data) (string, error) {
+	if data.ID == "" {
+		data.ID = models.NewDataID()
+	}
+
+	err := tx.saveBlob(ctx, data.BlobKey, data.BlobSize, data.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	revision, err := tx.nextRevision(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := `insert into data (id, user_id, data_type, data_content, metadata, idempotency_key, blob_key, file_name, revision)
+			 values ($1, $2, $3, $4, $5, nullif($6, ''), nullif($7, ''), nullif($8, ''), $9)
+			 on conflict do nothing
+			 returning revision, version`
+
+	var version int64
+	err = tx.QueryRowContext(ctx, query, data.ID, data.UserID, data.DataType, data.DataContent, data.Metadata,
+		data.IdempotencyKey, data.BlobKey, data.FileName, revision).Scan(&revision, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tx.createdData(ctx, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to insert data: %w", err)
+	}
+
+	tx.notifyChange(models.ChangeEvent{UserID: data.UserID, DataID: data.ID, Revision: revision})
+
+	data.Revision = revision
+	data.Version = version
+	return data.ID, nil
+}
+
+// saveBlob регистрирует файл пользователя с ключом key и размером size в транзакции tx, если он еще не зарегистрирован,
+// и отменяет отложенные удаления этого файла: на него ссылается сохраняемая запись.
+// Число ссылок на файл обновляется триггерами при сохранении ссылающихся на него записей. Пустой ключ пропускается.
+func (tx *sqliteTx) saveBlob(ctx context.Context, key string, size int64, userId int64) error {
+	if key == "" {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `insert into blobs (object_key, user_id, size) values ($1, $2, $3)
+			 on conflict (object_key) do nothing`, key, userId, size)
+	if err != nil {
+		return fmt.Errorf("failed to save blob: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from blob_outbox where object_key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending blob operations: %w", err)
+	}
+
+	return nil
+}
+
+// createdData возвращает ID записи пользователя, ранее созданной с ID data.ID или ключом идемпотентности
+// data.IdempotencyKey, и сохраняет ее текущие ревизию и версию в data.Revision и data.Version.
+func (tx *sqliteTx) createdData(ctx context.Context, data *models.Data) (string, error) {
+	query := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '') or idempotency_key = nullif($3, '')) limit 1`
+
+	var id string
+	err := tx.QueryRowContext(ctx, query, data.UserID, data.ID, data.IdempotencyKey).
+		Scan(&id, &data.Revision, &data.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", utils.ErrDataExists
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get created data: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetCreatedData ищет запись по ID или ключу идемпотентности с явным условием по user_id.
+func (db *sqliteAdapter) GetCreatedData(ctx context.Context, userId int64, dataId string, idempotencyKey string) (*models.Data, error) {
+	query := `select id, revision, version from data
+			 where user_id = $1 and (id = nullif($2, '') or idempotency_key = nullif($3, '')) limit 1`
+
+	var data models.Data
+	err := db.conn.GetContext(ctx, &data, query, userId, dataId, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// GetData выбирает записи с явным условием по user_id: политик построчной защиты в SQLite нет.
+func (db *sqliteAdapter) GetData(ctx context.Context, userId int64) ([]models.Data, error) {
+	dataList := make([]models.Data, 0)
+
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, ` + blobColumns + `
+			 from data where user_id = $1 and deleted_at is null order by created_at, id`
+
+	err := db.conn.SelectContext(ctx, &dataList, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data info: %w", err)
+	}
+
+	return dataList, nil
+}
+
+// ListData сравнивает метаданные с фильтром через json_each, так как оператора @> в SQLite нет.
+func (db *sqliteAdapter) ListData(ctx context.Context, userId int64, filter models.DataFilter) ([]models.Data, error) {
+	dataList := make([]models.Data, 0)
+
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, ` + blobColumns + `
+			 from data where user_id = $1 and deleted_at is null`
+	args := []interface{}{userId}
+
+	if filter.AfterID != "" {
+		args = append(args, filter.AfterID)
+		query += fmt.Sprintf(" and id > $%d", len(args))
+	}
+
+	if filter.DataType != "" {
+		args = append(args, filter.DataType)
+		query += fmt.Sprintf(" and data_type = $%d", len(args))
+	}
+
+	if !filter.UpdatedAfter.IsZero() {
+		args = append(args, filter.UpdatedAfter.UTC())
+		query += fmt.Sprintf(" and updated_at > $%d", len(args))
+	}
+
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		args = append(args, key, filter.Metadata[key])
+		query += fmt.Sprintf(` and exists (select 1 from json_each(cast(metadata as text))
+			 where key = $%d and type = 'text' and value = $%d)`, len(args)-1, len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" order by id limit $%d", len(args))
+
+	err := db.conn.SelectContext(ctx, &dataList, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing data: %w", err)
+	}
+
+	return dataList, nil
+}
+
+// GetDataByID выбирает запись с явным условием по user_id.
+func (db *sqliteAdapter) GetDataByID(ctx context.Context, dataID string, userId int64) (*models.Data, error) {
+	query := `select id, user_id, data_type, data_content, metadata, updated_at, revision, version, coalesce(blob_key, '') as blob_key
+			 from data where id = $1 and user_id = $2 and deleted_at is null`
+
+	var data models.Data
+	err := db.conn.GetContext(ctx, &data, query, dataID, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting data by ID: %w", err)
+	}
+
+	return &data, nil
+}
+
+// DeleteData помечает запись временем удаления в транзакции inTx.
+func (db *sqliteAdapter) DeleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (bool, error) {
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		_, err := tx.deleteData(ctx, dataId, userId, expectedVersion)
+		return err
+	})
+	if errors.Is(err, utils.ErrUserDataNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// deleteData перемещает запись пользователя в корзину в транзакции tx и запоминает событие об изменении.
+// Возвращает новую ревизию записи. Если записи нет, возвращается utils.ErrUserDataNotFound,
+// при несовпадении ненулевой expectedVersion - *utils.VersionConflictError.
+func (tx *sqliteTx) deleteData(ctx context.Context, dataId string, userId int64, expectedVersion int64) (int64, error) {
+	revision, err := tx.nextRevision(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `update data
+			 set deleted_at = ` + sqliteNow + `, updated_at = ` + sqliteNow + `,
+			     revision = $4, version = version + 1
+			 where id = $1 and user_id = $2 and deleted_at is null and ($3 = 0 or version = $3)`
+
+	res, err := tx.ExecContext(ctx, query, dataId, userId, expectedVersion, revision)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting data: %w", err)
+	}
+
+	if err := tx.checkUpdated(ctx, res, dataId, userId); err != nil {
+		return 0, err
+	}
+
+	tx.notifyChange(models.ChangeEvent{UserID: userId, DataID: dataId, Revision: revision, Deleted: true})
+
+	return revision, nil
+}
+
+// UpdateData полагается на то, что SQLite выполняет пишущие транзакции по очереди, и не блокирует запись.
+func (db *sqliteAdapter) UpdateData(ctx context.Context, data *models.Data) error {
+	return db.inTx(ctx, func(tx *sqliteTx) error {
+		return tx.updateData(ctx, data)
+	})
+}
+
+// updateData сохраняет текущее состояние записи в истории и обновляет запись в транзакции tx,
+// после чего запоминает событие об изменении. Новые ревизия и версия сохраняются в data.Revision и data.Version.
+func (tx *sqliteTx) updateData(ctx context.Context, data *models.Data) error {
+	err := tx.saveRevision(ctx, data.ID, data.UserID, data.Version)
+	if err != nil {
+		return err
+	}
+
+	err = tx.saveBlob(ctx, data.BlobKey, data.BlobSize, data.UserID)
+	if err != nil {
+		return err
+	}
+
+	revision, err := tx.nextRevision(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `update data
+			 set data_content = $1, metadata = $2, blob_key = nullif($6, ''), file_name = coalesce(nullif($8, ''), file_name),
+			     updated_at = ` + sqliteNow + `, revision = $7, version = version + 1
+			 where id = $3 and user_id = $4 and deleted_at is null and ($5 = 0 or version = $5)
+			 returning version`
+
+	var version int64
+	err = tx.QueryRowContext(ctx, query, data.DataContent, data.Metadata, data.ID, data.UserID, data.Version,
+		data.BlobKey, revision, data.FileName).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tx.currentVersionError(ctx, data.ID, data.UserID)
+	}
+	if err != nil {
+		return fmt.Errorf("error update update data: %w", err)
+	}
+
+	tx.notifyChange(models.ChangeEvent{UserID: data.UserID, DataID: data.ID, Revision: revision})
+
+	data.Revision = revision
+	data.Version = version
+	return nil
+}
+
+// ApplyMutations накапливает уведомления в транзакции и рассылает их подписчикам процесса после фиксации.
+func (db *sqliteAdapter) ApplyMutations(ctx context.Context, userId int64, mutations []models.Mutation) ([]models.MutationResult, error) {
+	results := make([]models.MutationResult, len(mutations))
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		for i, mutation := range mutations {
+			data := mutation.Data
+			data.UserID = userId
+
+			var err error
+			switch mutation.Type {
+			case models.MutationCreate:
+				data.ID, err = tx.insertData(ctx, &data)
+			case models.MutationUpdate:
+				err = tx.updateData(ctx, &data)
+			case models.MutationDelete:
+				data.Revision, err = tx.deleteData(ctx, data.ID, userId, data.Version)
+				data.Version = 0
+			default:
+				err = fmt.Errorf("unknown mutation type %d", mutation.Type)
+			}
+			if err != nil {
+				return &utils.OperationError{Index: i, Err: err}
+			}
+
+			results[i] = models.MutationResult{ID: data.ID, Revision: data.Revision, Version: data.Version}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// saveRevision сохраняет текущее состояние записи пользователя в истории изменений перед ее изменением.
+// Если expectedVersion не равен 0, состояние сохраняется только при совпадении версии.
+func (tx *sqliteTx) saveRevision(ctx context.Context, dataId string, userId int64, expectedVersion int64) error {
+	query := `insert into data_revisions (data_id, user_id, revision, version, data_type, data_content, metadata, updated_at,
+			     blob_key, file_name)
+			 select id, user_id, revision, version, data_type, data_content, metadata, updated_at, blob_key, file_name
+			 from data
+			 where id = $1 and user_id = $2 and deleted_at is null and ($3 = 0 or version = $3)
+			 on conflict (data_id, revision) do nothing`
+
+	_, err := tx.ExecContext(ctx, query, dataId, userId, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("error saving data revision: %w", err)
+	}
+
+	return nil
+}
+
+// checkUpdated проверяет, что запрос res изменил запись, иначе возвращает ошибку currentVersionError.
+func (tx *sqliteTx) checkUpdated(ctx context.Context, res sql.Result, dataId string, userId int64) error {
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting updated rows: %w", err)
+	}
+	if updated == 0 {
+		return tx.currentVersionError(ctx, dataId, userId)
+	}
+
+	return nil
+}
+
+// currentVersionError определяет, почему запись не была изменена: возвращает utils.ErrUserDataNotFound,
+// если записи пользователя нет, иначе *utils.VersionConflictError с ее текущей версией.
+func (tx *sqliteTx) currentVersionError(ctx context.Context, dataId string, userId int64) error {
+	var version int64
+	err := tx.GetContext(ctx, &version, `select version from data where id = $1 and user_id = $2 and deleted_at is null`,
+		dataId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.ErrUserDataNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error getting data version: %w", err)
+	}
+
+	return &utils.VersionConflictError{CurrentVersion: version}
+}
+
+// changedData возвращает запись пользователя после ее изменения в транзакции tx.
+func (tx *sqliteTx) changedData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	var data models.Data
+	err := tx.GetContext(ctx, &data, `select `+dataColumns+` from data where id = $1 and user_id = $2`, dataId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changed data: %w", err)
+	}
+
+	return &data, nil
+}
+
+// GetChanges выбирает изменения с явным условием по user_id в одной транзакции с проверкой курсора.
+func (db *sqliteAdapter) GetChanges(ctx context.Context, userId int64, since int64, limit int) ([]models.DataChange, error) {
+	changes := make([]models.DataChange, 0)
+
+	query := `select id,
+			 	case when deleted_at is null then data_type else '' end as data_type,
+			 	case when deleted_at is null then data_content end as data_content,
+			 	case when deleted_at is null then metadata else cast('{}' as blob) end as metadata,
+			 	updated_at, revision,
+			 	case when deleted_at is null then version else 0 end as version,
+			 	deleted_at is not null as deleted, ` + blobColumns + `
+			 from data where user_id = $1 and revision > $2
+			 order by revision limit $3`
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		if since > 0 {
+			var purgedRevision int64
+			err := tx.GetContext(ctx, &purgedRevision, `select purged_revision from users where id = $1`, userId)
+			if err != nil {
+				return fmt.Errorf("error getting purged revision: %w", err)
+			}
+			if since < purgedRevision {
+				return utils.ErrCursorExpired
+			}
+		}
+
+		err := tx.SelectContext(ctx, &changes, query, userId, since, limit)
+		if err != nil {
+			return fmt.Errorf("error getting data changes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetDataHistory выбирает историю с явным условием по user_id.
+func (db *sqliteAdapter) GetDataHistory(ctx context.Context, dataId string, userId int64) ([]models.Data, error) {
+	history := make([]models.Data, 0)
+
+	query := `select data_id as id, user_id, data_type, data_content, metadata, updated_at, revision, version
+			 from data_revisions where data_id = $1 and user_id = $2
+			 order by revision desc`
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		var exists bool
+		err := tx.GetContext(ctx, &exists,
+			`select exists(select 1 from data where id = $1 and user_id = $2 and deleted_at is null)`, dataId, userId)
+		if err != nil {
+			return fmt.Errorf("error checking data: %w", err)
+		}
+		if !exists {
+			return utils.ErrUserDataNotFound
+		}
+
+		err = tx.SelectContext(ctx, &history, query, dataId, userId)
+		if err != nil {
+			return fmt.Errorf("error getting data history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// RestoreRevision полагается на то, что SQLite выполняет пишущие транзакции по очереди, и не блокирует запись.
+func (db *sqliteAdapter) RestoreRevision(ctx context.Context, dataId string, userId int64, revision int64,
+	expectedVersion int64) (*models.Data, error) {
+	var data *models.Data
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		var exists bool
+		err := tx.GetContext(ctx, &exists,
+			`select exists(select 1 from data_revisions where data_id = $1 and user_id = $2 and revision = $3)`,
+			dataId, userId, revision)
+		if err != nil {
+			return fmt.Errorf("error checking data revision: %w", err)
+		}
+		if !exists {
+			return utils.ErrUserDataNotFound
+		}
+
+		err = tx.saveRevision(ctx, dataId, userId, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		newRevision, err := tx.nextRevision(ctx)
+		if err != nil {
+			return err
+		}
+
+		query := `update data as d
+				 set data_content = r.data_content, metadata = r.metadata, blob_key = r.blob_key, file_name = r.file_name,
+				     updated_at = ` + sqliteNow + `, revision = $5, version = d.version + 1
+				 from data_revisions r
+				 where d.id = $1 and d.user_id = $2 and d.deleted_at is null and ($4 = 0 or d.version = $4)
+				   and r.data_id = d.id and r.revision = $3`
+
+		res, err := tx.ExecContext(ctx, query, dataId, userId, revision, expectedVersion, newRevision)
+		if err != nil {
+			return fmt.Errorf("error restoring data revision: %w", err)
+		}
+
+		if err := tx.checkUpdated(ctx, res, dataId, userId); err != nil {
+			return err
+		}
+
+		data, err = tx.changedData(ctx, dataId, userId)
+		if err != nil {
+			return err
+		}
+
+		tx.notifyChange(models.ChangeEvent{UserID: userId, DataID: dataId, Revision: data.Revision})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ListTrash выбирает записи корзины с явным условием по user_id.
+func (db *sqliteAdapter) ListTrash(ctx context.Context, userId int64) ([]models.Data, error) {
+	dataList := make([]models.Data, 0)
+
+	query := `select id, data_type, data_content, metadata, updated_at, revision, version, deleted_at
+			 from data where user_id = $1 and deleted_at is not null and data_type <> ''
+			 order by deleted_at desc, id`
+
+	err := db.conn.SelectContext(ctx, &dataList, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing trash: %w", err)
+	}
+
+	return dataList, nil
+}
+
+// RestoreData снимает отметку удаления в транзакции inTx.
+func (db *sqliteAdapter) RestoreData(ctx context.Context, dataId string, userId int64) (*models.Data, error) {
+	var data *models.Data
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		revision, err := tx.nextRevision(ctx)
+		if err != nil {
+			return err
+		}
+
+		query := `update data
+				 set deleted_at = null, updated_at = ` + sqliteNow + `,
+				     revision = $3, version = version + 1
+				 where id = $1 and user_id = $2 and deleted_at is not null and data_type <> ''`
+
+		res, err := tx.ExecContext(ctx, query, dataId, userId, revision)
+		if err != nil {
+			return fmt.Errorf("error restoring data: %w", err)
+		}
+
+		restored, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error restoring data: %w", err)
+		}
+		if restored == 0 {
+			return utils.ErrUserDataNotFound
+		}
+
+		data, err = tx.changedData(ctx, dataId, userId)
+		if err != nil {
+			return err
+		}
+
+		tx.notifyChange(models.ChangeEvent{UserID: userId, DataID: dataId, Revision: revision})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// PurgeData удаляет только файлы пользователя userId, оставшиеся без ссылок.
+func (db *sqliteAdapter) PurgeData(ctx context.Context, userId int64, dataId string) (models.PurgeResult, error) {
+	return db.purge(ctx, userId, `user_id = $1 and ($2 = '' or id = $2)`, userId, dataId)
+}
+
+// PurgeTrash удаляет все файлы, оставшиеся без ссылок.
+func (db *sqliteAdapter) PurgeTrash(ctx context.Context, before time.Time) (models.PurgeResult, error) {
+	return db.purge(ctx, 0, `deleted_at < $1`, before.UTC())
+}
+
+// purge окончательно удаляет записи из корзины, удовлетворяющие условию condition.
+//
+// Для каждого пользователя запоминается максимальная ревизия удаленных записей (users.purged_revision).
+// История изменений записей удаляется вместе с ними, а триггеры уменьшают число ссылок на их файлы.
+// Файлы без ссылок удаляются из таблицы blobs, а их удаление из хранилища файлов в той же транзакции
+// записывается в отложенные операции; если userId не равен 0, удаляются только файлы этого пользователя.
+func (db *sqliteAdapter) purge(ctx context.Context, userId int64, condition string, args ...interface{}) (models.PurgeResult, error) {
+	var result models.PurgeResult
+
+	purged := `select id from data where deleted_at is not null and ` + condition
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		_, err := tx.ExecContext(ctx, `update users
+				 set purged_revision = max(purged_revision, (select max(revision) from data
+				     where data.user_id = users.id and id in (`+purged+`)))
+				 where id in (select user_id from data where id in (`+purged+`))`, args...)
+		if err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `delete from data_revisions where data_id in (`+purged+`)`, args...)
+		if err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx, `delete from data where id in (`+purged+`)`, args...)
+		if err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+
+		result.Count, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+
+		result.BlobOperations = make([]models.BlobOperation, 0)
+		err = tx.SelectContext(ctx, &result.BlobOperations, `insert into blob_outbox (object_key, user_id)
+				 select object_key, user_id from blobs where ref_count = 0 and ($1 = 0 or user_id = $1)
+				 returning id, user_id, object_key, attempts, last_error`, userId)
+		if err != nil {
+			return fmt.Errorf("error deleting unreferenced blobs: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `delete from blobs where ref_count = 0 and ($1 = 0 or user_id = $1)`, userId)
+		if err != nil {
+			return fmt.Errorf("error deleting unreferenced blobs: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.PurgeResult{}, err
+	}
+
+	return result, nil
+}
+
+// GetBlobKeys раскрывает список ключей для оператора in через sqlx.In.
+func (db *sqliteAdapter) GetBlobKeys(ctx context.Context, userId int64, keys []string) ([]string, error) {
+	existing := make([]string, 0)
+	if len(keys) == 0 {
+		return existing, nil
+	}
+
+	query, args, err := sqlx.In(`select object_key from blobs where user_id = ? and object_key in (?)`, userId, keys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blobs: %w", err)
+	}
+
+	err = db.conn.SelectContext(ctx, &existing, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blobs: %w", err)
+	}
+
+	return existing, nil
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// AddPendingBlob реализует Adapter.AddPendingBlob для SQLite.
+func (db *sqliteAdapter) AddPendingBlob(ctx context.Context, userId int64, key string, processAfter time.Time) error {
+	_, err := db.conn.ExecContext(ctx, `insert into blob_outbox (object_key, user_id, process_after) values ($1, $2, $3)`,
+		key, userId, processAfter.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add pending blob: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimBlobOperations не пропускает заблокированные операции: адаптер SQLite работает в одном процессе.
+func (db *sqliteAdapter) ClaimBlobOperations(ctx context.Context, now time.Time, retryAt time.Time, limit int) ([]models.BlobOperation, error) {
+	operations := make([]models.BlobOperation, 0)
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		_, err := tx.ExecContext(ctx, `delete from blob_outbox where object_key in (select object_key from blobs)`)
+		if err != nil {
+			return fmt.Errorf("failed to delete referenced blob operations: %w", err)
+		}
+
+		query := `update blob_outbox set attempts = attempts + 1, process_after = $2
+				 where id in (select id from blob_outbox where process_after <= $1 order by id limit $3)
+				 returning id, user_id, object_key, attempts, last_error`
+
+		err = tx.SelectContext(ctx, &operations, query, now.UTC(), retryAt.UTC(), limit)
+		if err != nil {
+			return fmt.Errorf("failed to claim blob operations: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
+// CompleteBlobOperation реализует Adapter.CompleteBlobOperation для SQLite.
+func (db *sqliteAdapter) CompleteBlobOperation(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx, `delete from blob_outbox where id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete blob operation: %w", err)
+	}
+
+	return nil
+}
+
+// FailBlobOperation реализует Adapter.FailBlobOperation для SQLite.
+func (db *sqliteAdapter) FailBlobOperation(ctx context.Context, id int64, reason string) error {
+	_, err := db.conn.ExecContext(ctx, `update blob_outbox set last_error = $2 where id = $1`, id, reason)
+	if err != nil {
+		return fmt.Errorf("failed to save blob operation error: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUnreferencedBlob проверяет ссылки на файл и удаляет его в одной транзакции. Адаптер SQLite использует
+// одно соединение, поэтому транзакция не пересекается с регистрацией файла и отдельная блокировка не нужна.
+func (db *sqliteAdapter) DeleteUnreferencedBlob(ctx context.Context, key string, operationID int64, deleteFile func() error) (bool, error) {
+	deleted := false
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		var unreferenced bool
+		err := tx.GetContext(ctx, &unreferenced, unreferencedBlobQuery, key, operationID)
+		if err != nil {
+			return fmt.Errorf("failed to check blob references: %w", err)
+		}
+		if !unreferenced {
+			return nil
+		}
+
+		if err := deleteFile(); err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// GetUnreferencedBlobKeys раскрывает список ключей для оператора in через sqlx.In.
+func (db *sqliteAdapter) GetUnreferencedBlobKeys(ctx context.Context, keys []string) ([]string, error) {
+	unreferenced := make([]string, 0)
+	if len(keys) == 0 {
+		return unreferenced, nil
+	}
+
+	query, args, err := sqlx.In(`select object_key from blobs where object_key in (?)
+			 union select object_key from blob_outbox where object_key in (?)`, keys, keys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting unreferenced blobs: %w", err)
+	}
+
+	var used []string
+	err = db.conn.SelectContext(ctx, &used, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting unreferenced blobs: %w", err)
+	}
+
+	usedKeys := make(map[string]struct{}, len(used))
+	for _, key := range used {
+		usedKeys[key] = struct{}{}
+	}
+
+	for _, key := range keys {
+		if _, ok := usedKeys[key]; !ok {
+			unreferenced = append(unreferenced, key)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+// GetLegacyBlobs возвращает пустой список: база данных SQLite появилась после перехода на хранение файлов по хешу содержимого.
+func (db *sqliteAdapter) GetLegacyBlobs(ctx context.Context) ([]models.LegacyBlob, error) {
+	return []models.LegacyBlob{}, nil
+}
+
+// ReplaceLegacyBlob не поддерживается: в базе данных SQLite нет файлов, загруженных до хранения по хешу содержимого.
+func (db *sqliteAdapter) ReplaceLegacyBlob(ctx context.Context, blob models.LegacyBlob, key string, size int64) error {
+	return fmt.Errorf("legacy blobs are not supported by SQLite")
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// CreateSession реализует Adapter.CreateSession для SQLite.
+func (db *sqliteAdapter) CreateSession(ctx context.Context, session *models.Session) error {
+	return db.inTx(ctx, func(tx *sqliteTx) error {
+		_, err := tx.ExecContext(ctx, `delete from sessions
+				 where user_id = $1 and (expires_at <= `+sqliteNow+` or revoked_at is not null)`, session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to delete expired sessions: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `insert into sessions (id, user_id, token_hash, expires_at) values ($1, $2, $3, $4)`,
+			session.ID, session.UserID, session.TokenHash, session.ExpiresAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return nil
+	})
+}
+
+// RotateSession сравнивает срок действия сессии с текущим временем SQLite (sqliteNow).
+func (db *sqliteAdapter) RotateSession(ctx context.Context, tokenHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	var session models.Session
+	reused := false
+
+	err := db.inTx(ctx, func(tx *sqliteTx) error {
+		query := `update sessions set previous_hash = token_hash, token_hash = $2, expires_at = $3
+				 where token_hash = $1 and revoked_at is null and expires_at > ` + sqliteNow + `
+				 returning id, user_id, token_hash, expires_at`
+
+		err := tx.GetContext(ctx, &session, query, tokenHash, newHash, expiresAt.UTC())
+		if err == nil {
+			err = tx.GetContext(ctx, &session.Username, `select username from users where id = $1`, session.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to get session user: %w", err)
+			}
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to rotate session: %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx, `update sessions set revoked_at = `+sqliteNow+`
+				 where previous_hash = $1 and revoked_at is null`, tokenHash)
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		revoked, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		reused = revoked > 0
+		session = models.Session{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, utils.ErrTokenReused
+	}
+	if session.ID == "" {
+		return nil, utils.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// RevokeSession отмечает время отзыва сессии текущим временем SQLite.
+func (db *sqliteAdapter) RevokeSession(ctx context.Context, sessionID string) error {
+	_, err := db.conn.ExecContext(ctx, `update sessions set revoked_at = `+sqliteNow+` where id = $1 and revoked_at is null`,
+		sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionActive сравнивает срок действия сессии с текущим временем SQLite.
+func (db *sqliteAdapter) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+	query := `select exists (select 1 from sessions where id = $1 and revoked_at is null and expires_at > ` + sqliteNow + `)`
+
+	err := db.conn.GetContext(ctx, &active, query, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return active, nil
+}
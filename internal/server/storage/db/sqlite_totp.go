@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+// GetTOTP реализует Adapter.GetTOTP для SQLite.
+func (db *sqliteAdapter) GetTOTP(ctx context.Context, userId int64) (*models.TOTP, error) {
+	var totp models.TOTP
+	err := db.conn.GetContext(ctx, &totp, `select secret, enabled, last_counter from user_totp where user_id = $1`, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TOTP settings: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// SaveTOTPSecret реализует Adapter.SaveTOTPSecret для SQLite.
+func (db *sqliteAdapter) SaveTOTPSecret(ctx context.Context, userId int64, secret string) error {
+	query := `insert into user_totp (user_id, secret) values ($1, $2)
+			 on conflict (user_id) do update set secret = excluded.secret, last_counter = 0
+			 where not user_totp.enabled`
+
+	res, err := db.conn.ExecContext(ctx, query, userId, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	if saved == 0 {
+		return utils.ErrTOTPEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP реализует Adapter.EnableTOTP для SQLite.
+func (db *sqliteAdapter) EnableTOTP(ctx context.Context, userId int64, counter int64, recoveryHashes []string) error {
+	return db.inTx(ctx, func(tx *sqliteTx) error {
+		res, err := tx.ExecContext(ctx, `update user_totp set enabled = true, last_counter = $2
+				 where user_id = $1 and not enabled`, userId, counter)
+		if err != nil {
+			return fmt.Errorf("failed to enable TOTP: %w", err)
+		}
+		enabled, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to enable TOTP: %w", err)
+		}
+		if enabled == 0 {
+			return utils.ErrTOTPNotEnrolled
+		}
+
+		_, err = tx.ExecContext(ctx, `delete from recovery_codes where user_id = $1`, userId)
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		for _, hash := range recoveryHashes {
+			_, err = tx.ExecContext(ctx, `insert into recovery_codes (user_id, code_hash) values ($1, $2)`, userId, hash)
+			if err != nil {
+				return fmt.Errorf("failed to save recovery code: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DisableTOTP реализует Adapter.DisableTOTP для SQLite.
+func (db *sqliteAdapter) DisableTOTP(ctx context.Context, userId int64) error {
+	return db.inTx(ctx, func(tx *sqliteTx) error {
+		_, err := tx.ExecContext(ctx, `delete from recovery_codes where user_id = $1`, userId)
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `delete from user_totp where user_id = $1`, userId)
+		if err != nil {
+			return fmt.Errorf("failed to disable TOTP: %w", err)
+		}
+		return nil
+	})
+}
+
+// UseTOTPCounter реализует Adapter.UseTOTPCounter для SQLite.
+func (db *sqliteAdapter) UseTOTPCounter(ctx context.Context, userId int64, counter int64) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, `update user_totp set last_counter = $2
+			 where user_id = $1 and enabled and last_counter < $2`, userId, counter)
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+
+	used, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+
+	return used > 0, nil
+}
+
+// UseRecoveryCode реализует Adapter.UseRecoveryCode для SQLite.
+func (db *sqliteAdapter) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, `delete from recovery_codes where user_id = $1 and code_hash = $2`, userId, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	used, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return used > 0, nil
+}
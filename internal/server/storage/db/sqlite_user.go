@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+)
+
+// CreateUser реализует Adapter.CreateUser для SQLite.
+func (db *sqliteAdapter) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	query := `insert into users (username, password) values ($1, $2)
+			 on conflict (username) do update set password = excluded.password`
+
+	_, err := db.conn.ExecContext(ctx, query, user.Username, user.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserIDByName реализует Adapter.GetUserIDByName для SQLite.
+func (db *sqliteAdapter) GetUserIDByName(ctx context.Context, username string) (bool, error) {
+	var id int64
+	err := db.conn.GetContext(ctx, &id, `select id from users where username = $1`, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("error getting id users: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetUserHashPassword реализует Adapter.GetUserHashPassword для SQLite.
+func (db *sqliteAdapter) GetUserHashPassword(ctx context.Context, username string) (string, error) {
+	var password string
+	err := db.conn.GetContext(ctx, &password, `select password from users where username = $1`, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error getting password on user: %w", err)
+	}
+
+	return password, nil
+}
+
+// GetUserID реализует Adapter.GetUserID для SQLite.
+func (db *sqliteAdapter) GetUserID(ctx context.Context, username string) (int64, error) {
+	var id int64
+	err := db.conn.GetContext(ctx, &id, `select id from users where username = $1`, username)
+	if err != nil {
+		return 0, fmt.Errorf("unable select id: %w", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+func (db *dbAdapter) GetTOTP(ctx context.Context, userId int64) (*models.TOTP, error) {
+	var totp models.TOTP
+	err := db.conn.GetContext(ctx, &totp, `select secret, enabled, last_counter from user_totp where user_id = $1`, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TOTP settings: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// SaveTOTPSecret проверяет, что настройка не включена, и заменяет секрет атомарно: секрет, подтвержденный
+// одновременным EnableTOTP, не будет заменен.
+func (db *dbAdapter) SaveTOTPSecret(ctx context.Context, userId int64, secret string) error {
+	query := `insert into user_totp (user_id, secret) values ($1, $2)
+			 on conflict (user_id) do update set secret = excluded.secret, last_counter = 0
+			 where not user_totp.enabled`
+
+	res, err := db.conn.ExecContext(ctx, query, userId, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	if saved == 0 {
+		return utils.ErrTOTPEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP заменяет коды восстановления в одной транзакции с включением настройки.
+func (db *dbAdapter) EnableTOTP(ctx context.Context, userId int64, counter int64, recoveryHashes []string) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `update user_totp set enabled = true, last_counter = $2 where user_id = $1 and not enabled`,
+		userId, counter)
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	enabled, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	if enabled == 0 {
+		return utils.ErrTOTPNotEnrolled
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from recovery_codes where user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, hash := range recoveryHashes {
+		_, err = tx.ExecContext(ctx, `insert into recovery_codes (user_id, code_hash) values ($1, $2)`, userId, hash)
+		if err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP удаляет настройку и коды восстановления в одной транзакции.
+func (db *dbAdapter) DisableTOTP(ctx context.Context, userId int64) error {
+	tx, err := db.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `delete from recovery_codes where user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from user_totp where user_id = $1`, userId)
+	if err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPCounter проверяет и обновляет интервал атомарно: из одновременных запросов с кодом одного интервала
+// строку обновит только первый, а остальные после ожидания блокировки строки не пройдут условие.
+func (db *dbAdapter) UseTOTPCounter(ctx context.Context, userId int64, counter int64) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, `update user_totp set last_counter = $2
+			 where user_id = $1 and enabled and last_counter < $2`, userId, counter)
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+
+	used, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+
+	return used > 0, nil
+}
+
+// UseRecoveryCode засчитывает код только одному из одновременных запросов: остальные не найдут строку,
+// удаленную первым.
+func (db *dbAdapter) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	res, err := db.conn.ExecContext(ctx, `delete from recovery_codes where user_id = $1 and code_hash = $2`, userId, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	used, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return used > 0, nil
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Sofja96/GophKeeper.git/internal/models"
+	"github.com/Sofja96/GophKeeper.git/internal/server/utils"
+)
+
+func TestGetTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	query := `select secret, enabled, last_counter from user_totp where user_id = $1`
+
+	t.Run("GetTOTPSuccessfully", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"secret", "enabled", "last_counter"}).AddRow("SECRET", true, int64(42)))
+
+		totp, err := pg.GetTOTP(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.TOTP{Secret: "SECRET", Enabled: true, LastCounter: 42}, totp)
+	})
+
+	t.Run("GetTOTPNotEnrolled", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(1)).WillReturnError(sql.ErrNoRows)
+
+		_, err := pg.GetTOTP(context.Background(), 1)
+		assert.ErrorIs(t, err, utils.ErrTOTPNotEnrolled)
+	})
+
+	t.Run("GetTOTPError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(1)).WillReturnError(fmt.Errorf("connection lost"))
+
+		_, err := pg.GetTOTP(context.Background(), 1)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get TOTP settings")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveTOTPSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	query := `insert into user_totp (user_id, secret) values ($1, $2)
+			 on conflict (user_id) do update set secret = excluded.secret, last_counter = 0
+			 where not user_totp.enabled`
+
+	t.Run("SaveTOTPSecretSuccessfully", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), "SECRET").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := pg.SaveTOTPSecret(context.Background(), 1, "SECRET")
+		assert.NoError(t, err)
+	})
+
+	t.Run("SaveTOTPSecretAlreadyEnabled", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), "SECRET").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := pg.SaveTOTPSecret(context.Background(), 1, "SECRET")
+		assert.ErrorIs(t, err, utils.ErrTOTPEnabled)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnableTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	enableQuery := `update user_totp set enabled = true, last_counter = $2 where user_id = $1 and not enabled`
+	deleteQuery := `delete from recovery_codes where user_id = $1`
+	insertQuery := `insert into recovery_codes (user_id, code_hash) values ($1, $2)`
+
+	t.Run("EnableTOTPSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(enableQuery)).WithArgs(int64(1), int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(int64(1), "hash-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(int64(1), "hash-2").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := pg.EnableTOTP(context.Background(), 1, 100, []string{"hash-1", "hash-2"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("EnableTOTPNotEnrolled", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(enableQuery)).WithArgs(int64(1), int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := pg.EnableTOTP(context.Background(), 1, 100, []string{"hash-1"})
+		assert.ErrorIs(t, err, utils.ErrTOTPNotEnrolled)
+	})
+
+	t.Run("EnableTOTPInsertError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(enableQuery)).WithArgs(int64(1), int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(int64(1), "hash-1").WillReturnError(fmt.Errorf("duplicate key"))
+		mock.ExpectRollback()
+
+		err := pg.EnableTOTP(context.Background(), 1, 100, []string{"hash-1"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to save recovery code")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisableTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`delete from recovery_codes where user_id = $1`)).
+		WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`delete from user_totp where user_id = $1`)).
+		WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = pg.DisableTOTP(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseTOTPCounter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	query := `update user_totp set last_counter = $2
+			 where user_id = $1 and enabled and last_counter < $2`
+
+	t.Run("UseTOTPCounterAccepted", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), int64(101)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		used, err := pg.UseTOTPCounter(context.Background(), 1, 101)
+		assert.NoError(t, err)
+		assert.True(t, used)
+	})
+
+	t.Run("UseTOTPCounterReplayed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), int64(101)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		used, err := pg.UseTOTPCounter(context.Background(), 1, 101)
+		assert.NoError(t, err)
+		assert.False(t, used)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %v", err)
+	}
+	defer db.Close()
+
+	pg := dbAdapter{conn: sqlx.NewDb(db, "sqlmock")}
+	query := `delete from recovery_codes where user_id = $1 and code_hash = $2`
+
+	t.Run("UseRecoveryCodeSuccessfully", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		used, err := pg.UseRecoveryCode(context.Background(), 1, "hash")
+		assert.NoError(t, err)
+		assert.True(t, used)
+	})
+
+	t.Run("UseRecoveryCodeError", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(1), "hash").WillReturnError(fmt.Errorf("connection lost"))
+
+		_, err := pg.UseRecoveryCode(context.Background(), 1, "hash")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to use recovery code")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
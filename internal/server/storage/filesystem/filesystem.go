@@ -0,0 +1,224 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+)
+
+// tmpDir - каталог внутри корня хранилища для файлов, загрузка которых еще не завершена.
+const tmpDir = "tmp"
+
+// store реализует blobstore.Store для хранения файлов в локальном каталоге сервера.
+// Ключ файла соответствует пути относительно корня хранилища.
+type store struct {
+	root string
+}
+
+// New создает хранилище файлов в каталоге root, создавая каталог при необходимости.
+func New(root string) (blobstore.Store, error) {
+	if root == "" {
+		return nil, errors.New("blob storage path is empty")
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, tmpDir), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create blob storage directory: %w", err)
+	}
+
+	return &store{root: root}, nil
+}
+
+// UploadFile сохраняет файл пользователя в хранилище.
+//
+// Принимает ID пользователя и содержимое файла в виде байтов. Если такой файл у пользователя уже есть,
+// повторно он не записывается. Возвращает ключ файла или ошибку, если сохранить файл не удалось.
+func (s *store) UploadFile(ctx context.Context, userID int64, fileContent []byte) (string, error) {
+	return s.UploadStream(ctx, userID, bytes.NewReader(fileContent), int64(len(fileContent)))
+}
+
+// UploadStream потоково сохраняет файл пользователя в хранилище, не считывая его целиком в память.
+//
+// Ключ файла зависит от содержимого, которое становится известно только после чтения потока,
+// поэтому файл сначала записывается во временный файл и затем переименовывается.
+// Переименование атомарно, поэтому по ключу никогда не читается недописанный файл.
+// Возвращает ключ файла или ошибку, если сохранить файл не удалось.
+func (s *store) UploadStream(ctx context.Context, userID int64, reader io.Reader, _ int64) (string, error) {
+	tmpFile, err := os.CreateTemp(filepath.Join(s.root, tmpDir), "upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmpFile, hash), contextReader{ctx: ctx, reader: reader})
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	key := blobstore.ObjectKey(userID, hash.Sum(nil))
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create user directory: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+
+	return key, nil
+}
+
+// DownloadStream потоково записывает содержимое файла из хранилища в writer.
+//
+// Принимает ключ файла. Возвращает ошибку, если ключ некорректен, файл не удается прочитать или записать.
+func (s *store) DownloadStream(ctx context.Context, key string, writer io.Writer) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(writer, contextReader{ctx: ctx, reader: file}); err != nil {
+		return fmt.Errorf("failed to stream file content: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFile удаляет файл из хранилища по ключу. Отсутствие файла не считается ошибкой.
+func (s *store) DeleteFile(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// FileExists сообщает, есть ли в хранилище файл с ключом key. Возвращает ошибку, если ключ некорректен
+// или состояние файла не удается получить.
+func (s *store) FileExists(_ context.Context, key string) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check file: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetFile читает файл из хранилища по ключу и возвращает его содержимое в виде байтов.
+// Возвращает ошибку, если ключ некорректен или файл не удается прочитать.
+func (s *store) GetFile(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fileContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return fileContent, nil
+}
+
+// ListFiles вызывает fn для ключа каждого файла пользователей, измененного раньше modifiedBefore.
+// Временные файлы незавершенных загрузок в обход не попадают.
+func (s *store) ListFiles(ctx context.Context, modifiedBefore time.Time, fn func(key string) error) error {
+	usersDir := filepath.Join(s.root, "users")
+
+	err := filepath.WalkDir(usersDir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == usersDir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(modifiedBefore) {
+			return nil
+		}
+
+		relative, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+
+		return fn(filepath.ToSlash(relative))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return nil
+}
+
+// path возвращает путь к файлу по ключу. Ключи, выходящие за пределы корня хранилища, отклоняются.
+func (s *store) path(key string) (string, error) {
+	if key == "" {
+		return "", errors.New("object key is empty")
+	}
+
+	relative := filepath.FromSlash(key)
+	if !filepath.IsLocal(relative) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+
+	return filepath.Join(s.root, relative), nil
+}
+
+// contextReader прерывает чтение reader после отмены ctx.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,45 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore/blobstoretest"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	root := t.TempDir()
+
+	store, err := New(root)
+	require.NoError(t, err)
+
+	blobstoretest.Run(t, store)
+
+	// Временные файлы незавершенных и повторных загрузок удаляются
+	entries, err := os.ReadDir(filepath.Join(root, tmpDir))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStore_InvalidKey(t *testing.T) {
+	store, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"../outside", "/etc/passwd", "users/1/../../../outside"} {
+		_, err := store.GetFile(context.Background(), key)
+		assert.Error(t, err, key)
+		assert.Error(t, store.DownloadStream(context.Background(), key, io.Discard), key)
+		assert.Error(t, store.DeleteFile(context.Background(), key), key)
+	}
+}
+
+func TestNew_EmptyPath(t *testing.T) {
+	_, err := New("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package memblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+)
+
+// store реализует blobstore.Store, храня файлы в памяти процесса.
+// Используется в режиме разработки и в тестах, когда внешнее хранилище недоступно.
+type store struct {
+	mu    sync.RWMutex
+	files map[string]file
+}
+
+// file - содержимое файла и время его сохранения.
+type file struct {
+	content []byte
+	savedAt time.Time
+}
+
+// New создает пустое хранилище файлов в памяти.
+func New() blobstore.Store {
+	return &store{files: make(map[string]file)}
+}
+
+// UploadFile сохраняет файл пользователя в хранилище.
+//
+// Принимает ID пользователя и содержимое файла в виде байтов.
+// Возвращает ключ файла или ошибку, если сохранить файл не удалось.
+func (s *store) UploadFile(ctx context.Context, userID int64, fileContent []byte) (string, error) {
+	return s.UploadStream(ctx, userID, bytes.NewReader(fileContent), int64(len(fileContent)))
+}
+
+// UploadStream считывает файл пользователя из reader и сохраняет его в хранилище.
+// Возвращает ключ файла или ошибку, если прочитать файл не удалось.
+func (s *store) UploadStream(ctx context.Context, userID int64, reader io.Reader, _ int64) (string, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, contextReader{ctx: ctx, reader: reader}); err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	hash := sha256.Sum256(buf.Bytes())
+	key := blobstore.ObjectKey(userID, hash[:])
+
+	s.mu.Lock()
+	s.files[key] = file{content: buf.Bytes(), savedAt: time.Now()}
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+// DownloadStream записывает содержимое файла из хранилища в writer.
+// Возвращает ошибку, если файл не найден или записать его не удалось.
+func (s *store) DownloadStream(_ context.Context, key string, writer io.Writer) error {
+	fileContent, err := s.get(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(fileContent); err != nil {
+		return fmt.Errorf("failed to stream file content: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFile удаляет файл из хранилища по ключу. Отсутствие файла не считается ошибкой.
+func (s *store) DeleteFile(_ context.Context, key string) error {
+	if key == "" {
+		return errors.New("object key is empty")
+	}
+
+	s.mu.Lock()
+	delete(s.files, key)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// FileExists сообщает, есть ли в хранилище файл с ключом key.
+func (s *store) FileExists(_ context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, errors.New("object key is empty")
+	}
+
+	s.mu.RLock()
+	_, ok := s.files[key]
+	s.mu.RUnlock()
+
+	return ok, nil
+}
+
+// GetFile возвращает копию содержимого файла по ключу или ошибку, если файл не найден.
+func (s *store) GetFile(_ context.Context, key string) ([]byte, error) {
+	fileContent, err := s.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(fileContent), nil
+}
+
+// get возвращает содержимое файла по ключу. Содержимое не изменяется после загрузки,
+// поэтому его можно читать без блокировки.
+func (s *store) get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("object key is empty")
+	}
+
+	s.mu.RLock()
+	stored, ok := s.files[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("file %q not found", key)
+	}
+
+	return stored.content, nil
+}
+
+// ListFiles вызывает fn для ключа каждого файла, сохраненного раньше modifiedBefore.
+// Ключи выбираются до вызова fn, поэтому fn может изменять хранилище.
+func (s *store) ListFiles(ctx context.Context, modifiedBefore time.Time, fn func(key string) error) error {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.files))
+	for key, stored := range s.files {
+		if stored.savedAt.Before(modifiedBefore) {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// contextReader прерывает чтение reader после отмены ctx.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
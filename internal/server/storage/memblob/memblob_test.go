@@ -0,0 +1,11 @@
+package memblob
+
+import (
+	"testing"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore/blobstoretest"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	blobstoretest.Run(t, New())
+}
@@ -3,32 +3,32 @@ package minio
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 
 	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
 )
 
-// Client представляет интерфейс для работы с MinIO: загрузка, получение, обновление и удаление файлов.
-type Client interface {
-	UploadFile(ctx context.Context, fileName string, fileContent []byte) (string, error)
-	DeleteFile(ctx context.Context, fileURL string) error
-	GetFile(ctx context.Context, fileURL string) ([]byte, error)
-	UpdateFile(ctx context.Context, oldFileName, fileName string, content []byte) (string, error)
-}
+// streamPartSize - размер части multipart-загрузки, если размер потока заранее неизвестен.
+const streamPartSize = 16 << 20
 
+// client реализует blobstore.Store для хранения файлов в bucket MinIO.
 type client struct {
 	Client *minio.Client
 	Bucket string
 }
 
 // NewMinioClient Инициализация клиента MinIO
-func NewMinioClient(settings *settings.Settings) (Client, error) {
+func NewMinioClient(settings *settings.Settings) (blobstore.Store, error) {
 	minioClient, err := minio.New(settings.MinioEndpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(settings.MinioUser, settings.MinioPassword, ""),
 		Secure: settings.MinioUseSsl,
@@ -56,44 +56,132 @@ func NewMinioClient(settings *settings.Settings) (Client, error) {
 	}, nil
 }
 
-// UploadFile загружает файл в MinIO.
+// UploadFile загружает файл пользователя в MinIO.
 //
-// Принимает имя файла и его содержимое в виде байтов. Возвращает URL загруженного файла или ошибку,
-// если загрузка не удалась.
-func (m *client) UploadFile(ctx context.Context, fileName string, fileContent []byte) (string, error) {
-	objectName := fmt.Sprintf("uploads/%s", fileName)
-	_, err := m.Client.PutObject(ctx, m.Bucket, objectName, bytes.NewReader(fileContent), int64(len(fileContent)), minio.PutObjectOptions{})
+// Принимает ID пользователя и содержимое файла в виде байтов. Если такой файл у пользователя уже есть,
+// повторно он не загружается. Возвращает ключ объекта или ошибку, если загрузка не удалась.
+func (m *client) UploadFile(ctx context.Context, userID int64, fileContent []byte) (string, error) {
+	hash := sha256.Sum256(fileContent)
+	key := blobstore.ObjectKey(userID, hash[:])
+
+	exists, err := m.objectExists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return key, nil
+	}
+
+	_, err = m.Client.PutObject(ctx, m.Bucket, key, bytes.NewReader(fileContent), int64(len(fileContent)), minio.PutObjectOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	fileURL := fmt.Sprintf("%s/%s/%s", m.Client.EndpointURL(), m.Bucket, objectName)
-	return fileURL, nil
+	return key, nil
+}
+
+// UploadStream потоково загружает файл пользователя в MinIO, не считывая его целиком в память.
+//
+// Ключ объекта зависит от содержимого, которое становится известно только после чтения потока,
+// поэтому файл сначала загружается под временным ключом и затем копируется на стороне MinIO.
+// Если такой файл у пользователя уже есть, копирование пропускается.
+// Если размер содержимого неизвестен (size <= 0), файл загружается частями фиксированного размера.
+// Возвращает ключ объекта или ошибку, если загрузка не удалась.
+func (m *client) UploadStream(ctx context.Context, userID int64, reader io.Reader, size int64) (string, error) {
+	tempKey, err := tempObjectKey(userID)
+	if err != nil {
+		return "", err
+	}
+
+	opts := minio.PutObjectOptions{}
+	if size <= 0 {
+		size = -1
+		opts.PartSize = streamPartSize
+	}
+
+	hash := sha256.New()
+	_, err = m.Client.PutObject(ctx, m.Bucket, tempKey, io.TeeReader(reader, hash), size, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer func() {
+		_ = m.Client.RemoveObject(context.WithoutCancel(ctx), m.Bucket, tempKey, minio.RemoveObjectOptions{})
+	}()
+
+	key := blobstore.ObjectKey(userID, hash.Sum(nil))
+
+	exists, err := m.objectExists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return key, nil
+	}
+
+	_, err = m.Client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: m.Bucket, Object: key},
+		minio.CopySrcOptions{Bucket: m.Bucket, Object: tempKey})
+	if err != nil {
+		return "", fmt.Errorf("failed to copy uploaded file: %w", err)
+	}
+
+	return key, nil
+}
+
+// DownloadStream потоково записывает содержимое файла из MinIO в writer.
+//
+// Принимает ключ объекта. Возвращает ошибку, если ключ пуст, файл не удается получить или записать.
+func (m *client) DownloadStream(ctx context.Context, key string, writer io.Writer) error {
+	if key == "" {
+		return fmt.Errorf("object key is empty")
+	}
+
+	object, err := m.Client.GetObject(ctx, m.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get file from MinIO: %w", err)
+	}
+	defer object.Close()
+
+	if _, err := io.Copy(writer, object); err != nil {
+		return fmt.Errorf("failed to stream file content: %w", err)
+	}
+
+	return nil
 }
 
-// DeleteFile удаляет файл из MinIO по его URL.
+// DeleteFile удаляет файл из MinIO по ключу объекта.
 //
-// Принимает URL файла и удаляет его из хранилища MinIO. Возвращает ошибку, если файл не удается удалить.
-func (m *client) DeleteFile(ctx context.Context, fileURL string) error {
-	objectName := strings.TrimPrefix(fileURL, fmt.Sprintf("%s/%s/", m.Client.EndpointURL(), m.Bucket))
-	err := m.Client.RemoveObject(ctx, m.Bucket, objectName, minio.RemoveObjectOptions{})
+// Возвращает ошибку, если файл не удается удалить.
+func (m *client) DeleteFile(ctx context.Context, key string) error {
+	err := m.Client.RemoveObject(ctx, m.Bucket, key, minio.RemoveObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
+
 	return nil
 }
 
-// GetFile загружает файл из MinIO по его URL.
+// FileExists сообщает, есть ли в MinIO объект с ключом key.
+//
+// Возвращает ошибку, если ключ пуст или состояние объекта не удается получить.
+func (m *client) FileExists(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("object key is empty")
+	}
+
+	return m.objectExists(ctx, key)
+}
+
+// GetFile загружает файл из MinIO по ключу объекта.
 //
-// Принимает URL файла, извлекает содержимое и возвращает его в виде байтов. Возвращает ошибку,
-// если файл не удается получить или URL пуст.
-func (m *client) GetFile(ctx context.Context, fileURL string) ([]byte, error) {
-	if fileURL == "" {
-		return nil, fmt.Errorf("fileURL is empty")
+// Извлекает содержимое и возвращает его в виде байтов. Возвращает ошибку,
+// если файл не удается получить или ключ пуст.
+func (m *client) GetFile(ctx context.Context, key string) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("object key is empty")
 	}
-	objectName := strings.TrimPrefix(fileURL, fmt.Sprintf("%s/%s/", m.Client.EndpointURL(), m.Bucket))
 
-	object, err := m.Client.GetObject(ctx, m.Bucket, objectName, minio.GetObjectOptions{})
+	object, err := m.Client.GetObject(ctx, m.Bucket, key, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file from MinIO: %w", err)
 	}
@@ -107,28 +195,46 @@ func (m *client) GetFile(ctx context.Context, fileURL string) ([]byte, error) {
 	return fileContent, nil
 }
 
-// UpdateFile обновляет файл в MinIO, если имя файла изменилось.
-//
-// Если имя старого файла совпадает с новым, то файл просто загружается заново, иначе старый файл удаляется,
-// а новый загружается в хранилище. Возвращает URL нового файла или ошибку.
-func (m *client) UpdateFile(ctx context.Context, oldFileName, fileName string, content []byte) (string, error) {
-	oldFileName = strings.TrimPrefix(oldFileName, fmt.Sprintf("%s/%s/", m.Client.EndpointURL(), m.Bucket))
-	if oldFileName == fileName {
-		FileUrl, err := m.UploadFile(ctx, fileName, content)
-		if err != nil {
-			return "", err
+// ListFiles вызывает fn для ключа каждого объекта пользователей в bucket, измененного раньше modifiedBefore.
+// В обход попадают и временные объекты незавершенных потоковых загрузок.
+func (m *client) ListFiles(ctx context.Context, modifiedBefore time.Time, fn func(key string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range m.Client.ListObjects(ctx, m.Bucket, minio.ListObjectsOptions{Prefix: "users/", Recursive: true}) {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list files: %w", object.Err)
+		}
+		if !object.LastModified.Before(modifiedBefore) {
+			continue
+		}
+		if err := fn(object.Key); err != nil {
+			return err
 		}
-		return FileUrl, nil
 	}
 
-	err := m.DeleteFile(ctx, oldFileName)
-	if err != nil {
-		return "", err
+	return nil
+}
+
+// objectExists проверяет, есть ли в MinIO объект с ключом key.
+func (m *client) objectExists(ctx context.Context, key string) (bool, error) {
+	_, err := m.Client.StatObject(ctx, m.Bucket, key, minio.StatObjectOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
 	}
 
-	fileUrl, err := m.UploadFile(ctx, fileName, content)
-	if err != nil {
-		return "", err
+	return false, fmt.Errorf("failed to check file: %w", err)
+}
+
+// tempObjectKey формирует уникальный временный ключ для потоковой загрузки: users/<ID пользователя>/tmp/<случайный суффикс>.
+func tempObjectKey(userID int64) (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate object name: %w", err)
 	}
-	return fileUrl, nil
+
+	return fmt.Sprintf("users/%d/tmp/%s", userID, hex.EncodeToString(suffix)), nil
 }
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/minio/minio-go/v7"
@@ -17,6 +18,7 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore/blobstoretest"
 )
 
 func TestFile_Integration(t *testing.T) {
@@ -37,94 +39,110 @@ func TestFile_Integration(t *testing.T) {
 	}
 
 	t.Run("successful upload", func(t *testing.T) {
-		fileName := "test.txt"
 		content := []byte("Hello, MinIO!")
-		expectedObjectName := "uploads/test.txt"
 
-		url, err := client.UploadFile(ctx, fileName, content)
+		key, err := client.UploadFile(ctx, 1, content)
 		assert.NoError(t, err)
+		assert.Equal(t, "users/1/799821cbf116daeef32f761fbfdde16394ad490ee6c231ca9ab0b13ef9d99692", key)
 
-		expectedURL := fmt.Sprintf("%s/%s/%s", minioClient.EndpointURL(), bucketName, expectedObjectName)
-		assert.Equal(t, expectedURL, url)
-
-		_, err = minioClient.StatObject(ctx, bucketName, expectedObjectName, minio.StatObjectOptions{})
+		_, err = minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
 		assert.NoError(t, err)
 	})
 
 	t.Run("successful get", func(t *testing.T) {
-		fileName := "test.txt"
 		content := []byte("Hello, MinIO!")
-		expectedObjectName := "uploads/test.txt"
 
-		_, err := client.UploadFile(ctx, fileName, content)
+		key, err := client.UploadFile(ctx, 1, content)
 		assert.NoError(t, err)
 
-		FileUrl := fmt.Sprintf("%s/%s/%s", minioClient.EndpointURL(), bucketName, expectedObjectName)
-
-		fileContent, err := client.GetFile(ctx, FileUrl)
+		fileContent, err := client.GetFile(ctx, key)
 		log.Println("file_content", fileContent)
 		assert.NoError(t, err)
 
 		expectedContent := []byte("Hello, MinIO!")
 		assert.Equal(t, expectedContent, fileContent)
-
-		_, err = minioClient.StatObject(ctx, bucketName, expectedObjectName, minio.StatObjectOptions{})
-		assert.NoError(t, err)
 	})
 
 	t.Run("successful delete", func(t *testing.T) {
-		fileName := "test.txt"
 		content := []byte("Hello, MinIO!")
-		expectedObjectName := "uploads/test.txt"
 
-		_, err := client.UploadFile(ctx, fileName, content)
+		key, err := client.UploadFile(ctx, 1, content)
 		assert.NoError(t, err)
 
-		FileUrl := fmt.Sprintf("%s/%s/%s", minioClient.EndpointURL(), bucketName, expectedObjectName)
-
-		err = client.DeleteFile(ctx, FileUrl)
+		err = client.DeleteFile(ctx, key)
 		assert.NoError(t, err)
 
-		_, err = minioClient.StatObject(ctx, bucketName, expectedObjectName, minio.StatObjectOptions{})
+		_, err = minioClient.StatObject(ctx, bucketName, key, minio.StatObjectOptions{})
 		assert.Error(t, err)
 	})
 
-	t.Run("successful update", func(t *testing.T) {
-		fileName := "test.txt"
+	t.Run("same content is stored once per user", func(t *testing.T) {
 		content := []byte("Hello, MinIO!")
-		newContent := []byte("Hello, New MinIO!")
-		expectedObjectName := "uploads/new_file.txt"
 
-		_, err := client.UploadFile(ctx, fileName, content)
+		firstKey, err := client.UploadFile(ctx, 1, content)
 		assert.NoError(t, err)
 
-		fileUrl, err := client.UpdateFile(ctx, fileName, "new_file.txt", newContent)
+		secondKey, err := client.UploadStream(ctx, 1, bytes.NewReader(content), int64(len(content)))
 		assert.NoError(t, err)
+		assert.Equal(t, firstKey, secondKey)
 
-		expectedURL := fmt.Sprintf("%s/%s/%s", minioClient.EndpointURL(), bucketName, expectedObjectName)
-		assert.Equal(t, expectedURL, fileUrl)
-
-		_, err = minioClient.StatObject(ctx, bucketName, expectedObjectName, minio.StatObjectOptions{})
+		otherUserKey, err := client.UploadFile(ctx, 2, content)
 		assert.NoError(t, err)
+		assert.NotEqual(t, firstKey, otherUserKey)
+		assert.True(t, strings.HasPrefix(otherUserKey, "users/2/"))
 	})
 
-	t.Run("successful update with exists filename", func(t *testing.T) {
-		fileName := "test.txt"
+	t.Run("upload with new content keeps previous version", func(t *testing.T) {
 		content := []byte("Hello, MinIO!")
 		newContent := []byte("Hello, New MinIO!")
-		expectedObjectName := "uploads/test.txt"
 
-		_, err := client.UploadFile(ctx, fileName, content)
+		oldKey, err := client.UploadFile(ctx, 1, content)
+		assert.NoError(t, err)
+
+		newKey, err := client.UploadFile(ctx, 1, newContent)
 		assert.NoError(t, err)
+		assert.NotEqual(t, oldKey, newKey)
 
-		fileUrl, err := client.UpdateFile(ctx, fileName, "test.txt", newContent)
+		oldContent, err := client.GetFile(ctx, oldKey)
 		assert.NoError(t, err)
+		assert.Equal(t, content, oldContent)
+
+		fileContent, err := client.GetFile(ctx, newKey)
+		assert.NoError(t, err)
+		assert.Equal(t, newContent, fileContent)
+	})
 
-		expectedURL := fmt.Sprintf("%s/%s/%s", minioClient.EndpointURL(), bucketName, expectedObjectName)
-		assert.Equal(t, expectedURL, fileUrl)
+	t.Run("successful stream upload and download", func(t *testing.T) {
+		content := bytes.Repeat([]byte("stream"), 1024)
 
-		_, err = minioClient.StatObject(ctx, bucketName, expectedObjectName, minio.StatObjectOptions{})
+		key, err := client.UploadStream(ctx, 1, bytes.NewReader(content), int64(len(content)))
 		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = client.DownloadStream(ctx, key, &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, content, buf.Bytes())
+
+		objects := minioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: "users/1/tmp/", Recursive: true})
+		for object := range objects {
+			t.Errorf("temporary object %s was not removed", object.Key)
+		}
+	})
+
+	t.Run("successful stream upload with unknown size", func(t *testing.T) {
+		content := []byte("Hello, stream!")
+
+		key, err := client.UploadStream(ctx, 1, bytes.NewReader(content), -1)
+		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = client.DownloadStream(ctx, key, &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, content, buf.Bytes())
+	})
+
+	t.Run("conformance", func(t *testing.T) {
+		blobstoretest.Run(t, client)
 	})
 }
 
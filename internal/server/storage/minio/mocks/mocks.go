@@ -1,94 +0,0 @@
-// Code generated by MockGen. DO NOT EDIT.
-// Source: ./internal/server/storage/minio/minio.go
-
-// Package mock_minio is a generated GoMock package.
-package mock_minio
-
-import (
-	context "context"
-	reflect "reflect"
-
-	gomock "github.com/golang/mock/gomock"
-)
-
-// MockClient is a mock of Client interface.
-type MockClient struct {
-	ctrl     *gomock.Controller
-	recorder *MockClientMockRecorder
-}
-
-// MockClientMockRecorder is the mock recorder for MockClient.
-type MockClientMockRecorder struct {
-	mock *MockClient
-}
-
-// NewMockClient creates a new mock instance.
-func NewMockClient(ctrl *gomock.Controller) *MockClient {
-	mock := &MockClient{ctrl: ctrl}
-	mock.recorder = &MockClientMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockClient) EXPECT() *MockClientMockRecorder {
-	return m.recorder
-}
-
-// DeleteFile mocks base method.
-func (m *MockClient) DeleteFile(ctx context.Context, fileURL string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteFile", ctx, fileURL)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// DeleteFile indicates an expected call of DeleteFile.
-func (mr *MockClientMockRecorder) DeleteFile(ctx, fileURL interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockClient)(nil).DeleteFile), ctx, fileURL)
-}
-
-// GetFile mocks base method.
-func (m *MockClient) GetFile(ctx context.Context, fileURL string) ([]byte, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFile", ctx, fileURL)
-	ret0, _ := ret[0].([]byte)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetFile indicates an expected call of GetFile.
-func (mr *MockClientMockRecorder) GetFile(ctx, fileURL interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockClient)(nil).GetFile), ctx, fileURL)
-}
-
-// UpdateFile mocks base method.
-func (m *MockClient) UpdateFile(ctx context.Context, oldFileName, fileName string, content []byte) (string, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateFile", ctx, oldFileName, fileName, content)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// UpdateFile indicates an expected call of UpdateFile.
-func (mr *MockClientMockRecorder) UpdateFile(ctx, oldFileName, fileName, content interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockClient)(nil).UpdateFile), ctx, oldFileName, fileName, content)
-}
-
-// UploadFile mocks base method.
-func (m *MockClient) UploadFile(ctx context.Context, fileName string, fileContent []byte) (string, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UploadFile", ctx, fileName, fileContent)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// UploadFile indicates an expected call of UploadFile.
-func (mr *MockClientMockRecorder) UploadFile(ctx, fileName, fileContent interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockClient)(nil).UploadFile), ctx, fileName, fileContent)
-}
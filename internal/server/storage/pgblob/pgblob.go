@@ -0,0 +1,239 @@
+package pgblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+)
+
+// chunkSize - размер части файла, которой содержимое large object читается и записывается за один запрос.
+const chunkSize = 1 << 20
+
+// store реализует blobstore.Store для хранения файлов в PostgreSQL.
+// Содержимое файла хранится как large object, а таблица blob_contents связывает ключ файла с ним.
+type store struct {
+	conn *sqlx.DB
+}
+
+// New подключается к базе данных из настроек и создает хранилище файлов в ней.
+// Таблица blob_contents создается миграциями базы данных.
+func New(settings *settings.Settings) (blobstore.Store, error) {
+	conn, err := sqlx.Connect("postgres", settings.DbDsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to blob storage database: %w", err)
+	}
+
+	return &store{conn: conn}, nil
+}
+
+// UploadFile сохраняет файл пользователя в базе данных.
+//
+// Принимает ID пользователя и содержимое файла в виде байтов. Если такой файл у пользователя уже есть,
+// повторно он не сохраняется. Возвращает ключ файла или ошибку, если сохранить файл не удалось.
+func (s *store) UploadFile(ctx context.Context, userID int64, fileContent []byte) (string, error) {
+	return s.UploadStream(ctx, userID, bytes.NewReader(fileContent), int64(len(fileContent)))
+}
+
+// UploadStream потоково сохраняет файл пользователя в базе данных частями, не считывая его целиком в память.
+//
+// Ключ файла зависит от содержимого, которое становится известно только после чтения потока,
+// поэтому содержимое сначала записывается в новый large object, а затем регистрируется под ключом.
+// Если такой файл у пользователя уже есть, новый large object удаляется. Все выполняется в одной транзакции,
+// поэтому при ошибке в базе данных не остается частично записанных файлов.
+func (s *store) UploadStream(ctx context.Context, userID int64, reader io.Reader, _ int64) (string, error) {
+	tx, err := s.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oid int64
+	if err := tx.GetContext(ctx, &oid, `select lo_create(0)`); err != nil {
+		return "", fmt.Errorf("failed to create large object: %w", err)
+	}
+
+	hash := sha256.New()
+	buf := make([]byte, chunkSize)
+	var size int64
+	for {
+		n, readErr := io.ReadFull(reader, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			if _, err := tx.ExecContext(ctx, `select lo_put($1, $2, $3)`, oid, size, buf[:n]); err != nil {
+				return "", fmt.Errorf("failed to upload file: %w", err)
+			}
+			size += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to upload file: %w", readErr)
+		}
+	}
+
+	key := blobstore.ObjectKey(userID, hash.Sum(nil))
+
+	res, err := tx.ExecContext(ctx,
+		`insert into blob_contents (object_key, content_oid, size) values ($1, $2, $3) on conflict (object_key) do nothing`,
+		key, oid, size)
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	// Такой файл уже сохранен, новая копия содержимого не нужна
+	if inserted == 0 {
+		if _, err := tx.ExecContext(ctx, `select lo_unlink($1)`, oid); err != nil {
+			return "", fmt.Errorf("failed to delete duplicate file: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return key, nil
+}
+
+// DownloadStream потоково записывает содержимое файла из базы данных в writer частями.
+//
+// Принимает ключ файла. Возвращает ошибку, если ключ пуст, файл не найден, не удается прочитать или записать.
+func (s *store) DownloadStream(ctx context.Context, key string, writer io.Writer) error {
+	if key == "" {
+		return errors.New("object key is empty")
+	}
+
+	// Части читаются в одной транзакции, чтобы файл не был удален между запросами
+	tx, err := s.conn.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var content struct {
+		OID  int64 `db:"content_oid"`
+		Size int64 `db:"size"`
+	}
+	err = tx.GetContext(ctx, &content, `select content_oid, size from blob_contents where object_key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("file %s not found", key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+
+	for offset := int64(0); offset < content.Size; offset += chunkSize {
+		var chunk []byte
+		if err := tx.GetContext(ctx, &chunk, `select lo_get($1, $2, $3)`, content.OID, offset, chunkSize); err != nil {
+			return fmt.Errorf("failed to read file content: %w", err)
+		}
+		if _, err := writer.Write(chunk); err != nil {
+			return fmt.Errorf("failed to stream file content: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFile удаляет файл из базы данных по ключу. Отсутствие файла не считается ошибкой.
+func (s *store) DeleteFile(ctx context.Context, key string) error {
+	tx, err := s.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oid int64
+	err = tx.GetContext(ctx, &oid, `delete from blob_contents where object_key = $1 returning content_oid`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `select lo_unlink($1)`, oid); err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FileExists сообщает, есть ли в базе данных файл с ключом key. Возвращает ошибку, если ключ пуст.
+func (s *store) FileExists(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, errors.New("object key is empty")
+	}
+
+	var exists bool
+	err := s.conn.GetContext(ctx, &exists, `select exists (select 1 from blob_contents where object_key = $1)`, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to check file: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListFiles вызывает fn для ключа каждого файла, сохраненного в базе данных раньше modifiedBefore.
+func (s *store) ListFiles(ctx context.Context, modifiedBefore time.Time, fn func(key string) error) error {
+	rows, err := s.conn.QueryContext(ctx, `select object_key from blob_contents where created_at < $1`, modifiedBefore)
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return nil
+}
+
+// GetFile загружает файл из базы данных по ключу и возвращает его содержимое в виде байтов.
+// Возвращает ошибку, если ключ пуст, файл не найден или его не удается прочитать.
+func (s *store) GetFile(ctx context.Context, key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("object key is empty")
+	}
+
+	var fileContent []byte
+	err := s.conn.GetContext(ctx, &fileContent,
+		`select lo_get(content_oid) from blob_contents where object_key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("file %s not found", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return fileContent, nil
+}
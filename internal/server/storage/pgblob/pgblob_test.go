@@ -0,0 +1,230 @@
+package pgblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/Sofja96/GophKeeper.git/internal/server/settings"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore"
+	"github.com/Sofja96/GophKeeper.git/internal/server/storage/blobstore/blobstoretest"
+)
+
+const insertQuery = `insert into blob_contents (object_key, content_oid, size) values ($1, $2, $3) on conflict (object_key) do nothing`
+
+func TestUploadStream(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &store{conn: sqlx.NewDb(db, "sqlmock")}
+	content := []byte("file content")
+	hash := sha256.Sum256(content)
+	key := blobstore.ObjectKey(1, hash[:])
+
+	t.Run("UploadStreamSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`select lo_create(0)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"lo_create"}).AddRow(42))
+		mock.ExpectExec(regexp.QuoteMeta(`select lo_put($1, $2, $3)`)).
+			WithArgs(int64(42), int64(0), content).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+			WithArgs(key, int64(42), int64(len(content))).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := s.UploadStream(context.Background(), 1, bytes.NewReader(content), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, key, got)
+	})
+
+	t.Run("UploadStreamDuplicate", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`select lo_create(0)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"lo_create"}).AddRow(43))
+		mock.ExpectExec(regexp.QuoteMeta(`select lo_put($1, $2, $3)`)).
+			WithArgs(int64(43), int64(0), content).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+			WithArgs(key, int64(43), int64(len(content))).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`select lo_unlink($1)`)).
+			WithArgs(int64(43)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := s.UploadFile(context.Background(), 1, content)
+		assert.NoError(t, err)
+		assert.Equal(t, key, got)
+	})
+
+	t.Run("UploadStreamWriteError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`select lo_create(0)`)).
+			WillReturnRows(sqlmock.NewRows([]string{"lo_create"}).AddRow(44))
+		mock.ExpectExec(regexp.QuoteMeta(`select lo_put($1, $2, $3)`)).
+			WillReturnError(fmt.Errorf("connection error"))
+		mock.ExpectRollback()
+
+		_, err := s.UploadStream(context.Background(), 1, bytes.NewReader(content), int64(len(content)))
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &store{conn: sqlx.NewDb(db, "sqlmock")}
+	deleteQuery := `delete from blob_contents where object_key = $1 returning content_oid`
+
+	t.Run("DeleteFileSuccessfully", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(deleteQuery)).
+			WithArgs("users/1/abc").
+			WillReturnRows(sqlmock.NewRows([]string{"content_oid"}).AddRow(42))
+		mock.ExpectExec(regexp.QuoteMeta(`select lo_unlink($1)`)).
+			WithArgs(int64(42)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, s.DeleteFile(context.Background(), "users/1/abc"))
+	})
+
+	t.Run("DeleteMissingFile", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(deleteQuery)).
+			WithArgs("users/1/abc").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		assert.NoError(t, s.DeleteFile(context.Background(), "users/1/abc"))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFileExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &store{conn: sqlx.NewDb(db, "sqlmock")}
+	existsQuery := `select exists (select 1 from blob_contents where object_key = $1)`
+
+	t.Run("FileExists", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("users/1/abc").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		exists, err := s.FileExists(context.Background(), "users/1/abc")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("FileExistsError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).
+			WithArgs("users/1/abc").
+			WillReturnError(fmt.Errorf("db error"))
+
+		_, err := s.FileExists(context.Background(), "users/1/abc")
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := &store{conn: sqlx.NewDb(db, "sqlmock")}
+	getQuery := `select lo_get(content_oid) from blob_contents where object_key = $1`
+
+	t.Run("GetFileSuccessfully", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+			WithArgs("users/1/abc").
+			WillReturnRows(sqlmock.NewRows([]string{"lo_get"}).AddRow([]byte("file content")))
+
+		content, err := s.GetFile(context.Background(), "users/1/abc")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("file content"), content)
+	})
+
+	t.Run("FileNotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+			WithArgs("users/1/abc").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := s.GetFile(context.Background(), "users/1/abc")
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Conformance(t *testing.T) {
+	skipWithoutDocker(t)
+
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:16-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_USER":     "keeper",
+				"POSTGRES_PASSWORD": "secret",
+				"POSTGRES_DB":       "keeper",
+			},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	defer func() { _ = container.Terminate(ctx) }()
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	s, err := New(&settings.Settings{
+		DbDsn: fmt.Sprintf("postgres://keeper:secret@%s:%s/keeper?sslmode=disable", host, port.Port()),
+	})
+	require.NoError(t, err)
+
+	migration, err := os.ReadFile("../db/migrations/000011_blob_contents.up.sql")
+	require.NoError(t, err)
+	_, err = s.(*store).conn.ExecContext(ctx, string(migration))
+	require.NoError(t, err)
+
+	blobstoretest.Run(t, s)
+}
+
+// skipWithoutDocker пропускает тест, если Docker недоступен: testcontainers в этом случае завершается паникой.
+func skipWithoutDocker(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
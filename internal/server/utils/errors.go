@@ -1,8 +1,58 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUserExists       = errors.New("user already exists")
 	ErrUserDataNotFound = errors.New("no data found")
+	ErrNotBinaryData    = errors.New("data is not binary")
+	ErrDataExists       = errors.New("data with this ID already exists")
+	ErrVersionConflict  = errors.New("data version conflict")
+	ErrCursorExpired    = errors.New("sync cursor expired")
+	ErrBlobNotFound     = errors.New("blob not found")
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrTokenReused      = errors.New("refresh token reused")
+	// ErrInvalidCredentials возвращается и для неизвестного пользователя, и для неверного пароля,
+	// чтобы по ответу нельзя было узнать, зарегистрировано ли имя.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrTOTPEnabled        = errors.New("two-factor authentication already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not set up")
 )
+
+// VersionConflictError сообщает, что ожидаемая версия данных не совпала с текущей версией в базе данных.
+// Сравнивается с ErrVersionConflict через errors.Is.
+type VersionConflictError struct {
+	CurrentVersion int64
+}
+
+// Error возвращает описание конфликта с текущей версией данных.
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: current version %d", ErrVersionConflict, e.CurrentVersion)
+}
+
+// Is позволяет сравнивать ошибку с ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// OperationError сообщает, какая операция пакетного изменения данных не выполнена.
+// Исходная ошибка операции доступна через errors.Is и errors.As.
+type OperationError struct {
+	Index int   // номер операции в пакете, начиная с 0
+	Err   error // ошибка операции
+}
+
+// Error возвращает описание ошибки с номером операции.
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+// Unwrap возвращает ошибку операции.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
@@ -37,6 +37,46 @@ func (m *MockGophKeeperClient) EXPECT() *MockGophKeeperClientMockRecorder {
 	return m.recorder
 }
 
+// BatchMutate mocks base method.
+func (m *MockGophKeeperClient) BatchMutate(ctx context.Context, in *proto.BatchMutateRequest, opts ...grpc.CallOption) (*proto.BatchMutateResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BatchMutate", varargs...)
+	ret0, _ := ret[0].(*proto.BatchMutateResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchMutate indicates an expected call of BatchMutate.
+func (mr *MockGophKeeperClientMockRecorder) BatchMutate(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchMutate", reflect.TypeOf((*MockGophKeeperClient)(nil).BatchMutate), varargs...)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockGophKeeperClient) ConfirmTOTP(ctx context.Context, in *proto.ConfirmTOTPRequest, opts ...grpc.CallOption) (*proto.ConfirmTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmTOTP", varargs...)
+	ret0, _ := ret[0].(*proto.ConfirmTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockGophKeeperClientMockRecorder) ConfirmTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockGophKeeperClient)(nil).ConfirmTOTP), varargs...)
+}
+
 // CreateData mocks base method.
 func (m *MockGophKeeperClient) CreateData(ctx context.Context, in *proto.CreateDataRequest, opts ...grpc.CallOption) (*proto.CreateDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -77,6 +117,86 @@ func (mr *MockGophKeeperClientMockRecorder) DeleteData(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockGophKeeperClient)(nil).DeleteData), varargs...)
 }
 
+// DisableTOTP mocks base method.
+func (m *MockGophKeeperClient) DisableTOTP(ctx context.Context, in *proto.DisableTOTPRequest, opts ...grpc.CallOption) (*proto.DisableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableTOTP", varargs...)
+	ret0, _ := ret[0].(*proto.DisableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockGophKeeperClientMockRecorder) DisableTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockGophKeeperClient)(nil).DisableTOTP), varargs...)
+}
+
+// DownloadBlob mocks base method.
+func (m *MockGophKeeperClient) DownloadBlob(ctx context.Context, in *proto.DownloadBlobRequest, opts ...grpc.CallOption) (proto.GophKeeper_FetchBlobClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DownloadBlob", varargs...)
+	ret0, _ := ret[0].(proto.GophKeeper_FetchBlobClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadBlob indicates an expected call of DownloadBlob.
+func (mr *MockGophKeeperClientMockRecorder) DownloadBlob(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBlob", reflect.TypeOf((*MockGophKeeperClient)(nil).DownloadBlob), varargs...)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockGophKeeperClient) EnableTOTP(ctx context.Context, in *proto.EnableTOTPRequest, opts ...grpc.CallOption) (*proto.EnableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EnableTOTP", varargs...)
+	ret0, _ := ret[0].(*proto.EnableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockGophKeeperClientMockRecorder) EnableTOTP(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockGophKeeperClient)(nil).EnableTOTP), varargs...)
+}
+
+// FetchBlob mocks base method.
+func (m *MockGophKeeperClient) FetchBlob(ctx context.Context, in *proto.FetchBlobRequest, opts ...grpc.CallOption) (proto.GophKeeper_FetchBlobClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FetchBlob", varargs...)
+	ret0, _ := ret[0].(proto.GophKeeper_FetchBlobClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchBlob indicates an expected call of FetchBlob.
+func (mr *MockGophKeeperClientMockRecorder) FetchBlob(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlob", reflect.TypeOf((*MockGophKeeperClient)(nil).FetchBlob), varargs...)
+}
+
 // GetAllData mocks base method.
 func (m *MockGophKeeperClient) GetAllData(ctx context.Context, in *proto.GetAllDataRequest, opts ...grpc.CallOption) (*proto.GetAllDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +217,106 @@ func (mr *MockGophKeeperClientMockRecorder) GetAllData(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllData", reflect.TypeOf((*MockGophKeeperClient)(nil).GetAllData), varargs...)
 }
 
+// GetChanges mocks base method.
+func (m *MockGophKeeperClient) GetChanges(ctx context.Context, in *proto.GetChangesRequest, opts ...grpc.CallOption) (*proto.GetChangesResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetChanges", varargs...)
+	ret0, _ := ret[0].(*proto.GetChangesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockGophKeeperClientMockRecorder) GetChanges(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockGophKeeperClient)(nil).GetChanges), varargs...)
+}
+
+// GetDataHistory mocks base method.
+func (m *MockGophKeeperClient) GetDataHistory(ctx context.Context, in *proto.GetDataHistoryRequest, opts ...grpc.CallOption) (*proto.GetDataHistoryResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetDataHistory", varargs...)
+	ret0, _ := ret[0].(*proto.GetDataHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataHistory indicates an expected call of GetDataHistory.
+func (mr *MockGophKeeperClientMockRecorder) GetDataHistory(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataHistory", reflect.TypeOf((*MockGophKeeperClient)(nil).GetDataHistory), varargs...)
+}
+
+// GetJWKS mocks base method.
+func (m *MockGophKeeperClient) GetJWKS(ctx context.Context, in *proto.GetJWKSRequest, opts ...grpc.CallOption) (*proto.GetJWKSResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetJWKS", varargs...)
+	ret0, _ := ret[0].(*proto.GetJWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockGophKeeperClientMockRecorder) GetJWKS(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockGophKeeperClient)(nil).GetJWKS), varargs...)
+}
+
+// ListData mocks base method.
+func (m *MockGophKeeperClient) ListData(ctx context.Context, in *proto.ListDataRequest, opts ...grpc.CallOption) (*proto.ListDataResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListData", varargs...)
+	ret0, _ := ret[0].(*proto.ListDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListData indicates an expected call of ListData.
+func (mr *MockGophKeeperClientMockRecorder) ListData(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockGophKeeperClient)(nil).ListData), varargs...)
+}
+
+// ListTrash mocks base method.
+func (m *MockGophKeeperClient) ListTrash(ctx context.Context, in *proto.ListTrashRequest, opts ...grpc.CallOption) (*proto.ListTrashResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListTrash", varargs...)
+	ret0, _ := ret[0].(*proto.ListTrashResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockGophKeeperClientMockRecorder) ListTrash(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockGophKeeperClient)(nil).ListTrash), varargs...)
+}
+
 // Login mocks base method.
 func (m *MockGophKeeperClient) Login(ctx context.Context, in *proto.LoginRequest, opts ...grpc.CallOption) (*proto.LoginResponse, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +337,66 @@ func (mr *MockGophKeeperClientMockRecorder) Login(ctx, in interface{}, opts ...i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGophKeeperClient)(nil).Login), varargs...)
 }
 
+// Logout mocks base method.
+func (m *MockGophKeeperClient) Logout(ctx context.Context, in *proto.LogoutRequest, opts ...grpc.CallOption) (*proto.LogoutResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Logout", varargs...)
+	ret0, _ := ret[0].(*proto.LogoutResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockGophKeeperClientMockRecorder) Logout(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockGophKeeperClient)(nil).Logout), varargs...)
+}
+
+// PurgeData mocks base method.
+func (m *MockGophKeeperClient) PurgeData(ctx context.Context, in *proto.PurgeDataRequest, opts ...grpc.CallOption) (*proto.PurgeDataResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PurgeData", varargs...)
+	ret0, _ := ret[0].(*proto.PurgeDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeData indicates an expected call of PurgeData.
+func (mr *MockGophKeeperClientMockRecorder) PurgeData(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeData", reflect.TypeOf((*MockGophKeeperClient)(nil).PurgeData), varargs...)
+}
+
+// RefreshToken mocks base method.
+func (m *MockGophKeeperClient) RefreshToken(ctx context.Context, in *proto.RefreshTokenRequest, opts ...grpc.CallOption) (*proto.RefreshTokenResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RefreshToken", varargs...)
+	ret0, _ := ret[0].(*proto.RefreshTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockGophKeeperClientMockRecorder) RefreshToken(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockGophKeeperClient)(nil).RefreshToken), varargs...)
+}
+
 // Register mocks base method.
 func (m *MockGophKeeperClient) Register(ctx context.Context, in *proto.RegisterRequest, opts ...grpc.CallOption) (*proto.RegisterResponse, error) {
 	m.ctrl.T.Helper()
@@ -137,6 +417,46 @@ func (mr *MockGophKeeperClientMockRecorder) Register(ctx, in interface{}, opts .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockGophKeeperClient)(nil).Register), varargs...)
 }
 
+// RestoreData mocks base method.
+func (m *MockGophKeeperClient) RestoreData(ctx context.Context, in *proto.RestoreDataRequest, opts ...grpc.CallOption) (*proto.RestoreDataResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RestoreData", varargs...)
+	ret0, _ := ret[0].(*proto.RestoreDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreData indicates an expected call of RestoreData.
+func (mr *MockGophKeeperClientMockRecorder) RestoreData(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreData", reflect.TypeOf((*MockGophKeeperClient)(nil).RestoreData), varargs...)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockGophKeeperClient) RestoreRevision(ctx context.Context, in *proto.RestoreRevisionRequest, opts ...grpc.CallOption) (*proto.RestoreRevisionResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RestoreRevision", varargs...)
+	ret0, _ := ret[0].(*proto.RestoreRevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockGophKeeperClientMockRecorder) RestoreRevision(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockGophKeeperClient)(nil).RestoreRevision), varargs...)
+}
+
 // UpdateData mocks base method.
 func (m *MockGophKeeperClient) UpdateData(ctx context.Context, in *proto.UpdateDataRequest, opts ...grpc.CallOption) (*proto.UpdateDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -157,6 +477,46 @@ func (mr *MockGophKeeperClientMockRecorder) UpdateData(ctx, in interface{}, opts
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockGophKeeperClient)(nil).UpdateData), varargs...)
 }
 
+// UploadBlob mocks base method.
+func (m *MockGophKeeperClient) UploadBlob(ctx context.Context, opts ...grpc.CallOption) (proto.GophKeeper_UploadBlobClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UploadBlob", varargs...)
+	ret0, _ := ret[0].(proto.GophKeeper_UploadBlobClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadBlob indicates an expected call of UploadBlob.
+func (mr *MockGophKeeperClientMockRecorder) UploadBlob(ctx interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadBlob", reflect.TypeOf((*MockGophKeeperClient)(nil).UploadBlob), varargs...)
+}
+
+// WatchChanges mocks base method.
+func (m *MockGophKeeperClient) WatchChanges(ctx context.Context, in *proto.WatchChangesRequest, opts ...grpc.CallOption) (proto.GophKeeper_WatchChangesClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WatchChanges", varargs...)
+	ret0, _ := ret[0].(proto.GophKeeper_WatchChangesClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchChanges indicates an expected call of WatchChanges.
+func (mr *MockGophKeeperClientMockRecorder) WatchChanges(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchChanges", reflect.TypeOf((*MockGophKeeperClient)(nil).WatchChanges), varargs...)
+}
+
 // MockGophKeeperServer is a mock of GophKeeperServer interface.
 type MockGophKeeperServer struct {
 	ctrl     *gomock.Controller
@@ -180,6 +540,36 @@ func (m *MockGophKeeperServer) EXPECT() *MockGophKeeperServerMockRecorder {
 	return m.recorder
 }
 
+// BatchMutate mocks base method.
+func (m *MockGophKeeperServer) BatchMutate(arg0 context.Context, arg1 *proto.BatchMutateRequest) (*proto.BatchMutateResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchMutate", arg0, arg1)
+	ret0, _ := ret[0].(*proto.BatchMutateResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchMutate indicates an expected call of BatchMutate.
+func (mr *MockGophKeeperServerMockRecorder) BatchMutate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchMutate", reflect.TypeOf((*MockGophKeeperServer)(nil).BatchMutate), arg0, arg1)
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockGophKeeperServer) ConfirmTOTP(arg0 context.Context, arg1 *proto.ConfirmTOTPRequest) (*proto.ConfirmTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*proto.ConfirmTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockGophKeeperServerMockRecorder) ConfirmTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockGophKeeperServer)(nil).ConfirmTOTP), arg0, arg1)
+}
+
 // CreateData mocks base method.
 func (m *MockGophKeeperServer) CreateData(arg0 context.Context, arg1 *proto.CreateDataRequest) (*proto.CreateDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -210,6 +600,64 @@ func (mr *MockGophKeeperServerMockRecorder) DeleteData(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteData", reflect.TypeOf((*MockGophKeeperServer)(nil).DeleteData), arg0, arg1)
 }
 
+// DisableTOTP mocks base method.
+func (m *MockGophKeeperServer) DisableTOTP(arg0 context.Context, arg1 *proto.DisableTOTPRequest) (*proto.DisableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*proto.DisableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockGophKeeperServerMockRecorder) DisableTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockGophKeeperServer)(nil).DisableTOTP), arg0, arg1)
+}
+
+// DownloadBlob mocks base method.
+func (m *MockGophKeeperServer) DownloadBlob(arg0 *proto.DownloadBlobRequest, arg1 proto.GophKeeper_FetchBlobServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadBlob", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadBlob indicates an expected call of DownloadBlob.
+func (mr *MockGophKeeperServerMockRecorder) DownloadBlob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBlob", reflect.TypeOf((*MockGophKeeperServer)(nil).DownloadBlob), arg0, arg1)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockGophKeeperServer) EnableTOTP(arg0 context.Context, arg1 *proto.EnableTOTPRequest) (*proto.EnableTOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*proto.EnableTOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockGophKeeperServerMockRecorder) EnableTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockGophKeeperServer)(nil).EnableTOTP), arg0, arg1)
+}
+
+// FetchBlob mocks base method.
+func (m *MockGophKeeperServer) FetchBlob(arg0 *proto.FetchBlobRequest, arg1 proto.GophKeeper_FetchBlobServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchBlob", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchBlob indicates an expected call of FetchBlob.
+func (mr *MockGophKeeperServerMockRecorder) FetchBlob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlob", reflect.TypeOf((*MockGophKeeperServer)(nil).FetchBlob), arg0, arg1)
+}
+
 // GetAllData mocks base method.
 func (m *MockGophKeeperServer) GetAllData(arg0 context.Context, arg1 *proto.GetAllDataRequest) (*proto.GetAllDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -225,6 +673,81 @@ func (mr *MockGophKeeperServerMockRecorder) GetAllData(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllData", reflect.TypeOf((*MockGophKeeperServer)(nil).GetAllData), arg0, arg1)
 }
 
+// GetChanges mocks base method.
+func (m *MockGophKeeperServer) GetChanges(arg0 context.Context, arg1 *proto.GetChangesRequest) (*proto.GetChangesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChanges", arg0, arg1)
+	ret0, _ := ret[0].(*proto.GetChangesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChanges indicates an expected call of GetChanges.
+func (mr *MockGophKeeperServerMockRecorder) GetChanges(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChanges", reflect.TypeOf((*MockGophKeeperServer)(nil).GetChanges), arg0, arg1)
+}
+
+// GetDataHistory mocks base method.
+func (m *MockGophKeeperServer) GetDataHistory(arg0 context.Context, arg1 *proto.GetDataHistoryRequest) (*proto.GetDataHistoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataHistory", arg0, arg1)
+	ret0, _ := ret[0].(*proto.GetDataHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataHistory indicates an expected call of GetDataHistory.
+func (mr *MockGophKeeperServerMockRecorder) GetDataHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataHistory", reflect.TypeOf((*MockGophKeeperServer)(nil).GetDataHistory), arg0, arg1)
+}
+
+// GetJWKS mocks base method.
+func (m *MockGophKeeperServer) GetJWKS(arg0 context.Context, arg1 *proto.GetJWKSRequest) (*proto.GetJWKSResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWKS", arg0, arg1)
+	ret0, _ := ret[0].(*proto.GetJWKSResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJWKS indicates an expected call of GetJWKS.
+func (mr *MockGophKeeperServerMockRecorder) GetJWKS(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockGophKeeperServer)(nil).GetJWKS), arg0, arg1)
+}
+
+// ListData mocks base method.
+func (m *MockGophKeeperServer) ListData(arg0 context.Context, arg1 *proto.ListDataRequest) (*proto.ListDataResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListData", arg0, arg1)
+	ret0, _ := ret[0].(*proto.ListDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListData indicates an expected call of ListData.
+func (mr *MockGophKeeperServerMockRecorder) ListData(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListData", reflect.TypeOf((*MockGophKeeperServer)(nil).ListData), arg0, arg1)
+}
+
+// ListTrash mocks base method.
+func (m *MockGophKeeperServer) ListTrash(arg0 context.Context, arg1 *proto.ListTrashRequest) (*proto.ListTrashResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", arg0, arg1)
+	ret0, _ := ret[0].(*proto.ListTrashResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockGophKeeperServerMockRecorder) ListTrash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockGophKeeperServer)(nil).ListTrash), arg0, arg1)
+}
+
 // Login mocks base method.
 func (m *MockGophKeeperServer) Login(arg0 context.Context, arg1 *proto.LoginRequest) (*proto.LoginResponse, error) {
 	m.ctrl.T.Helper()
@@ -240,6 +763,51 @@ func (mr *MockGophKeeperServerMockRecorder) Login(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGophKeeperServer)(nil).Login), arg0, arg1)
 }
 
+// Logout mocks base method.
+func (m *MockGophKeeperServer) Logout(arg0 context.Context, arg1 *proto.LogoutRequest) (*proto.LogoutResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", arg0, arg1)
+	ret0, _ := ret[0].(*proto.LogoutResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockGophKeeperServerMockRecorder) Logout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockGophKeeperServer)(nil).Logout), arg0, arg1)
+}
+
+// PurgeData mocks base method.
+func (m *MockGophKeeperServer) PurgeData(arg0 context.Context, arg1 *proto.PurgeDataRequest) (*proto.PurgeDataResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeData", arg0, arg1)
+	ret0, _ := ret[0].(*proto.PurgeDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeData indicates an expected call of PurgeData.
+func (mr *MockGophKeeperServerMockRecorder) PurgeData(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeData", reflect.TypeOf((*MockGophKeeperServer)(nil).PurgeData), arg0, arg1)
+}
+
+// RefreshToken mocks base method.
+func (m *MockGophKeeperServer) RefreshToken(arg0 context.Context, arg1 *proto.RefreshTokenRequest) (*proto.RefreshTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(*proto.RefreshTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockGophKeeperServerMockRecorder) RefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockGophKeeperServer)(nil).RefreshToken), arg0, arg1)
+}
+
 // Register mocks base method.
 func (m *MockGophKeeperServer) Register(arg0 context.Context, arg1 *proto.RegisterRequest) (*proto.RegisterResponse, error) {
 	m.ctrl.T.Helper()
@@ -255,6 +823,36 @@ func (mr *MockGophKeeperServerMockRecorder) Register(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockGophKeeperServer)(nil).Register), arg0, arg1)
 }
 
+// RestoreData mocks base method.
+func (m *MockGophKeeperServer) RestoreData(arg0 context.Context, arg1 *proto.RestoreDataRequest) (*proto.RestoreDataResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreData", arg0, arg1)
+	ret0, _ := ret[0].(*proto.RestoreDataResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreData indicates an expected call of RestoreData.
+func (mr *MockGophKeeperServerMockRecorder) RestoreData(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreData", reflect.TypeOf((*MockGophKeeperServer)(nil).RestoreData), arg0, arg1)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockGophKeeperServer) RestoreRevision(arg0 context.Context, arg1 *proto.RestoreRevisionRequest) (*proto.RestoreRevisionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", arg0, arg1)
+	ret0, _ := ret[0].(*proto.RestoreRevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockGophKeeperServerMockRecorder) RestoreRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockGophKeeperServer)(nil).RestoreRevision), arg0, arg1)
+}
+
 // UpdateData mocks base method.
 func (m *MockGophKeeperServer) UpdateData(arg0 context.Context, arg1 *proto.UpdateDataRequest) (*proto.UpdateDataResponse, error) {
 	m.ctrl.T.Helper()
@@ -270,6 +868,34 @@ func (mr *MockGophKeeperServerMockRecorder) UpdateData(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockGophKeeperServer)(nil).UpdateData), arg0, arg1)
 }
 
+// UploadBlob mocks base method.
+func (m *MockGophKeeperServer) UploadBlob(arg0 proto.GophKeeper_UploadBlobServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadBlob", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadBlob indicates an expected call of UploadBlob.
+func (mr *MockGophKeeperServerMockRecorder) UploadBlob(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadBlob", reflect.TypeOf((*MockGophKeeperServer)(nil).UploadBlob), arg0)
+}
+
+// WatchChanges mocks base method.
+func (m *MockGophKeeperServer) WatchChanges(arg0 *proto.WatchChangesRequest, arg1 proto.GophKeeper_WatchChangesServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchChanges", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchChanges indicates an expected call of WatchChanges.
+func (mr *MockGophKeeperServerMockRecorder) WatchChanges(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchChanges", reflect.TypeOf((*MockGophKeeperServer)(nil).WatchChanges), arg0, arg1)
+}
+
 // mustEmbedUnimplementedGophKeeperServer mocks base method.
 func (m *MockGophKeeperServer) mustEmbedUnimplementedGophKeeperServer() {
 	m.ctrl.T.Helper()